@@ -3,7 +3,10 @@ module github.com/nandani-y-meizo/school-backend
 go 1.24.4
 
 require (
+	github.com/boombuler/barcode v1.1.0
+	github.com/jung-kurt/gofpdf v1.16.2
 	go.mongodb.org/mongo-driver v1.17.9
+	golang.org/x/text v0.34.0
 	shared v0.0.0-00010101000000-000000000000
 )
 
@@ -51,7 +54,6 @@ require (
 	golang.org/x/net v0.50.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.41.0 // indirect
-	golang.org/x/text v0.34.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
 )
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"shared/pkgs/jwtmanager"
 
 	"github.com/nandani-y-meizo/school-backend/routes"
+	"github.com/nandani-y-meizo/school-backend/services"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -31,6 +33,62 @@ func main() {
 	}
 	fmt.Println("Vault JWT initialized")
 
+	// Company database indexes; failed ones are listed and can be rebuilt through
+	// POST /companies/:company_code/indexes once the data is fixed
+	indexCtx, cancelIndexes := context.WithTimeout(context.Background(), 5*time.Minute)
+	companyIndexes, err := services.EnsureAllCompanyIndexes(indexCtx)
+	cancelIndexes()
+	if err != nil {
+		fmt.Printf("Building company indexes failed: %v\n", err)
+	}
+	for companyCode, indexes := range companyIndexes {
+		for _, index := range indexes {
+			if index.Built {
+				continue
+			}
+			fmt.Printf("Index %s.%s not built for %s: %s\n", index.Collection, index.Name, companyCode, index.Error)
+			if index.Detail != "" {
+				fmt.Printf("  %s\n", index.Detail)
+			}
+		}
+	}
+
+	// Scheduled report emails (disabled when SMTP is not configured)
+	if mailer, err := services.NewSMTPMailerFromEnv(); err != nil {
+		fmt.Printf("Scheduled report emails disabled: %v\n", err)
+	} else {
+		services.SetReportMailer(mailer)
+		go services.NewReportScheduler(time.Minute).Start(context.Background())
+		fmt.Println("Report scheduler started")
+	}
+
+	// Fee reminders over SMS / WhatsApp (disabled when no provider is configured)
+	providers := services.NewNotificationProvidersFromEnv()
+	if len(providers) == 0 {
+		fmt.Println("Fee reminders disabled: no SMS or WhatsApp provider configured")
+	} else {
+		for channel, provider := range providers {
+			services.SetNotificationProvider(channel, provider)
+		}
+		go services.NewReminderScheduler(time.Minute).Start(context.Background())
+		fmt.Println("Reminder scheduler started")
+	}
+
+	// Student photos and documents (uploads fail until a storage backend is configured)
+	if storage, err := services.NewAttachmentStorageFromEnv(); err != nil {
+		fmt.Printf("Attachment storage disabled: %v\n", err)
+	} else {
+		services.SetAttachmentStorage(storage)
+		fmt.Printf("Attachment storage: %s\n", storage.Name())
+	}
+
+	// Signed receipt QR codes (receipts carry no QR when the key is not configured)
+	if key, err := services.ReceiptSigningKeyFromEnv(); err != nil {
+		fmt.Printf("Receipt QR codes disabled: %v\n", err)
+	} else {
+		services.SetReceiptSigningKey(key)
+	}
+
 	// Create main app router
 	app := gin.Default()
 
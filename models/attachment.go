@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment is a file kept for a student, such as a photo or a birth certificate.
+// The file itself lives in the attachment storage under StorageKey.
+type Attachment struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	StudentEntityID string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	Kind            string             `json:"kind" bson:"kind"` // "photo", "birth_certificate", "transfer_certificate" or "other"
+	FileName        string             `json:"file_name" bson:"file_name"`
+	ContentType     string             `json:"content_type" bson:"content_type"` // sniffed from the content, not taken from the client
+	Size            int64              `json:"size" bson:"size"`
+	SHA256          string             `json:"sha256" bson:"sha256"` // hex checksum, verified on every download
+	Description     string             `json:"description,omitempty" bson:"description,omitempty"`
+	StorageBackend  string             `json:"storage_backend" bson:"storage_backend"`
+	StorageKey      string             `json:"-" bson:"storage_key"`
+	UploadedBy      *PaymentActor      `json:"uploaded_by,omitempty" bson:"uploaded_by,omitempty"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewAttachment() *Attachment {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &Attachment{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AttendanceRecord is one student's attendance on one day. Date is the school's local
+// calendar day, so a day's register never shifts with the server's timezone.
+type AttendanceRecord struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	StudentEntityID string             `json:"student_entity_id" bson:"student_entity_id"`
+	BoardEntityID   string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID   string             `json:"class_entity_id" bson:"class_entity_id"`
+	Div             string             `json:"div,omitempty" bson:"div,omitempty"`
+	Date            string             `json:"date" bson:"date"`     // YYYY-MM-DD
+	Status          string             `json:"status" bson:"status"` // "present", "absent", "late" or "leave"
+	Remarks         string             `json:"remarks,omitempty" bson:"remarks,omitempty"`
+	MarkedBy        *PaymentActor      `json:"marked_by,omitempty" bson:"marked_by,omitempty"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// AttendanceSettings controls how long a day's attendance stays editable. A company has
+// at most one settings document.
+type AttendanceSettings struct {
+	ID                     primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID               string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	EditWindowHours        int                `json:"edit_window_hours" bson:"edit_window_hours"` // after the day ends; 0 locks at midnight
+	Timezone               string             `json:"timezone" bson:"timezone"`                   // IANA name the school's days are counted in
+	CountLateAsPresent     bool               `json:"count_late_as_present" bson:"count_late_as_present"`
+	RestrictToClassTeacher bool               `json:"restrict_to_class_teacher" bson:"restrict_to_class_teacher"` // only a division's class teacher may submit
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// AttendanceSheet is a division's attendance for one day, with every student on the
+// roll whether marked yet or not
+type AttendanceSheet struct {
+	Date          string              `json:"date"`
+	BoardEntityID string              `json:"board_entity_id,omitempty"`
+	ClassEntityID string              `json:"class_entity_id"`
+	Div           string              `json:"div"`
+	Holiday       string              `json:"holiday,omitempty"` // title of the holiday falling on the date
+	Locked        bool                `json:"locked"`
+	LocksAt       time.Time           `json:"locks_at"`
+	Entries       []AttendanceEntry   `json:"entries"`
+	Summary       AttendanceBreakdown `json:"summary"`
+}
+
+type AttendanceEntry struct {
+	StudentEntityID string        `json:"student_entity_id"`
+	RefNo           string        `json:"ref_no"`
+	Name            string        `json:"name"`
+	Status          string        `json:"status,omitempty"` // empty until marked
+	Remarks         string        `json:"remarks,omitempty"`
+	MarkedBy        *PaymentActor `json:"marked_by,omitempty"`
+}
+
+// AttendanceBreakdown counts days by status. Percentage is attended days, present and
+// (when the school counts them) late, over the days the student was marked.
+type AttendanceBreakdown struct {
+	Present    int     `json:"present"`
+	Absent     int     `json:"absent"`
+	Late       int     `json:"late"`
+	Leave      int     `json:"leave"`
+	Unmarked   int     `json:"unmarked,omitempty"`
+	Marked     int     `json:"marked"`
+	Percentage float64 `json:"percentage"`
+}
+
+// AttendanceSubmission reports what a bulk submission changed
+type AttendanceSubmission struct {
+	Date      string    `json:"date"`
+	Created   int       `json:"created"`
+	Updated   int       `json:"updated"`
+	Unchanged int       `json:"unchanged"`
+	LocksAt   time.Time `json:"locks_at"`
+}
+
+// AttendanceRegister is the monthly register of a class or division: one row per
+// student, one mark per day
+type AttendanceRegister struct {
+	Month         string                  `json:"month"` // YYYY-MM
+	BoardEntityID string                  `json:"board_entity_id,omitempty"`
+	BoardName     string                  `json:"board_name,omitempty"`
+	ClassEntityID string                  `json:"class_entity_id"`
+	ClassName     string                  `json:"class_name,omitempty"`
+	Div           string                  `json:"div,omitempty"`
+	Days          []string                `json:"days"`     // every date of the month
+	Holidays      []string                `json:"holidays"` // dates that are holidays on the calendar
+	WorkingDays   int                     `json:"working_days"`
+	Rows          []AttendanceRegisterRow `json:"rows"`
+}
+
+type AttendanceRegisterRow struct {
+	StudentEntityID string              `json:"student_entity_id"`
+	RefNo           string              `json:"ref_no"`
+	Name            string              `json:"name"`
+	Div             string              `json:"div,omitempty"`
+	Marks           []string            `json:"marks"` // per day: "P", "A", "L", "LV", "H" for a holiday or "" when unmarked
+	Summary         AttendanceBreakdown `json:"summary"`
+}
+
+// StudentAttendance is one student's attendance over a session or date range
+type StudentAttendance struct {
+	StudentEntityID string                   `json:"student_entity_id"`
+	RefNo           string                   `json:"ref_no"`
+	Name            string                   `json:"name"`
+	SessionEntityID string                   `json:"session_entity_id,omitempty"`
+	From            string                   `json:"from,omitempty"`
+	To              string                   `json:"to,omitempty"`
+	Summary         AttendanceBreakdown      `json:"summary"`
+	Months          []StudentAttendanceMonth `json:"months"`
+	Absences        []AttendanceRecord       `json:"absences"` // absent and leave days, latest first
+}
+
+type StudentAttendanceMonth struct {
+	Month   string              `json:"month"` // YYYY-MM
+	Summary AttendanceBreakdown `json:"summary"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewAttendanceRecord() *AttendanceRecord {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &AttendanceRecord{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// NewAttendanceSettings returns the defaults used until a company saves its own
+func NewAttendanceSettings() *AttendanceSettings {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &AttendanceSettings{
+		ID:                 id,
+		EntityID:           entityID,
+		EditWindowHours:    48,
+		Timezone:           "UTC",
+		CountLateAsPresent: true,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
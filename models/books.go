@@ -10,30 +10,33 @@ import (
 )
 
 type Book struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
-	BookID        string             `json:"book_id,omitempty" bson:"book_id,omitempty"`
-	BoardEntityID string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
-	ClassEntityID string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
-	BookName      string             `json:"book_name,omitempty" bson:"book_name,omitempty"`
-	Amount        float64            `json:"amount,omitempty" bson:"amount,omitempty"`
-	FeesPaid      bool               `json:"fees_paid" bson:"fees_paid"`                     // true = compulsory, false = optional
-	FeesType      string             `json:"fees_type,omitempty" bson:"fees_type,omitempty"` // "compulsory" or "optional"
-	IsDeleted     bool               `json:"is_deleted" bson:"is_deleted"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	BookID          string             `json:"book_id,omitempty" bson:"book_id,omitempty"`
+	BoardEntityID   string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID   string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	BookName        string             `json:"book_name,omitempty" bson:"book_name,omitempty"`
+	Amount          float64            `json:"amount,omitempty" bson:"amount,omitempty"`
+	FeesPaid        bool               `json:"fees_paid" bson:"fees_paid"`                     // true = compulsory, false = optional
+	FeesType        string             `json:"fees_type,omitempty" bson:"fees_type,omitempty"` // "compulsory" or "optional"
+	DueDate         *time.Time         `json:"due_date,omitempty" bson:"due_date,omitempty"`   // falls back to created_at when unset
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 }
 
 type UpdateBook struct {
-	BookID        *string  `json:"book_id,omitempty" bson:"book_id,omitempty"`
-	BoardEntityID *string  `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
-	ClassEntityID *string  `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
-	BookName      *string  `json:"book_name,omitempty" bson:"book_name,omitempty"`
-	Amount        *float64 `json:"amount,omitempty" bson:"amount,omitempty"`
-	FeesPaid      *bool    `json:"fees_paid,omitempty" bson:"fees_paid,omitempty"`
-	FeesType      *string  `json:"fees_type,omitempty" bson:"fees_type,omitempty"`
-	IsDeleted     *bool    `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
+	BookID        *string    `json:"book_id,omitempty" bson:"book_id,omitempty"`
+	BoardEntityID *string    `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID *string    `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	BookName      *string    `json:"book_name,omitempty" bson:"book_name,omitempty"`
+	Amount        *float64   `json:"amount,omitempty" bson:"amount,omitempty"`
+	FeesPaid      *bool      `json:"fees_paid,omitempty" bson:"fees_paid,omitempty"`
+	FeesType      *string    `json:"fees_type,omitempty" bson:"fees_type,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty" bson:"due_date,omitempty"`
+	IsDeleted     *bool      `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
 }
 
 //
@@ -74,6 +77,11 @@ func (b *Book) Bind(req *requests.CreateBookRequest) {
 	b.Amount = req.Amount
 	b.FeesPaid = req.FeesPaid
 	b.FeesType = req.FeesType
+	if req.DueDate != nil && *req.DueDate != "" {
+		if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			b.DueDate = &dueDate
+		}
+	}
 }
 
 //
@@ -103,6 +111,11 @@ func (b *UpdateBook) Bind(req *requests.UpdateBookRequest) {
 	if req.FeesType != nil {
 		b.FeesType = req.FeesType
 	}
+	if req.DueDate != nil && *req.DueDate != "" {
+		if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			b.DueDate = &dueDate
+		}
+	}
 	if req.IsDeleted != nil {
 		b.IsDeleted = req.IsDeleted
 	}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CalendarEntry is a holiday, exam date or event on a company's academic calendar
+type CalendarEntry struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Title           string             `json:"title,omitempty" bson:"title,omitempty"`
+	Description     string             `json:"description,omitempty" bson:"description,omitempty"`
+	EntryType       string             `json:"entry_type,omitempty" bson:"entry_type,omitempty"` // "holiday", "exam" or "event"
+	StartDate       time.Time          `json:"start_date" bson:"start_date"`
+	EndDate         time.Time          `json:"end_date" bson:"end_date"`                         // last day, inclusive
+	Recurrence      string             `json:"recurrence,omitempty" bson:"recurrence,omitempty"` // "none", "weekly", "monthly" or "yearly"
+	RecurrenceUntil *time.Time         `json:"recurrence_until,omitempty" bson:"recurrence_until,omitempty"`
+	BoardEntityIDs  []string           `json:"board_entity_ids" bson:"board_entity_ids"` // empty = all boards
+	Flag            string             `json:"flag,omitempty" bson:"flag,omitempty"`
+	UID             string             `json:"uid,omitempty" bson:"uid,omitempty"` // iCalendar UID, kept for re-imports
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// CalendarOccurrence is one dated instance of a (possibly recurring) calendar entry
+type CalendarOccurrence struct {
+	EntryEntityID  string    `json:"entry_entity_id"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description,omitempty"`
+	EntryType      string    `json:"entry_type"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	BoardEntityIDs []string  `json:"board_entity_ids"`
+	Flag           string    `json:"flag,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCalendarEntry() *CalendarEntry {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &CalendarEntry{
+		ID:             id,
+		EntityID:       entityID,
+		Recurrence:     "none",
+		BoardEntityIDs: []string{},
+		IsDeleted:      false,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (e *CalendarEntry) Bind(req *requests.CreateCalendarEntryRequest) {
+	e.Title = req.Title
+	e.Description = req.Description
+	e.EntryType = req.EntryType
+	e.Flag = req.Flag
+
+	e.StartDate, _ = time.Parse("2006-01-02", req.StartDate)
+	e.EndDate = e.StartDate
+	if req.EndDate != nil && *req.EndDate != "" {
+		e.EndDate, _ = time.Parse("2006-01-02", *req.EndDate)
+	}
+
+	if req.Recurrence != "" {
+		e.Recurrence = req.Recurrence
+	}
+	if req.RecurrenceUntil != nil && *req.RecurrenceUntil != "" {
+		if until, err := time.Parse("2006-01-02", *req.RecurrenceUntil); err == nil {
+			e.RecurrenceUntil = &until
+		}
+	}
+	if req.BoardEntityIDs != nil {
+		e.BoardEntityIDs = req.BoardEntityIDs
+	}
+}
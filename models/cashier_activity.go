@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// CashierActivityRow audits one user's collections and reversals over the report range.
+// Collections are counted when taken, even if later voided or refunded; reversals are
+// counted against the user who issued them.
+type CashierActivityRow struct {
+	UserID             string             `json:"user_id"` // empty for payments taken before collectors were recorded
+	Name               string             `json:"name"`
+	Email              string             `json:"email,omitempty"`
+	Role               string             `json:"role,omitempty"`
+	Checkouts          int                `json:"checkouts"`
+	Items              int                `json:"items"`
+	Collected          float64            `json:"collected"`
+	ByPaymentMode      map[string]float64 `json:"by_payment_mode"`
+	Refunds            int                `json:"refunds"`
+	RefundedAmount     float64            `json:"refunded_amount"`
+	Voids              int                `json:"voids"`
+	VoidedAmount       float64            `json:"voided_amount"`
+	FirstTransactionAt *time.Time         `json:"first_transaction_at,omitempty"`
+	LastTransactionAt  *time.Time         `json:"last_transaction_at,omitempty"`
+}
+
+// CashierActivityResponse for API response
+type CashierActivityResponse struct {
+	Cashiers       []CashierActivityRow `json:"cashiers"`
+	TotalCollected float64              `json:"total_collected"`
+	TotalRefunded  float64              `json:"total_refunded"`
+	TotalVoided    float64              `json:"total_voided"`
+}
+
+// Track widens the first/last transaction window to include t
+func (r *CashierActivityRow) Track(t time.Time) {
+	if r.FirstTransactionAt == nil || t.Before(*r.FirstTransactionAt) {
+		first := t
+		r.FirstTransactionAt = &first
+	}
+	if r.LastTransactionAt == nil || t.After(*r.LastTransactionAt) {
+		last := t
+		r.LastTransactionAt = &last
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+// AmountSplit breaks an amount down by item type and fees type
+type AmountSplit struct {
+	ExamCompulsory float64 `json:"exam_compulsory"`
+	ExamOptional   float64 `json:"exam_optional"`
+	BookCompulsory float64 `json:"book_compulsory"`
+	BookOptional   float64 `json:"book_optional"`
+	Total          float64 `json:"total"`
+}
+
+// Add books an amount under the given item type ("exam"/"book") and fees type
+func (a *AmountSplit) Add(itemType string, isCompulsory bool, amount float64) {
+	switch {
+	case itemType == "exam" && isCompulsory:
+		a.ExamCompulsory += amount
+	case itemType == "exam":
+		a.ExamOptional += amount
+	case itemType == "book" && isCompulsory:
+		a.BookCompulsory += amount
+	case itemType == "book":
+		a.BookOptional += amount
+	}
+	a.Total += amount
+}
+
+// CollectionSummaryGroup is one board / class / division row of the summary
+type CollectionSummaryGroup struct {
+	BoardEntityID  string      `json:"board_entity_id"`
+	BoardName      string      `json:"board_name"`
+	ClassEntityID  string      `json:"class_entity_id"`
+	ClassName      string      `json:"class_name"`
+	Div            string      `json:"div"`
+	StudentCount   int         `json:"student_count"`
+	FullyPaidCount int         `json:"fully_paid_count"`
+	Collected      AmountSplit `json:"collected"`
+	Outstanding    AmountSplit `json:"outstanding"`
+}
+
+// CollectionSummaryResponse for API response
+type CollectionSummaryResponse struct {
+	Groups []CollectionSummaryGroup `json:"groups"`
+	Totals CollectionSummaryGroup   `json:"totals"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TrendPoint is the collection total for one day, week or month
+type TrendPoint struct {
+	BucketStart   time.Time          `json:"bucket_start"`
+	Total         float64            `json:"total"`
+	Payments      int                `json:"payments"`
+	ByPaymentMode map[string]float64 `json:"by_payment_mode"`
+	ByItemType    map[string]float64 `json:"by_item_type"`
+}
+
+// TrendTotals compares the requested range with the comparison range
+type TrendTotals struct {
+	Current       float64  `json:"current"`
+	Previous      *float64 `json:"previous,omitempty"`
+	ChangePercent *float64 `json:"change_percent,omitempty"`
+}
+
+// CollectionTrendsResponse for API response
+type CollectionTrendsResponse struct {
+	Interval   string       `json:"interval"`
+	Timezone   string       `json:"timezone"`
+	StartDate  time.Time    `json:"start_date"`
+	EndDate    time.Time    `json:"end_date"`
+	Series     []TrendPoint `json:"series"`
+	Comparison []TrendPoint `json:"comparison,omitempty"`
+	Totals     TrendTotals  `json:"totals"`
+
+	ComparisonStartDate *time.Time `json:"comparison_start_date,omitempty"`
+	ComparisonEndDate   *time.Time `json:"comparison_end_date,omitempty"`
+}
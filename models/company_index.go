@@ -0,0 +1,10 @@
+package models
+
+// CompanyIndex reports the build of one index on a company database
+type CompanyIndex struct {
+	Collection string `json:"collection"`
+	Name       string `json:"name"`
+	Built      bool   `json:"built"`
+	Error      string `json:"error,omitempty"`
+	Detail     string `json:"detail,omitempty"` // the data that blocks the index, e.g. duplicate ref numbers
+}
@@ -3,23 +3,44 @@ package models
 import "time"
 
 type DashboardStats struct {
-	Collection CollectionStats `json:"collection"`
-	FeesStatus FeesStatusStats `json:"fees_status"`
-	Holidays   []Holiday       `json:"holidays"`
+	Collection   CollectionStats      `json:"collection"`
+	FeesStatus   FeesStatusStats      `json:"fees_status"`
+	OptionalFees OptionalFeesStats    `json:"optional_fees"`
+	Holidays     []Holiday            `json:"holidays"`
+	Upcoming     []CalendarOccurrence `json:"upcoming"`
 }
 
+// CollectionStats only counts settled (status "paid"), non-deleted payments
 type CollectionStats struct {
-	TotalPaidAmount float64 `json:"total_paid_amount"`
-	CashAmount      float64 `json:"cash_amount"`
-	UPIAmount       float64 `json:"upi_amount"`
+	TotalPaidAmount float64          `json:"total_paid_amount"`
+	CashAmount      float64          `json:"cash_amount"`
+	UPIAmount       float64          `json:"upi_amount"`
+	Today           PeriodCollection `json:"today"`
+	ThisMonth       PeriodCollection `json:"this_month"`
 }
 
+type PeriodCollection struct {
+	TotalAmount float64 `json:"total_amount"`
+	CashAmount  float64 `json:"cash_amount"`
+	UPIAmount   float64 `json:"upi_amount"`
+	Payments    int     `json:"payments"`
+}
+
+// FeesStatusStats counts a student as paid once every compulsory item is paid
 type FeesStatusStats struct {
 	PaidStudents   int `json:"paid_students"`
 	UnpaidStudents int `json:"unpaid_students"`
 	TotalStudents  int `json:"total_students"`
 }
 
+// OptionalFeesStats reports optional items separately from the paid/unpaid counts
+type OptionalFeesStats struct {
+	StudentsTakingOptional int     `json:"students_taking_optional"`
+	PaidItems              int     `json:"paid_items"`
+	OfferedItems           int     `json:"offered_items"`
+	CollectedAmount        float64 `json:"collected_amount"`
+}
+
 type Holiday struct {
 	Name string    `json:"name"`
 	Date time.Time `json:"date"`
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// AgingBuckets splits an outstanding amount by how many days it is overdue
+type AgingBuckets struct {
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Days90Plus float64 `json:"days_90_plus"`
+	Total      float64 `json:"total"`
+}
+
+// Add books an amount into the bucket for the given number of days overdue
+func (a *AgingBuckets) Add(daysOverdue int, amount float64) {
+	switch {
+	case daysOverdue <= 0:
+		a.Current += amount
+	case daysOverdue <= 30:
+		a.Days1To30 += amount
+	case daysOverdue <= 60:
+		a.Days31To60 += amount
+	case daysOverdue <= 90:
+		a.Days61To90 += amount
+	default:
+		a.Days90Plus += amount
+	}
+	a.Total += amount
+}
+
+// Merge adds another set of buckets into this one
+func (a *AgingBuckets) Merge(other AgingBuckets) {
+	a.Current += other.Current
+	a.Days1To30 += other.Days1To30
+	a.Days31To60 += other.Days31To60
+	a.Days61To90 += other.Days61To90
+	a.Days90Plus += other.Days90Plus
+	a.Total += other.Total
+}
+
+// DefaulterAgingStudent is one student's outstanding amount by age
+type DefaulterAgingStudent struct {
+	EntityID       string       `json:"entity_id"`
+	RefNo          string       `json:"ref_no"`
+	StudentName    string       `json:"student_name"`
+	BoardEntityID  string       `json:"board_entity_id"`
+	BoardName      string       `json:"board_name"`
+	ClassEntityID  string       `json:"class_entity_id"`
+	ClassName      string       `json:"class_name"`
+	Div            string       `json:"div"`
+	Buckets        AgingBuckets `json:"buckets"`
+	OldestDueDate  time.Time    `json:"oldest_due_date"`
+	MaxDaysOverdue int          `json:"max_days_overdue"`
+}
+
+// DefaulterAgingRollup sums the buckets for one board and class
+type DefaulterAgingRollup struct {
+	BoardEntityID string       `json:"board_entity_id"`
+	BoardName     string       `json:"board_name"`
+	ClassEntityID string       `json:"class_entity_id"`
+	ClassName     string       `json:"class_name"`
+	StudentCount  int          `json:"student_count"`
+	Buckets       AgingBuckets `json:"buckets"`
+}
+
+// DefaulterAgingResponse for API response
+type DefaulterAgingResponse struct {
+	AsOfDate time.Time               `json:"as_of_date"`
+	Students []DefaulterAgingStudent `json:"students"`
+	Rollups  []DefaulterAgingRollup  `json:"rollups"`
+	Totals   AgingBuckets            `json:"totals"`
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Division is a section of a class such as "A". Students and enrolments refer to it by
+// name through their div field, which always holds the division's name as stored here.
+type Division struct {
+	ID                   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID             string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	ClassEntityID        string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	Name                 string             `json:"name,omitempty" bson:"name,omitempty"`
+	Capacity             int                `json:"capacity" bson:"capacity"` // 0 means no limit
+	ClassTeacherEntityID string             `json:"class_teacher_entity_id,omitempty" bson:"class_teacher_entity_id,omitempty"`
+	Enrolled             int                `json:"enrolled" bson:"-"` // students in the division in the active session
+	IsDeleted            bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type UpdateDivision struct {
+	Name                 *string `json:"name,omitempty" bson:"name,omitempty"`
+	Capacity             *int    `json:"capacity,omitempty" bson:"capacity,omitempty"`
+	ClassTeacherEntityID *string `json:"class_teacher_entity_id,omitempty" bson:"class_teacher_entity_id,omitempty"`
+}
+
+// DivisionMismatch is a free-text div value that matches no division of its class
+type DivisionMismatch struct {
+	ClassEntityID string `json:"class_entity_id"`
+	Div           string `json:"div"`
+	Students      int64  `json:"students"`
+}
+
+// DivisionMigration reports what normalising the free-text div values changed, or
+// would change on a dry run
+type DivisionMigration struct {
+	DryRun           bool               `json:"dry_run"`
+	Students         int64              `json:"students"`
+	Enrolments       int64              `json:"enrolments"`
+	DivisionsCreated []Division         `json:"divisions_created"`
+	Unmatched        []DivisionMismatch `json:"unmatched"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewDivision() *Division {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &Division{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewUpdateDivision() *UpdateDivision {
+	return &UpdateDivision{}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (d *Division) Bind(req *requests.CreateDivisionRequest) {
+	d.Name = req.Name
+	d.Capacity = req.Capacity
+	d.ClassTeacherEntityID = req.ClassTeacherEntityID
+}
+
+//
+// ================= BIND UPDATE =================
+//
+
+func (d *UpdateDivision) Bind(req *requests.UpdateDivisionRequest) {
+	if req.Name != nil {
+		d.Name = req.Name
+	}
+	if req.Capacity != nil {
+		d.Capacity = req.Capacity
+	}
+	if req.ClassTeacherEntityID != nil {
+		d.ClassTeacherEntityID = req.ClassTeacherEntityID
+	}
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExamMarkStatuses are the stages the marks of a paper move through. Marks are entered
+// in draft, adjusted by a moderator, then locked for report cards.
+const (
+	ExamMarksDraft     = "draft"
+	ExamMarksModerated = "moderated"
+	ExamMarksLocked    = "locked"
+)
+
+// ExamMark is one student's result in one paper of an exam
+type ExamMark struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID         string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID  string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	ExamEntityID     string             `json:"exam_entity_id" bson:"exam_entity_id"`
+	PaperEntityID    string             `json:"paper_entity_id" bson:"paper_entity_id"`
+	Subject          string             `json:"subject" bson:"subject"`
+	StudentEntityID  string             `json:"student_entity_id" bson:"student_entity_id"`
+	ClassEntityID    string             `json:"class_entity_id" bson:"class_entity_id"`
+	Div              string             `json:"div,omitempty" bson:"div,omitempty"`
+	Marks            float64            `json:"marks" bson:"marks"`
+	Absent           bool               `json:"absent" bson:"absent"`
+	Remarks          string             `json:"remarks,omitempty" bson:"remarks,omitempty"`
+	ModeratedFrom    *float64           `json:"moderated_from,omitempty" bson:"moderated_from,omitempty"` // the marks as entered, before moderation
+	ModerationReason string             `json:"moderation_reason,omitempty" bson:"moderation_reason,omitempty"`
+	EnteredBy        *PaymentActor      `json:"entered_by,omitempty" bson:"entered_by,omitempty"`
+	ModeratedBy      *PaymentActor      `json:"moderated_by,omitempty" bson:"moderated_by,omitempty"`
+	IsDeleted        bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ExamMarkState is where the marks of one paper are in moderation. Papers without one
+// are still in draft.
+type ExamMarkState struct {
+	ID            primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	EntityID      string                `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	ExamEntityID  string                `json:"exam_entity_id" bson:"exam_entity_id"`
+	PaperEntityID string                `json:"paper_entity_id" bson:"paper_entity_id"`
+	Status        string                `json:"status" bson:"status"`
+	History       []ExamMarkStateChange `json:"history" bson:"history"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type ExamMarkStateChange struct {
+	From      string        `json:"from" bson:"from"`
+	To        string        `json:"to" bson:"to"`
+	Reason    string        `json:"reason,omitempty" bson:"reason,omitempty"`
+	ChangedBy *PaymentActor `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	ChangedAt time.Time     `json:"changed_at" bson:"changed_at"`
+}
+
+// ExamMarkSheet is the marks of one paper for a division, or the whole class, with a
+// row for every student due to sit it
+type ExamMarkSheet struct {
+	ExamEntityID  string          `json:"exam_entity_id"`
+	ExamName      string          `json:"exam_name"`
+	PaperEntityID string          `json:"paper_entity_id"`
+	Subject       string          `json:"subject"`
+	MaxMarks      float64         `json:"max_marks"`
+	PassMarks     float64         `json:"pass_marks"`
+	Status        string          `json:"status"`
+	Div           string          `json:"div,omitempty"`
+	Entered       int             `json:"entered"`
+	Pending       int             `json:"pending"`
+	Entries       []ExamMarkEntry `json:"entries"`
+}
+
+type ExamMarkEntry struct {
+	StudentEntityID string   `json:"student_entity_id"`
+	RefNo           string   `json:"ref_no"`
+	Name            string   `json:"name"`
+	Div             string   `json:"div,omitempty"`
+	Entered         bool     `json:"entered"`
+	Marks           *float64 `json:"marks,omitempty"`
+	Absent          bool     `json:"absent"`
+	Remarks         string   `json:"remarks,omitempty"`
+	ModeratedFrom   *float64 `json:"moderated_from,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewExamMark() *ExamMark {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ExamMark{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewExamMarkState() *ExamMarkState {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ExamMarkState{
+		ID:        id,
+		EntityID:  entityID,
+		Status:    ExamMarksDraft,
+		History:   []ExamMarkStateChange{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
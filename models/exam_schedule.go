@@ -0,0 +1,201 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExamPaper is one sitting of an exam, such as the Mathematics paper on a given morning.
+// Times are the school's local HH:MM.
+type ExamPaper struct {
+	EntityID  string  `json:"entity_id" bson:"entity_id"`
+	Subject   string  `json:"subject" bson:"subject"`
+	Date      string  `json:"date" bson:"date"` // YYYY-MM-DD
+	StartTime string  `json:"start_time" bson:"start_time"`
+	EndTime   string  `json:"end_time" bson:"end_time"`
+	Venue     string  `json:"venue,omitempty" bson:"venue,omitempty"`
+	MaxMarks  float64 `json:"max_marks" bson:"max_marks"`
+	PassMarks float64 `json:"pass_marks,omitempty" bson:"pass_marks,omitempty"` // the board's pass percentage applies when unset
+}
+
+// ExamRoom is a room papers are sat in, seating up to Capacity students at a time
+type ExamRoom struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID  string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Building  string             `json:"building,omitempty" bson:"building,omitempty"`
+	Capacity  int                `json:"capacity" bson:"capacity"`
+	IsDeleted bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type UpdateExamRoom struct {
+	Name     *string `json:"name,omitempty" bson:"name,omitempty"`
+	Building *string `json:"building,omitempty" bson:"building,omitempty"`
+	Capacity *int    `json:"capacity,omitempty" bson:"capacity,omitempty"`
+}
+
+// ExamSeatingPlan seats the students sitting one paper. Generating it again replaces it.
+type ExamSeatingPlan struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	ExamEntityID    string             `json:"exam_entity_id" bson:"exam_entity_id"`
+	PaperEntityID   string             `json:"paper_entity_id" bson:"paper_entity_id"`
+	Subject         string             `json:"subject" bson:"subject"`
+	Date            string             `json:"date" bson:"date"`
+	StartTime       string             `json:"start_time" bson:"start_time"`
+	EndTime         string             `json:"end_time" bson:"end_time"`
+	Rooms           []ExamRoomUsage    `json:"rooms" bson:"rooms"`
+	Seats           []ExamSeat         `json:"seats" bson:"seats"`
+	Conflicts       []ExamSeat         `json:"conflicts" bson:"conflicts"` // students seated for an overlapping paper, left out
+	GeneratedBy     *PaymentActor      `json:"generated_by,omitempty" bson:"generated_by,omitempty"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ExamRoomUsage is how much of a room a plan uses. Available is what was left after
+// overlapping papers took their seats.
+type ExamRoomUsage struct {
+	RoomEntityID string `json:"room_entity_id" bson:"room_entity_id"`
+	Name         string `json:"name" bson:"name"`
+	Capacity     int    `json:"capacity" bson:"capacity"`
+	Available    int    `json:"available" bson:"available"`
+	Allocated    int    `json:"allocated" bson:"allocated"`
+}
+
+type ExamSeat struct {
+	StudentEntityID string `json:"student_entity_id" bson:"student_entity_id"`
+	RefNo           string `json:"ref_no" bson:"ref_no"`
+	Name            string `json:"name" bson:"name"`
+	Div             string `json:"div,omitempty" bson:"div,omitempty"`
+	RoomEntityID    string `json:"room_entity_id,omitempty" bson:"room_entity_id,omitempty"`
+	RoomName        string `json:"room_name,omitempty" bson:"room_name,omitempty"`
+	SeatNo          int    `json:"seat_no,omitempty" bson:"seat_no,omitempty"` // 1-based within the room
+	ConflictsWith   string `json:"conflicts_with,omitempty" bson:"conflicts_with,omitempty"`
+}
+
+// ExamPaperRef identifies a paper in a conflict report
+type ExamPaperRef struct {
+	ExamEntityID  string `json:"exam_entity_id"`
+	ExamName      string `json:"exam_name"`
+	PaperEntityID string `json:"paper_entity_id"`
+	Subject       string `json:"subject"`
+	Date          string `json:"date"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+}
+
+// ExamConflict is a student due to sit two papers whose times overlap
+type ExamConflict struct {
+	StudentEntityID string         `json:"student_entity_id"`
+	RefNo           string         `json:"ref_no"`
+	Name            string         `json:"name"`
+	ClassEntityID   string         `json:"class_entity_id"`
+	Div             string         `json:"div,omitempty"`
+	Papers          []ExamPaperRef `json:"papers"` // the two overlapping papers
+}
+
+type ExamConflictReport struct {
+	SessionEntityID string         `json:"session_entity_id,omitempty"`
+	PapersChecked   int            `json:"papers_checked"`
+	StudentsChecked int            `json:"students_checked"`
+	Conflicts       []ExamConflict `json:"conflicts"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewExamRoom() *ExamRoom {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ExamRoom{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewUpdateExamRoom() *UpdateExamRoom {
+	return &UpdateExamRoom{}
+}
+
+func NewExamSeatingPlan() *ExamSeatingPlan {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ExamSeatingPlan{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// NewExamPaper keeps the entity id of a paper being rescheduled, or makes a new one
+func NewExamPaper(req requests.ExamPaperRequest) ExamPaper {
+	entityID := req.EntityID
+	if entityID == "" {
+		entityID, _ = uuids.NewUUID5(primitive.NewObjectID().Hex(), uuids.OidNamespace)
+	}
+
+	return ExamPaper{
+		EntityID:  entityID,
+		Subject:   req.Subject,
+		Date:      req.Date,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Venue:     req.Venue,
+		MaxMarks:  req.MaxMarks,
+		PassMarks: req.PassMarks,
+	}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (r *ExamRoom) Bind(req *requests.CreateExamRoomRequest) {
+	r.Name = req.Name
+	r.Building = req.Building
+	r.Capacity = req.Capacity
+}
+
+//
+// ================= BIND UPDATE =================
+//
+
+func (r *UpdateExamRoom) Bind(req *requests.UpdateExamRoomRequest) {
+	if req.Name != nil {
+		r.Name = req.Name
+	}
+	if req.Building != nil {
+		r.Building = req.Building
+	}
+	if req.Capacity != nil {
+		r.Capacity = req.Capacity
+	}
+}
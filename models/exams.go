@@ -10,28 +10,32 @@ import (
 )
 
 type Exam struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
-	BoardEntityID string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
-	ClassEntityID string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
-	ExamName      string             `json:"exam_name,omitempty" bson:"exam_name,omitempty"`
-	ExamAmount    float64            `json:"exam_amount,omitempty" bson:"exam_amount,omitempty"`
-	FeesPaid      bool               `json:"fees_paid" bson:"fees_paid"`                     // true = compulsory, false = optional
-	FeesType      string             `json:"fees_type,omitempty" bson:"fees_type,omitempty"` // "compulsory" or "optional"
-	IsDeleted     bool               `json:"is_deleted" bson:"is_deleted"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	BoardEntityID   string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID   string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	ExamName        string             `json:"exam_name,omitempty" bson:"exam_name,omitempty"`
+	ExamAmount      float64            `json:"exam_amount,omitempty" bson:"exam_amount,omitempty"`
+	FeesPaid        bool               `json:"fees_paid" bson:"fees_paid"`                     // true = compulsory, false = optional
+	FeesType        string             `json:"fees_type,omitempty" bson:"fees_type,omitempty"` // "compulsory" or "optional"
+	DueDate         *time.Time         `json:"due_date,omitempty" bson:"due_date,omitempty"`   // falls back to created_at when unset
+	Schedule        []ExamPaper        `json:"schedule,omitempty" bson:"schedule,omitempty"`   // the papers of the exam, in date order
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 }
 
 type UpdateExam struct {
-	BoardEntityID *string  `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
-	ClassEntityID *string  `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
-	ExamName      *string  `json:"exam_name,omitempty" bson:"exam_name,omitempty"`
-	ExamAmount    *float64 `json:"exam_amount,omitempty" bson:"exam_amount,omitempty"`
-	FeesPaid      *bool    `json:"fees_paid,omitempty" bson:"fees_paid,omitempty"`
-	FeesType      *string  `json:"fees_type,omitempty" bson:"fees_type,omitempty"`
-	IsDeleted     *bool    `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
+	BoardEntityID *string    `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID *string    `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	ExamName      *string    `json:"exam_name,omitempty" bson:"exam_name,omitempty"`
+	ExamAmount    *float64   `json:"exam_amount,omitempty" bson:"exam_amount,omitempty"`
+	FeesPaid      *bool      `json:"fees_paid,omitempty" bson:"fees_paid,omitempty"`
+	FeesType      *string    `json:"fees_type,omitempty" bson:"fees_type,omitempty"`
+	DueDate       *time.Time `json:"due_date,omitempty" bson:"due_date,omitempty"`
+	IsDeleted     *bool      `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
 }
 
 //
@@ -71,6 +75,11 @@ func (b *Exam) Bind(req *requests.CreateExamRequest) {
 	b.ExamAmount = req.ExamAmount
 	b.FeesPaid = req.FeesPaid
 	b.FeesType = req.FeesType
+	if req.DueDate != nil && *req.DueDate != "" {
+		if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			b.DueDate = &dueDate
+		}
+	}
 }
 
 //
@@ -97,6 +106,11 @@ func (b *UpdateExam) Bind(req *requests.UpdateExamRequest) {
 	if req.FeesType != nil {
 		b.FeesType = req.FeesType
 	}
+	if req.DueDate != nil && *req.DueDate != "" {
+		if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			b.DueDate = &dueDate
+		}
+	}
 	if req.IsDeleted != nil {
 		b.IsDeleted = req.IsDeleted
 	}
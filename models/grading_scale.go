@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GradingScale turns percentages into grades for the exams of a board. Bands are kept
+// highest first; a percentage gets the first band whose minimum it reaches.
+type GradingScale struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	BoardEntityID string             `json:"board_entity_id" bson:"board_entity_id"`
+	Bands         []GradeBand        `json:"bands" bson:"bands"`
+	PassPercent   float64            `json:"pass_percent" bson:"pass_percent"` // for papers without pass marks of their own
+	IsDefault     bool               `json:"is_default" bson:"-"`              // true until the board saves its own scale
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type GradeBand struct {
+	Grade      string   `json:"grade" bson:"grade"`
+	MinPercent float64  `json:"min_percent" bson:"min_percent"`
+	GradePoint *float64 `json:"grade_point,omitempty" bson:"grade_point,omitempty"`
+	Remark     string   `json:"remark,omitempty" bson:"remark,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+// NewGradingScale is the scale a board uses until it saves its own: nine-point bands
+// with 33% to pass
+func NewGradingScale(boardEntityID string) *GradingScale {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	point := func(value float64) *float64 { return &value }
+
+	return &GradingScale{
+		ID:            id,
+		EntityID:      entityID,
+		BoardEntityID: boardEntityID,
+		Bands: []GradeBand{
+			{Grade: "A1", MinPercent: 91, GradePoint: point(10), Remark: "Outstanding"},
+			{Grade: "A2", MinPercent: 81, GradePoint: point(9), Remark: "Excellent"},
+			{Grade: "B1", MinPercent: 71, GradePoint: point(8), Remark: "Very good"},
+			{Grade: "B2", MinPercent: 61, GradePoint: point(7), Remark: "Good"},
+			{Grade: "C1", MinPercent: 51, GradePoint: point(6), Remark: "Above average"},
+			{Grade: "C2", MinPercent: 41, GradePoint: point(5), Remark: "Average"},
+			{Grade: "D", MinPercent: 33, GradePoint: point(4), Remark: "Pass"},
+			{Grade: "E", MinPercent: 0, Remark: "Needs improvement"},
+		},
+		PassPercent: 33,
+		IsDefault:   true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+//
+// ================= BIND UPDATE =================
+//
+
+func (g *GradingScale) Bind(req *requests.UpdateGradingScaleRequest) {
+	g.Bands = make([]GradeBand, 0, len(req.Bands))
+	for _, band := range req.Bands {
+		g.Bands = append(g.Bands, GradeBand{
+			Grade:      band.Grade,
+			MinPercent: band.MinPercent,
+			GradePoint: band.GradePoint,
+			Remark:     band.Remark,
+		})
+	}
+	if req.PassPercent != nil {
+		g.PassPercent = *req.PassPercent
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Guardian is a parent or other contact of a student. A student can have several;
+// the primary guardian receives reminders and receipts.
+type Guardian struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	StudentEntityID string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	Name            string             `json:"name,omitempty" bson:"name,omitempty"`
+	Relationship    string             `json:"relationship,omitempty" bson:"relationship,omitempty"` // "father", "mother", "guardian" or "other"
+	Phone           string             `json:"phone,omitempty" bson:"phone,omitempty"`
+	PhoneTokens     []string           `json:"-" bson:"phone_tokens,omitempty"` // leading and trailing digit runs for student search
+	Email           string             `json:"email,omitempty" bson:"email,omitempty"`
+	Address         string             `json:"address,omitempty" bson:"address,omitempty"`
+	IsPrimary       bool               `json:"is_primary" bson:"is_primary"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type UpdateGuardian struct {
+	Name         *string `json:"name,omitempty" bson:"name,omitempty"`
+	Relationship *string `json:"relationship,omitempty" bson:"relationship,omitempty"`
+	Phone        *string `json:"phone,omitempty" bson:"phone,omitempty"`
+	Email        *string `json:"email,omitempty" bson:"email,omitempty"`
+	Address      *string `json:"address,omitempty" bson:"address,omitempty"`
+	IsPrimary    *bool   `json:"is_primary,omitempty" bson:"is_primary,omitempty"`
+	IsDeleted    *bool   `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewGuardian() *Guardian {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &Guardian{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewUpdateGuardian() *UpdateGuardian {
+	return &UpdateGuardian{}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (g *Guardian) Bind(req *requests.CreateGuardianRequest) {
+	g.Name = req.Name
+	g.Relationship = req.Relationship
+	g.Phone = req.Phone
+	g.Email = req.Email
+	g.Address = req.Address
+	g.IsPrimary = req.IsPrimary
+}
+
+//
+// ================= BIND UPDATE =================
+//
+
+func (g *UpdateGuardian) Bind(req *requests.UpdateGuardianRequest) {
+	if req.Name != nil {
+		g.Name = req.Name
+	}
+	if req.Relationship != nil {
+		g.Relationship = req.Relationship
+	}
+	if req.Phone != nil {
+		g.Phone = req.Phone
+	}
+	if req.Email != nil {
+		g.Email = req.Email
+	}
+	if req.Address != nil {
+		g.Address = req.Address
+	}
+	if req.IsPrimary != nil {
+		g.IsPrimary = req.IsPrimary
+	}
+	if req.IsDeleted != nil {
+		g.IsDeleted = req.IsDeleted
+	}
+}
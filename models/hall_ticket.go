@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// HallTicketPaper is one line of the timetable printed on a hall ticket, with the seat
+// once a seating plan has been generated for the paper
+type HallTicketPaper struct {
+	PaperEntityID string `json:"paper_entity_id"`
+	Subject       string `json:"subject"`
+	Date          string `json:"date"`
+	StartTime     string `json:"start_time"`
+	EndTime       string `json:"end_time"`
+	Venue         string `json:"venue,omitempty"`
+	RoomName      string `json:"room_name,omitempty"`
+	SeatNo        int    `json:"seat_no,omitempty"`
+}
+
+// HallTicketBlockedStudent is a student who gets no hall ticket until the exam is paid
+type HallTicketBlockedStudent struct {
+	StudentEntityID string  `json:"student_entity_id"`
+	RefNo           string  `json:"ref_no"`
+	Name            string  `json:"name"`
+	Div             string  `json:"div,omitempty"`
+	PaymentStatus   string  `json:"payment_status"` // "unpaid", or the latest payment's status
+	AmountDue       float64 `json:"amount_due"`
+}
+
+type HallTicketBlockedList struct {
+	ExamEntityID string                     `json:"exam_entity_id"`
+	ExamName     string                     `json:"exam_name"`
+	Div          string                     `json:"div,omitempty"`
+	Eligible     int                        `json:"eligible"`
+	Blocked      []HallTicketBlockedStudent `json:"blocked"`
+}
+
+// HallTicketVerification is what an invigilator sees after scanning a hall ticket.
+// Payment is checked at scan time, so a fee refunded after printing shows as unpaid.
+type HallTicketVerification struct {
+	Admit           bool              `json:"admit"`
+	Message         string            `json:"message"`
+	ExamEntityID    string            `json:"exam_entity_id"`
+	ExamName        string            `json:"exam_name"`
+	StudentEntityID string            `json:"student_entity_id"`
+	RefNo           string            `json:"ref_no"`
+	Name            string            `json:"name"`
+	ClassName       string            `json:"class_name,omitempty"`
+	Div             string            `json:"div,omitempty"`
+	StudentStatus   string            `json:"student_status"`
+	Paid            bool              `json:"paid"`
+	PaymentStatus   string            `json:"payment_status"`
+	PaymentID       string            `json:"payment_id,omitempty"`
+	PaidAt          *time.Time        `json:"paid_at,omitempty"`
+	Papers          []HallTicketPaper `json:"papers"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// ItemSalesRow is the sales figure for one exam or book
+type ItemSalesRow struct {
+	ItemType         string   `json:"item_type"` // "exam" or "book"
+	ItemEntityID     string   `json:"item_entity_id"`
+	ItemName         string   `json:"item_name"`
+	FeesType         string   `json:"fees_type"` // "compulsory" or "optional"
+	BoardEntityID    string   `json:"board_entity_id"`
+	BoardName        string   `json:"board_name"`
+	ClassEntityID    string   `json:"class_entity_id"`
+	ClassName        string   `json:"class_name"`
+	UnitPrice        float64  `json:"unit_price"`
+	UnitsPaid        int      `json:"units_paid"`
+	Revenue          float64  `json:"revenue"`
+	EligibleStudents int      `json:"eligible_students"`
+	TakeUpRate       *float64 `json:"take_up_rate,omitempty"` // percentage, optional items only
+}
+
+// ItemSalesResponse for API response
+type ItemSalesResponse struct {
+	Items        []ItemSalesRow `json:"items"`
+	TotalUnits   int            `json:"total_units"`
+	TotalRevenue float64        `json:"total_revenue"`
+}
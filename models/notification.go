@@ -0,0 +1,216 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultReminderThrottleHours is the cooldown between two reminders to the same student
+const DefaultReminderThrottleHours = 72
+
+// NotificationMessage is one reminder to one guardian, kept per student with its delivery status
+type NotificationMessage struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID          string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	BatchID           string             `json:"batch_id,omitempty" bson:"batch_id,omitempty"`
+	StudentEntityID   string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	RefNo             string             `json:"ref_no,omitempty" bson:"ref_no,omitempty"`
+	RecipientName     string             `json:"recipient_name,omitempty" bson:"recipient_name,omitempty"`
+	Recipient         string             `json:"recipient,omitempty" bson:"recipient,omitempty"` // phone number
+	Channel           string             `json:"channel,omitempty" bson:"channel,omitempty"`     // "sms" or "whatsapp"
+	Provider          string             `json:"provider,omitempty" bson:"provider,omitempty"`
+	ProviderMessageID string             `json:"provider_message_id,omitempty" bson:"provider_message_id,omitempty"`
+	Body              string             `json:"body,omitempty" bson:"body,omitempty"`
+	TotalDue          float64            `json:"total_due" bson:"total_due"`
+	Status            string             `json:"status,omitempty" bson:"status,omitempty"` // "sent", "delivered", "failed", "throttled", "opted_out" or "no_contact"
+	Error             string             `json:"error,omitempty" bson:"error,omitempty"`
+	Trigger           string             `json:"trigger,omitempty" bson:"trigger,omitempty"` // "manual" or "schedule"
+	TriggeredBy       *PaymentActor      `json:"triggered_by,omitempty" bson:"triggered_by,omitempty"`
+	SentAt            *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	DeliveredAt       *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// NotificationOptOut stops reminders to a phone number on one channel, or on every channel
+type NotificationOptOut struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Phone           string             `json:"phone,omitempty" bson:"phone,omitempty"`     // normalised, digits only
+	Channel         string             `json:"channel,omitempty" bson:"channel,omitempty"` // "sms", "whatsapp" or "all"
+	StudentEntityID string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	Reason          string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ReminderFilter picks the unpaid students a reminder goes to
+type ReminderFilter struct {
+	BoardEntityID    string   `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID    string   `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	Div              string   `json:"div,omitempty" bson:"div,omitempty"`
+	ItemType         string   `json:"item_type,omitempty" bson:"item_type,omitempty"`
+	IncludeOptional  bool     `json:"include_optional" bson:"include_optional"`
+	MinDue           *float64 `json:"min_due,omitempty" bson:"min_due,omitempty"`
+	StudentEntityIDs []string `json:"student_entity_ids,omitempty" bson:"student_entity_ids,omitempty"`
+}
+
+// ReminderSchedule sends fee reminders to the filtered unpaid students on a schedule
+type ReminderSchedule struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Name          string             `json:"name,omitempty" bson:"name,omitempty"`
+	Channel       string             `json:"channel,omitempty" bson:"channel,omitempty"`
+	Template      string             `json:"template,omitempty" bson:"template,omitempty"`
+	ThrottleHours int                `json:"throttle_hours" bson:"throttle_hours"`
+	Filter        ReminderFilter     `json:"filter" bson:"filter"`
+	Schedule      ReportSchedule     `json:"schedule" bson:"schedule"`
+	IsActive      bool               `json:"is_active" bson:"is_active"`
+	LastRunAt     *time.Time         `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	NextRunAt     time.Time          `json:"next_run_at" bson:"next_run_at"`
+	IsDeleted     bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ReminderBatch summarises one manual or scheduled send
+type ReminderBatch struct {
+	BatchID   string                 `json:"batch_id"`
+	Channel   string                 `json:"channel"`
+	Students  int                    `json:"students"`
+	Sent      int                    `json:"sent"`
+	Failed    int                    `json:"failed"`
+	Throttled int                    `json:"throttled"`
+	OptedOut  int                    `json:"opted_out"`
+	NoContact int                    `json:"no_contact"`
+	Messages  []*NotificationMessage `json:"messages"`
+}
+
+// Count adds a message's outcome to the totals
+func (b *ReminderBatch) Count(message *NotificationMessage) {
+	switch message.Status {
+	case "sent", "delivered":
+		b.Sent++
+	case "failed":
+		b.Failed++
+	case "throttled":
+		b.Throttled++
+	case "opted_out":
+		b.OptedOut++
+	case "no_contact":
+		b.NoContact++
+	}
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewNotificationMessage() *NotificationMessage {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &NotificationMessage{
+		ID:        id,
+		EntityID:  entityID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewNotificationOptOut() *NotificationOptOut {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &NotificationOptOut{
+		ID:        id,
+		EntityID:  entityID,
+		Channel:   "all",
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewReminderSchedule() *ReminderSchedule {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ReminderSchedule{
+		ID:            id,
+		EntityID:      entityID,
+		ThrottleHours: DefaultReminderThrottleHours,
+		IsActive:      true,
+		IsDeleted:     false,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (o *NotificationOptOut) Bind(req *requests.CreateNotificationOptOutRequest) {
+	o.Phone = req.Phone
+	if req.Channel != "" {
+		o.Channel = req.Channel
+	}
+	o.StudentEntityID = req.StudentEntityID
+	o.Reason = req.Reason
+}
+
+func (s *ReminderSchedule) Bind(req *requests.CreateReminderScheduleRequest) {
+	s.Name = req.Name
+	s.Channel = req.Channel
+	s.Template = req.Template
+	if req.ThrottleHours != nil {
+		s.ThrottleHours = *req.ThrottleHours
+	}
+	s.Filter = NewReminderFilter(&req.Filter)
+	s.Schedule = ReportSchedule{
+		Frequency:  req.Schedule.Frequency,
+		TimeOfDay:  req.Schedule.TimeOfDay,
+		Weekday:    req.Schedule.Weekday,
+		DayOfMonth: req.Schedule.DayOfMonth,
+		Timezone:   req.Schedule.Timezone,
+	}
+	if req.IsActive != nil {
+		s.IsActive = *req.IsActive
+	}
+}
+
+func NewReminderFilter(req *requests.ReminderFilterRequest) ReminderFilter {
+	return ReminderFilter{
+		BoardEntityID:    req.BoardEntityID,
+		ClassEntityID:    req.ClassEntityID,
+		Div:              req.Div,
+		ItemType:         req.ItemType,
+		IncludeOptional:  req.IncludeOptional,
+		MinDue:           req.MinDue,
+		StudentEntityIDs: req.StudentEntityIDs,
+	}
+}
@@ -12,20 +12,34 @@ import (
 type PaymentScanner struct {
 	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
 	StudentEntityID string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
 	ExamEntityID    string             `json:"exam_entity_id,omitempty" bson:"exam_entity_id,omitempty"`
 	PaymentID       string             `json:"payment_id,omitempty" bson:"payment_id,omitempty"`
 	PaymentDate     time.Time          `json:"payment_date,omitempty" bson:"payment_date,omitempty"`
 	PaymentMethod   string             `json:"payment_method,omitempty" bson:"payment_method,omitempty"`
 	Amount          float64            `json:"amount,omitempty" bson:"amount,omitempty"`
-	Status          string             `json:"status,omitempty" bson:"status,omitempty"` // paid, pending, failed
+	Status          string             `json:"status,omitempty" bson:"status,omitempty"` // paid, pending, failed, void, refunded
 	TransactionID   string             `json:"transaction_id,omitempty" bson:"transaction_id,omitempty"`
+	UPIReference    string             `json:"upi_reference,omitempty" bson:"upi_reference,omitempty"` // 12-digit UTR of a UPI payment
+	CollectedBy     *PaymentActor      `json:"collected_by,omitempty" bson:"collected_by,omitempty"`
+	ReversedBy      *PaymentActor      `json:"reversed_by,omitempty" bson:"reversed_by,omitempty"`
+	ReversedAt      *time.Time         `json:"reversed_at,omitempty" bson:"reversed_at,omitempty"`
+	ReversalReason  string             `json:"reversal_reason,omitempty" bson:"reversal_reason,omitempty"`
 	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
 }
 
+// PaymentActor is the logged-in user (from the JWT) who collected or reversed a payment
+type PaymentActor struct {
+	UserID string `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Name   string `json:"name,omitempty" bson:"name,omitempty"`
+	Email  string `json:"email,omitempty" bson:"email,omitempty"`
+	Role   string `json:"role,omitempty" bson:"role,omitempty"`
+}
+
 type UpdatePaymentScanner struct {
 	StudentEntityID *string    `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
 	ExamEntityID    *string    `json:"exam_entity_id,omitempty" bson:"exam_entity_id,omitempty"`
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PromotionUndoWindow is how long an applied promotion can still be undone
+const PromotionUndoWindow = 48 * time.Hour
+
+// PromotionStudent is one student's move from the source session to the target session.
+// Graduated students are not enrolled in the target session.
+type PromotionStudent struct {
+	StudentEntityID   string        `json:"student_entity_id" bson:"student_entity_id"`
+	RefNo             string        `json:"ref_no" bson:"ref_no"`
+	StudentName       string        `json:"student_name" bson:"student_name"`
+	FromBoardEntityID string        `json:"from_board_entity_id" bson:"from_board_entity_id"`
+	FromClassEntityID string        `json:"from_class_entity_id" bson:"from_class_entity_id"`
+	FromDiv           string        `json:"from_div" bson:"from_div"`
+	Outcome           string        `json:"outcome" bson:"outcome"` // "promoted", "detained" or "graduated"; "unmapped" in a preview
+	ToBoardEntityID   string        `json:"to_board_entity_id,omitempty" bson:"to_board_entity_id,omitempty"`
+	ToClassEntityID   string        `json:"to_class_entity_id,omitempty" bson:"to_class_entity_id,omitempty"`
+	ToDiv             string        `json:"to_div,omitempty" bson:"to_div,omitempty"`
+	Overridden        bool          `json:"overridden" bson:"overridden"`
+	CarriedItems      []CarriedItem `json:"carried_items" bson:"carried_items"` // compulsory dues outstanding in the source session
+	CarriedDue        float64       `json:"carried_due" bson:"carried_due"`
+	EnrolmentEntityID string        `json:"enrolment_entity_id,omitempty" bson:"enrolment_entity_id,omitempty"`
+}
+
+// CarriedItem is a compulsory item a student still owed when promoted
+type CarriedItem struct {
+	ItemType            string    `json:"item_type" bson:"item_type"` // "exam" or "book"
+	ItemEntityID        string    `json:"item_entity_id" bson:"item_entity_id"`
+	ItemName            string    `json:"item_name" bson:"item_name"`
+	Amount              float64   `json:"amount" bson:"amount"`
+	DueDate             time.Time `json:"due_date" bson:"due_date"`
+	FromSessionEntityID string    `json:"from_session_entity_id" bson:"from_session_entity_id"` // session the item was charged in
+}
+
+type PromotionTotals struct {
+	Students         int     `json:"students" bson:"students"`
+	Promoted         int     `json:"promoted" bson:"promoted"`
+	Detained         int     `json:"detained" bson:"detained"`
+	Graduated        int     `json:"graduated" bson:"graduated"`
+	Unmapped         int     `json:"unmapped" bson:"unmapped"` // students of classes without a mapping, left where they are
+	StudentsWithDues int     `json:"students_with_dues" bson:"students_with_dues"`
+	CarriedDue       float64 `json:"carried_due" bson:"carried_due"`
+}
+
+// PromotionPreview is what a promotion would do, without writing anything
+type PromotionPreview struct {
+	FromSessionEntityID string             `json:"from_session_entity_id"`
+	ToSessionEntityID   string             `json:"to_session_entity_id"`
+	Students            []PromotionStudent `json:"students"`
+	Unmapped            []PromotionStudent `json:"unmapped"`
+	Totals              PromotionTotals    `json:"totals"`
+}
+
+// PromotionBatch is an applied promotion. It can be undone until UndoUntil as long as
+// nothing has been collected from the promoted students in the target session.
+type PromotionBatch struct {
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID            string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	FromSessionEntityID string             `json:"from_session_entity_id" bson:"from_session_entity_id"`
+	ToSessionEntityID   string             `json:"to_session_entity_id" bson:"to_session_entity_id"`
+	Status              string             `json:"status" bson:"status"` // "pending", "applied" or "undone"
+	Students            []PromotionStudent `json:"students" bson:"students"`
+	Totals              PromotionTotals    `json:"totals" bson:"totals"`
+	AppliedBy           *PaymentActor      `json:"applied_by,omitempty" bson:"applied_by,omitempty"`
+	AppliedAt           time.Time          `json:"applied_at" bson:"applied_at"`
+	UndoUntil           time.Time          `json:"undo_until" bson:"undo_until"`
+	UndoneBy            *PaymentActor      `json:"undone_by,omitempty" bson:"undone_by,omitempty"`
+	UndoneAt            *time.Time         `json:"undone_at,omitempty" bson:"undone_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// CarriedDue is an item a student still owed when promoted out of a session. It is
+// owed in the target session alongside that session's own fees.
+type CarriedDue struct {
+	ID                  primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID            string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	StudentEntityID     string             `json:"student_entity_id" bson:"student_entity_id"`
+	PromotionEntityID   string             `json:"promotion_entity_id" bson:"promotion_entity_id"`
+	FromSessionEntityID string             `json:"from_session_entity_id" bson:"from_session_entity_id"` // session the item was originally charged in
+	ToSessionEntityID   string             `json:"to_session_entity_id" bson:"to_session_entity_id"`
+	ItemType            string             `json:"item_type" bson:"item_type"`
+	ItemEntityID        string             `json:"item_entity_id" bson:"item_entity_id"`
+	ItemName            string             `json:"item_name" bson:"item_name"`
+	Amount              float64            `json:"amount" bson:"amount"`
+	DueDate             time.Time          `json:"due_date" bson:"due_date"`
+	IsDeleted           bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// ClassHistoryEntry is one session of a student's class history
+type ClassHistoryEntry struct {
+	SessionEntityID   string    `json:"session_entity_id"`
+	SessionName       string    `json:"session_name"`
+	StartDate         time.Time `json:"start_date"`
+	EndDate           time.Time `json:"end_date"`
+	BoardEntityID     string    `json:"board_entity_id"`
+	BoardName         string    `json:"board_name"`
+	ClassEntityID     string    `json:"class_entity_id"`
+	ClassName         string    `json:"class_name"`
+	Div               string    `json:"div"`
+	Outcome           string    `json:"outcome,omitempty"`
+	PromotionEntityID string    `json:"promotion_entity_id,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewPromotionBatch() *PromotionBatch {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &PromotionBatch{
+		ID:        id,
+		EntityID:  entityID,
+		Status:    "applied",
+		AppliedAt: now,
+		UndoUntil: now.Add(PromotionUndoWindow),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewCarriedDue() *CarriedDue {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &CarriedDue{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Count adds the student to the totals
+func (t *PromotionTotals) Count(student PromotionStudent) {
+	switch student.Outcome {
+	case "promoted":
+		t.Promoted++
+	case "detained":
+		t.Detained++
+	case "graduated":
+		t.Graduated++
+	default:
+		t.Unmapped++
+		return
+	}
+	t.Students++
+	if student.CarriedDue > 0 {
+		t.StudentsWithDues++
+		t.CarriedDue += student.CarriedDue
+	}
+}
@@ -9,6 +9,8 @@ import (
 // Receipt represents a student's payment receipt with complete details
 type Receipt struct {
 	StudentDetails  StudentPaymentDetails `json:"student_details"`
+	Session         *AcademicSession      `json:"session,omitempty"` // session the fees belong to
+	Guardians       []Guardian            `json:"guardians"`         // primary first
 	PaymentHistory  []PaymentHistoryItem  `json:"payment_history"`
 	TotalPaid       float64               `json:"total_paid"`
 	TotalDue        float64               `json:"total_due"`
@@ -38,6 +40,7 @@ type StudentPaymentDetails struct {
 	Div           string             `json:"div"`
 	BoardEntityID string             `json:"board_entity_id"`
 	ClassEntityID string             `json:"class_entity_id"`
+	Status        string             `json:"status"` // "active", "transferred", "withdrawn" or "alumni"
 	BoardName     string             `json:"board_name,omitempty"`
 	ClassName     string             `json:"class_name,omitempty"`
 }
@@ -53,20 +56,54 @@ type PaymentHistoryItem struct {
 	Amount        float64            `json:"amount"`
 	Status        string             `json:"status"`
 	TransactionID string             `json:"transaction_id"`
+	UPIReference  string             `json:"upi_reference,omitempty"`
 	ExamName      string             `json:"exam_name"`
 	ExamAmount    float64            `json:"exam_amount"`
 }
 
-// PendingPayment contains details of payments that need to be made
+// PendingPayment contains details of payments that need to be made. The exam_*
+// fields hold books too, like exam_entity_id on payments; item_type tells them apart.
 type PendingPayment struct {
-	ExamEntityID string  `json:"exam_entity_id"`
-	ExamName     string  `json:"exam_name"`
-	ExamAmount   float64 `json:"exam_amount"`
-	FeesPaid     bool    `json:"fees_paid"`
-	DueAmount    float64 `json:"due_amount"`
+	ItemType                   string  `json:"item_type"` // "exam" or "book"
+	ExamEntityID               string  `json:"exam_entity_id"`
+	ExamName                   string  `json:"exam_name"`
+	ExamAmount                 float64 `json:"exam_amount"`
+	FeesPaid                   bool    `json:"fees_paid"`
+	DueAmount                  float64 `json:"due_amount"`
+	IsCompulsory               bool    `json:"is_compulsory"`
+	CarriedFromSessionEntityID string  `json:"carried_from_session_entity_id,omitempty"` // set for dues carried forward from an earlier session
 }
 
 // ReceiptRequest for looking up student by refNo
 type ReceiptRequest struct {
 	RefNo string `json:"ref_no" binding:"required"`
 }
+
+// CheckoutReceipt is one checkout, the items collected together under a payment_id
+type CheckoutReceipt struct {
+	PaymentID       string                `json:"payment_id"`
+	SessionEntityID string                `json:"session_entity_id,omitempty"`
+	PaymentDate     time.Time             `json:"payment_date"`
+	PaymentMethod   string                `json:"payment_method"`
+	UPIReference    string                `json:"upi_reference,omitempty"`
+	Status          string                `json:"status"` // "paid", "partially_reversed" or "reversed"
+	StudentDetails  StudentPaymentDetails `json:"student_details"`
+	Items           []PaymentHistoryItem  `json:"items"`
+	TotalPaid       float64               `json:"total_paid"` // items still paid, reversed ones excluded
+	CollectedBy     *PaymentActor         `json:"collected_by,omitempty"`
+}
+
+// ReceiptCode is the signed code printed as a QR on a checkout's receipt
+type ReceiptCode struct {
+	PaymentID string `json:"payment_id"`
+	Code      string `json:"code"`
+}
+
+// ReceiptScanResult is what a scanned code resolved to. A student ID card gives the
+// student's dues view; a receipt QR or a UPI reference gives the checkout it paid for.
+type ReceiptScanResult struct {
+	Source   string           `json:"source"` // "ref_no", "receipt_code" or "upi_reference"
+	Code     string           `json:"code"`   // the payload as read after cleaning
+	Receipt  *Receipt         `json:"receipt,omitempty"`
+	Checkout *CheckoutReceipt `json:"checkout,omitempty"`
+}
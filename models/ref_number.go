@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefNumberSettings configures the ref numbers handed to new admissions that arrive
+// without one, e.g. "ADM-2025-0042". A company has at most one settings document.
+type RefNumberSettings struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID    string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	IsEnabled   bool               `json:"is_enabled" bson:"is_enabled"`
+	Prefix      string             `json:"prefix" bson:"prefix"`
+	Separator   string             `json:"separator" bson:"separator"`       // "", "-" or "/"
+	IncludeYear bool               `json:"include_year" bson:"include_year"` // start year of the active session
+	Padding     int                `json:"padding" bson:"padding"`           // digits of the zero-padded sequence
+	NextRefNo   string             `json:"next_ref_no,omitempty" bson:"-"`   // what the next admission would get
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// RefNoConflict is a ref number that is already taken by an active student
+type RefNoConflict struct {
+	RefNo           string `json:"ref_no"`
+	StudentEntityID string `json:"student_entity_id,omitempty"` // the student holding it, when already saved
+	Row             int    `json:"row,omitempty"`               // CSV row of an import
+}
+
+// DuplicateRefNo is a ref number shared by several active students
+type DuplicateRefNo struct {
+	RefNo            string   `json:"ref_no" bson:"_id"`
+	StudentEntityIDs []string `json:"student_entity_ids" bson:"student_entity_ids"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+// NewRefNumberSettings returns the defaults used until a company saves its own
+func NewRefNumberSettings() *RefNumberSettings {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &RefNumberSettings{
+		ID:          id,
+		EntityID:    entityID,
+		IsEnabled:   false,
+		Separator:   "-",
+		IncludeYear: true,
+		Padding:     4,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+// ReportCard is one student's results in an exam. Papers without marks yet leave the
+// result incomplete and the student unranked.
+type ReportCard struct {
+	StudentEntityID string              `json:"student_entity_id"`
+	RefNo           string              `json:"ref_no"`
+	Name            string              `json:"name"`
+	BoardName       string              `json:"board_name,omitempty"`
+	ClassName       string              `json:"class_name,omitempty"`
+	Div             string              `json:"div,omitempty"`
+	Subjects        []ReportCardSubject `json:"subjects"`
+	Total           float64             `json:"total"`
+	MaxTotal        float64             `json:"max_total"`
+	Percentage      float64             `json:"percentage"`
+	Grade           string              `json:"grade,omitempty"`
+	GradeRemark     string              `json:"grade_remark,omitempty"`
+	Result          string              `json:"result"`         // "pass", "fail" or "incomplete"
+	Rank            int                 `json:"rank,omitempty"` // within the class, ties sharing a rank
+	RankOutOf       int                 `json:"rank_out_of,omitempty"`
+}
+
+type ReportCardSubject struct {
+	PaperEntityID string   `json:"paper_entity_id"`
+	Subject       string   `json:"subject"`
+	MaxMarks      float64  `json:"max_marks"`
+	PassMarks     float64  `json:"pass_marks"`
+	Marks         *float64 `json:"marks,omitempty"` // unset until entered
+	Absent        bool     `json:"absent"`
+	Grade         string   `json:"grade,omitempty"`
+	Passed        bool     `json:"passed"`
+	Status        string   `json:"status"` // the paper's marks status
+}
+
+// ReportCardSet is the report cards of an exam for a class, a division or one student.
+// They are provisional until every paper's marks are locked.
+type ReportCardSet struct {
+	SessionEntityID string       `json:"session_entity_id,omitempty"`
+	ExamEntityID    string       `json:"exam_entity_id"`
+	ExamName        string       `json:"exam_name"`
+	SessionName     string       `json:"session_name,omitempty"`
+	Provisional     bool         `json:"provisional"`
+	Cards           []ReportCard `json:"cards"`
+}
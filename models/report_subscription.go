@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportSubscription emails one report to a list of recipients on a schedule
+type ReportSubscription struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Name          string             `json:"name,omitempty" bson:"name,omitempty"`
+	ReportType    string             `json:"report_type,omitempty" bson:"report_type,omitempty"` // "daily_collection", "unpaid_students" or "dashboard_stats"
+	Recipients    []string           `json:"recipients" bson:"recipients"`
+	Format        string             `json:"format,omitempty" bson:"format,omitempty"` // attachment format: "csv" or "pdf"
+	Schedule      ReportSchedule     `json:"schedule" bson:"schedule"`
+	BoardEntityID string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	IsActive      bool               `json:"is_active" bson:"is_active"`
+	LastRunAt     *time.Time         `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	NextRunAt     time.Time          `json:"next_run_at" bson:"next_run_at"`
+	IsDeleted     bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type ReportSchedule struct {
+	Frequency  string `json:"frequency" bson:"frequency"`                           // "daily", "weekly" or "monthly"
+	TimeOfDay  string `json:"time_of_day" bson:"time_of_day"`                       // HH:MM in Timezone
+	Weekday    int    `json:"weekday,omitempty" bson:"weekday,omitempty"`           // 0 = Sunday, weekly only
+	DayOfMonth int    `json:"day_of_month,omitempty" bson:"day_of_month,omitempty"` // 1-28, monthly only
+	Timezone   string `json:"timezone,omitempty" bson:"timezone,omitempty"`         // IANA name, defaults to UTC
+}
+
+// ReportRun logs one execution of a subscription and every delivery attempt it made
+type ReportRun struct {
+	ID                   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID             string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SubscriptionEntityID string             `json:"subscription_entity_id,omitempty" bson:"subscription_entity_id,omitempty"`
+	ReportType           string             `json:"report_type,omitempty" bson:"report_type,omitempty"`
+	Trigger              string             `json:"trigger,omitempty" bson:"trigger,omitempty"` // "schedule" or "manual"
+	Status               string             `json:"status,omitempty" bson:"status,omitempty"`   // "running", "success", "partial" or "failed"
+	Error                string             `json:"error,omitempty" bson:"error,omitempty"`
+	AttachmentName       string             `json:"attachment_name,omitempty" bson:"attachment_name,omitempty"`
+	Deliveries           []ReportDelivery   `json:"deliveries" bson:"deliveries"`
+	StartedAt            time.Time          `json:"started_at" bson:"started_at"`
+	FinishedAt           *time.Time         `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+type ReportDelivery struct {
+	Recipient   string    `json:"recipient" bson:"recipient"`
+	Attempt     int       `json:"attempt" bson:"attempt"`
+	Status      string    `json:"status" bson:"status"` // "sent" or "failed"
+	Error       string    `json:"error,omitempty" bson:"error,omitempty"`
+	AttemptedAt time.Time `json:"attempted_at" bson:"attempted_at"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewReportSubscription() *ReportSubscription {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ReportSubscription{
+		ID:         id,
+		EntityID:   entityID,
+		Recipients: []string{},
+		Format:     "csv",
+		IsActive:   true,
+		IsDeleted:  false,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func NewReportRun(subscription *ReportSubscription, trigger string) *ReportRun {
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &ReportRun{
+		ID:                   id,
+		EntityID:             entityID,
+		SubscriptionEntityID: subscription.EntityID,
+		ReportType:           subscription.ReportType,
+		Trigger:              trigger,
+		Status:               "running",
+		Deliveries:           []ReportDelivery{},
+		StartedAt:            time.Now().UTC(),
+	}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (s *ReportSubscription) Bind(req *requests.CreateReportSubscriptionRequest) {
+	s.Name = req.Name
+	s.ReportType = req.ReportType
+	s.Recipients = req.Recipients
+	if req.Format != "" {
+		s.Format = req.Format
+	}
+	s.Schedule = ReportSchedule{
+		Frequency:  req.Schedule.Frequency,
+		TimeOfDay:  req.Schedule.TimeOfDay,
+		Weekday:    req.Schedule.Weekday,
+		DayOfMonth: req.Schedule.DayOfMonth,
+		Timezone:   req.Schedule.Timezone,
+	}
+	if s.Schedule.Timezone == "" {
+		s.Schedule.Timezone = "UTC"
+	}
+	s.BoardEntityID = req.BoardEntityID
+	s.ClassEntityID = req.ClassEntityID
+	if req.IsActive != nil {
+		s.IsActive = *req.IsActive
+	}
+}
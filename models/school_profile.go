@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SchoolProfile is the school's name, contact details and logo as printed on ID cards
+// and other documents. A company has at most one profile document.
+type SchoolProfile struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Name            string             `json:"name" bson:"name"`
+	Address         string             `json:"address,omitempty" bson:"address,omitempty"`
+	Phone           string             `json:"phone,omitempty" bson:"phone,omitempty"`
+	Email           string             `json:"email,omitempty" bson:"email,omitempty"`
+	Website         string             `json:"website,omitempty" bson:"website,omitempty"`
+	HasLogo         bool               `json:"has_logo" bson:"has_logo"`
+	LogoContentType string             `json:"logo_content_type,omitempty" bson:"logo_content_type,omitempty"`
+	LogoSHA256      string             `json:"logo_sha256,omitempty" bson:"logo_sha256,omitempty"`
+	LogoStorageKey  string             `json:"-" bson:"logo_storage_key,omitempty"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewSchoolProfile() *SchoolProfile {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &SchoolProfile{
+		ID:        id,
+		EntityID:  entityID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
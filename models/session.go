@@ -0,0 +1,143 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcademicSession is a school year such as "2025-26". Exams, books, enrolments and
+// payments belong to a session; exactly one session per company is active. Sessions
+// that started before the active one are closed and read-only.
+type AcademicSession struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID  string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	Name      string             `json:"name,omitempty" bson:"name,omitempty"`
+	StartDate time.Time          `json:"start_date" bson:"start_date"`
+	EndDate   time.Time          `json:"end_date" bson:"end_date"`
+	IsActive  bool               `json:"is_active" bson:"is_active"`
+	IsDeleted bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+type UpdateAcademicSession struct {
+	Name      *string    `json:"name,omitempty" bson:"name,omitempty"`
+	StartDate *time.Time `json:"start_date,omitempty" bson:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty" bson:"end_date,omitempty"`
+}
+
+// Enrolment records a student's board, class and division within one session. The
+// student document carries the enrolment of the session it was last enrolled in; the
+// enrolments collection keeps every session so past sessions can still be listed.
+type Enrolment struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID          string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	StudentEntityID   string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	SessionEntityID   string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"`
+	BoardEntityID     string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID     string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	Div               string             `json:"div,omitempty" bson:"div,omitempty"`
+	Status            string             `json:"status,omitempty" bson:"status,omitempty"`                           // the student's status in this session; empty is active
+	Outcome           string             `json:"outcome,omitempty" bson:"outcome,omitempty"`                         // "promoted", "detained" or "graduated" once the session's promotion ran
+	PromotionEntityID string             `json:"promotion_entity_id,omitempty" bson:"promotion_entity_id,omitempty"` // promotion batch that created this enrolment
+	IsDeleted         bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// SessionBackfill reports how many unscoped documents were assigned to a session
+type SessionBackfill struct {
+	SessionEntityID string `json:"session_entity_id"`
+	Exams           int64  `json:"exams"`
+	Books           int64  `json:"books"`
+	Students        int64  `json:"students"`
+	Enrolments      int64  `json:"enrolments"`
+	Payments        int64  `json:"payments"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewAcademicSession() *AcademicSession {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &AcademicSession{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewUpdateAcademicSession() *UpdateAcademicSession {
+	return &UpdateAcademicSession{}
+}
+
+// NewEnrolment enrols the student in the session with the student's current board, class and division
+func NewEnrolment(student *Student, sessionEntityID string) *Enrolment {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &Enrolment{
+		ID:              id,
+		EntityID:        entityID,
+		StudentEntityID: student.EntityID,
+		SessionEntityID: sessionEntityID,
+		BoardEntityID:   student.BoardEntityID,
+		ClassEntityID:   student.ClassEntityID,
+		Div:             student.Div,
+		IsDeleted:       false,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+//
+// ================= BIND CREATE =================
+//
+
+func (s *AcademicSession) Bind(req *requests.CreateAcademicSessionRequest) {
+	s.Name = req.Name
+	s.StartDate, _ = time.Parse("2006-01-02", req.StartDate)
+	s.EndDate, _ = time.Parse("2006-01-02", req.EndDate)
+	s.IsActive = req.IsActive
+}
+
+//
+// ================= BIND UPDATE =================
+//
+
+func (s *UpdateAcademicSession) Bind(req *requests.UpdateAcademicSessionRequest) {
+	if req.Name != nil {
+		s.Name = req.Name
+	}
+	if req.StartDate != nil {
+		if startDate, err := time.Parse("2006-01-02", *req.StartDate); err == nil {
+			s.StartDate = &startDate
+		}
+	}
+	if req.EndDate != nil {
+		if endDate, err := time.Parse("2006-01-02", *req.EndDate); err == nil {
+			s.EndDate = &endDate
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+// StudentSearchResult is one ranked match of the student search
+type StudentSearchResult struct {
+	Student
+	Score     int      `json:"score"`
+	MatchedOn []string `json:"matched_on"` // "ref_no", "name" and/or "guardian_phone"
+}
+
+type StudentSearchResponse struct {
+	Query     string                `json:"query"`
+	Results   []StudentSearchResult `json:"results"`
+	Truncated bool                  `json:"truncated"` // more students matched than were ranked
+}
+
+// StudentSearchReindex reports a rebuild of the search tokens of all students and the
+// phone tokens of all guardians
+type StudentSearchReindex struct {
+	Students         int `json:"students"`
+	Updated          int `json:"updated"`
+	Guardians        int `json:"guardians"`
+	GuardiansUpdated int `json:"guardians_updated"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"shared/pkgs/uuids"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StudentStatusChange records one change of a student's lifecycle status. Students
+// who leave keep their documents and payments; only their status changes.
+type StudentStatusChange struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID          string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	StudentEntityID   string             `json:"student_entity_id,omitempty" bson:"student_entity_id,omitempty"`
+	SessionEntityID   string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"` // session of the enrolment the change applies to
+	FromStatus        string             `json:"from_status" bson:"from_status"`
+	Status            string             `json:"status" bson:"status"` // "active", "transferred", "withdrawn" or "alumni"
+	EffectiveDate     time.Time          `json:"effective_date" bson:"effective_date"`
+	Reason            string             `json:"reason,omitempty" bson:"reason,omitempty"`
+	DestinationSchool string             `json:"destination_school,omitempty" bson:"destination_school,omitempty"` // school a transferred student moves to
+	Remarks           string             `json:"remarks,omitempty" bson:"remarks,omitempty"`
+	PromotionEntityID string             `json:"promotion_entity_id,omitempty" bson:"promotion_entity_id,omitempty"` // promotion that graduated the student
+	ChangedBy         *PaymentActor      `json:"changed_by,omitempty" bson:"changed_by,omitempty"`
+	IsDeleted         bool               `json:"is_deleted" bson:"is_deleted"`
+
+	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// LeavingCertificate is the data printed on a student's leaving (transfer) certificate
+type LeavingCertificate struct {
+	StudentEntityID   string     `json:"student_entity_id"`
+	RefNo             string     `json:"ref_no"`
+	FirstName         string     `json:"first_name"`
+	MiddleName        string     `json:"middle_name,omitempty"`
+	LastName          string     `json:"last_name"`
+	GuardianName      string     `json:"guardian_name,omitempty"` // primary guardian
+	GuardianRelation  string     `json:"guardian_relation,omitempty"`
+	BoardName         string     `json:"board_name"`
+	ClassName         string     `json:"class_name"` // class last attended
+	Div               string     `json:"div"`
+	SessionName       string     `json:"session_name,omitempty"`
+	AdmissionDate     time.Time  `json:"admission_date"`
+	LeavingDate       *time.Time `json:"leaving_date,omitempty"`
+	Status            string     `json:"status"`
+	Reason            string     `json:"reason,omitempty"`
+	DestinationSchool string     `json:"destination_school,omitempty"`
+	Remarks           string     `json:"remarks,omitempty"`
+	DuesOutstanding   float64    `json:"dues_outstanding"` // compulsory items still unpaid, including carried dues
+	DuesCleared       bool       `json:"dues_cleared"`
+	TotalPaid         float64    `json:"total_paid"`
+	GeneratedAt       time.Time  `json:"generated_at"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewStudentStatusChange() *StudentStatusChange {
+	now := time.Now().UTC()
+	id := primitive.NewObjectID()
+
+	entityID, err := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	if err != nil {
+		return nil
+	}
+
+	return &StudentStatusChange{
+		ID:        id,
+		EntityID:  entityID,
+		IsDeleted: false,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -10,16 +10,21 @@ import (
 )
 
 type Student struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	EntityID      string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
-	BoardEntityID string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
-	ClassEntityID string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
-	RefNo         string             `json:"ref_no,omitempty" bson:"ref_no,omitempty"`
-	Div           string             `json:"div,omitempty" bson:"div,omitempty"`
-	FirstName     string             `json:"first_name,omitempty" bson:"first_name,omitempty"`
-	MiddleName    string             `json:"middle_name,omitempty" bson:"middle_name,omitempty"`
-	LastName      string             `json:"last_name,omitempty" bson:"last_name,omitempty"`
-	IsDeleted     bool               `json:"is_deleted" bson:"is_deleted"`
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EntityID        string             `json:"entity_id,omitempty" bson:"entity_id,omitempty"`
+	SessionEntityID string             `json:"session_entity_id,omitempty" bson:"session_entity_id,omitempty"` // session of the current enrolment
+	BoardEntityID   string             `json:"board_entity_id,omitempty" bson:"board_entity_id,omitempty"`
+	ClassEntityID   string             `json:"class_entity_id,omitempty" bson:"class_entity_id,omitempty"`
+	RefNo           string             `json:"ref_no,omitempty" bson:"ref_no,omitempty"`
+	Div             string             `json:"div,omitempty" bson:"div,omitempty"`
+	FirstName       string             `json:"first_name,omitempty" bson:"first_name,omitempty"`
+	MiddleName      string             `json:"middle_name,omitempty" bson:"middle_name,omitempty"`
+	LastName        string             `json:"last_name,omitempty" bson:"last_name,omitempty"`
+	SearchTokens    []string           `json:"-" bson:"search_tokens,omitempty"`                   // folded name and ref_no words for student search
+	Status          string             `json:"status,omitempty" bson:"status,omitempty"`           // "active", "transferred", "withdrawn" or "alumni"; empty is active
+	StatusDate      *time.Time         `json:"status_date,omitempty" bson:"status_date,omitempty"` // date the status took effect
+	StatusReason    string             `json:"status_reason,omitempty" bson:"status_reason,omitempty"`
+	IsDeleted       bool               `json:"is_deleted" bson:"is_deleted"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
@@ -52,12 +57,19 @@ func NewStudent() *Student {
 	return &Student{
 		ID:        id,
 		EntityID:  entityID,
+		Status:    "active",
 		IsDeleted: false,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 }
 
+// IsActive reports whether the student is currently enrolled. Students saved before
+// statuses existed have none and count as active.
+func (s *Student) IsActive() bool {
+	return s.Status == "" || s.Status == "active"
+}
+
 func NewUpdateStudent() *UpdateStudent {
 	return &UpdateStudent{}
 }
@@ -21,23 +21,20 @@ type UnpaidStudent struct {
 
 // PendingItem represents an unpaid item (exam or book)
 type PendingItem struct {
-	ItemType     string  `json:"item_type"` // "exam" or "book"
-	ItemEntityID string  `json:"item_entity_id"`
-	ItemName     string  `json:"item_name"`
-	ItemAmount   float64 `json:"item_amount"`
-	DueAmount    float64 `json:"due_amount"`
-	IsCompulsory bool    `json:"is_compulsory"`
+	ItemType                   string  `json:"item_type"` // "exam" or "book"
+	ItemEntityID               string  `json:"item_entity_id"`
+	ItemName                   string  `json:"item_name"`
+	ItemAmount                 float64 `json:"item_amount"`
+	DueAmount                  float64 `json:"due_amount"`
+	IsCompulsory               bool    `json:"is_compulsory"`
+	CarriedFromSessionEntityID string  `json:"carried_from_session_entity_id,omitempty"` // set for dues carried forward from an earlier session
 }
 
-// UnpaidStudentsResponse for API response
+// UnpaidStudentsResponse for API response. Total counts every matching student,
+// Students holds the current page.
 type UnpaidStudentsResponse struct {
-	Students []UnpaidStudent `json:"students"`
-	Total    int             `json:"total"`
-}
-
-// UnpaidStudentsRequest for filtering
-type UnpaidStudentsRequest struct {
-	ClassEntityID *string `json:"class_entity_id,omitempty"`
-	BoardEntityID *string `json:"board_entity_id,omitempty"`
-	ItemType      *string `json:"item_type,omitempty"` // "exam", "book", or "all"
+	Students   []UnpaidStudent `json:"students"`
+	Total      int             `json:"total"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	HasMore    bool            `json:"has_more"`
 }
@@ -15,6 +15,7 @@ type User struct {
 	Name      string             `json:"name,omitempty" bson:"name,omitempty"`
 	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
 	Password  string             `json:"password,omitempty" bson:"password,omitempty"`
+	Role      string             `json:"role,omitempty" bson:"role,omitempty"` // issued in the login token
 	IsDeleted bool               `json:"is_deleted" bson:"is_deleted"`
 
 	CreatedAt time.Time `json:"created_at,omitempty" bson:"created_at,omitempty"`
@@ -25,6 +26,7 @@ type UpdateUser struct {
 	Name      *string `json:"name,omitempty" bson:"name,omitempty"`
 	Email     *string `json:"email,omitempty" bson:"email,omitempty"`
 	Password  *string `json:"password,omitempty" bson:"password,omitempty"`
+	Role      *string `json:"role,omitempty" bson:"role,omitempty"`
 	IsDeleted *bool   `json:"is_deleted,omitempty" bson:"is_deleted,omitempty"`
 }
 
@@ -61,6 +63,7 @@ func (b *User) Bind(request *requests.CreateUserRequest) {
 	b.Name = request.Name
 	b.Email = request.Email
 	b.Password = request.Password
+	b.Role = request.Role
 }
 
 //
@@ -75,4 +78,8 @@ func (b *UpdateUser) Bind(request *requests.UpdateUserRequest) {
 	if request.Password != nil {
 		b.Password = request.Password
 	}
+
+	if request.Role != nil {
+		b.Role = request.Role
+	}
 }
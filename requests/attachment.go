@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadAttachmentRequest holds the form fields sent with the file of a multipart upload
+type UploadAttachmentRequest struct {
+	Kind        string `form:"kind" binding:"required"` // "photo", "birth_certificate", "transfer_certificate" or "other"
+	Description string `form:"description"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewUploadAttachmentRequest() *UploadAttachmentRequest {
+	return &UploadAttachmentRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *UploadAttachmentRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBind(r); err != nil {
+		return err
+	}
+
+	r.Kind = strings.ToLower(strings.TrimSpace(r.Kind))
+	r.Description = strings.TrimSpace(r.Description)
+
+	switch r.Kind {
+	case "photo", "birth_certificate", "transfer_certificate", "other":
+	default:
+		return errors.New("kind must be 'photo', 'birth_certificate', 'transfer_certificate' or 'other'")
+	}
+	if len(r.Description) > 500 {
+		return errors.New("description must not exceed 500 characters")
+	}
+
+	return nil
+}
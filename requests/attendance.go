@@ -0,0 +1,197 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttendanceStatuses are the marks a student can get for a day
+var AttendanceStatuses = []string{"present", "absent", "late", "leave"}
+
+type AttendanceEntryRequest struct {
+	StudentEntityID string `json:"student_entity_id" binding:"required"`
+	Status          string `json:"status" binding:"required"`
+	Remarks         string `json:"remarks,omitempty"`
+}
+
+// SubmitAttendanceRequest marks a division for one day. With default_status set, every
+// student on the roll not listed in entries gets it, so a class teacher can send only
+// the exceptions.
+type SubmitAttendanceRequest struct {
+	ClassEntityID string                   `json:"class_entity_id" binding:"required"`
+	Div           string                   `json:"div" binding:"required"`
+	Date          string                   `json:"date" binding:"required"` // YYYY-MM-DD
+	DefaultStatus string                   `json:"default_status,omitempty"`
+	Entries       []AttendanceEntryRequest `json:"entries,omitempty"`
+}
+
+// AttendanceSheetRequest is bound from the query string of GET /attendance/sheet
+type AttendanceSheetRequest struct {
+	ClassEntityID   string  `form:"class_entity_id" binding:"required"`
+	Div             string  `form:"div" binding:"required"`
+	Date            string  `form:"date" binding:"required"`
+	SessionEntityID *string `form:"session_entity_id"` // defaults to the active session
+}
+
+// AttendanceRegisterRequest is bound from the query string of GET /attendance/register
+type AttendanceRegisterRequest struct {
+	ClassEntityID   string  `form:"class_entity_id" binding:"required"`
+	Div             *string `form:"div"`                      // every division of the class when empty
+	Month           string  `form:"month" binding:"required"` // YYYY-MM
+	SessionEntityID *string `form:"session_entity_id"`
+}
+
+// StudentAttendanceRequest is bound from the query string of GET /students/:id/attendance
+type StudentAttendanceRequest struct {
+	SessionEntityID *string `form:"session_entity_id"`
+	From            *string `form:"from"` // YYYY-MM-DD
+	To              *string `form:"to"`   // YYYY-MM-DD
+}
+
+type UpdateAttendanceSettingsRequest struct {
+	EditWindowHours        *int    `json:"edit_window_hours,omitempty"` // 0 to 720
+	Timezone               *string `json:"timezone,omitempty"`
+	CountLateAsPresent     *bool   `json:"count_late_as_present,omitempty"`
+	RestrictToClassTeacher *bool   `json:"restrict_to_class_teacher,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewSubmitAttendanceRequest() *SubmitAttendanceRequest {
+	return &SubmitAttendanceRequest{}
+}
+
+func NewAttendanceSheetRequest() *AttendanceSheetRequest {
+	return &AttendanceSheetRequest{}
+}
+
+func NewAttendanceRegisterRequest() *AttendanceRegisterRequest {
+	return &AttendanceRegisterRequest{}
+}
+
+func NewStudentAttendanceRequest() *StudentAttendanceRequest {
+	return &StudentAttendanceRequest{}
+}
+
+func NewUpdateAttendanceSettingsRequest() *UpdateAttendanceSettingsRequest {
+	return &UpdateAttendanceSettingsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *SubmitAttendanceRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Div = NormaliseDivision(r.Div)
+	if r.Div == "" {
+		return errors.New("div is required")
+	}
+	if err := validateDate("date", &r.Date); err != nil {
+		return err
+	}
+
+	r.DefaultStatus = strings.ToLower(strings.TrimSpace(r.DefaultStatus))
+	if r.DefaultStatus != "" && !isAttendanceStatus(r.DefaultStatus) {
+		return errors.New("default_status must be 'present', 'absent', 'late' or 'leave'")
+	}
+	if r.DefaultStatus == "" && len(r.Entries) == 0 {
+		return errors.New("entries or default_status is required")
+	}
+
+	seen := make(map[string]bool, len(r.Entries))
+	for i := range r.Entries {
+		entry := &r.Entries[i]
+		entry.StudentEntityID = strings.TrimSpace(entry.StudentEntityID)
+		entry.Status = strings.ToLower(strings.TrimSpace(entry.Status))
+		entry.Remarks = strings.TrimSpace(entry.Remarks)
+
+		if !isAttendanceStatus(entry.Status) {
+			return fmt.Errorf("entries[%d]: status must be 'present', 'absent', 'late' or 'leave'", i)
+		}
+		if seen[entry.StudentEntityID] {
+			return fmt.Errorf("entries[%d]: student %s is listed twice", i, entry.StudentEntityID)
+		}
+		seen[entry.StudentEntityID] = true
+	}
+	return nil
+}
+
+func (r *AttendanceSheetRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	r.Div = NormaliseDivision(r.Div)
+	return validateDate("date", &r.Date)
+}
+
+func (r *AttendanceRegisterRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	if _, err := time.Parse("2006-01", r.Month); err != nil {
+		return errors.New("month must be in YYYY-MM format")
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+	return nil
+}
+
+func (r *StudentAttendanceRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	if err := validateDate("from", r.From); err != nil {
+		return err
+	}
+	if err := validateDate("to", r.To); err != nil {
+		return err
+	}
+	if r.From != nil && r.To != nil && *r.From != "" && *r.To != "" && *r.To < *r.From {
+		return errors.New("to must not be before from")
+	}
+	return nil
+}
+
+func (r *UpdateAttendanceSettingsRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.EditWindowHours != nil && (*r.EditWindowHours < 0 || *r.EditWindowHours > 720) {
+		return errors.New("edit_window_hours must be between 0 and 720")
+	}
+	if r.Timezone != nil {
+		timezone := strings.TrimSpace(*r.Timezone)
+		if _, err := time.LoadLocation(timezone); err != nil || timezone == "" {
+			return fmt.Errorf("unknown timezone: %s", timezone)
+		}
+		r.Timezone = &timezone
+	}
+	return nil
+}
+
+func isAttendanceStatus(status string) bool {
+	for _, s := range AttendanceStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,7 @@ type CreateBookRequest struct {
 	Amount        float64 `json:"amount" binding:"required,gt=0"`
 	FeesPaid      bool    `json:"fees_paid"`
 	FeesType      string  `json:"fees_type,omitempty"`
+	DueDate       *string `json:"due_date,omitempty"` // YYYY-MM-DD
 }
 
 type UpdateBookRequest struct {
@@ -24,6 +25,7 @@ type UpdateBookRequest struct {
 	Amount        *float64 `json:"amount,omitempty"`
 	FeesPaid      *bool    `json:"fees_paid,omitempty"`
 	FeesType      *string  `json:"fees_type,omitempty"`
+	DueDate       *string  `json:"due_date,omitempty"` // YYYY-MM-DD, empty clears it
 	IsDeleted     *bool    `json:"is_deleted,omitempty"`
 }
 
@@ -54,12 +56,12 @@ func (r *CreateBookRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	return nil
+	return validateDate("due_date", r.DueDate)
 }
 
 func (r *UpdateBookRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	return nil
+	return validateDate("due_date", r.DueDate)
 }
@@ -0,0 +1,152 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateCalendarEntryRequest struct {
+	Title           string   `json:"title" binding:"required"`
+	Description     string   `json:"description,omitempty"`
+	EntryType       string   `json:"entry_type" binding:"required"` // "holiday", "exam" or "event"
+	StartDate       string   `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate         *string  `json:"end_date,omitempty"`            // YYYY-MM-DD, inclusive, defaults to start_date
+	Recurrence      string   `json:"recurrence,omitempty"`          // "none", "weekly", "monthly" or "yearly"
+	RecurrenceUntil *string  `json:"recurrence_until,omitempty"`    // YYYY-MM-DD
+	BoardEntityIDs  []string `json:"board_entity_ids,omitempty"`    // empty = all boards
+	Flag            string   `json:"flag,omitempty"`
+}
+
+type UpdateCalendarEntryRequest struct {
+	Title           *string   `json:"title,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	EntryType       *string   `json:"entry_type,omitempty"`
+	StartDate       *string   `json:"start_date,omitempty"`
+	EndDate         *string   `json:"end_date,omitempty"`
+	Recurrence      *string   `json:"recurrence,omitempty"`
+	RecurrenceUntil *string   `json:"recurrence_until,omitempty"` // empty clears it
+	BoardEntityIDs  *[]string `json:"board_entity_ids,omitempty"`
+	Flag            *string   `json:"flag,omitempty"`
+	IsDeleted       *bool     `json:"is_deleted,omitempty"`
+}
+
+// CalendarOccurrencesRequest is bound from the query string of GET /calendar/upcoming
+type CalendarOccurrencesRequest struct {
+	From          *string `form:"from"` // YYYY-MM-DD, defaults to today
+	To            *string `form:"to"`   // YYYY-MM-DD, inclusive, defaults to from + 90 days
+	BoardEntityID *string `form:"board_entity_id"`
+	EntryType     *string `form:"entry_type"`
+	Limit         int     `form:"limit"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCreateCalendarEntryRequest() *CreateCalendarEntryRequest {
+	return &CreateCalendarEntryRequest{}
+}
+
+func NewUpdateCalendarEntryRequest() *UpdateCalendarEntryRequest {
+	return &UpdateCalendarEntryRequest{}
+}
+
+func NewCalendarOccurrencesRequest() *CalendarOccurrencesRequest {
+	return &CalendarOccurrencesRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CreateCalendarEntryRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if err := validateCalendarEntryType(r.EntryType); err != nil {
+		return err
+	}
+	if err := validateCalendarRecurrence(r.Recurrence); err != nil {
+		return err
+	}
+	if err := validateDate("start_date", &r.StartDate); err != nil {
+		return err
+	}
+	if err := validateDateRange(&r.StartDate, r.EndDate); err != nil {
+		return err
+	}
+	return validateDate("recurrence_until", r.RecurrenceUntil)
+}
+
+func (r *UpdateCalendarEntryRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.EntryType != nil {
+		if err := validateCalendarEntryType(*r.EntryType); err != nil {
+			return err
+		}
+	}
+	if r.Recurrence != nil {
+		if err := validateCalendarRecurrence(*r.Recurrence); err != nil {
+			return err
+		}
+	}
+	if err := validateDate("start_date", r.StartDate); err != nil {
+		return err
+	}
+	if err := validateDate("end_date", r.EndDate); err != nil {
+		return err
+	}
+	return validateDate("recurrence_until", r.RecurrenceUntil)
+}
+
+func (r *CalendarOccurrencesRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	if r.EntryType != nil {
+		if err := validateCalendarEntryType(*r.EntryType); err != nil {
+			return err
+		}
+	}
+	if r.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+	if err := validateDateRange(r.From, r.To); err != nil {
+		return err
+	}
+
+	// Keep expansion of recurring entries bounded
+	if r.From != nil && *r.From != "" && r.To != nil && *r.To != "" {
+		from, _ := time.Parse("2006-01-02", *r.From)
+		to, _ := time.Parse("2006-01-02", *r.To)
+		if to.Sub(from) > 2*366*24*time.Hour {
+			return errors.New("range between from and to must not exceed two years")
+		}
+	}
+
+	return nil
+}
+
+func validateCalendarEntryType(entryType string) error {
+	if entryType != "holiday" && entryType != "exam" && entryType != "event" {
+		return errors.New("entry_type must be 'holiday', 'exam' or 'event'")
+	}
+	return nil
+}
+
+func validateCalendarRecurrence(recurrence string) error {
+	switch recurrence {
+	case "", "none", "weekly", "monthly", "yearly":
+		return nil
+	}
+	return errors.New("recurrence must be 'none', 'weekly', 'monthly' or 'yearly'")
+}
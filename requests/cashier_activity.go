@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CashierActivityRequest struct {
+	StartDate       *string `json:"start_date,omitempty"`        // YYYY-MM-DD
+	EndDate         *string `json:"end_date,omitempty"`          // YYYY-MM-DD, inclusive
+	UserID          *string `json:"user_id,omitempty"`           // limit the report to one cashier
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCashierActivityRequest() *CashierActivityRequest {
+	return &CashierActivityRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CashierActivityRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+	return validateDateRange(r.StartDate, r.EndDate)
+}
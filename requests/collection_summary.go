@@ -0,0 +1,75 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionSummaryRequest struct {
+	StartDate       *string `json:"start_date,omitempty"`   // YYYY-MM-DD
+	EndDate         *string `json:"end_date,omitempty"`     // YYYY-MM-DD, inclusive
+	PaymentMode     *string `json:"payment_mode,omitempty"` // "cash", "upi", ... or "all"
+	BoardEntityID   *string `json:"board_entity_id,omitempty"`
+	ClassEntityID   *string `json:"class_entity_id,omitempty"`
+	Div             *string `json:"div,omitempty"`
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCollectionSummaryRequest() *CollectionSummaryRequest {
+	return &CollectionSummaryRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CollectionSummaryRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+	return validateDateRange(r.StartDate, r.EndDate)
+}
+
+// validateDate checks an optional YYYY-MM-DD field; an empty value is allowed
+func validateDate(field string, value *string) error {
+	if value == nil || *value == "" {
+		return nil
+	}
+	if _, err := time.Parse("2006-01-02", *value); err != nil {
+		return fmt.Errorf("%s must be in YYYY-MM-DD format", field)
+	}
+	return nil
+}
+
+// validateDateRange checks optional YYYY-MM-DD bounds used by the report requests.
+func validateDateRange(startDate, endDate *string) error {
+	var start, end time.Time
+	var err error
+
+	if startDate != nil && *startDate != "" {
+		start, err = time.Parse("2006-01-02", *startDate)
+		if err != nil {
+			return errors.New("start_date must be in YYYY-MM-DD format")
+		}
+	}
+	if endDate != nil && *endDate != "" {
+		end, err = time.Parse("2006-01-02", *endDate)
+		if err != nil {
+			return errors.New("end_date must be in YYYY-MM-DD format")
+		}
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return errors.New("end_date must not be before start_date")
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionTrendsRequest struct {
+	StartDate           *string `json:"start_date,omitempty"`        // YYYY-MM-DD, defaults to the start of the session
+	EndDate             *string `json:"end_date,omitempty"`          // YYYY-MM-DD, inclusive, defaults to the end of the session
+	Interval            string  `json:"interval,omitempty"`          // "day", "week" or "month" (default)
+	PaymentMode         *string `json:"payment_mode,omitempty"`      // "cash", "upi", ... or "all"
+	Timezone            string  `json:"timezone,omitempty"`          // IANA name used for bucketing, defaults to UTC
+	ComparePreviousYear bool    `json:"compare_previous_year"`       // add the same range one year earlier
+	SessionEntityID     *string `json:"session_entity_id,omitempty"` // default range is this session, or the active one
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCollectionTrendsRequest() *CollectionTrendsRequest {
+	return &CollectionTrendsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CollectionTrendsRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Interval == "" {
+		r.Interval = "month"
+	}
+	if r.Interval != "day" && r.Interval != "week" && r.Interval != "month" {
+		return errors.New("interval must be 'day', 'week' or 'month'")
+	}
+
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return errors.New("timezone must be a valid IANA time zone name")
+	}
+
+	return validateDateRange(r.StartDate, r.EndDate)
+}
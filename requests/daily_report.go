@@ -7,14 +7,15 @@ import (
 )
 
 type DailyReportRequest struct {
-	StartDate     *string `json:"start_date,omitempty"`
-	EndDate       *string `json:"end_date,omitempty"`
-	ItemType      *string `json:"item_type,omitempty"` // "exam", "book", "all"
-	Status        *string `json:"status,omitempty"`    // "paid", "pending", "failed", "all"
-	ClassEntityID *string `json:"class_entity_id,omitempty"`
-	BoardEntityID *string `json:"board_entity_id,omitempty"`
-	ExamEntityID  *string `json:"exam_entity_id,omitempty"`
-	BookEntityID  *string `json:"book_entity_id,omitempty"`
+	StartDate       *string `json:"start_date,omitempty"`
+	EndDate         *string `json:"end_date,omitempty"`
+	ItemType        *string `json:"item_type,omitempty"` // "exam", "book", "all"
+	Status          *string `json:"status,omitempty"`    // "paid", "pending", "failed", "all"
+	ClassEntityID   *string `json:"class_entity_id,omitempty"`
+	BoardEntityID   *string `json:"board_entity_id,omitempty"`
+	ExamEntityID    *string `json:"exam_entity_id,omitempty"`
+	BookEntityID    *string `json:"book_entity_id,omitempty"`
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
 }
 
 //
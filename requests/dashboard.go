@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DashboardStatsRequest is bound from the query string of GET /dashboard/stats
+type DashboardStatsRequest struct {
+	StartDate       *string `form:"start_date"` // YYYY-MM-DD
+	EndDate         *string `form:"end_date"`   // YYYY-MM-DD, inclusive
+	BoardEntityID   *string `form:"board_entity_id"`
+	ClassEntityID   *string `form:"class_entity_id"`
+	Div             *string `form:"div"`
+	Timezone        string  `form:"timezone"`          // IANA name for "today" and "this month", defaults to UTC
+	SessionEntityID *string `form:"session_entity_id"` // defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewDashboardStatsRequest() *DashboardStatsRequest {
+	return &DashboardStatsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *DashboardStatsRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return errors.New("timezone must be a valid IANA time zone name")
+	}
+
+	return validateDateRange(r.StartDate, r.EndDate)
+}
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DefaulterAgingRequest struct {
+	AsOfDate        *string `json:"as_of_date,omitempty"` // YYYY-MM-DD, defaults to today
+	BoardEntityID   *string `json:"board_entity_id,omitempty"`
+	ClassEntityID   *string `json:"class_entity_id,omitempty"`
+	Div             *string `json:"div,omitempty"`
+	IncludeOptional bool    `json:"include_optional"`            // count unpaid optional items as owed
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewDefaulterAgingRequest() *DefaulterAgingRequest {
+	return &DefaulterAgingRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *DefaulterAgingRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+	return validateDate("as_of_date", r.AsOfDate)
+}
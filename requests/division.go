@@ -0,0 +1,101 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateDivisionRequest struct {
+	Name                 string `json:"name" binding:"required"` // e.g. "A"
+	Capacity             int    `json:"capacity,omitempty"`      // 0 means no limit
+	ClassTeacherEntityID string `json:"class_teacher_entity_id,omitempty"`
+}
+
+type UpdateDivisionRequest struct {
+	Name                 *string `json:"name,omitempty"`
+	Capacity             *int    `json:"capacity,omitempty"`
+	ClassTeacherEntityID *string `json:"class_teacher_entity_id,omitempty"` // "" removes the class teacher
+}
+
+// NormaliseDivisionsRequest rewrites the free-text div of students and enrolments to the
+// name of the matching division
+type NormaliseDivisionsRequest struct {
+	CreateMissing bool `json:"create_missing,omitempty"` // create a division for values that match none
+	DryRun        bool `json:"dry_run,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCreateDivisionRequest() *CreateDivisionRequest {
+	return &CreateDivisionRequest{}
+}
+
+func NewUpdateDivisionRequest() *UpdateDivisionRequest {
+	return &UpdateDivisionRequest{}
+}
+
+func NewNormaliseDivisionsRequest() *NormaliseDivisionsRequest {
+	return &NormaliseDivisionsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CreateDivisionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Name = NormaliseDivision(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.Capacity < 0 {
+		return errors.New("capacity must not be negative")
+	}
+	r.ClassTeacherEntityID = strings.TrimSpace(r.ClassTeacherEntityID)
+	return nil
+}
+
+func (r *UpdateDivisionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Name != nil {
+		name := NormaliseDivision(*r.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		r.Name = &name
+	}
+	if r.Capacity != nil && *r.Capacity < 0 {
+		return errors.New("capacity must not be negative")
+	}
+	if r.ClassTeacherEntityID != nil {
+		teacher := strings.TrimSpace(*r.ClassTeacherEntityID)
+		r.ClassTeacherEntityID = &teacher
+	}
+	return nil
+}
+
+func (r *NormaliseDivisionsRequest) Validate(c *gin.Context) error {
+	// The body is optional; an empty one normalises without creating divisions
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	return validations.ValidateJSON(c, r)
+}
+
+// NormaliseDivision trims a division name and collapses inner whitespace. Names are
+// compared case-insensitively, so "A", "a" and "A " are the same division.
+func NormaliseDivision(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
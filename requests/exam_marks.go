@@ -0,0 +1,262 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExamMarkEntryRequest struct {
+	StudentEntityID string   `json:"student_entity_id" binding:"required"`
+	Marks           *float64 `json:"marks,omitempty"` // required unless absent
+	Absent          bool     `json:"absent,omitempty"`
+	Remarks         string   `json:"remarks,omitempty"`
+}
+
+// SubmitExamMarksRequest enters the marks of one paper for a division in bulk. Students
+// not listed keep whatever was entered before.
+type SubmitExamMarksRequest struct {
+	Div     string                 `json:"div" binding:"required"`
+	Entries []ExamMarkEntryRequest `json:"entries" binding:"required"`
+}
+
+// ExamMarkSheetRequest is bound from the query string of GET /exams/:id/papers/:paper_id/marks
+type ExamMarkSheetRequest struct {
+	Div *string `form:"div"` // the whole class when empty
+}
+
+type ExamMarkAdjustmentRequest struct {
+	StudentEntityID string  `json:"student_entity_id" binding:"required"`
+	Marks           float64 `json:"marks"`
+	Reason          string  `json:"reason" binding:"required"`
+}
+
+// ModerateExamMarksRequest closes entry for a paper, applying any adjustments. An empty
+// list accepts the marks as entered.
+type ModerateExamMarksRequest struct {
+	Adjustments []ExamMarkAdjustmentRequest `json:"adjustments,omitempty"`
+}
+
+// ChangeExamMarksStatusRequest locks moderated marks, or reopens them
+type ChangeExamMarksStatusRequest struct {
+	Status string `json:"status" binding:"required"` // "draft", "moderated" or "locked"
+	Reason string `json:"reason,omitempty"`          // required to unlock
+}
+
+type GradeBandRequest struct {
+	Grade      string   `json:"grade" binding:"required"`
+	MinPercent float64  `json:"min_percent"`
+	GradePoint *float64 `json:"grade_point,omitempty"`
+	Remark     string   `json:"remark,omitempty"`
+}
+
+// UpdateGradingScaleRequest replaces a board's grade bands
+type UpdateGradingScaleRequest struct {
+	Bands       []GradeBandRequest `json:"bands" binding:"required"`
+	PassPercent *float64           `json:"pass_percent,omitempty"`
+}
+
+// ReportCardRequest is bound from the query string of GET /exams/:id/report-cards
+type ReportCardRequest struct {
+	Div             *string `form:"div"`
+	StudentEntityID *string `form:"student_entity_id"`
+	Format          string  `form:"format"` // "json" (default) or "pdf"
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewSubmitExamMarksRequest() *SubmitExamMarksRequest {
+	return &SubmitExamMarksRequest{}
+}
+
+func NewExamMarkSheetRequest() *ExamMarkSheetRequest {
+	return &ExamMarkSheetRequest{}
+}
+
+func NewModerateExamMarksRequest() *ModerateExamMarksRequest {
+	return &ModerateExamMarksRequest{}
+}
+
+func NewChangeExamMarksStatusRequest() *ChangeExamMarksStatusRequest {
+	return &ChangeExamMarksStatusRequest{}
+}
+
+func NewUpdateGradingScaleRequest() *UpdateGradingScaleRequest {
+	return &UpdateGradingScaleRequest{}
+}
+
+func NewReportCardRequest() *ReportCardRequest {
+	return &ReportCardRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *SubmitExamMarksRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Div = NormaliseDivision(r.Div)
+	if r.Div == "" {
+		return errors.New("div is required")
+	}
+	if len(r.Entries) == 0 {
+		return errors.New("entries must not be empty")
+	}
+
+	seen := make(map[string]bool, len(r.Entries))
+	for i := range r.Entries {
+		entry := &r.Entries[i]
+		entry.StudentEntityID = strings.TrimSpace(entry.StudentEntityID)
+		entry.Remarks = strings.TrimSpace(entry.Remarks)
+
+		if entry.StudentEntityID == "" {
+			return fmt.Errorf("entries[%d]: student_entity_id is required", i)
+		}
+		if seen[entry.StudentEntityID] {
+			return fmt.Errorf("entries[%d]: student %s is listed twice", i, entry.StudentEntityID)
+		}
+		seen[entry.StudentEntityID] = true
+
+		if entry.Absent && entry.Marks != nil {
+			return fmt.Errorf("entries[%d]: an absent student cannot have marks", i)
+		}
+		if !entry.Absent && entry.Marks == nil {
+			return fmt.Errorf("entries[%d]: marks are required unless absent", i)
+		}
+		if entry.Marks != nil && *entry.Marks < 0 {
+			return fmt.Errorf("entries[%d]: marks must not be negative", i)
+		}
+	}
+	return nil
+}
+
+func (r *ExamMarkSheetRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+	return nil
+}
+
+func (r *ModerateExamMarksRequest) Validate(c *gin.Context) error {
+	// An empty body accepts the marks as entered
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(r.Adjustments))
+	for i := range r.Adjustments {
+		adjustment := &r.Adjustments[i]
+		adjustment.StudentEntityID = strings.TrimSpace(adjustment.StudentEntityID)
+		adjustment.Reason = strings.TrimSpace(adjustment.Reason)
+
+		if adjustment.Reason == "" {
+			return fmt.Errorf("adjustments[%d]: reason is required", i)
+		}
+		if adjustment.Marks < 0 {
+			return fmt.Errorf("adjustments[%d]: marks must not be negative", i)
+		}
+		if seen[adjustment.StudentEntityID] {
+			return fmt.Errorf("adjustments[%d]: student %s is listed twice", i, adjustment.StudentEntityID)
+		}
+		seen[adjustment.StudentEntityID] = true
+	}
+	return nil
+}
+
+func (r *ChangeExamMarksStatusRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Status = strings.ToLower(strings.TrimSpace(r.Status))
+	r.Reason = strings.TrimSpace(r.Reason)
+	switch r.Status {
+	case "draft", "moderated", "locked":
+	default:
+		return errors.New("status must be 'draft', 'moderated' or 'locked'")
+	}
+	return nil
+}
+
+func (r *UpdateGradingScaleRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if len(r.Bands) == 0 {
+		return errors.New("bands must not be empty")
+	}
+
+	grades := make(map[string]bool, len(r.Bands))
+	minimums := make(map[float64]bool, len(r.Bands))
+	hasZero := false
+	for i := range r.Bands {
+		band := &r.Bands[i]
+		band.Grade = strings.TrimSpace(band.Grade)
+		band.Remark = strings.TrimSpace(band.Remark)
+
+		if band.Grade == "" {
+			return fmt.Errorf("bands[%d]: grade is required", i)
+		}
+		if grades[strings.ToUpper(band.Grade)] {
+			return fmt.Errorf("bands[%d]: grade %s is listed twice", i, band.Grade)
+		}
+		grades[strings.ToUpper(band.Grade)] = true
+
+		if band.MinPercent < 0 || band.MinPercent > 100 {
+			return fmt.Errorf("bands[%d]: min_percent must be between 0 and 100", i)
+		}
+		if minimums[band.MinPercent] {
+			return fmt.Errorf("bands[%d]: another band already starts at %g%%", i, band.MinPercent)
+		}
+		minimums[band.MinPercent] = true
+		if band.MinPercent == 0 {
+			hasZero = true
+		}
+	}
+	// Without a band from 0% some results would get no grade at all
+	if !hasZero {
+		return errors.New("one band must start at 0%")
+	}
+
+	if r.PassPercent != nil && (*r.PassPercent < 0 || *r.PassPercent > 100) {
+		return errors.New("pass_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+func (r *ReportCardRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	r.Format = strings.ToLower(strings.TrimSpace(r.Format))
+	switch r.Format {
+	case "":
+		r.Format = "json"
+	case "json", "pdf":
+	default:
+		return errors.New("format must be 'json' or 'pdf'")
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+	return nil
+}
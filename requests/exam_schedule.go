@@ -0,0 +1,192 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExamPaperRequest struct {
+	EntityID  string  `json:"entity_id,omitempty"` // keeps an existing paper, and its seating plan, when rescheduled
+	Subject   string  `json:"subject" binding:"required"`
+	Date      string  `json:"date" binding:"required"`       // YYYY-MM-DD
+	StartTime string  `json:"start_time" binding:"required"` // HH:MM, 24-hour
+	EndTime   string  `json:"end_time" binding:"required"`   // HH:MM, 24-hour
+	Venue     string  `json:"venue,omitempty"`
+	MaxMarks  float64 `json:"max_marks,omitempty"`  // defaults to 100
+	PassMarks float64 `json:"pass_marks,omitempty"` // the board's pass percentage applies when unset
+}
+
+// UpdateExamScheduleRequest replaces the whole timetable of an exam
+type UpdateExamScheduleRequest struct {
+	Papers []ExamPaperRequest `json:"papers"`
+}
+
+type CreateExamRoomRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Building string `json:"building,omitempty"`
+	Capacity int    `json:"capacity" binding:"required,gt=0"`
+}
+
+type UpdateExamRoomRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Building *string `json:"building,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+// GenerateSeatingPlanRequest lists the rooms to seat a paper in, filled in order
+type GenerateSeatingPlanRequest struct {
+	RoomEntityIDs []string `json:"room_entity_ids,omitempty"` // every room, largest first, when empty
+}
+
+// ExamConflictsRequest is bound from the query string of GET /exams/conflicts
+type ExamConflictsRequest struct {
+	SessionEntityID *string `form:"session_entity_id"` // defaults to the active session
+	From            *string `form:"from"`              // YYYY-MM-DD
+	To              *string `form:"to"`                // YYYY-MM-DD
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewUpdateExamScheduleRequest() *UpdateExamScheduleRequest {
+	return &UpdateExamScheduleRequest{}
+}
+
+func NewCreateExamRoomRequest() *CreateExamRoomRequest {
+	return &CreateExamRoomRequest{}
+}
+
+func NewUpdateExamRoomRequest() *UpdateExamRoomRequest {
+	return &UpdateExamRoomRequest{}
+}
+
+func NewGenerateSeatingPlanRequest() *GenerateSeatingPlanRequest {
+	return &GenerateSeatingPlanRequest{}
+}
+
+func NewExamConflictsRequest() *ExamConflictsRequest {
+	return &ExamConflictsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *UpdateExamScheduleRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	for i := range r.Papers {
+		paper := &r.Papers[i]
+		paper.EntityID = strings.TrimSpace(paper.EntityID)
+		paper.Subject = strings.TrimSpace(paper.Subject)
+		paper.Venue = strings.TrimSpace(paper.Venue)
+		paper.StartTime = strings.TrimSpace(paper.StartTime)
+		paper.EndTime = strings.TrimSpace(paper.EndTime)
+
+		if paper.Subject == "" {
+			return fmt.Errorf("papers[%d]: subject is required", i)
+		}
+		if err := validateDate(fmt.Sprintf("papers[%d].date", i), &paper.Date); err != nil {
+			return err
+		}
+		start, err := ParseClock(paper.StartTime)
+		if err != nil {
+			return fmt.Errorf("papers[%d]: start_time %v", i, err)
+		}
+		end, err := ParseClock(paper.EndTime)
+		if err != nil {
+			return fmt.Errorf("papers[%d]: end_time %v", i, err)
+		}
+		if end <= start {
+			return fmt.Errorf("papers[%d]: end_time must be after start_time", i)
+		}
+
+		if paper.MaxMarks == 0 {
+			paper.MaxMarks = 100
+		}
+		if paper.MaxMarks < 0 {
+			return fmt.Errorf("papers[%d]: max_marks must be greater than 0", i)
+		}
+		if paper.PassMarks < 0 || paper.PassMarks > paper.MaxMarks {
+			return fmt.Errorf("papers[%d]: pass_marks must be between 0 and max_marks", i)
+		}
+	}
+	return nil
+}
+
+func (r *CreateExamRoomRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	r.Building = strings.TrimSpace(r.Building)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func (r *UpdateExamRoomRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		r.Name = &name
+	}
+	if r.Building != nil {
+		building := strings.TrimSpace(*r.Building)
+		r.Building = &building
+	}
+	if r.Capacity != nil && *r.Capacity <= 0 {
+		return errors.New("capacity must be greater than 0")
+	}
+	return nil
+}
+
+func (r *GenerateSeatingPlanRequest) Validate(c *gin.Context) error {
+	// An empty body seats the paper across every room
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	return validations.ValidateJSON(c, r)
+}
+
+func (r *ExamConflictsRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+	if err := validateDate("from", r.From); err != nil {
+		return err
+	}
+	if err := validateDate("to", r.To); err != nil {
+		return err
+	}
+	if r.From != nil && r.To != nil && *r.From != "" && *r.To != "" && *r.To < *r.From {
+		return errors.New("to must not be before from")
+	}
+	return nil
+}
+
+// ParseClock reads a 24-hour HH:MM time as minutes after midnight
+func ParseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("must be in HH:MM format")
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
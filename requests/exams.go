@@ -13,6 +13,7 @@ type CreateExamRequest struct {
 	ExamAmount    float64 `json:"exam_amount" binding:"required,gt=0"`
 	FeesPaid      bool    `json:"fees_paid"`
 	FeesType      string  `json:"fees_type,omitempty"`
+	DueDate       *string `json:"due_date,omitempty"` // YYYY-MM-DD
 }
 
 type UpdateExamRequest struct {
@@ -22,6 +23,7 @@ type UpdateExamRequest struct {
 	ExamAmount    *float64 `json:"exam_amount,omitempty"`
 	FeesPaid      *bool    `json:"fees_paid,omitempty"`
 	FeesType      *string  `json:"fees_type,omitempty"`
+	DueDate       *string  `json:"due_date,omitempty"` // YYYY-MM-DD, empty clears it
 	IsDeleted     *bool    `json:"is_deleted,omitempty"`
 }
 
@@ -52,12 +54,12 @@ func (r *CreateExamRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	return nil
+	return validateDate("due_date", r.DueDate)
 }
 
 func (r *UpdateExamRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	return nil
+	return validateDate("due_date", r.DueDate)
 }
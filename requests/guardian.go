@@ -0,0 +1,128 @@
+package requests
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateGuardianRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Relationship string `json:"relationship" binding:"required"` // "father", "mother", "guardian" or "other"
+	Phone        string `json:"phone,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Address      string `json:"address,omitempty"`
+	IsPrimary    bool   `json:"is_primary,omitempty"`
+}
+
+type UpdateGuardianRequest struct {
+	Name         *string `json:"name,omitempty"`
+	Relationship *string `json:"relationship,omitempty"`
+	Phone        *string `json:"phone,omitempty"`
+	Email        *string `json:"email,omitempty"`
+	Address      *string `json:"address,omitempty"`
+	IsPrimary    *bool   `json:"is_primary,omitempty"`
+	IsDeleted    *bool   `json:"is_deleted,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCreateGuardianRequest() *CreateGuardianRequest {
+	return &CreateGuardianRequest{}
+}
+
+func NewUpdateGuardianRequest() *UpdateGuardianRequest {
+	return &UpdateGuardianRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CreateGuardianRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Relationship = strings.ToLower(strings.TrimSpace(r.Relationship))
+	if err := ValidateGuardianRelationship(r.Relationship); err != nil {
+		return err
+	}
+	if err := ValidateGuardianPhone(r.Phone); err != nil {
+		return err
+	}
+	return ValidateGuardianEmail(r.Email)
+}
+
+func (r *UpdateGuardianRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Relationship != nil {
+		relationship := strings.ToLower(strings.TrimSpace(*r.Relationship))
+		r.Relationship = &relationship
+		if err := ValidateGuardianRelationship(relationship); err != nil {
+			return err
+		}
+	}
+	if r.Phone != nil {
+		if err := ValidateGuardianPhone(*r.Phone); err != nil {
+			return err
+		}
+	}
+	if r.Email != nil {
+		return ValidateGuardianEmail(*r.Email)
+	}
+	return nil
+}
+
+// The guardian validators are exported so the CSV importer can apply the same rules
+
+func ValidateGuardianRelationship(relationship string) error {
+	switch relationship {
+	case "father", "mother", "guardian", "other":
+		return nil
+	}
+	return errors.New("relationship must be 'father', 'mother', 'guardian' or 'other'")
+}
+
+// ValidateGuardianPhone accepts 10 to 15 digits with common separators and an optional leading +
+func ValidateGuardianPhone(phone string) error {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return nil
+	}
+
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case unicode.IsDigit(r):
+			digits++
+		case r == '+' && i == 0, r == ' ', r == '-', r == '(', r == ')':
+		default:
+			return errors.New("phone may only contain digits, spaces, dashes, brackets and a leading +")
+		}
+	}
+	if digits < 10 || digits > 15 {
+		return errors.New("phone must have between 10 and 15 digits")
+	}
+	return nil
+}
+
+func ValidateGuardianEmail(email string) error {
+	if strings.TrimSpace(email) == "" {
+		return nil
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return errors.New("invalid email: " + email)
+	}
+	return nil
+}
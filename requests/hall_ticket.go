@@ -0,0 +1,59 @@
+package requests
+
+import (
+	"errors"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HallTicketRequest is bound from the query string of GET /exams/:id/hall-tickets and
+// GET /exams/:id/hall-tickets/blocked
+type HallTicketRequest struct {
+	Div             *string `form:"div"`
+	StudentEntityID *string `form:"student_entity_id"` // one student's ticket
+}
+
+// VerifyHallTicketRequest carries the raw text read from a hall ticket's QR code
+type VerifyHallTicketRequest struct {
+	Payload string `json:"payload" binding:"required"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewHallTicketRequest() *HallTicketRequest {
+	return &HallTicketRequest{}
+}
+
+func NewVerifyHallTicketRequest() *VerifyHallTicketRequest {
+	return &VerifyHallTicketRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *HallTicketRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+	return nil
+}
+
+func (r *VerifyHallTicketRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if len(r.Payload) > 512 {
+		return errors.New("payload must be at most 512 characters")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IDCardRequest is bound from the query string of GET /id-cards
+type IDCardRequest struct {
+	ClassEntityID   string  `form:"class_entity_id" binding:"required"`
+	BoardEntityID   *string `form:"board_entity_id"`
+	Div             *string `form:"div"`
+	SessionEntityID *string `form:"session_entity_id"` // defaults to the active session
+	Code            string  `form:"code"`              // "code128" (default) or "qr"
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewIDCardRequest() *IDCardRequest {
+	return &IDCardRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *IDCardRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	r.Code = strings.ToLower(strings.TrimSpace(r.Code))
+	switch r.Code {
+	case "":
+		r.Code = "code128"
+	case "code128", "qr":
+	default:
+		return errors.New("code must be 'code128' or 'qr'")
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"errors"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ItemSalesRequest struct {
+	StartDate       *string `json:"start_date,omitempty"` // YYYY-MM-DD
+	EndDate         *string `json:"end_date,omitempty"`   // YYYY-MM-DD, inclusive
+	BoardEntityID   *string `json:"board_entity_id,omitempty"`
+	ClassEntityID   *string `json:"class_entity_id,omitempty"`
+	Div             *string `json:"div,omitempty"`               // counts only students of the division and their payments
+	ItemType        *string `json:"item_type,omitempty"`         // "exam", "book", or "all"
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewItemSalesRequest() *ItemSalesRequest {
+	return &ItemSalesRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *ItemSalesRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	// Validate item_type if provided
+	if r.ItemType != nil {
+		itemType := *r.ItemType
+		if itemType != "exam" && itemType != "book" && itemType != "all" {
+			return errors.New("item_type must be 'exam', 'book', or 'all'")
+		}
+	}
+
+	return validateDateRange(r.StartDate, r.EndDate)
+}
@@ -0,0 +1,225 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+	"text/template"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReminderThrottleHours caps the per-student cooldown between reminders (30 days)
+const maxReminderThrottleHours = 720
+
+type ReminderFilterRequest struct {
+	BoardEntityID    string   `json:"board_entity_id,omitempty"`
+	ClassEntityID    string   `json:"class_entity_id,omitempty"`
+	Div              string   `json:"div,omitempty"`
+	ItemType         string   `json:"item_type,omitempty"` // "exam", "book", or "all"
+	IncludeOptional  bool     `json:"include_optional,omitempty"`
+	MinDue           *float64 `json:"min_due,omitempty"`
+	StudentEntityIDs []string `json:"student_entity_ids,omitempty"` // limit to these students
+}
+
+type SendRemindersRequest struct {
+	Channel       string                `json:"channel" binding:"required"` // "sms" or "whatsapp"
+	Template      string                `json:"template,omitempty"`         // text/template body, defaults to the standard fee reminder
+	ThrottleHours *int                  `json:"throttle_hours,omitempty"`   // skip students reminded within this many hours, default 72
+	Filter        ReminderFilterRequest `json:"filter"`
+}
+
+type CreateReminderScheduleRequest struct {
+	Name          string                `json:"name" binding:"required"`
+	Channel       string                `json:"channel" binding:"required"`
+	Template      string                `json:"template,omitempty"`
+	ThrottleHours *int                  `json:"throttle_hours,omitempty"`
+	Filter        ReminderFilterRequest `json:"filter"`
+	Schedule      ReportScheduleRequest `json:"schedule" binding:"required"`
+	IsActive      *bool                 `json:"is_active,omitempty"`
+}
+
+type UpdateReminderScheduleRequest struct {
+	Name          *string                `json:"name,omitempty"`
+	Channel       *string                `json:"channel,omitempty"`
+	Template      *string                `json:"template,omitempty"`
+	ThrottleHours *int                   `json:"throttle_hours,omitempty"`
+	Filter        *ReminderFilterRequest `json:"filter,omitempty"`
+	Schedule      *ReportScheduleRequest `json:"schedule,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	IsDeleted     *bool                  `json:"is_deleted,omitempty"`
+}
+
+type CreateNotificationOptOutRequest struct {
+	Phone           string `json:"phone" binding:"required"`
+	Channel         string `json:"channel,omitempty"` // "sms", "whatsapp" or "all" (default)
+	StudentEntityID string `json:"student_entity_id,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// NotificationDeliveryStatusRequest records a delivery report from the provider
+type NotificationDeliveryStatusRequest struct {
+	ProviderMessageID string `json:"provider_message_id" binding:"required"`
+	Status            string `json:"status" binding:"required"` // "delivered" or "failed"
+	Error             string `json:"error,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewSendRemindersRequest() *SendRemindersRequest {
+	return &SendRemindersRequest{}
+}
+
+func NewCreateReminderScheduleRequest() *CreateReminderScheduleRequest {
+	return &CreateReminderScheduleRequest{}
+}
+
+func NewUpdateReminderScheduleRequest() *UpdateReminderScheduleRequest {
+	return &UpdateReminderScheduleRequest{}
+}
+
+func NewCreateNotificationOptOutRequest() *CreateNotificationOptOutRequest {
+	return &CreateNotificationOptOutRequest{}
+}
+
+func NewNotificationDeliveryStatusRequest() *NotificationDeliveryStatusRequest {
+	return &NotificationDeliveryStatusRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *SendRemindersRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if err := validateNotificationChannel(r.Channel); err != nil {
+		return err
+	}
+	if err := validateReminderTemplate(r.Template); err != nil {
+		return err
+	}
+	if r.ThrottleHours != nil {
+		if err := validateThrottleHours(*r.ThrottleHours); err != nil {
+			return err
+		}
+	}
+	return r.Filter.validate()
+}
+
+func (r *CreateReminderScheduleRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if err := validateNotificationChannel(r.Channel); err != nil {
+		return err
+	}
+	if err := validateReminderTemplate(r.Template); err != nil {
+		return err
+	}
+	if r.ThrottleHours != nil {
+		if err := validateThrottleHours(*r.ThrottleHours); err != nil {
+			return err
+		}
+	}
+	if err := r.Filter.validate(); err != nil {
+		return err
+	}
+	return r.Schedule.validate()
+}
+
+func (r *UpdateReminderScheduleRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Channel != nil {
+		if err := validateNotificationChannel(*r.Channel); err != nil {
+			return err
+		}
+	}
+	if r.Template != nil {
+		if err := validateReminderTemplate(*r.Template); err != nil {
+			return err
+		}
+	}
+	if r.ThrottleHours != nil {
+		if err := validateThrottleHours(*r.ThrottleHours); err != nil {
+			return err
+		}
+	}
+	if r.Filter != nil {
+		if err := r.Filter.validate(); err != nil {
+			return err
+		}
+	}
+	if r.Schedule != nil {
+		return r.Schedule.validate()
+	}
+	return nil
+}
+
+func (r *CreateNotificationOptOutRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(r.Phone) == "" {
+		return errors.New("phone is required")
+	}
+	if r.Channel != "" && r.Channel != "all" {
+		return validateNotificationChannel(r.Channel)
+	}
+	return nil
+}
+
+func (r *NotificationDeliveryStatusRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Status != "delivered" && r.Status != "failed" {
+		return errors.New("status must be 'delivered' or 'failed'")
+	}
+	return nil
+}
+
+func (r *ReminderFilterRequest) validate() error {
+	if r.ItemType != "" && r.ItemType != "exam" && r.ItemType != "book" && r.ItemType != "all" {
+		return errors.New("filter.item_type must be 'exam', 'book', or 'all'")
+	}
+	if r.MinDue != nil && *r.MinDue < 0 {
+		return errors.New("filter.min_due must not be negative")
+	}
+	return nil
+}
+
+func validateNotificationChannel(channel string) error {
+	if channel != "sms" && channel != "whatsapp" {
+		return errors.New("channel must be 'sms' or 'whatsapp'")
+	}
+	return nil
+}
+
+func validateReminderTemplate(body string) error {
+	if body == "" {
+		return nil
+	}
+	if _, err := template.New("reminder").Parse(body); err != nil {
+		return errors.New("template is not valid: " + err.Error())
+	}
+	return nil
+}
+
+func validateThrottleHours(hours int) error {
+	if hours < 0 || hours > maxReminderThrottleHours {
+		return errors.New("throttle_hours must be between 0 and 720")
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package requests
 
 import (
+	"errors"
+	"strings"
+
 	"shared/pkgs/validations"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +15,15 @@ type ConfirmPaymentRequest struct {
 	SelectedExams []string `json:"selected_exams,omitempty"`
 	SelectedBooks []string `json:"selected_books,omitempty"`
 	TotalAmount   float64  `json:"total_amount" binding:"required"`
+	UPIReference  string   `json:"upi_reference,omitempty"` // 12-digit UTR shown by the payer's UPI app
+}
+
+// ReversePaymentRequest voids or refunds the paid items of one checkout
+type ReversePaymentRequest struct {
+	PaymentID string   `json:"payment_id" binding:"required"`
+	EntityIDs []string `json:"entity_ids,omitempty"`      // specific items; empty = every paid item of the checkout
+	Action    string   `json:"action" binding:"required"` // "void" or "refund"
+	Reason    string   `json:"reason" binding:"required"`
 }
 
 //
@@ -22,6 +34,10 @@ func NewConfirmPaymentRequest() *ConfirmPaymentRequest {
 	return &ConfirmPaymentRequest{}
 }
 
+func NewReversePaymentRequest() *ReversePaymentRequest {
+	return &ReversePaymentRequest{}
+}
+
 //
 // ================= VALIDATION =================
 //
@@ -30,5 +46,42 @@ func (r *ConfirmPaymentRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
+
+	r.UPIReference = strings.TrimSpace(r.UPIReference)
+	if r.UPIReference != "" {
+		if !strings.EqualFold(r.PaymentMode, "upi") {
+			return errors.New("upi_reference is only allowed for upi payments")
+		}
+		if !IsUPIReference(r.UPIReference) {
+			return errors.New("upi_reference must be the 12-digit UTR of the payment")
+		}
+	}
+	return nil
+}
+
+// IsUPIReference reports whether value looks like a UPI transaction reference (UTR)
+func IsUPIReference(value string) bool {
+	if len(value) != 12 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ReversePaymentRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Action != "void" && r.Action != "refund" {
+		return errors.New("action must be 'void' or 'refund'")
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return errors.New("reason is required")
+	}
 	return nil
 }
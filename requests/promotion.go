@@ -0,0 +1,102 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PromotionMappingRequest moves every student of one board and class to a target class
+type PromotionMappingRequest struct {
+	BoardEntityID     string `json:"board_entity_id" binding:"required"`
+	FromClassEntityID string `json:"from_class_entity_id" binding:"required"`
+	Outcome           string `json:"outcome" binding:"required"`   // "promoted", "detained" or "graduated"
+	ToBoardEntityID   string `json:"to_board_entity_id,omitempty"` // defaults to board_entity_id
+	ToClassEntityID   string `json:"to_class_entity_id,omitempty"` // required when promoted
+}
+
+// PromotionOverrideRequest replaces the class mapping for one student
+type PromotionOverrideRequest struct {
+	StudentEntityID string  `json:"student_entity_id" binding:"required"`
+	Outcome         string  `json:"outcome" binding:"required"` // "promoted", "detained" or "graduated"
+	ToBoardEntityID string  `json:"to_board_entity_id,omitempty"`
+	ToClassEntityID string  `json:"to_class_entity_id,omitempty"` // required when promoted
+	ToDiv           *string `json:"to_div,omitempty"`             // defaults to the student's current division
+}
+
+type PromotionRequest struct {
+	FromSessionEntityID *string                    `json:"from_session_entity_id,omitempty"` // defaults to the active session
+	ToSessionEntityID   string                     `json:"to_session_entity_id" binding:"required"`
+	Mappings            []PromotionMappingRequest  `json:"mappings" binding:"required,min=1,dive"`
+	Overrides           []PromotionOverrideRequest `json:"overrides,omitempty" binding:"dive"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewPromotionRequest() *PromotionRequest {
+	return &PromotionRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *PromotionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.FromSessionEntityID != nil && *r.FromSessionEntityID == r.ToSessionEntityID {
+		return errors.New("to_session_entity_id must differ from from_session_entity_id")
+	}
+
+	mapped := make(map[string]bool)
+	for i := range r.Mappings {
+		mapping := &r.Mappings[i]
+		mapping.Outcome = strings.ToLower(strings.TrimSpace(mapping.Outcome))
+		if err := validatePromotionOutcome(mapping.Outcome, mapping.ToClassEntityID); err != nil {
+			return fmt.Errorf("mapping for class %s: %v", mapping.FromClassEntityID, err)
+		}
+
+		key := mapping.BoardEntityID + "|" + mapping.FromClassEntityID
+		if mapped[key] {
+			return fmt.Errorf("class %s of board %s is mapped more than once", mapping.FromClassEntityID, mapping.BoardEntityID)
+		}
+		mapped[key] = true
+	}
+
+	overridden := make(map[string]bool)
+	for i := range r.Overrides {
+		override := &r.Overrides[i]
+		override.Outcome = strings.ToLower(strings.TrimSpace(override.Outcome))
+		if err := validatePromotionOutcome(override.Outcome, override.ToClassEntityID); err != nil {
+			return fmt.Errorf("override for student %s: %v", override.StudentEntityID, err)
+		}
+
+		if overridden[override.StudentEntityID] {
+			return fmt.Errorf("student %s is overridden more than once", override.StudentEntityID)
+		}
+		overridden[override.StudentEntityID] = true
+	}
+
+	return nil
+}
+
+func validatePromotionOutcome(outcome, toClassEntityID string) error {
+	switch outcome {
+	case "promoted":
+		if toClassEntityID == "" {
+			return errors.New("to_class_entity_id is required when promoted")
+		}
+		return nil
+	case "detained", "graduated":
+		return nil
+	}
+	return errors.New("outcome must be 'promoted', 'detained' or 'graduated'")
+}
@@ -1,13 +1,17 @@
 package requests
 
 import (
+	"errors"
+	"strings"
+
 	"shared/pkgs/validations"
 
 	"github.com/gin-gonic/gin"
 )
 
 type GetReceiptByRefNoRequest struct {
-	RefNo string `json:"ref_no" binding:"required"`
+	RefNo           string  `json:"ref_no" binding:"required"`
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // defaults to the active session
 }
 
 //
@@ -26,5 +30,11 @@ func (r *GetReceiptByRefNoRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
+
+	// Barcode scanners send the ref_no printed on ID cards followed by Enter or Tab
+	r.RefNo = strings.TrimSpace(r.RefNo)
+	if r.RefNo == "" {
+		return errors.New("ref_no is required")
+	}
 	return nil
 }
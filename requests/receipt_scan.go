@@ -0,0 +1,38 @@
+package requests
+
+import (
+	"errors"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScanReceiptRequest carries the raw text a barcode or QR scanner typed in
+type ScanReceiptRequest struct {
+	Payload         string  `json:"payload" binding:"required"`
+	SessionEntityID *string `json:"session_entity_id,omitempty"` // for ref_no scans; defaults to the active session
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewScanReceiptRequest() *ScanReceiptRequest {
+	return &ScanReceiptRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *ScanReceiptRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if len(r.Payload) > 512 {
+		return errors.New("payload must be at most 512 characters")
+	}
+	return nil
+}
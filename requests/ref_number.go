@@ -0,0 +1,61 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateRefNumberSettingsRequest struct {
+	IsEnabled   *bool   `json:"is_enabled,omitempty"`
+	Prefix      *string `json:"prefix,omitempty"`    // letters and digits, up to 10
+	Separator   *string `json:"separator,omitempty"` // "", "-" or "/"
+	IncludeYear *bool   `json:"include_year,omitempty"`
+	Padding     *int    `json:"padding,omitempty"` // 1 to 10 digits
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewUpdateRefNumberSettingsRequest() *UpdateRefNumberSettingsRequest {
+	return &UpdateRefNumberSettingsRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *UpdateRefNumberSettingsRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Prefix != nil {
+		prefix := strings.ToUpper(strings.TrimSpace(*r.Prefix))
+		if len(prefix) > 10 {
+			return errors.New("prefix must be at most 10 characters")
+		}
+		for _, ch := range prefix {
+			if !unicode.IsLetter(ch) && !unicode.IsDigit(ch) {
+				return errors.New("prefix may only contain letters and digits")
+			}
+		}
+		r.Prefix = &prefix
+	}
+	if r.Separator != nil {
+		switch *r.Separator {
+		case "", "-", "/":
+		default:
+			return errors.New("separator must be '', '-' or '/'")
+		}
+	}
+	if r.Padding != nil && (*r.Padding < 1 || *r.Padding > 10) {
+		return errors.New("padding must be between 1 and 10")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package requests
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportScheduleRequest struct {
+	Frequency  string `json:"frequency" binding:"required"`   // "daily", "weekly" or "monthly"
+	TimeOfDay  string `json:"time_of_day" binding:"required"` // HH:MM
+	Weekday    int    `json:"weekday,omitempty"`              // 0 = Sunday, weekly only
+	DayOfMonth int    `json:"day_of_month,omitempty"`         // 1-28, monthly only
+	Timezone   string `json:"timezone,omitempty"`             // IANA name, defaults to UTC
+}
+
+type CreateReportSubscriptionRequest struct {
+	Name          string                `json:"name" binding:"required"`
+	ReportType    string                `json:"report_type" binding:"required"` // "daily_collection", "unpaid_students" or "dashboard_stats"
+	Recipients    []string              `json:"recipients" binding:"required"`
+	Format        string                `json:"format,omitempty"` // "csv" (default) or "pdf"
+	Schedule      ReportScheduleRequest `json:"schedule" binding:"required"`
+	BoardEntityID string                `json:"board_entity_id,omitempty"`
+	ClassEntityID string                `json:"class_entity_id,omitempty"`
+	IsActive      *bool                 `json:"is_active,omitempty"`
+}
+
+type UpdateReportSubscriptionRequest struct {
+	Name          *string                `json:"name,omitempty"`
+	Recipients    *[]string              `json:"recipients,omitempty"`
+	Format        *string                `json:"format,omitempty"`
+	Schedule      *ReportScheduleRequest `json:"schedule,omitempty"`
+	BoardEntityID *string                `json:"board_entity_id,omitempty"`
+	ClassEntityID *string                `json:"class_entity_id,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	IsDeleted     *bool                  `json:"is_deleted,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCreateReportSubscriptionRequest() *CreateReportSubscriptionRequest {
+	return &CreateReportSubscriptionRequest{}
+}
+
+func NewUpdateReportSubscriptionRequest() *UpdateReportSubscriptionRequest {
+	return &UpdateReportSubscriptionRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CreateReportSubscriptionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	switch r.ReportType {
+	case "daily_collection", "unpaid_students", "dashboard_stats":
+	default:
+		return errors.New("report_type must be 'daily_collection', 'unpaid_students' or 'dashboard_stats'")
+	}
+	if err := validateReportFormat(r.Format); err != nil {
+		return err
+	}
+	if err := validateRecipients(r.Recipients); err != nil {
+		return err
+	}
+	return r.Schedule.validate()
+}
+
+func (r *UpdateReportSubscriptionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Format != nil {
+		if err := validateReportFormat(*r.Format); err != nil {
+			return err
+		}
+	}
+	if r.Recipients != nil {
+		if err := validateRecipients(*r.Recipients); err != nil {
+			return err
+		}
+	}
+	if r.Schedule != nil {
+		return r.Schedule.validate()
+	}
+	return nil
+}
+
+func (r *ReportScheduleRequest) validate() error {
+	switch r.Frequency {
+	case "daily":
+	case "weekly":
+		if r.Weekday < 0 || r.Weekday > 6 {
+			return errors.New("schedule.weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	case "monthly":
+		if r.DayOfMonth < 1 || r.DayOfMonth > 28 {
+			return errors.New("schedule.day_of_month must be between 1 and 28")
+		}
+	default:
+		return errors.New("schedule.frequency must be 'daily', 'weekly' or 'monthly'")
+	}
+
+	if _, err := time.Parse("15:04", r.TimeOfDay); err != nil {
+		return errors.New("schedule.time_of_day must be in HH:MM format")
+	}
+	if r.Timezone == "" {
+		r.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(r.Timezone); err != nil {
+		return errors.New("schedule.timezone must be a valid IANA time zone name")
+	}
+
+	return nil
+}
+
+func validateReportFormat(format string) error {
+	if format != "" && format != "csv" && format != "pdf" {
+		return errors.New("format must be 'csv' or 'pdf'")
+	}
+	return nil
+}
+
+func validateRecipients(recipients []string) error {
+	if len(recipients) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	for _, recipient := range recipients {
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return fmt.Errorf("invalid recipient email: %s", recipient)
+		}
+	}
+	return nil
+}
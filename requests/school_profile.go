@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UpdateSchoolProfileRequest struct {
+	Name    *string `json:"name,omitempty"`
+	Address *string `json:"address,omitempty"`
+	Phone   *string `json:"phone,omitempty"`
+	Email   *string `json:"email,omitempty"`
+	Website *string `json:"website,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewUpdateSchoolProfileRequest() *UpdateSchoolProfileRequest {
+	return &UpdateSchoolProfileRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *UpdateSchoolProfileRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	for _, field := range []*string{r.Name, r.Address, r.Phone, r.Email, r.Website} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+	if r.Name != nil && *r.Name == "" {
+		return errors.New("name must not be empty")
+	}
+	if r.Name != nil && len(*r.Name) > 120 {
+		return errors.New("name must not exceed 120 characters")
+	}
+	if r.Email != nil && *r.Email != "" && !strings.Contains(*r.Email, "@") {
+		return errors.New("email is not valid")
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAcademicSessionRequest struct {
+	Name      string `json:"name" binding:"required"`       // e.g. "2025-26"
+	StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD, inclusive
+	IsActive  bool   `json:"is_active,omitempty"`           // the first session of a company is always active
+}
+
+type UpdateAcademicSessionRequest struct {
+	Name      *string `json:"name,omitempty"`
+	StartDate *string `json:"start_date,omitempty"`
+	EndDate   *string `json:"end_date,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewCreateAcademicSessionRequest() *CreateAcademicSessionRequest {
+	return &CreateAcademicSessionRequest{}
+}
+
+func NewUpdateAcademicSessionRequest() *UpdateAcademicSessionRequest {
+	return &UpdateAcademicSessionRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *CreateAcademicSessionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := validateDate("start_date", &r.StartDate); err != nil {
+		return err
+	}
+	if err := validateDate("end_date", &r.EndDate); err != nil {
+		return err
+	}
+	return validateDateRange(&r.StartDate, &r.EndDate)
+}
+
+func (r *UpdateAcademicSessionRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return errors.New("name must not be empty")
+		}
+		r.Name = &name
+	}
+	if err := validateDate("start_date", r.StartDate); err != nil {
+		return err
+	}
+	if err := validateDate("end_date", r.EndDate); err != nil {
+		return err
+	}
+	return validateDateRange(r.StartDate, r.EndDate)
+}
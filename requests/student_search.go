@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StudentSearchRequest is bound from the query string of GET /students/search
+type StudentSearchRequest struct {
+	Query         string  `form:"q" binding:"required"` // name words, ref_no or guardian phone digits
+	BoardEntityID *string `form:"board_entity_id"`
+	ClassEntityID *string `form:"class_entity_id"`
+	Div           *string `form:"div"`
+	Limit         int     `form:"limit"` // defaults to 20, at most 100
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewStudentSearchRequest() *StudentSearchRequest {
+	return &StudentSearchRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *StudentSearchRequest) Validate(c *gin.Context) error {
+	if err := c.ShouldBindQuery(r); err != nil {
+		return err
+	}
+
+	r.Query = strings.TrimSpace(r.Query)
+	if r.Query == "" {
+		return errors.New("q is required")
+	}
+	if len(r.Query) > 100 {
+		return errors.New("q must not exceed 100 characters")
+	}
+	if r.Limit < 0 || r.Limit > 100 {
+		return errors.New("limit must be between 0 and 100")
+	}
+	if r.Limit == 0 {
+		r.Limit = 20
+	}
+	if r.Div != nil {
+		div := NormaliseDivision(*r.Div)
+		r.Div = &div
+	}
+
+	return nil
+}
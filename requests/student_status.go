@@ -0,0 +1,67 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"shared/pkgs/validations"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChangeStudentStatusRequest struct {
+	Status            string  `json:"status" binding:"required"`    // "active", "transferred", "withdrawn" or "alumni"
+	EffectiveDate     *string `json:"effective_date,omitempty"`     // YYYY-MM-DD, defaults to today
+	Reason            string  `json:"reason,omitempty"`             // required when transferred or withdrawn
+	DestinationSchool string  `json:"destination_school,omitempty"` // only for transferred students
+	Remarks           string  `json:"remarks,omitempty"`
+}
+
+//
+// ================= CONSTRUCTORS =================
+//
+
+func NewChangeStudentStatusRequest() *ChangeStudentStatusRequest {
+	return &ChangeStudentStatusRequest{}
+}
+
+//
+// ================= VALIDATION =================
+//
+
+func (r *ChangeStudentStatusRequest) Validate(c *gin.Context) error {
+	if err := validations.ValidateJSON(c, r); err != nil {
+		return err
+	}
+
+	r.Status = strings.ToLower(strings.TrimSpace(r.Status))
+	r.Reason = strings.TrimSpace(r.Reason)
+	r.DestinationSchool = strings.TrimSpace(r.DestinationSchool)
+	r.Remarks = strings.TrimSpace(r.Remarks)
+
+	switch r.Status {
+	case "transferred", "withdrawn":
+		if r.Reason == "" {
+			return errors.New("reason is required when a student is " + r.Status)
+		}
+	case "active", "alumni":
+	default:
+		return errors.New("status must be 'active', 'transferred', 'withdrawn' or 'alumni'")
+	}
+	if r.DestinationSchool != "" && r.Status != "transferred" {
+		return errors.New("destination_school is only allowed for transferred students")
+	}
+
+	if err := validateDate("effective_date", r.EffectiveDate); err != nil {
+		return err
+	}
+	if r.EffectiveDate != nil && *r.EffectiveDate != "" {
+		effective, _ := time.Parse("2006-01-02", *r.EffectiveDate)
+		if effective.After(time.Now().UTC()) {
+			return errors.New("effective_date must not be in the future")
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package requests
 
 import (
+	"errors"
+	"strings"
+
 	"shared/pkgs/validations"
 
 	"github.com/gin-gonic/gin"
@@ -9,7 +12,7 @@ import (
 type CreateStudentRequest struct {
 	BoardEntityID string `json:"board_entity_id" binding:"required"`
 	ClassEntityID string `json:"class_entity_id" binding:"required"`
-	RefNo         string `json:"ref_no" binding:"required"`
+	RefNo         string `json:"ref_no"` // generated when empty and ref numbers are enabled
 	Div           string `json:"div" binding:"required"`
 	FirstName     string `json:"first_name" binding:"required"`
 	MiddleName    string `json:"middle_name"`
@@ -58,6 +61,7 @@ func (r *CreateStudentRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
+	r.RefNo = strings.TrimSpace(r.RefNo)
 	return nil
 }
 
@@ -65,5 +69,12 @@ func (r *UpdateStudentRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
+	if r.RefNo != nil {
+		refNo := strings.TrimSpace(*r.RefNo)
+		if refNo == "" {
+			return errors.New("ref_no must not be empty")
+		}
+		r.RefNo = &refNo
+	}
 	return nil
 }
@@ -7,10 +7,22 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxUnpaidStudentsPageSize caps one page of GET unpaid students
+const maxUnpaidStudentsPageSize = 500
+
 type GetUnpaidStudentsRequest struct {
-	ClassEntityID *string `json:"class_entity_id,omitempty"`
-	BoardEntityID *string `json:"board_entity_id,omitempty"`
-	ItemType      *string `json:"item_type,omitempty"` // "exam", "book", or "all"
+	ClassEntityID   *string  `json:"class_entity_id,omitempty"`
+	BoardEntityID   *string  `json:"board_entity_id,omitempty"`
+	ItemType        *string  `json:"item_type,omitempty"`        // "exam", "book", or "all"
+	IncludeOptional bool     `json:"include_optional,omitempty"` // count unpaid optional items as owed
+	Div             *string  `json:"div,omitempty"`
+	Search          *string  `json:"search,omitempty"`            // matches name parts and ref_no
+	MinDue          *float64 `json:"min_due,omitempty"`           // only students owing at least this much
+	SortBy          string   `json:"sort_by,omitempty"`           // "total_due" (default), "name" or "ref_no"
+	SortOrder       string   `json:"sort_order,omitempty"`        // "asc" or "desc"; total_due defaults to desc, others to asc
+	Limit           int      `json:"limit,omitempty"`             // page size, default 50
+	Cursor          string   `json:"cursor,omitempty"`            // next_cursor from the previous page
+	SessionEntityID *string  `json:"session_entity_id,omitempty"` // defaults to the active session
 }
 
 //
@@ -38,5 +50,35 @@ func (r *GetUnpaidStudentsRequest) Validate(c *gin.Context) error {
 		}
 	}
 
+	switch r.SortBy {
+	case "":
+		r.SortBy = "total_due"
+	case "total_due", "name", "ref_no":
+	default:
+		return errors.New("sort_by must be 'total_due', 'name' or 'ref_no'")
+	}
+
+	switch r.SortOrder {
+	case "":
+		r.SortOrder = "asc"
+		if r.SortBy == "total_due" {
+			r.SortOrder = "desc"
+		}
+	case "asc", "desc":
+	default:
+		return errors.New("sort_order must be 'asc' or 'desc'")
+	}
+
+	if r.MinDue != nil && *r.MinDue < 0 {
+		return errors.New("min_due must not be negative")
+	}
+
+	if r.Limit < 0 || r.Limit > maxUnpaidStudentsPageSize {
+		return errors.New("limit must be between 1 and 500")
+	}
+	if r.Limit == 0 {
+		r.Limit = 50
+	}
+
 	return nil
 }
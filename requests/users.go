@@ -1,6 +1,9 @@
 package requests
 
 import (
+	"errors"
+	"strings"
+
 	"shared/pkgs/validations"
 
 	"github.com/gin-gonic/gin"
@@ -10,12 +13,14 @@ type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Role     string `json:"role,omitempty"` // "user" (default), "accountant" or "admin"
 }
 
 type UpdateUserRequest struct {
 	Name      *string `json:"name,omitempty"`
 	Email     *string `json:"email,omitempty"`
 	Password  *string `json:"password,omitempty"`
+	Role      *string `json:"role,omitempty"`
 	IsDeleted *bool   `json:"is_deleted,omitempty"`
 }
 
@@ -39,14 +44,32 @@ func (r *CreateUserRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	// Add any custom validation logic here if needed
-	return nil
+
+	r.Role = strings.ToLower(strings.TrimSpace(r.Role))
+	if r.Role == "" {
+		r.Role = "user"
+	}
+	return validateUserRole(r.Role)
 }
 
 func (r *UpdateUserRequest) Validate(c *gin.Context) error {
 	if err := validations.ValidateJSON(c, r); err != nil {
 		return err
 	}
-	// Add any custom validation logic here if needed
+
+	if r.Role != nil {
+		role := strings.ToLower(strings.TrimSpace(*r.Role))
+		r.Role = &role
+		return validateUserRole(role)
+	}
 	return nil
 }
+
+// validateUserRole accepts the roles the login token can carry
+func validateUserRole(role string) error {
+	switch role {
+	case "user", "accountant", "admin":
+		return nil
+	}
+	return errors.New("role must be 'user', 'accountant' or 'admin'")
+}
@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+func UploadAttachment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Access check; the claims identify the user uploading the file
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and student id
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	if companyCode == "" || studentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Refuse oversized bodies before the multipart form is parsed
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAttachmentSize+1<<20)
+
+	// Bind and validate the form fields
+	req := requests.NewUploadAttachmentRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	service := services.NewAttachmentService()
+	attachment, err := service.Upload(ctx, companyCode, studentID, req, header.Filename, file, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func GetStudentAttachments(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and student id
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	if companyCode == "" || studentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	service := services.NewAttachmentService()
+	attachments, err := service.GetByStudent(ctx, companyCode, studentID, c.Query("kind"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+func GetAttachmentByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and attachment ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	attachmentID := c.Param("attachment_id")
+	if companyCode == "" || studentID == "" || attachmentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and attachment_id are required"})
+		return
+	}
+
+	service := services.NewAttachmentService()
+	attachment, err := service.GetByID(ctx, companyCode, studentID, attachmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+func DownloadAttachment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and attachment ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	attachmentID := c.Param("attachment_id")
+	if companyCode == "" || studentID == "" || attachmentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and attachment_id are required"})
+		return
+	}
+
+	service := services.NewAttachmentService()
+	attachment, data, err := service.Download(ctx, companyCode, studentID, attachmentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ?inline=true shows photos and PDFs in the browser instead of saving them
+	disposition := "attachment"
+	if c.Query("inline") == "true" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}))
+	c.Header("ETag", `"`+attachment.SHA256+`"`)
+	c.Header("X-Checksum-Sha256", attachment.SHA256)
+	c.Data(http.StatusOK, attachment.ContentType, data)
+}
+
+func DeleteAttachment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and attachment ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	attachmentID := c.Param("attachment_id")
+	if companyCode == "" || studentID == "" || attachmentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and attachment_id are required"})
+		return
+	}
+
+	service := services.NewAttachmentService()
+	if err := service.Delete(ctx, companyCode, studentID, attachmentID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
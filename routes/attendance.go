@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+func GetAttendanceSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	settings, err := service.GetSettings(ctx, companyCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func UpdateAttendanceSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewUpdateAttendanceSettingsRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	settings, err := service.UpdateSettings(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SubmitAttendance marks a division for one day in bulk
+func SubmitAttendance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the user marking attendance
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewSubmitAttendanceRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	submission, err := service.Submit(ctx, companyCode, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+func GetAttendanceSheet(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewAttendanceSheetRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	sheet, err := service.GetSheet(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sheet)
+}
+
+// GetAttendanceRegister returns the monthly register of a class or division
+func GetAttendanceRegister(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewAttendanceRegisterRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	register, err := service.GetRegister(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, register)
+}
+
+// GetStudentAttendance returns a student's attendance percentage with a monthly breakdown
+func GetStudentAttendance(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and student id
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	if companyCode == "" || studentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewStudentAttendanceRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewAttendanceService()
+	attendance, err := service.GetStudentAttendance(ctx, companyCode, studentID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
@@ -0,0 +1,299 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+func CreateCalendarEntry(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewCreateCalendarEntryRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCalendarService()
+	entry, err := service.Create(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func GetCalendarEntries(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	service := services.NewCalendarService()
+	data, err := service.GetAll(ctx, companyCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func GetCalendarEntryByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and entry ID
+	companyCode := c.Param("company_code")
+	id := c.Param("id")
+	if companyCode == "" || id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	service := services.NewCalendarService()
+	data, err := service.GetByID(ctx, companyCode, id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func UpdateCalendarEntry(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and entry ID
+	companyCode := c.Param("company_code")
+	id := c.Param("id")
+	if companyCode == "" || id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewUpdateCalendarEntryRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewCalendarService()
+	entry, err := service.Update(ctx, companyCode, id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func DeleteCalendarEntry(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and entry ID
+	companyCode := c.Param("company_code")
+	id := c.Param("id")
+	if companyCode == "" || id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	service := services.NewCalendarService()
+	if err := service.Delete(ctx, companyCode, id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar entry deleted successfully"})
+}
+
+func GetUpcomingCalendarEntries(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate query filters
+	req := requests.NewCalendarOccurrencesRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Window defaults to the next 90 days
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.From != nil && *req.From != "" {
+		from, _ = time.Parse("2006-01-02", *req.From)
+	}
+	to := from.AddDate(0, 0, 90)
+	if req.To != nil && *req.To != "" {
+		to, _ = time.Parse("2006-01-02", *req.To)
+	}
+
+	boardEntityID := ""
+	if req.BoardEntityID != nil {
+		boardEntityID = *req.BoardEntityID
+	}
+	entryType := ""
+	if req.EntryType != nil {
+		entryType = *req.EntryType
+	}
+
+	service := services.NewCalendarService()
+	occurrences, err := service.GetOccurrences(ctx, companyCode, from, to, boardEntityID, entryType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Limit > 0 && len(occurrences) > req.Limit {
+		occurrences = occurrences[:req.Limit]
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+func ExportCalendar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	service := services.NewCalendarService()
+	data, err := service.ExportICS(ctx, companyCode, c.Query("board_entity_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="calendar.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+func ImportCalendar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	defer file.Close()
+
+	// Events without a recognised CATEGORIES value get this type
+	entryType := c.DefaultPostForm("entry_type", "holiday")
+	if entryType != "holiday" && entryType != "exam" && entryType != "event" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entry_type must be 'holiday', 'exam' or 'event'"})
+		return
+	}
+
+	service := services.NewCalendarService()
+	count, err := service.ImportICS(ctx, companyCode, file, entryType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar imported successfully", "count": count})
+}
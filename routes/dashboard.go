@@ -4,14 +4,22 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nandani-y-meizo/school-backend/requests"
 	"github.com/nandani-y-meizo/school-backend/services"
 )
 
 func GetDashboardStats(c *gin.Context) {
 	companyCode := c.Param("company_code")
 
+	// Bind and validate query filters
+	req := requests.NewDashboardStatsRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	service := services.NewDashboardService()
-	stats, err := service.GetDashboardStats(c.Request.Context(), companyCode)
+	stats, err := service.GetDashboardStats(c.Request.Context(), companyCode, req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,210 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+func CreateDivision(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and class ID
+	companyCode := c.Param("company_code")
+	classID := c.Param("id")
+	if companyCode == "" || classID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewCreateDivisionRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDivisionService()
+	division, err := service.Create(ctx, companyCode, classID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, division)
+}
+
+func GetClassDivisions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and class ID
+	companyCode := c.Param("company_code")
+	classID := c.Param("id")
+	if companyCode == "" || classID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	service := services.NewDivisionService()
+	data, err := service.GetByClass(ctx, companyCode, classID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func GetDivisionByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, class ID and division ID
+	companyCode := c.Param("company_code")
+	classID := c.Param("id")
+	divisionID := c.Param("division_id")
+	if companyCode == "" || classID == "" || divisionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and division_id are required"})
+		return
+	}
+
+	service := services.NewDivisionService()
+	data, err := service.GetByID(ctx, companyCode, classID, divisionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func UpdateDivision(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, class ID and division ID
+	companyCode := c.Param("company_code")
+	classID := c.Param("id")
+	divisionID := c.Param("division_id")
+	if companyCode == "" || classID == "" || divisionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and division_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewUpdateDivisionRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDivisionService()
+	division, err := service.Update(ctx, companyCode, classID, divisionID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, division)
+}
+
+func DeleteDivision(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, class ID and division ID
+	companyCode := c.Param("company_code")
+	classID := c.Param("id")
+	divisionID := c.Param("division_id")
+	if companyCode == "" || classID == "" || divisionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and division_id are required"})
+		return
+	}
+
+	service := services.NewDivisionService()
+	if err := service.Delete(ctx, companyCode, classID, divisionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Division deleted successfully"})
+}
+
+func NormaliseDivisions(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewNormaliseDivisionsRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewDivisionService()
+	result, err := service.Normalise(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
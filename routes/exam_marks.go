@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+// SubmitExamMarks enters the marks of one paper for a division in bulk
+func SubmitExamMarks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the user entering marks
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewSubmitExamMarksRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamMarksService()
+	sheet, err := service.Submit(ctx, companyCode, examID, paperID, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sheet)
+}
+
+func GetExamMarkSheet(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewExamMarkSheetRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamMarksService()
+	sheet, err := service.GetSheet(ctx, companyCode, examID, paperID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sheet)
+}
+
+// ModerateExamMarks closes entry for a paper, applying any adjustments
+func ModerateExamMarks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the moderator
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewModerateExamMarksRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamMarksService()
+	sheet, err := service.Moderate(ctx, companyCode, examID, paperID, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sheet)
+}
+
+// ChangeExamMarksStatus locks, reopens or unlocks the marks of a paper
+func ChangeExamMarksStatus(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the user changing the status
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewChangeExamMarksStatusRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamMarksService()
+	state, err := service.ChangeStatus(ctx, companyCode, examID, paperID, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, state)
+}
+
+// GetReportCards returns the report cards of an exam as JSON, or as a PDF with format=pdf
+func GetReportCards(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and exam ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	if companyCode == "" || examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewReportCardRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReportCardService()
+	if req.Format == "pdf" {
+		pdf, err := service.Render(ctx, companyCode, examID, req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="report-cards.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	cards, err := service.Get(ctx, companyCode, examID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cards)
+}
@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+// UpdateExamSchedule replaces the papers of an exam
+func UpdateExamSchedule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and exam ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	if companyCode == "" || examID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewUpdateExamScheduleRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamScheduleService()
+	exam, err := service.UpdateSchedule(ctx, companyCode, examID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, exam)
+}
+
+// GenerateExamSeatingPlan seats the paid students of an exam for one paper
+func GenerateExamSeatingPlan(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the user generating the plan
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewGenerateSeatingPlanRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamScheduleService()
+	plan, err := service.GenerateSeatingPlan(ctx, companyCode, examID, paperID, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+func GetExamSeatingPlan(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, exam ID and paper ID
+	companyCode := c.Param("company_code")
+	examID := c.Param("id")
+	paperID := c.Param("paper_id")
+	if companyCode == "" || examID == "" || paperID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and paper_id are required"})
+		return
+	}
+
+	service := services.NewExamScheduleService()
+	plan, err := service.GetSeatingPlan(ctx, companyCode, examID, paperID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// GetExamConflicts lists students with overlapping papers
+func GetExamConflicts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate query
+	req := requests.NewExamConflictsRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewExamScheduleService()
+	report, err := service.GetConflicts(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -249,7 +249,7 @@ func GetBooks(c *gin.Context) {
 
 	service := services.NewBookService()
 
-	data, err := service.GetAll(ctx, companyCode)
+	data, err := service.GetAll(ctx, companyCode, c.Query("session_entity_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -358,7 +358,7 @@ func GetExams(c *gin.Context) {
 
 	service := services.NewExamService()
 
-	data, err := service.GetAll(ctx, companyCode)
+	data, err := service.GetAll(ctx, companyCode, c.Query("session_entity_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -467,7 +467,7 @@ func GetStudents(c *gin.Context) {
 
 	service := services.NewStudentService()
 
-	data, err := service.GetAll(ctx, companyCode)
+	data, err := service.GetAll(ctx, companyCode, c.Query("session_entity_id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -809,3 +809,39 @@ func GetUnpaidStudents(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+func ExportUnpaidStudents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Same filters as the list; paging fields are ignored
+	req := requests.NewGetUnpaidStudentsRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewUnpaidStudentsService()
+	data, err := service.ExportUnpaidStudentsCSV(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="unpaid-students.csv"`)
+	c.Data(http.StatusOK, "text/csv", data)
+}
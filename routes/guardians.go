@@ -0,0 +1,175 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+func CreateGuardian(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and student ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	if companyCode == "" || studentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewCreateGuardianRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewGuardianService()
+	guardian, err := service.Create(ctx, companyCode, studentID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, guardian)
+}
+
+func GetStudentGuardians(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and student ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	if companyCode == "" || studentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and id are required"})
+		return
+	}
+
+	service := services.NewGuardianService()
+	data, err := service.GetByStudent(ctx, companyCode, studentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func GetGuardianByID(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and guardian ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	guardianID := c.Param("guardian_id")
+	if companyCode == "" || studentID == "" || guardianID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and guardian_id are required"})
+		return
+	}
+
+	service := services.NewGuardianService()
+	data, err := service.GetByID(ctx, companyCode, studentID, guardianID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+func UpdateGuardian(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and guardian ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	guardianID := c.Param("guardian_id")
+	if companyCode == "" || studentID == "" || guardianID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and guardian_id are required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewUpdateGuardianRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewGuardianService()
+	guardian, err := service.Update(ctx, companyCode, studentID, guardianID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, guardian)
+}
+
+func DeleteGuardian(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code, student ID and guardian ID
+	companyCode := c.Param("company_code")
+	studentID := c.Param("id")
+	guardianID := c.Param("guardian_id")
+	if companyCode == "" || studentID == "" || guardianID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code, id and guardian_id are required"})
+		return
+	}
+
+	service := services.NewGuardianService()
+	if err := service.Delete(ctx, companyCode, studentID, guardianID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Guardian deleted successfully"})
+}
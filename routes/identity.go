@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"strings"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+// paymentActor records the logged-in user from the access token claims
+func paymentActor(claims *middleware.AccessClaims) models.PaymentActor {
+	name := claims.Name
+	if name == "" {
+		name = claims.Username
+	}
+
+	return models.PaymentActor{
+		UserID: claims.UserID,
+		Name:   name,
+		Email:  claims.Email,
+		Role:   claims.Role,
+	}
+}
+
+// hasRole reports whether the logged-in user holds one of the roles
+func hasRole(claims *middleware.AccessClaims, roles ...string) bool {
+	for _, role := range roles {
+		if strings.EqualFold(claims.Role, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// canReversePayment reports whether the logged-in user may void or refund a payment.
+// Voids and refunds undo collected money, so only admins and accountants may issue them.
+func canReversePayment(claims *middleware.AccessClaims) bool {
+	return hasRole(claims, "admin", "accountant")
+}
+
+// canAssignRole reports whether the logged-in user may give a user the role. Anyone may
+// add plain users; only admins hand out the roles that unlock payment reversals.
+func canAssignRole(claims *middleware.AccessClaims, role string) bool {
+	return role == "" || role == "user" || hasRole(claims, "admin")
+}
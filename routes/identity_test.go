@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"testing"
+
+	"shared/middleware"
+)
+
+func TestCanReversePayment(t *testing.T) {
+	cases := []struct {
+		role string
+		want bool
+	}{
+		{"admin", true},
+		{"Accountant", true},
+		{"user", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		claims := &middleware.AccessClaims{Role: tc.role}
+		if got := canReversePayment(claims); got != tc.want {
+			t.Errorf("canReversePayment(%q) = %v, want %v", tc.role, got, tc.want)
+		}
+	}
+}
+
+func TestCanAssignRole(t *testing.T) {
+	admin := &middleware.AccessClaims{Role: "admin"}
+	accountant := &middleware.AccessClaims{Role: "accountant"}
+
+	if !canAssignRole(accountant, "user") {
+		t.Error("anyone may add a plain user")
+	}
+	if canAssignRole(accountant, "accountant") || canAssignRole(accountant, "admin") {
+		t.Error("only an admin may hand out the accountant and admin roles")
+	}
+	if !canAssignRole(admin, "accountant") || !canAssignRole(admin, "admin") {
+		t.Error("an admin may hand out every role")
+	}
+}
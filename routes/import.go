@@ -56,6 +56,9 @@ func ImportStudents(c *gin.Context) {
 
 	service := services.NewImportService()
 	count, err := service.ImportStudents(c.Request.Context(), companyCode, file)
+	if respondRefNoConflict(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
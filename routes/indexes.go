@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+// EnsureCompanyIndexes builds the company's database indexes and reports each one,
+// e.g. after duplicate ref numbers that blocked the unique index were resolved
+func EnsureCompanyIndexes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Access check; building indexes is a migration step for admins
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !hasRole(claims, "admin") {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "only an admin can build indexes"})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	indexes, err := services.EnsureCompanyIndexes(ctx, companyCode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, indexes)
+}
@@ -180,6 +180,9 @@ func CreateStudent(c *gin.Context) {
 	// Call service to create student
 	service := services.NewStudentService()
 	student, err := service.Create(ctx, companyCode, req)
+	if respondRefNoConflict(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -193,7 +196,7 @@ func CreateUser(c *gin.Context) {
 	defer cancel()
 
 	// Check access claims
-	_, err := middleware.GetAccessClaims(c)
+	claims, err := middleware.GetAccessClaims(c)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -212,6 +215,10 @@ func CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !canAssignRole(claims, req.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only an admin can assign the " + req.Role + " role"})
+		return
+	}
 
 	// Call service to create user
 	service := services.NewUserService()
@@ -287,7 +294,7 @@ func GetReceiptByRefNo(c *gin.Context) {
 
 	// Call service to get receipt
 	service := services.NewReceiptService()
-	receipt, err := service.GetReceiptByRefNo(ctx, companyCode, req.RefNo)
+	receipt, err := service.GetReceiptByRefNo(ctx, companyCode, req.RefNo, req.SessionEntityID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -300,8 +307,8 @@ func ConfirmPayment(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Access check
-	_, err := middleware.GetAccessClaims(c)
+	// Access check; the claims identify the collecting user
+	claims, err := middleware.GetAccessClaims(c)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -323,13 +330,60 @@ func ConfirmPayment(c *gin.Context) {
 
 	// Call service to confirm payment
 	service := services.NewPaymentConfirmationService()
-	err = service.ConfirmPayment(ctx, companyCode, req)
+	paymentID, err := service.ConfirmPayment(ctx, companyCode, req, paymentActor(claims))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"message": "Payment confirmed successfully"}
+	if paymentID != "" {
+		response["payment_id"] = paymentID
+		// The signed code for the receipt QR, when receipt signing is configured
+		if code, err := services.NewReceiptScanService().GetReceiptCode(ctx, companyCode, paymentID); err == nil {
+			response["receipt_code"] = code.Code
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func ReversePayment(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check; the claims identify the user issuing the void or refund
+	claims, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if !canReversePayment(claims) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "only an admin or accountant can reverse a payment"})
+		return
+	}
+
+	// Get company code from URL param
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewReversePaymentRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewPaymentConfirmationService()
+	count, err := service.ReversePayment(ctx, companyCode, req, paymentActor(claims))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Payment confirmed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Payment reversed successfully", "count": count})
 }
 
 func GetDailyReports(c *gin.Context) {
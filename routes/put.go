@@ -188,6 +188,9 @@ func UpdateStudent(c *gin.Context) {
 	// Call service to update student
 	service := services.NewStudentService()
 	updatedStudent, err := service.Update(ctx, companyCode, id, req)
+	if respondRefNoConflict(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -201,7 +204,7 @@ func UpdateUser(c *gin.Context) {
 	defer cancel()
 
 	// Access check
-	_, err := middleware.GetAccessClaims(c)
+	claims, err := middleware.GetAccessClaims(c)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -221,6 +224,10 @@ func UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Role != nil && !hasRole(claims, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only an admin can change a user's role"})
+		return
+	}
 
 	// Call service to update student
 	service := services.NewUserService()
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shared/middleware"
+
+	"github.com/nandani-y-meizo/school-backend/requests"
+	"github.com/nandani-y-meizo/school-backend/services"
+)
+
+// ScanReceipt resolves a raw scanned payload: a student ID barcode, a receipt QR or a UPI reference
+func ScanReceipt(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code
+	companyCode := c.Param("company_code")
+	if companyCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code is required"})
+		return
+	}
+
+	// Bind and validate JSON payload
+	req := requests.NewScanReceiptRequest()
+	if err := req.Validate(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	service := services.NewReceiptScanService()
+	result, err := service.Scan(ctx, companyCode, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func GetReceiptCode(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and payment id
+	companyCode := c.Param("company_code")
+	paymentID := c.Param("payment_id")
+	if companyCode == "" || paymentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and payment_id are required"})
+		return
+	}
+
+	service := services.NewReceiptScanService()
+	code, err := service.GetReceiptCode(ctx, companyCode, paymentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// GetReceiptQRCode returns the receipt code of a checkout as a PNG for printing
+func GetReceiptQRCode(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Access check
+	_, err := middleware.GetAccessClaims(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Company code and payment id
+	companyCode := c.Param("company_code")
+	paymentID := c.Param("payment_id")
+	if companyCode == "" || paymentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "company_code and payment_id are required"})
+		return
+	}
+
+	service := services.NewReceiptScanService()
+	png, err := service.GetReceiptQRCode(ctx, companyCode, paymentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
@@ -72,13 +72,19 @@ func LoginRegularUser(c *gin.Context) {
 		return
 	}
 
+	// Users created before roles were stored log in as plain users
+	role := user.Role
+	if role == "" {
+		role = "user"
+	}
+
 	// Generate proper JWT token with user claims
 	customClaims := map[string]interface{}{
 		"user_id":      user.ID.Hex(),
 		"email":        user.Email,
 		"name":         user.Name,
 		"username":     user.Name, // Add username field for middleware
-		"role":         "user",
+		"role":         role,
 		"company_code": req.CompanyCode,
 		"group_code":   "", // Add group_code field for middleware
 	}
@@ -15,6 +15,8 @@ func Routes(api *gin.RouterGroup) {
 
 		// Additional board routes
 		boards.POST("/batch", GetBoardsByUUIDs)
+		boards.GET("/:id/grading-scale", GetGradingScale)
+		boards.PUT("/:id/grading-scale", UpdateGradingScale)
 	}
 
 	//class routes
@@ -25,6 +27,41 @@ func Routes(api *gin.RouterGroup) {
 		class.GET("/:id", GetClassByID)
 		class.PUT("/:id", UpdateClass)
 		class.DELETE("/:id", DeleteClass)
+
+		// Division routes
+		class.POST("/:id/divisions", CreateDivision)
+		class.GET("/:id/divisions", GetClassDivisions)
+		class.GET("/:id/divisions/:division_id", GetDivisionByID)
+		class.PUT("/:id/divisions/:division_id", UpdateDivision)
+		class.DELETE("/:id/divisions/:division_id", DeleteDivision)
+	}
+
+	divisions := api.Group("/companies/:company_code/divisions")
+	{
+		divisions.POST("/normalise", NormaliseDivisions)
+	}
+
+	sessions := api.Group("/companies/:company_code/sessions")
+	{
+		sessions.POST("", CreateAcademicSession)
+		sessions.GET("", GetAcademicSessions)
+		sessions.GET("/active", GetActiveAcademicSession)
+		sessions.GET("/:id", GetAcademicSessionByID)
+		sessions.PUT("/:id", UpdateAcademicSession)
+		sessions.DELETE("/:id", DeleteAcademicSession)
+
+		// Additional session routes
+		sessions.POST("/:id/activate", ActivateAcademicSession)
+		sessions.POST("/:id/backfill", BackfillAcademicSession)
+	}
+
+	promotions := api.Group("/companies/:company_code/promotions")
+	{
+		promotions.POST("/preview", PreviewPromotion)
+		promotions.POST("", ApplyPromotion)
+		promotions.GET("", GetPromotions)
+		promotions.GET("/:id", GetPromotionByID)
+		promotions.POST("/:id/undo", UndoPromotion)
 	}
 
 	//books routes
@@ -48,6 +85,33 @@ func Routes(api *gin.RouterGroup) {
 
 		// Additional exam routes
 		exams.POST("/batch", GetExamsByUUIDs)
+
+		// Exam schedule, seating and conflicts
+		exams.GET("/conflicts", GetExamConflicts)
+		exams.PUT("/:id/schedule", UpdateExamSchedule)
+		exams.POST("/:id/papers/:paper_id/seating", GenerateExamSeatingPlan)
+		exams.GET("/:id/papers/:paper_id/seating", GetExamSeatingPlan)
+
+		// Marks, moderation and report cards
+		exams.PUT("/:id/papers/:paper_id/marks", SubmitExamMarks)
+		exams.GET("/:id/papers/:paper_id/marks", GetExamMarkSheet)
+		exams.POST("/:id/papers/:paper_id/marks/moderate", ModerateExamMarks)
+		exams.POST("/:id/papers/:paper_id/marks/status", ChangeExamMarksStatus)
+		exams.GET("/:id/report-cards", GetReportCards)
+
+		// Hall tickets
+		exams.GET("/:id/hall-tickets", GetHallTickets)
+		exams.GET("/:id/hall-tickets/blocked", GetBlockedHallTickets)
+		exams.POST("/hall-tickets/verify", VerifyHallTicket)
+	}
+
+	examRooms := api.Group("/companies/:company_code/exam-rooms")
+	{
+		examRooms.POST("", CreateExamRoom)
+		examRooms.GET("", GetExamRooms)
+		examRooms.GET("/:id", GetExamRoomByID)
+		examRooms.PUT("/:id", UpdateExamRoom)
+		examRooms.DELETE("/:id", DeleteExamRoom)
 	}
 
 	students := api.Group("/companies/:company_code/students")
@@ -61,6 +125,62 @@ func Routes(api *gin.RouterGroup) {
 		// Additional student routes
 		students.POST("/batch", GetStudentsByUUIDs)
 		students.POST("/import", ImportStudents)
+		students.GET("/:id/history", GetStudentClassHistory)
+		students.POST("/:id/status", ChangeStudentStatus)
+		students.GET("/:id/status-history", GetStudentStatusHistory)
+		students.GET("/:id/leaving-certificate", GetLeavingCertificate)
+		students.GET("/:id/attendance", GetStudentAttendance)
+		students.GET("/duplicate-ref-nos", GetDuplicateRefNumbers)
+		students.GET("/search", SearchStudents)
+		students.POST("/search/reindex", ReindexStudentSearch)
+
+		// Guardian routes
+		students.POST("/:id/guardians", CreateGuardian)
+		students.GET("/:id/guardians", GetStudentGuardians)
+		students.GET("/:id/guardians/:guardian_id", GetGuardianByID)
+		students.PUT("/:id/guardians/:guardian_id", UpdateGuardian)
+		students.DELETE("/:id/guardians/:guardian_id", DeleteGuardian)
+
+		// Attachment routes
+		students.POST("/:id/attachments", UploadAttachment)
+		students.GET("/:id/attachments", GetStudentAttachments)
+		students.GET("/:id/attachments/:attachment_id", GetAttachmentByID)
+		students.GET("/:id/attachments/:attachment_id/download", DownloadAttachment)
+		students.DELETE("/:id/attachments/:attachment_id", DeleteAttachment)
+	}
+
+	refNumbers := api.Group("/companies/:company_code/ref-numbers")
+	{
+		refNumbers.GET("/settings", GetRefNumberSettings)
+		refNumbers.PUT("/settings", UpdateRefNumberSettings)
+		refNumbers.GET("/duplicates", GetDuplicateRefNumbers)
+	}
+
+	indexes := api.Group("/companies/:company_code/indexes")
+	{
+		indexes.POST("", EnsureCompanyIndexes)
+	}
+
+	attendance := api.Group("/companies/:company_code/attendance")
+	{
+		attendance.POST("", SubmitAttendance)
+		attendance.GET("/sheet", GetAttendanceSheet)
+		attendance.GET("/register", GetAttendanceRegister)
+		attendance.GET("/settings", GetAttendanceSettings)
+		attendance.PUT("/settings", UpdateAttendanceSettings)
+	}
+
+	schoolProfile := api.Group("/companies/:company_code/school-profile")
+	{
+		schoolProfile.GET("", GetSchoolProfile)
+		schoolProfile.PUT("", UpdateSchoolProfile)
+		schoolProfile.PUT("/logo", UploadSchoolLogo)
+		schoolProfile.GET("/logo", GetSchoolLogo)
+	}
+
+	idCards := api.Group("/companies/:company_code/id-cards")
+	{
+		idCards.GET("", GetIDCards)
 	}
 
 	importRoutes := api.Group("/companies/:company_code/import")
@@ -98,11 +218,16 @@ func Routes(api *gin.RouterGroup) {
 	{
 		receipts.POST("/lookup", GetReceiptByRefNo)
 		receipts.POST("/confirm", ConfirmPayment)
+		receipts.POST("/reverse", ReversePayment)
+		receipts.POST("/scan", ScanReceipt)
+		receipts.GET("/payments/:payment_id/code", GetReceiptCode)
+		receipts.GET("/payments/:payment_id/qr", GetReceiptQRCode)
 	}
 
 	unpaidStudents := api.Group("/companies/:company_code/unpaid-students")
 	{
 		unpaidStudents.POST("", GetUnpaidStudents)
+		unpaidStudents.POST("/export", ExportUnpaidStudents)
 	}
 
 	dailyReports := api.Group("/companies/:company_code/daily-reports")
@@ -114,6 +239,59 @@ func Routes(api *gin.RouterGroup) {
 	{
 		dashboard.GET("/stats", GetDashboardStats)
 	}
+
+	reports := api.Group("/companies/:company_code/reports")
+	{
+		reports.POST("/collection-summary", GetCollectionSummary)
+		reports.POST("/item-sales", GetItemSales)
+		reports.POST("/defaulter-aging", GetDefaulterAging)
+		reports.POST("/defaulter-aging/export", ExportDefaulterAging)
+		reports.POST("/collection-trends", GetCollectionTrends)
+		reports.POST("/cashier-activity", GetCashierActivity)
+	}
+
+	calendar := api.Group("/companies/:company_code/calendar")
+	{
+		calendar.POST("", CreateCalendarEntry)
+		calendar.GET("", GetCalendarEntries)
+		calendar.GET("/upcoming", GetUpcomingCalendarEntries)
+		calendar.GET("/export", ExportCalendar)
+		calendar.POST("/import", ImportCalendar)
+		calendar.GET("/:id", GetCalendarEntryByID)
+		calendar.PUT("/:id", UpdateCalendarEntry)
+		calendar.DELETE("/:id", DeleteCalendarEntry)
+	}
+
+	reportSubscriptions := api.Group("/companies/:company_code/report-subscriptions")
+	{
+		reportSubscriptions.POST("", CreateReportSubscription)
+		reportSubscriptions.GET("", GetReportSubscriptions)
+		reportSubscriptions.GET("/:id", GetReportSubscriptionByID)
+		reportSubscriptions.PUT("/:id", UpdateReportSubscription)
+		reportSubscriptions.DELETE("/:id", DeleteReportSubscription)
+
+		// Additional report subscription routes
+		reportSubscriptions.POST("/:id/run", RunReportSubscription)
+		reportSubscriptions.GET("/:id/runs", GetReportSubscriptionRuns)
+	}
+
+	notifications := api.Group("/companies/:company_code/notifications")
+	{
+		notifications.POST("/reminders", SendReminders)
+		notifications.GET("/messages", GetNotificationMessages)
+		notifications.POST("/delivery-status", UpdateNotificationDeliveryStatus)
+
+		notifications.POST("/opt-outs", CreateNotificationOptOut)
+		notifications.GET("/opt-outs", GetNotificationOptOuts)
+		notifications.DELETE("/opt-outs/:id", DeleteNotificationOptOut)
+
+		notifications.POST("/schedules", CreateReminderSchedule)
+		notifications.GET("/schedules", GetReminderSchedules)
+		notifications.GET("/schedules/:id", GetReminderScheduleByID)
+		notifications.PUT("/schedules/:id", UpdateReminderSchedule)
+		notifications.DELETE("/schedules/:id", DeleteReminderSchedule)
+		notifications.POST("/schedules/:id/run", RunReminderSchedule)
+	}
 }
 
 // PublicRoutes sets up public API routes that don't require authentication
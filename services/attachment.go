@@ -0,0 +1,404 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const AttachmentCollection = "student_attachments"
+
+// MaxAttachmentSize is the largest file accepted for any kind of attachment
+const MaxAttachmentSize = 10 << 20
+
+// attachmentRule lists the content types and size allowed for one kind of attachment
+type attachmentRule struct {
+	ContentTypes []string
+	MaxSize      int64
+}
+
+var attachmentRules = map[string]attachmentRule{
+	"photo":                {ContentTypes: []string{"image/jpeg", "image/png", "image/webp"}, MaxSize: 5 << 20},
+	"birth_certificate":    {ContentTypes: []string{"application/pdf", "image/jpeg", "image/png"}, MaxSize: MaxAttachmentSize},
+	"transfer_certificate": {ContentTypes: []string{"application/pdf", "image/jpeg", "image/png"}, MaxSize: MaxAttachmentSize},
+	"other":                {ContentTypes: []string{"application/pdf", "image/jpeg", "image/png", "image/webp"}, MaxSize: MaxAttachmentSize},
+	// The school logo is printed into PDFs, which take JPEG and PNG only
+	"logo": {ContentTypes: []string{"image/jpeg", "image/png"}, MaxSize: 2 << 20},
+}
+
+var attachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type AttachmentService interface {
+	Upload(ctx context.Context, companyCode string, studentID string, req *requests.UploadAttachmentRequest, fileName string, file io.Reader, uploadedBy models.PaymentActor) (*models.Attachment, error)
+	GetByStudent(ctx context.Context, companyCode string, studentID string, kind string) ([]models.Attachment, error)
+	GetByID(ctx context.Context, companyCode string, studentID string, id string) (*models.Attachment, error)
+	Download(ctx context.Context, companyCode string, studentID string, id string) (*models.Attachment, []byte, error)
+	Delete(ctx context.Context, companyCode string, studentID string, id string) error
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type attachmentService struct{}
+
+func NewAttachmentService() AttachmentService {
+	return &attachmentService{}
+}
+
+//
+// ================= UPLOAD =================
+//
+
+// Upload checks the file against the rules of its kind, stores it and records its
+// metadata. A new photo replaces the student's previous one.
+func (s *attachmentService) Upload(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	req *requests.UploadAttachmentRequest,
+	fileName string,
+	file io.Reader,
+	uploadedBy models.PaymentActor,
+) (*models.Attachment, error) {
+
+	storage := GetAttachmentStorage()
+	if storage == nil {
+		return nil, errors.New("attachment storage is not configured")
+	}
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := attachmentRules[req.Kind]
+	data, err := io.ReadAll(io.LimitReader(file, rule.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := checkAttachmentContent(req.Kind, data)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(AttachmentCollection)
+
+	// The same file attached twice as the same kind is a mistake, not a new document
+	duplicates, err := collection.CountDocuments(ctx, bson.M{
+		"student_entity_id": student.EntityID,
+		"kind":              req.Kind,
+		"sha256":            checksum,
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if duplicates > 0 {
+		return nil, fmt.Errorf("this file is already attached as %s", req.Kind)
+	}
+
+	attachment := models.NewAttachment()
+	attachment.StudentEntityID = student.EntityID
+	attachment.Kind = req.Kind
+	attachment.FileName = cleanAttachmentName(fileName, contentType)
+	attachment.ContentType = contentType
+	attachment.Size = int64(len(data))
+	attachment.SHA256 = checksum
+	attachment.Description = req.Description
+	attachment.StorageBackend = storage.Name()
+	attachment.StorageKey = fmt.Sprintf("%s/%s/%s%s", companyCode, student.EntityID, attachment.EntityID, attachmentExtensions[contentType])
+	attachment.UploadedBy = &uploadedBy
+
+	if err := storage.Put(ctx, attachment.StorageKey, data, contentType); err != nil {
+		return nil, err
+	}
+	if _, err := collection.InsertOne(ctx, attachment); err != nil {
+		storage.Delete(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	if req.Kind == "photo" {
+		if err := s.deleteWhere(ctx, collection, bson.M{
+			"student_entity_id": student.EntityID,
+			"kind":              "photo",
+			"entity_id":         bson.M{"$ne": attachment.EntityID},
+			"is_deleted":        false,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return attachment, nil
+}
+
+//
+// ================= GET BY STUDENT =================
+//
+
+// GetByStudent lists the student's attachments, newest first, optionally of one kind
+func (s *attachmentService) GetByStudent(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	kind string,
+) ([]models.Attachment, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(AttachmentCollection)
+
+	filter := bson.M{"student_entity_id": student.EntityID, "is_deleted": false}
+	if kind != "" {
+		filter["kind"] = kind
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attachments := []models.Attachment{}
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *attachmentService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+) (*models.Attachment, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(AttachmentCollection)
+
+	var attachment models.Attachment
+	err = collection.FindOne(ctx, attachmentFilter(student.EntityID, id)).Decode(&attachment)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("attachment not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+//
+// ================= DOWNLOAD =================
+//
+
+// Download reads the attachment's file and checks it against the stored checksum, so a
+// corrupted or replaced file is never served
+func (s *attachmentService) Download(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+) (*models.Attachment, []byte, error) {
+
+	storage := GetAttachmentStorage()
+	if storage == nil {
+		return nil, nil, errors.New("attachment storage is not configured")
+	}
+
+	attachment, err := s.GetByID(ctx, companyCode, studentID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attachment.StorageBackend != storage.Name() {
+		return nil, nil, fmt.Errorf("attachment is kept in %s storage, but %s storage is configured", attachment.StorageBackend, storage.Name())
+	}
+
+	data, err := readStoredFile(ctx, storage, attachment.StorageKey, attachment.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, data, nil
+}
+
+//
+// ================= DELETE =================
+//
+
+// Delete removes the file from storage and soft deletes its metadata
+func (s *attachmentService) Delete(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+) error {
+
+	attachment, err := s.GetByID(ctx, companyCode, studentID, id)
+	if err != nil {
+		return err
+	}
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(AttachmentCollection)
+
+	return s.deleteWhere(ctx, collection, bson.M{"_id": attachment.ID, "is_deleted": false})
+}
+
+// deleteWhere soft deletes the matching attachments and removes their files
+func (s *attachmentService) deleteWhere(ctx context.Context, collection *mongo.Collection, filter bson.M) error {
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []models.Attachment
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return err
+	}
+
+	storage := GetAttachmentStorage()
+	for _, attachment := range attachments {
+		if _, err := collection.UpdateOne(ctx,
+			bson.M{"_id": attachment.ID},
+			bson.M{"$set": bson.M{"is_deleted": true, "updated_at": time.Now()}},
+		); err != nil {
+			return err
+		}
+		if storage != nil && attachment.StorageBackend == storage.Name() {
+			if err := storage.Delete(ctx, attachment.StorageKey); err != nil {
+				fmt.Printf("Attachment file %s not removed: %v\n", attachment.StorageKey, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// readStoredFile reads a file from storage and checks it against its checksum
+func readStoredFile(ctx context.Context, storage AttachmentStorage, key string, checksum string) ([]byte, error) {
+	reader, err := storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, MaxAttachmentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return nil, errors.New("stored file does not match its checksum")
+	}
+	return data, nil
+}
+
+func attachmentFilter(studentEntityID string, id string) bson.M {
+	filter := bson.M{"student_entity_id": studentEntityID, "entity_id": id, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"student_entity_id": studentEntityID, "_id": oid, "is_deleted": false}
+	}
+	return filter
+}
+
+// checkAttachmentContent enforces the size limit of the kind and sniffs the content
+// type from the file itself, returning it when the kind allows it
+func checkAttachmentContent(kind string, data []byte) (string, error) {
+	rule, ok := attachmentRules[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown attachment kind: %s", kind)
+	}
+	if len(data) == 0 {
+		return "", errors.New("file is empty")
+	}
+	if int64(len(data)) > rule.MaxSize {
+		return "", fmt.Errorf("file exceeds the %d MB limit for %s", rule.MaxSize>>20, kind)
+	}
+
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	for _, allowed := range rule.ContentTypes {
+		if contentType == allowed {
+			return contentType, nil
+		}
+	}
+	return "", fmt.Errorf("%s files are not accepted for %s; use %s", contentType, kind, strings.Join(rule.ContentTypes, ", "))
+}
+
+// cleanAttachmentName keeps the base name of the uploaded file without control
+// characters, giving it the extension of its actual content type
+func cleanAttachmentName(name string, contentType string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	extension := attachmentExtensions[contentType]
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if base == "" || base == "." || base == "/" {
+		base = "attachment"
+	}
+	if len(base) > 200 {
+		base = strings.ToValidUTF8(base[:200], "")
+	}
+	return base + extension
+}
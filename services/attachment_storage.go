@@ -0,0 +1,346 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAttachmentNotFound is returned by a storage backend for a key it does not hold
+var ErrAttachmentNotFound = errors.New("attachment file not found")
+
+// AttachmentStorage keeps the files behind student attachments. The attachment service
+// only depends on this interface, so the local filesystem or any S3-compatible object
+// store (AWS S3, MinIO) can hold them.
+type AttachmentStorage interface {
+	Name() string
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	attachmentStorageMu sync.RWMutex
+	attachmentStorage   AttachmentStorage
+)
+
+// SetAttachmentStorage sets the backend used for attachment files
+func SetAttachmentStorage(storage AttachmentStorage) {
+	attachmentStorageMu.Lock()
+	defer attachmentStorageMu.Unlock()
+	attachmentStorage = storage
+}
+
+func GetAttachmentStorage() AttachmentStorage {
+	attachmentStorageMu.RLock()
+	defer attachmentStorageMu.RUnlock()
+	return attachmentStorage
+}
+
+// NewAttachmentStorageFromEnv builds the backend named by ATTACHMENT_STORAGE. "local"
+// (the default) writes under ATTACHMENT_DIR, "data/attachments" unless set; "s3" needs
+// S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY, with S3_REGION defaulting to
+// us-east-1 and S3_PATH_STYLE=true for MinIO-style bucket paths.
+func NewAttachmentStorageFromEnv() (AttachmentStorage, error) {
+	switch backend := os.Getenv("ATTACHMENT_STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("ATTACHMENT_DIR")
+		if dir == "" {
+			dir = filepath.Join("data", "attachments")
+		}
+		return NewLocalAttachmentStorage(dir), nil
+
+	case "s3":
+		config := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if config.Endpoint == "" || config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+			return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required for s3 attachment storage")
+		}
+		return NewS3AttachmentStorage(config)
+
+	default:
+		return nil, fmt.Errorf("unknown ATTACHMENT_STORAGE: %s", backend)
+	}
+}
+
+//
+// ================= LOCAL FILESYSTEM =================
+//
+
+type localAttachmentStorage struct {
+	root string
+}
+
+func NewLocalAttachmentStorage(root string) AttachmentStorage {
+	return &localAttachmentStorage{root: root}
+}
+
+func (s *localAttachmentStorage) Name() string {
+	return "local"
+}
+
+// path maps a key below the root; cleaning it as an absolute path keeps ".." inside
+func (s *localAttachmentStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// Put writes to a temporary file first so a failed upload never leaves a partial file
+func (s *localAttachmentStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *localAttachmentStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrAttachmentNotFound
+	}
+	return file, err
+}
+
+func (s *localAttachmentStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+//
+// ================= S3 COMPATIBLE =================
+//
+
+type S3Config struct {
+	Endpoint  string // e.g. https://s3.ap-south-1.amazonaws.com or http://localhost:9000
+	Region    string // defaults to us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool // bucket in the path (MinIO) rather than as a subdomain
+}
+
+type s3AttachmentStorage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3AttachmentStorage(config S3Config) (AttachmentStorage, error) {
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %s", config.Endpoint)
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	return &s3AttachmentStorage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 60 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *s3AttachmentStorage) Name() string {
+	return "s3"
+}
+
+func (s *s3AttachmentStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *s3AttachmentStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3AttachmentStorage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// objectURL addresses the key in the bucket, path-style or as a bucket subdomain
+func (s *s3AttachmentStorage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	key = strings.TrimLeft(key, "/")
+	if s.config.PathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	return &u
+}
+
+// do sends one signed request and turns error responses into errors
+func (s *s3AttachmentStorage) do(ctx context.Context, method, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	payloadHash := sha256.Sum256(body)
+	signS3Request(req, s.config, hex.EncodeToString(payloadHash[:]), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrAttachmentNotFound
+	}
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s failed: %s %s", method, key, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
+
+// signS3Request adds AWS Signature Version 4 headers. Every header already set on the
+// request is signed along with the host.
+func signS3Request(req *http.Request, config S3Config, payloadHash string, at time.Time) {
+	amzDate := at.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name := range req.Header {
+		values[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			params = append(params, s3URIEncode(key, true)+"="+s3URIEncode(value, true))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+config.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		config.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3URIEncode percent-encodes everything but unreserved characters, and "/" unless
+// encodeSlash is set, as Signature Version 4 requires
+func s3URIEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			encoded.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
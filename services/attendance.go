@@ -0,0 +1,802 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AttendanceCollection         = "attendance"
+	AttendanceSettingsCollection = "attendance_settings"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type AttendanceService interface {
+	GetSettings(ctx context.Context, companyCode string) (*models.AttendanceSettings, error)
+	UpdateSettings(ctx context.Context, companyCode string, req *requests.UpdateAttendanceSettingsRequest) (*models.AttendanceSettings, error)
+	Submit(ctx context.Context, companyCode string, req *requests.SubmitAttendanceRequest, markedBy models.PaymentActor) (*models.AttendanceSubmission, error)
+	GetSheet(ctx context.Context, companyCode string, req *requests.AttendanceSheetRequest) (*models.AttendanceSheet, error)
+	GetRegister(ctx context.Context, companyCode string, req *requests.AttendanceRegisterRequest) (*models.AttendanceRegister, error)
+	GetStudentAttendance(ctx context.Context, companyCode string, studentID string, req *requests.StudentAttendanceRequest) (*models.StudentAttendance, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type attendanceService struct{}
+
+func NewAttendanceService() AttendanceService {
+	return &attendanceService{}
+}
+
+//
+// ================= SETTINGS =================
+//
+
+func (s *attendanceService) GetSettings(
+	ctx context.Context,
+	companyCode string,
+) (*models.AttendanceSettings, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return loadAttendanceSettings(ctx, database)
+}
+
+func (s *attendanceService) UpdateSettings(
+	ctx context.Context,
+	companyCode string,
+	req *requests.UpdateAttendanceSettingsRequest,
+) (*models.AttendanceSettings, error) {
+
+	updateFields := bson.M{}
+	if req.EditWindowHours != nil {
+		updateFields["edit_window_hours"] = *req.EditWindowHours
+	}
+	if req.Timezone != nil {
+		updateFields["timezone"] = *req.Timezone
+	}
+	if req.CountLateAsPresent != nil {
+		updateFields["count_late_as_present"] = *req.CountLateAsPresent
+	}
+	if req.RestrictToClassTeacher != nil {
+		updateFields["restrict_to_class_teacher"] = *req.RestrictToClassTeacher
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	// The first save starts from the defaults for whatever was not given
+	defaults := models.NewAttendanceSettings()
+	insertFields := bson.M{
+		"_id":        defaults.ID,
+		"entity_id":  defaults.EntityID,
+		"created_at": defaults.CreatedAt,
+	}
+	for key, value := range map[string]interface{}{
+		"edit_window_hours":         defaults.EditWindowHours,
+		"timezone":                  defaults.Timezone,
+		"count_late_as_present":     defaults.CountLateAsPresent,
+		"restrict_to_class_teacher": defaults.RestrictToClassTeacher,
+	} {
+		if _, ok := updateFields[key]; !ok {
+			insertFields[key] = value
+		}
+	}
+	updateFields["updated_at"] = time.Now()
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var settings models.AttendanceSettings
+	err := database.Collection(AttendanceSettingsCollection).
+		FindOneAndUpdate(ctx, bson.M{}, bson.M{"$set": updateFields, "$setOnInsert": insertFields}, opts).
+		Decode(&settings)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+//
+// ================= SUBMIT =================
+//
+
+// Submit marks a division for one day. Marks can be changed until the edit window after
+// the day has passed; students already marked the same way are left untouched.
+func (s *attendanceService) Submit(
+	ctx context.Context,
+	companyCode string,
+	req *requests.SubmitAttendanceRequest,
+	markedBy models.PaymentActor,
+) (*models.AttendanceSubmission, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	settings, err := loadAttendanceSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	locksAt, err := attendanceLocksAt(req.Date, settings)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if req.Date > attendanceToday(settings, now) {
+		return nil, errors.New("attendance cannot be marked for a future date")
+	}
+	if now.After(locksAt) {
+		return nil, fmt.Errorf("attendance for %s is locked since %s", req.Date, locksAt.Format(time.RFC3339))
+	}
+
+	// Attendance is taken in the active session, within its dates
+	session, err := activeSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil && !dateInSession(req.Date, session) {
+		return nil, fmt.Errorf("%s is outside session %s", req.Date, session.Name)
+	}
+
+	class, err := findAttendanceClass(ctx, database, req.ClassEntityID)
+	if err != nil {
+		return nil, err
+	}
+	division, err := findClassDivision(ctx, database, class.EntityID, req.Div)
+	if err != nil {
+		return nil, err
+	}
+	div := req.Div
+	if division != nil {
+		div = division.Name
+		if settings.RestrictToClassTeacher {
+			if err := checkClassTeacher(ctx, companyCode, division, markedBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	holidays, err := attendanceHolidays(ctx, companyCode, req.Date, req.Date, class.BoardEntityID)
+	if err != nil {
+		return nil, err
+	}
+	if title, ok := holidays[req.Date]; ok {
+		return nil, fmt.Errorf("%s is a holiday (%s)", req.Date, title)
+	}
+
+	students, err := attendanceRoll(ctx, database, session, class.EntityID, &div)
+	if err != nil {
+		return nil, err
+	}
+	if len(students) == 0 {
+		return nil, errors.New("no active students in this division")
+	}
+
+	// The marks to save: the listed entries, then the default for everyone else
+	onRoll := make(map[string]models.Student, len(students))
+	for _, student := range students {
+		onRoll[student.EntityID] = student
+	}
+	marks := make(map[string]requests.AttendanceEntryRequest, len(students))
+	var unknown []string
+	for _, entry := range req.Entries {
+		if _, ok := onRoll[entry.StudentEntityID]; !ok {
+			unknown = append(unknown, entry.StudentEntityID)
+			continue
+		}
+		marks[entry.StudentEntityID] = entry
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("students not on the roll of this division: %s", strings.Join(unknown, ", "))
+	}
+	if req.DefaultStatus != "" {
+		for id := range onRoll {
+			if _, ok := marks[id]; !ok {
+				marks[id] = requests.AttendanceEntryRequest{StudentEntityID: id, Status: req.DefaultStatus}
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(marks))
+	for id := range marks {
+		ids = append(ids, id)
+	}
+	existing, err := loadAttendanceRecords(ctx, database, bson.M{"student_entity_id": bson.M{"$in": ids}, "date": req.Date})
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]models.AttendanceRecord, len(existing))
+	for _, record := range existing {
+		current[record.StudentEntityID] = record
+	}
+
+	result := &models.AttendanceSubmission{Date: req.Date, LocksAt: locksAt}
+	sessionEntityID := ""
+	if session != nil {
+		sessionEntityID = session.EntityID
+	}
+
+	var writes []mongo.WriteModel
+	for _, id := range ids {
+		mark := marks[id]
+		record, found := current[id]
+		if found && record.Status == mark.Status && record.Remarks == mark.Remarks {
+			result.Unchanged++
+			continue
+		}
+		if found {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+
+		fresh := models.NewAttendanceRecord()
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"student_entity_id": id, "date": req.Date, "is_deleted": false}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"session_entity_id": sessionEntityID,
+					"board_entity_id":   class.BoardEntityID,
+					"class_entity_id":   class.EntityID,
+					"div":               div,
+					"status":            mark.Status,
+					"remarks":           mark.Remarks,
+					"marked_by":         markedBy,
+					"updated_at":        now,
+				},
+				"$setOnInsert": bson.M{
+					"_id":        fresh.ID,
+					"entity_id":  fresh.EntityID,
+					"created_at": fresh.CreatedAt,
+				},
+			}).
+			SetUpsert(true))
+	}
+
+	if len(writes) > 0 {
+		if _, err := database.Collection(AttendanceCollection).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+//
+// ================= SHEET =================
+//
+
+// GetSheet lists a division's roll for one day with each student's mark, if any
+func (s *attendanceService) GetSheet(
+	ctx context.Context,
+	companyCode string,
+	req *requests.AttendanceSheetRequest,
+) (*models.AttendanceSheet, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	settings, err := loadAttendanceSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	locksAt, err := attendanceLocksAt(req.Date, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	class, err := findAttendanceClass(ctx, database, req.ClassEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := attendanceRoll(ctx, database, session, class.EntityID, &req.Div)
+	if err != nil {
+		return nil, err
+	}
+	records, err := loadAttendanceRecords(ctx, database, bson.M{"student_entity_id": bson.M{"$in": studentEntityIDs(students)}, "date": req.Date})
+	if err != nil {
+		return nil, err
+	}
+	marked := make(map[string]models.AttendanceRecord, len(records))
+	for _, record := range records {
+		marked[record.StudentEntityID] = record
+	}
+
+	holidays, err := attendanceHolidays(ctx, companyCode, req.Date, req.Date, class.BoardEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &models.AttendanceSheet{
+		Date:          req.Date,
+		BoardEntityID: class.BoardEntityID,
+		ClassEntityID: class.EntityID,
+		Div:           req.Div,
+		Holiday:       holidays[req.Date],
+		Locked:        time.Now().After(locksAt),
+		LocksAt:       locksAt,
+		Entries:       make([]models.AttendanceEntry, 0, len(students)),
+	}
+	for _, student := range students {
+		entry := models.AttendanceEntry{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+		}
+		if record, ok := marked[student.EntityID]; ok {
+			entry.Status = record.Status
+			entry.Remarks = record.Remarks
+			entry.MarkedBy = record.MarkedBy
+		}
+		addAttendance(&sheet.Summary, entry.Status)
+		sheet.Entries = append(sheet.Entries, entry)
+	}
+	finishAttendance(&sheet.Summary, settings.CountLateAsPresent)
+
+	return sheet, nil
+}
+
+//
+// ================= MONTHLY REGISTER =================
+//
+
+// GetRegister builds the month's register of a class, or one division of it
+func (s *attendanceService) GetRegister(
+	ctx context.Context,
+	companyCode string,
+	req *requests.AttendanceRegisterRequest,
+) (*models.AttendanceRegister, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	settings, err := loadAttendanceSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	class, err := findAttendanceClass(ctx, database, req.ClassEntityID)
+	if err != nil {
+		return nil, err
+	}
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := attendanceRoll(ctx, database, session, class.EntityID, req.Div)
+	if err != nil {
+		return nil, err
+	}
+
+	days := monthDays(req.Month)
+	first, last := days[0], days[len(days)-1]
+
+	records, err := loadAttendanceRecords(ctx, database, bson.M{
+		"student_entity_id": bson.M{"$in": studentEntityIDs(students)},
+		"date":              bson.M{"$gte": first, "$lte": last},
+	})
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := attendanceHolidays(ctx, companyCode, first, last, class.BoardEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	register := &models.AttendanceRegister{
+		Month:         req.Month,
+		BoardEntityID: class.BoardEntityID,
+		BoardName:     boardNames[class.BoardEntityID],
+		ClassEntityID: class.EntityID,
+		ClassName:     classNames[class.EntityID],
+		Days:          days,
+		Holidays:      make([]string, 0),
+		Rows:          make([]models.AttendanceRegisterRow, 0, len(students)),
+	}
+	if req.Div != nil {
+		register.Div = *req.Div
+	}
+	for _, day := range days {
+		if _, ok := holidays[day]; ok {
+			register.Holidays = append(register.Holidays, day)
+		}
+	}
+
+	statuses := make(map[string]map[string]string, len(students))
+	taken := make(map[string]bool)
+	for _, record := range records {
+		if statuses[record.StudentEntityID] == nil {
+			statuses[record.StudentEntityID] = make(map[string]string)
+		}
+		statuses[record.StudentEntityID][record.Date] = record.Status
+		taken[record.Date] = true
+	}
+	register.WorkingDays = len(taken)
+
+	for _, student := range students {
+		row := models.AttendanceRegisterRow{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+			Div:             student.Div,
+			Marks:           make([]string, len(days)),
+		}
+		for i, day := range days {
+			status := statuses[student.EntityID][day]
+			row.Marks[i] = attendanceMark(status)
+			if status == "" {
+				if _, ok := holidays[day]; ok {
+					row.Marks[i] = "H"
+				}
+				continue
+			}
+			addAttendance(&row.Summary, status)
+		}
+		finishAttendance(&row.Summary, settings.CountLateAsPresent)
+		register.Rows = append(register.Rows, row)
+	}
+
+	return register, nil
+}
+
+//
+// ================= STUDENT ATTENDANCE =================
+//
+
+// GetStudentAttendance sums up a student's attendance over a date range, or over the
+// session (the active one unless given) when no range is given
+func (s *attendanceService) GetStudentAttendance(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	req *requests.StudentAttendanceRequest,
+) (*models.StudentAttendance, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := loadAttendanceSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StudentAttendance{
+		StudentEntityID: student.EntityID,
+		RefNo:           student.RefNo,
+		Name:            studentFullName(*student),
+		Months:          make([]models.StudentAttendanceMonth, 0),
+		Absences:        make([]models.AttendanceRecord, 0),
+	}
+
+	filter := bson.M{"student_entity_id": student.EntityID}
+	dates := bson.M{}
+	if req.From != nil && *req.From != "" {
+		dates["$gte"] = *req.From
+		result.From = *req.From
+	}
+	if req.To != nil && *req.To != "" {
+		dates["$lte"] = *req.To
+		result.To = *req.To
+	}
+	if len(dates) > 0 {
+		filter["date"] = dates
+	}
+	if len(dates) == 0 || (req.SessionEntityID != nil && *req.SessionEntityID != "") {
+		session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			filter["session_entity_id"] = session.EntityID
+			result.SessionEntityID = session.EntityID
+		}
+	}
+
+	records, err := loadAttendanceRecords(ctx, database, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make(map[string]*models.AttendanceBreakdown)
+	for _, record := range records {
+		addAttendance(&result.Summary, record.Status)
+		month := record.Date[:7]
+		if months[month] == nil {
+			months[month] = &models.AttendanceBreakdown{}
+		}
+		addAttendance(months[month], record.Status)
+		if record.Status == "absent" || record.Status == "leave" {
+			result.Absences = append(result.Absences, record)
+		}
+	}
+	finishAttendance(&result.Summary, settings.CountLateAsPresent)
+
+	for month, breakdown := range months {
+		finishAttendance(breakdown, settings.CountLateAsPresent)
+		result.Months = append(result.Months, models.StudentAttendanceMonth{Month: month, Summary: *breakdown})
+	}
+	sort.Slice(result.Months, func(i, j int) bool { return result.Months[i].Month < result.Months[j].Month })
+	sort.SliceStable(result.Absences, func(i, j int) bool { return result.Absences[i].Date > result.Absences[j].Date })
+
+	return result, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// attendanceIndexes makes a student's mark for a day unique and keeps day and month
+// lookups of a class on an index
+var attendanceIndexes = []companyIndex{
+	{
+		Collection: AttendanceCollection,
+		Model: mongo.IndexModel{
+			Keys: bson.D{{Key: "student_entity_id", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().
+				SetName("student_date_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"is_deleted": false}),
+		},
+	},
+	{
+		Collection: AttendanceCollection,
+		Model: mongo.IndexModel{
+			Keys:    bson.D{{Key: "class_entity_id", Value: 1}, {Key: "date", Value: 1}},
+			Options: options.Index().SetName("class_date"),
+		},
+	},
+}
+
+func loadAttendanceSettings(ctx context.Context, database *mongo.Database) (*models.AttendanceSettings, error) {
+	var settings models.AttendanceSettings
+	err := database.Collection(AttendanceSettingsCollection).FindOne(ctx, bson.M{}).Decode(&settings)
+	if err == mongo.ErrNoDocuments {
+		return models.NewAttendanceSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func loadAttendanceRecords(ctx context.Context, database *mongo.Database, filter bson.M) ([]models.AttendanceRecord, error) {
+	filter["is_deleted"] = false
+
+	cursor, err := database.Collection(AttendanceCollection).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.AttendanceRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func findAttendanceClass(ctx context.Context, database *mongo.Database, classEntityID string) (*models.Class, error) {
+	var class models.Class
+	err := database.Collection(ClassCollection).FindOne(ctx, bson.M{"entity_id": classEntityID, "is_deleted": false}).Decode(&class)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("class not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+// findClassDivision returns the class's division named div. Classes without divisions
+// keep free-text div values, so nil with no error means there is nothing to check.
+func findClassDivision(ctx context.Context, database *mongo.Database, classEntityID string, div string) (*models.Division, error) {
+	cursor, err := database.Collection(DivisionCollection).Find(ctx, bson.M{"class_entity_id": classEntityID, "is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var divisions []models.Division
+	if err := cursor.All(ctx, &divisions); err != nil {
+		return nil, err
+	}
+	if len(divisions) == 0 {
+		return nil, nil
+	}
+	for i := range divisions {
+		if strings.EqualFold(divisions[i].Name, div) {
+			return &divisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("div %q is not a division of this class", div)
+}
+
+// checkClassTeacher allows the division's class teacher, matched on the user's entity
+// id or object id, which is what the access claims carry
+func checkClassTeacher(ctx context.Context, companyCode string, division *models.Division, actor models.PaymentActor) error {
+	if division.ClassTeacherEntityID == "" {
+		return fmt.Errorf("division %s has no class teacher to submit its attendance", division.Name)
+	}
+	if actor.UserID == division.ClassTeacherEntityID {
+		return nil
+	}
+	if actor.UserID != "" {
+		if user, err := NewUserService().GetByID(ctx, companyCode, actor.UserID); err == nil && user.EntityID == division.ClassTeacherEntityID {
+			return nil
+		}
+	}
+	return fmt.Errorf("only the class teacher of division %s can submit its attendance", division.Name)
+}
+
+// attendanceRoll lists the active students of a class, or one division of it, sorted
+// by division and name
+func attendanceRoll(ctx context.Context, database *mongo.Database, session *models.AcademicSession, classEntityID string, div *string) ([]models.Student, error) {
+	filter := bson.M{"class_entity_id": classEntityID, "is_deleted": false}
+	if div != nil && *div != "" {
+		filter["div"] = divisionMatch(*div)
+	}
+
+	students, err := findSessionStudents(ctx, database, session, filter)
+	if err != nil {
+		return nil, err
+	}
+	students = activeStudents(students)
+
+	sort.SliceStable(students, func(i, j int) bool {
+		if students[i].Div != students[j].Div {
+			return students[i].Div < students[j].Div
+		}
+		return studentFullName(students[i]) < studentFullName(students[j])
+	})
+	return students, nil
+}
+
+// attendanceHolidays maps the holiday dates between from and to (YYYY-MM-DD, inclusive)
+// that apply to the board to their titles
+func attendanceHolidays(ctx context.Context, companyCode string, from, to string, boardEntityID string) (map[string]string, error) {
+	start, _ := time.Parse("2006-01-02", from)
+	end, _ := time.Parse("2006-01-02", to)
+
+	occurrences, err := NewCalendarService().GetOccurrences(ctx, companyCode, start, end, boardEntityID, "holiday")
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make(map[string]string)
+	for _, occurrence := range occurrences {
+		for day := occurrence.StartDate.UTC(); !day.After(occurrence.EndDate.UTC()); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			if date >= from && date <= to {
+				holidays[date] = occurrence.Title
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// attendanceLocksAt is when a day's attendance stops being editable: the edit window
+// after the day ends in the school's timezone
+func attendanceLocksAt(date string, settings *models.AttendanceSettings) (time.Time, error) {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, errors.New("date must be in YYYY-MM-DD format")
+	}
+	return day.AddDate(0, 0, 1).Add(time.Duration(settings.EditWindowHours) * time.Hour), nil
+}
+
+// attendanceToday is the school's current date
+func attendanceToday(settings *models.AttendanceSettings, now time.Time) string {
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return now.In(loc).Format("2006-01-02")
+}
+
+// dateInSession checks a YYYY-MM-DD date against the session's dates, where set
+func dateInSession(date string, session *models.AcademicSession) bool {
+	if !session.StartDate.IsZero() && date < session.StartDate.UTC().Format("2006-01-02") {
+		return false
+	}
+	if !session.EndDate.IsZero() && date > session.EndDate.UTC().Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// monthDays lists every date of a YYYY-MM month
+func monthDays(month string) []string {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil
+	}
+	var days []string
+	for day := start; day.Month() == start.Month(); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+	return days
+}
+
+// attendanceMark is the register's short form of a status
+func attendanceMark(status string) string {
+	switch status {
+	case "present":
+		return "P"
+	case "absent":
+		return "A"
+	case "late":
+		return "L"
+	case "leave":
+		return "LV"
+	}
+	return ""
+}
+
+func addAttendance(breakdown *models.AttendanceBreakdown, status string) {
+	switch status {
+	case "present":
+		breakdown.Present++
+	case "absent":
+		breakdown.Absent++
+	case "late":
+		breakdown.Late++
+	case "leave":
+		breakdown.Leave++
+	default:
+		breakdown.Unmarked++
+	}
+}
+
+// finishAttendance totals the marked days and works out the percentage attended
+func finishAttendance(breakdown *models.AttendanceBreakdown, countLateAsPresent bool) {
+	breakdown.Marked = breakdown.Present + breakdown.Absent + breakdown.Late + breakdown.Leave
+	if breakdown.Marked == 0 {
+		breakdown.Percentage = 0
+		return
+	}
+	attended := breakdown.Present
+	if countLateAsPresent {
+		attended += breakdown.Late
+	}
+	breakdown.Percentage = math.Round(float64(attended)/float64(breakdown.Marked)*10000) / 100
+}
+
+func studentEntityIDs(students []models.Student) []string {
+	ids := make([]string, 0, len(students))
+	for _, student := range students {
+		ids = append(ids, student.EntityID)
+	}
+	return ids
+}
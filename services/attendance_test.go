@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestAttendanceLocksAt(t *testing.T) {
+	settings := &models.AttendanceSettings{EditWindowHours: 48, Timezone: "Asia/Kolkata"}
+
+	locksAt, err := attendanceLocksAt("2026-07-15", settings)
+	if err != nil {
+		t.Fatalf("attendanceLocksAt: %v", err)
+	}
+	// Midnight ending 15 July in India, plus two days
+	want := time.Date(2026, 7, 18, 0, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	if !locksAt.Equal(want) {
+		t.Errorf("locksAt = %v, want %v", locksAt, want)
+	}
+
+	settings.EditWindowHours = 0
+	locksAt, _ = attendanceLocksAt("2026-07-15", settings)
+	if !locksAt.Equal(want.AddDate(0, 0, -2)) {
+		t.Errorf("a zero window should lock at the end of the day, got %v", locksAt)
+	}
+
+	if _, err := attendanceLocksAt("15-07-2026", settings); err == nil {
+		t.Error("expected a malformed date to be refused")
+	}
+
+	// Late in the evening UTC is already the next day in India
+	now := time.Date(2026, 7, 15, 20, 0, 0, 0, time.UTC)
+	if got := attendanceToday(settings, now); got != "2026-07-16" {
+		t.Errorf("attendanceToday = %s, want 2026-07-16", got)
+	}
+}
+
+func TestFinishAttendance(t *testing.T) {
+	var breakdown models.AttendanceBreakdown
+	for _, status := range []string{"present", "present", "late", "absent", "leave", "present", ""} {
+		addAttendance(&breakdown, status)
+	}
+
+	counted := breakdown
+	finishAttendance(&counted, true)
+	if counted.Marked != 6 || counted.Unmarked != 1 || counted.Percentage != 66.67 {
+		t.Errorf("late as present: %+v", counted)
+	}
+
+	strict := breakdown
+	finishAttendance(&strict, false)
+	if strict.Percentage != 50 {
+		t.Errorf("late as absent: percentage = %v, want 50", strict.Percentage)
+	}
+
+	var empty models.AttendanceBreakdown
+	finishAttendance(&empty, true)
+	if empty.Percentage != 0 {
+		t.Errorf("no marked days: percentage = %v", empty.Percentage)
+	}
+}
+
+func TestMonthDaysAndMarks(t *testing.T) {
+	days := monthDays("2028-02")
+	if len(days) != 29 || days[0] != "2028-02-01" || days[28] != "2028-02-29" {
+		t.Errorf("monthDays(2028-02) = %d days, %v .. %v", len(days), days[0], days[len(days)-1])
+	}
+	if len(monthDays("2026-04")) != 30 {
+		t.Error("April should have 30 days")
+	}
+
+	for status, want := range map[string]string{"present": "P", "absent": "A", "late": "L", "leave": "LV", "": ""} {
+		if got := attendanceMark(status); got != want {
+			t.Errorf("attendanceMark(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestDateInSession(t *testing.T) {
+	session := &models.AcademicSession{
+		StartDate: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2027, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for date, want := range map[string]bool{
+		"2026-03-31": false,
+		"2026-04-01": true,
+		"2027-03-31": true,
+		"2027-04-01": false,
+	} {
+		if got := dateInSession(date, session); got != want {
+			t.Errorf("dateInSession(%s) = %v, want %v", date, got, want)
+		}
+	}
+	if !dateInSession("2030-01-01", &models.AcademicSession{}) {
+		t.Error("a session without dates should accept any date")
+	}
+}
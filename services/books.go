@@ -25,7 +25,7 @@ const BookCollection = "books"
 
 type BookService interface {
 	Create(ctx context.Context, companyCode string, req *requests.CreateBookRequest) (*models.Book, error)
-	GetAll(ctx context.Context, companyCode string) ([]*models.Book, error)
+	GetAll(ctx context.Context, companyCode string, sessionID string) ([]*models.Book, error)
 	GetByID(ctx context.Context, companyCode string, id string) (*models.Book, error)
 	GetByUUIDs(ctx context.Context, companyCode string, ids []string) ([]*models.Book, error)
 	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateBookRequest) (*models.Book, error)
@@ -52,15 +52,19 @@ func (s *bookService) Create(
 	req *requests.CreateBookRequest,
 ) (*models.Book, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(BookCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(BookCollection)
 
 	book := models.NewBook()
 	book.Bind(req)
 
-	_, err := collection.InsertOne(ctx, book)
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	book.SessionEntityID = sessionEntityID
+
+	_, err = collection.InsertOne(ctx, book)
 	if err != nil {
 		return nil, err
 	}
@@ -72,17 +76,22 @@ func (s *bookService) Create(
 // ================= GET ALL =================
 //
 
+// GetAll lists the books of the given session, or of the active session when sessionID is empty
 func (s *bookService) GetAll(
 	ctx context.Context,
 	companyCode string,
+	sessionID string,
 ) ([]*models.Book, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(BookCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(BookCollection)
+
+	session, err := resolveSession(ctx, database, sessionID)
+	if err != nil {
+		return nil, err
+	}
 
-	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	cursor, err := collection.Find(ctx, withSession(bson.M{"is_deleted": false}, session))
 	if err != nil {
 		return nil, err
 	}
@@ -171,10 +180,16 @@ func (s *bookService) Update(
 	req *requests.UpdateBookRequest,
 ) (*models.Book, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(BookCollection)
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, current.SessionEntityID); err != nil {
+		return nil, err
+	}
+	collection := database.Collection(BookCollection)
 
 	updateFields := bson.M{}
 
@@ -199,6 +214,13 @@ func (s *bookService) Update(
 	if req.FeesType != nil {
 		updateFields["fees_type"] = *req.FeesType
 	}
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			updateFields["due_date"] = nil
+		} else if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			updateFields["due_date"] = dueDate
+		}
+	}
 	if req.IsDeleted != nil {
 		updateFields["is_deleted"] = *req.IsDeleted
 	}
@@ -211,14 +233,9 @@ func (s *bookService) Update(
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
-	}
-
 	var updated models.Book
-	err := collection.
-		FindOneAndUpdate(ctx, filter, bson.M{"$set": updateFields}, opts).
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{"$set": updateFields}, opts).
 		Decode(&updated)
 
 	if err == mongo.ErrNoDocuments {
@@ -241,17 +258,18 @@ func (s *bookService) Delete(
 	id string,
 ) error {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(BookCollection)
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return err
+	}
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, current.SessionEntityID); err != nil {
+		return err
 	}
+	collection := database.Collection(BookCollection)
 
-	result, err := collection.UpdateOne(ctx, filter, bson.M{
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{
 		"$set": bson.M{
 			"is_deleted": true,
 			"updated_at": time.Now(),
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CalendarCollection = "calendar_entries"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type CalendarService interface {
+	Create(ctx context.Context, companyCode string, req *requests.CreateCalendarEntryRequest) (*models.CalendarEntry, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.CalendarEntry, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.CalendarEntry, error)
+	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateCalendarEntryRequest) (*models.CalendarEntry, error)
+	Delete(ctx context.Context, companyCode string, id string) error
+	GetOccurrences(ctx context.Context, companyCode string, from, to time.Time, boardEntityID string, entryType string) ([]models.CalendarOccurrence, error)
+	ImportICS(ctx context.Context, companyCode string, r io.Reader, defaultEntryType string) (int, error)
+	ExportICS(ctx context.Context, companyCode string, boardEntityID string) ([]byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type calendarService struct{}
+
+func NewCalendarService() CalendarService {
+	return &calendarService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+func (s *calendarService) Create(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateCalendarEntryRequest,
+) (*models.CalendarEntry, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	entry := models.NewCalendarEntry()
+	entry.Bind(req)
+
+	if entry.EndDate.Before(entry.StartDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	_, err := collection.InsertOne(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+func (s *calendarService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.CalendarEntry, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	opts := options.Find().SetSort(bson.M{"start_date": 1})
+	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.CalendarEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *calendarService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.CalendarEntry, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	filter := bson.M{"entity_id": id, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": oid, "is_deleted": false}
+	}
+
+	var entry models.CalendarEntry
+	err := collection.FindOne(ctx, filter).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("calendar entry not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *calendarService) Update(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.UpdateCalendarEntryRequest,
+) (*models.CalendarEntry, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updateFields := bson.M{}
+	startDate, endDate := current.StartDate, current.EndDate
+
+	if req.Title != nil {
+		updateFields["title"] = *req.Title
+	}
+	if req.Description != nil {
+		updateFields["description"] = *req.Description
+	}
+	if req.EntryType != nil {
+		updateFields["entry_type"] = *req.EntryType
+	}
+	if req.StartDate != nil {
+		startDate, _ = time.Parse("2006-01-02", *req.StartDate)
+		updateFields["start_date"] = startDate
+	}
+	if req.EndDate != nil {
+		endDate, _ = time.Parse("2006-01-02", *req.EndDate)
+		updateFields["end_date"] = endDate
+	}
+	if req.Recurrence != nil {
+		updateFields["recurrence"] = *req.Recurrence
+	}
+	if req.RecurrenceUntil != nil {
+		if *req.RecurrenceUntil == "" {
+			updateFields["recurrence_until"] = nil
+		} else {
+			until, _ := time.Parse("2006-01-02", *req.RecurrenceUntil)
+			updateFields["recurrence_until"] = until
+		}
+	}
+	if req.BoardEntityIDs != nil {
+		updateFields["board_entity_ids"] = *req.BoardEntityIDs
+	}
+	if req.Flag != nil {
+		updateFields["flag"] = *req.Flag
+	}
+	if req.IsDeleted != nil {
+		updateFields["is_deleted"] = *req.IsDeleted
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	// Validate the merged entry before anything is written
+	if endDate.Before(startDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.CalendarEntry
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("calendar entry not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+func (s *calendarService) Delete(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	filter := bson.M{"entity_id": id, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": oid, "is_deleted": false}
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("calendar entry not found")
+	}
+
+	return nil
+}
+
+//
+// ================= OCCURRENCES =================
+//
+
+// GetOccurrences expands every entry into dated occurrences overlapping [from, to].
+// A board filter keeps entries that apply to all boards or list that board.
+func (s *calendarService) GetOccurrences(
+	ctx context.Context,
+	companyCode string,
+	from, to time.Time,
+	boardEntityID string,
+	entryType string,
+) ([]models.CalendarOccurrence, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	filter := bson.M{
+		"is_deleted": false,
+		"start_date": bson.M{"$lte": to},
+		"$or": bson.A{
+			bson.M{"recurrence": bson.M{"$in": bson.A{"", "none", nil}}, "end_date": bson.M{"$gte": from}},
+			bson.M{"recurrence": bson.M{"$in": bson.A{"weekly", "monthly", "yearly"}}},
+		},
+	}
+	if entryType != "" {
+		filter["entry_type"] = entryType
+	}
+	if boardEntityID != "" {
+		filter["$and"] = bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"board_entity_ids": bson.M{"$size": 0}},
+				bson.M{"board_entity_ids": nil},
+				bson.M{"board_entity_ids": boardEntityID},
+			}},
+		}
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []models.CalendarEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	occurrences := make([]models.CalendarOccurrence, 0)
+	for _, entry := range entries {
+		occurrences = append(occurrences, expandCalendarEntry(entry, from, to)...)
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].StartDate.Before(occurrences[j].StartDate)
+	})
+
+	return occurrences, nil
+}
+
+// expandCalendarEntry lists the occurrences of one entry that overlap [from, to].
+// Monthly and yearly repeats skip months that do not have the original day (e.g. 31st, 29 Feb).
+func expandCalendarEntry(entry models.CalendarEntry, from, to time.Time) []models.CalendarOccurrence {
+	span := entry.EndDate.Sub(entry.StartDate)
+	if span < 0 {
+		span = 0
+	}
+
+	occurrence := func(start time.Time) models.CalendarOccurrence {
+		boards := entry.BoardEntityIDs
+		if boards == nil {
+			boards = []string{}
+		}
+		return models.CalendarOccurrence{
+			EntryEntityID:  entry.EntityID,
+			Title:          entry.Title,
+			Description:    entry.Description,
+			EntryType:      entry.EntryType,
+			StartDate:      start,
+			EndDate:        start.Add(span),
+			BoardEntityIDs: boards,
+			Flag:           entry.Flag,
+		}
+	}
+
+	var result []models.CalendarOccurrence
+
+	switch entry.Recurrence {
+	case "weekly", "monthly", "yearly":
+	default:
+		if !entry.StartDate.After(to) && !entry.EndDate.Before(from) {
+			result = append(result, occurrence(entry.StartDate))
+		}
+		return result
+	}
+
+	limit := to
+	if entry.RecurrenceUntil != nil && entry.RecurrenceUntil.Before(limit) {
+		limit = *entry.RecurrenceUntil
+	}
+
+	// Jump weekly series close to the window instead of walking from the first date
+	n := 0
+	if entry.Recurrence == "weekly" && from.After(entry.StartDate) {
+		n = int(from.Sub(entry.StartDate).Hours()/(24*7)) - 1
+		if n < 0 {
+			n = 0
+		}
+	}
+
+	for ; ; n++ {
+		var start time.Time
+		switch entry.Recurrence {
+		case "weekly":
+			start = entry.StartDate.AddDate(0, 0, 7*n)
+		case "monthly":
+			start = entry.StartDate.AddDate(0, n, 0)
+		case "yearly":
+			start = entry.StartDate.AddDate(n, 0, 0)
+		}
+
+		if start.After(limit) {
+			break
+		}
+		if entry.Recurrence != "weekly" && start.Day() != entry.StartDate.Day() {
+			continue
+		}
+		if start.Add(span).Before(from) {
+			continue
+		}
+		result = append(result, occurrence(start))
+	}
+
+	return result
+}
+
+//
+// ================= ICALENDAR IMPORT / EXPORT =================
+//
+
+// ImportICS creates entries from the VEVENTs of an .ics file. Events whose UID was
+// imported before are updated in place, so re-importing the same feed is safe.
+func (s *calendarService) ImportICS(
+	ctx context.Context,
+	companyCode string,
+	r io.Reader,
+	defaultEntryType string,
+) (int, error) {
+
+	entries, err := parseICS(r, defaultEntryType)
+	if err != nil {
+		return 0, err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(CalendarCollection)
+
+	count := 0
+	for _, entry := range entries {
+		if entry.UID != "" {
+			result, err := collection.UpdateOne(ctx, bson.M{"uid": entry.UID, "is_deleted": false}, bson.M{
+				"$set": bson.M{
+					"title":            entry.Title,
+					"description":      entry.Description,
+					"entry_type":       entry.EntryType,
+					"start_date":       entry.StartDate,
+					"end_date":         entry.EndDate,
+					"recurrence":       entry.Recurrence,
+					"recurrence_until": entry.RecurrenceUntil,
+					"board_entity_ids": entry.BoardEntityIDs,
+					"flag":             entry.Flag,
+					"updated_at":       time.Now(),
+				},
+			})
+			if err != nil {
+				return count, err
+			}
+			if result.MatchedCount > 0 {
+				count++
+				continue
+			}
+		}
+
+		if _, err := collection.InsertOne(ctx, entry); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// ExportICS renders the calendar (optionally for one board) as an iCalendar feed
+func (s *calendarService) ExportICS(
+	ctx context.Context,
+	companyCode string,
+	boardEntityID string,
+) ([]byte, error) {
+
+	entries, err := s.GetAll(ctx, companyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*models.CalendarEntry
+	for _, entry := range entries {
+		if boardEntityID == "" || appliesToBoard(entry.BoardEntityIDs, boardEntityID) {
+			selected = append(selected, entry)
+		}
+	}
+
+	return writeICS(selected), nil
+}
+
+func appliesToBoard(boardEntityIDs []string, boardEntityID string) bool {
+	if len(boardEntityIDs) == 0 {
+		return true
+	}
+	for _, id := range boardEntityIDs {
+		if id == boardEntityID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+// Minimal RFC 5545 support: VEVENTs with all-day or timed DTSTART/DTEND, SUMMARY,
+// DESCRIPTION, CATEGORIES, UID and an RRULE of FREQ=WEEKLY|MONTHLY|YEARLY[;UNTIL=].
+// Board applicability and flags travel in X-SCHOOL-BOARDS / X-SCHOOL-FLAG.
+
+// parseICS reads the events of an iCalendar stream into unsaved calendar entries
+func parseICS(r io.Reader, defaultEntryType string) ([]*models.CalendarEntry, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*models.CalendarEntry
+	var current *models.CalendarEntry
+	endExclusive := false
+
+	for _, line := range lines {
+		name, params, value := splitICSLine(line)
+
+		switch name {
+		case "BEGIN":
+			if value == "VEVENT" {
+				current = models.NewCalendarEntry()
+				current.EntryType = defaultEntryType
+				endExclusive = false
+			}
+			continue
+		case "END":
+			if value == "VEVENT" && current != nil {
+				if current.StartDate.IsZero() {
+					return nil, fmt.Errorf("event %q has no DTSTART", current.Title)
+				}
+				if current.EndDate.IsZero() {
+					current.EndDate = current.StartDate
+				} else if endExclusive && current.EndDate.After(current.StartDate) {
+					// All-day DTEND is the day after the last day
+					current.EndDate = current.EndDate.AddDate(0, 0, -1)
+				}
+				entries = append(entries, current)
+				current = nil
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		switch name {
+		case "SUMMARY":
+			current.Title = unescapeICSText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeICSText(value)
+		case "UID":
+			current.UID = value
+		case "DTSTART":
+			start, err := parseICSDate(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTSTART %q", value)
+			}
+			current.StartDate = start
+		case "DTEND":
+			end, err := parseICSDate(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTEND %q", value)
+			}
+			current.EndDate = end
+			endExclusive = len(value) == 8
+		case "RRULE":
+			for _, part := range strings.Split(value, ";") {
+				key, val, _ := strings.Cut(part, "=")
+				switch strings.ToUpper(key) {
+				case "FREQ":
+					switch strings.ToUpper(val) {
+					case "WEEKLY":
+						current.Recurrence = "weekly"
+					case "MONTHLY":
+						current.Recurrence = "monthly"
+					case "YEARLY":
+						current.Recurrence = "yearly"
+					default:
+						return nil, fmt.Errorf("unsupported RRULE frequency %q", val)
+					}
+				case "UNTIL":
+					until, err := parseICSDate(val, nil)
+					if err != nil {
+						return nil, fmt.Errorf("invalid RRULE UNTIL %q", val)
+					}
+					current.RecurrenceUntil = &until
+				}
+			}
+		case "CATEGORIES":
+			for _, category := range strings.Split(value, ",") {
+				category = strings.ToLower(strings.TrimSpace(category))
+				if category == "holiday" || category == "exam" || category == "event" {
+					current.EntryType = category
+					break
+				}
+			}
+		case "X-SCHOOL-BOARDS":
+			for _, id := range strings.Split(value, ",") {
+				if id = strings.TrimSpace(id); id != "" {
+					current.BoardEntityIDs = append(current.BoardEntityIDs, id)
+				}
+			}
+		case "X-SCHOOL-FLAG":
+			current.Flag = unescapeICSText(value)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, errors.New("no events found in calendar file")
+	}
+
+	return entries, nil
+}
+
+// writeICS renders entries as an iCalendar document with all-day events
+func writeICS(entries []*models.CalendarEntry) []byte {
+	var buf bytes.Buffer
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	writeLine := func(line string) {
+		buf.WriteString(foldICSLine(line))
+		buf.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//school-backend//calendar//EN")
+	writeLine("CALSCALE:GREGORIAN")
+
+	for _, entry := range entries {
+		uid := entry.UID
+		if uid == "" {
+			uid = entry.EntityID + "@school-backend"
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + uid)
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;VALUE=DATE:" + entry.StartDate.Format("20060102"))
+		writeLine("DTEND;VALUE=DATE:" + entry.EndDate.AddDate(0, 0, 1).Format("20060102"))
+		writeLine("SUMMARY:" + escapeICSText(entry.Title))
+		if entry.Description != "" {
+			writeLine("DESCRIPTION:" + escapeICSText(entry.Description))
+		}
+		if entry.EntryType != "" {
+			writeLine("CATEGORIES:" + strings.ToUpper(entry.EntryType))
+		}
+		switch entry.Recurrence {
+		case "weekly", "monthly", "yearly":
+			rule := "RRULE:FREQ=" + strings.ToUpper(entry.Recurrence)
+			if entry.RecurrenceUntil != nil {
+				rule += ";UNTIL=" + entry.RecurrenceUntil.Format("20060102")
+			}
+			writeLine(rule)
+		}
+		if len(entry.BoardEntityIDs) > 0 {
+			writeLine("X-SCHOOL-BOARDS:" + strings.Join(entry.BoardEntityIDs, ","))
+		}
+		if entry.Flag != "" {
+			writeLine("X-SCHOOL-FLAG:" + escapeICSText(entry.Flag))
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+//
+// ================= HELPERS =================
+//
+
+// unfoldICSLines joins continuation lines (starting with a space or tab) onto the previous line
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// splitICSLine splits "NAME;PARAM=X:value" into its name, parameters and value
+func splitICSLine(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = val
+	}
+
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// parseICSDate accepts DATE (20260815), UTC DATE-TIME (20260815T090000Z) and
+// floating or TZID DATE-TIME values; only the calendar day is kept.
+func parseICSDate(value string, params map[string]string) (time.Time, error) {
+	if len(value) == 8 {
+		return time.Parse("20060102", value)
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	var t time.Time
+	var err error
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else {
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func escapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+func unescapeICSText(value string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	return replacer.Replace(value)
+}
+
+// foldICSLine breaks lines longer than 75 octets without splitting UTF-8 sequences
+func foldICSLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var buf strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		width = limit - 1
+	}
+	buf.WriteString(line)
+
+	return buf.String()
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func calendarDate(value string) time.Time {
+	date, _ := time.Parse("2006-01-02", value)
+	return date
+}
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:diwali-2026",
+		"SUMMARY:Diwali\\, school closed",
+		"DESCRIPTION:Festival break over ",
+		" three days",
+		"DTSTART;VALUE=DATE:20261108",
+		"DTEND;VALUE=DATE:20261111",
+		"CATEGORIES:Holiday",
+		"X-SCHOOL-BOARDS:cbse, icse",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Assembly",
+		"DTSTART;TZID=Asia/Kolkata:20260907T083000",
+		"RRULE:FREQ=WEEKLY;UNTIL=20261231T000000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	entries, err := parseICS(strings.NewReader(ics), "event")
+	if err != nil {
+		t.Fatalf("parseICS: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	diwali := entries[0]
+	if diwali.Title != "Diwali, school closed" || diwali.Description != "Festival break over three days" {
+		t.Errorf("unexpected text %q / %q", diwali.Title, diwali.Description)
+	}
+	if diwali.EntryType != "holiday" || diwali.UID != "diwali-2026" || len(diwali.BoardEntityIDs) != 2 {
+		t.Errorf("unexpected entry %+v", diwali)
+	}
+	// An all-day DTEND is exclusive, so the last day is the day before
+	if !diwali.StartDate.Equal(calendarDate("2026-11-08")) || !diwali.EndDate.Equal(calendarDate("2026-11-10")) {
+		t.Errorf("dates %s..%s, want 2026-11-08..2026-11-10", diwali.StartDate, diwali.EndDate)
+	}
+
+	assembly := entries[1]
+	if assembly.EntryType != "event" || assembly.Recurrence != "weekly" || assembly.RecurrenceUntil == nil {
+		t.Errorf("unexpected recurring entry %+v", assembly)
+	}
+	if !assembly.StartDate.Equal(calendarDate("2026-09-07")) || !assembly.EndDate.Equal(assembly.StartDate) {
+		t.Errorf("timed event dates %s..%s, want 2026-09-07", assembly.StartDate, assembly.EndDate)
+	}
+
+	if _, err := parseICS(strings.NewReader("BEGIN:VEVENT\r\nSUMMARY:No start\r\nEND:VEVENT"), "event"); err == nil {
+		t.Error("expected an event without DTSTART to be refused")
+	}
+	if _, err := parseICS(strings.NewReader("BEGIN:VEVENT\r\nDTSTART:20260101\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT"), "event"); err == nil {
+		t.Error("expected an unsupported frequency to be refused")
+	}
+	if _, err := parseICS(strings.NewReader("BEGIN:VCALENDAR\r\nEND:VCALENDAR"), "event"); err == nil {
+		t.Error("expected a calendar without events to be refused")
+	}
+}
+
+func TestExpandCalendarEntry(t *testing.T) {
+	starts := func(occurrences []models.CalendarOccurrence) string {
+		var dates []string
+		for _, occurrence := range occurrences {
+			dates = append(dates, occurrence.StartDate.Format("2006-01-02"))
+		}
+		return strings.Join(dates, ",")
+	}
+
+	// A one-off entry overlapping the window is returned once
+	once := models.CalendarEntry{StartDate: calendarDate("2026-06-30"), EndDate: calendarDate("2026-07-02")}
+	if got := starts(expandCalendarEntry(once, calendarDate("2026-07-01"), calendarDate("2026-07-31"))); got != "2026-06-30" {
+		t.Errorf("one-off = %s", got)
+	}
+
+	// Weekly entries keep their span and stop at recurrence_until
+	until := calendarDate("2026-07-20")
+	weekly := models.CalendarEntry{
+		StartDate: calendarDate("2026-06-01"), EndDate: calendarDate("2026-06-02"),
+		Recurrence: "weekly", RecurrenceUntil: &until,
+	}
+	occurrences := expandCalendarEntry(weekly, calendarDate("2026-07-01"), calendarDate("2026-07-31"))
+	if got := starts(occurrences); got != "2026-07-06,2026-07-13,2026-07-20" {
+		t.Errorf("weekly = %s", got)
+	}
+	if !occurrences[0].EndDate.Equal(calendarDate("2026-07-07")) {
+		t.Errorf("weekly occurrence ends %s, want 2026-07-07", occurrences[0].EndDate)
+	}
+
+	// Monthly entries on the 31st skip shorter months
+	monthly := models.CalendarEntry{StartDate: calendarDate("2026-01-31"), EndDate: calendarDate("2026-01-31"), Recurrence: "monthly"}
+	if got := starts(expandCalendarEntry(monthly, calendarDate("2026-01-01"), calendarDate("2026-05-31"))); got != "2026-01-31,2026-03-31,2026-05-31" {
+		t.Errorf("monthly = %s", got)
+	}
+
+	// Yearly entries on 29 February only fall in leap years
+	yearly := models.CalendarEntry{StartDate: calendarDate("2024-02-29"), EndDate: calendarDate("2024-02-29"), Recurrence: "yearly"}
+	if got := starts(expandCalendarEntry(yearly, calendarDate("2025-01-01"), calendarDate("2028-12-31"))); got != "2028-02-29" {
+		t.Errorf("yearly = %s", got)
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type CashierActivityService interface {
+	GetCashierActivity(ctx context.Context, companyCode string, req *requests.CashierActivityRequest) (*models.CashierActivityResponse, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type cashierActivityService struct{}
+
+func NewCashierActivityService() CashierActivityService {
+	return &cashierActivityService{}
+}
+
+//
+// ================= GET CASHIER ACTIVITY =================
+//
+
+func (s *cashierActivityService) GetCashierActivity(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CashierActivityRequest,
+) (*models.CashierActivityResponse, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	paymentCollection := database.Collection("payment_scanners")
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[string]*models.CashierActivityRow)
+	checkouts := make(map[string]map[string]bool)
+
+	row := func(actor *models.PaymentActor) *models.CashierActivityRow {
+		if actor == nil {
+			actor = &models.PaymentActor{Name: "Unattributed"}
+		}
+		existing, exists := rows[actor.UserID]
+		if !exists {
+			existing = &models.CashierActivityRow{
+				UserID:        actor.UserID,
+				Name:          actor.Name,
+				Email:         actor.Email,
+				Role:          actor.Role,
+				ByPaymentMode: map[string]float64{},
+			}
+			rows[actor.UserID] = existing
+			checkouts[actor.UserID] = make(map[string]bool)
+		}
+		return existing
+	}
+
+	// Collections: every item taken in the range, including ones reversed later
+	collectionFilter := withSession(bson.M{
+		"is_deleted": false,
+		"status":     bson.M{"$in": bson.A{"paid", "void", "refunded"}},
+	}, session)
+	paymentDateFilter(collectionFilter, req.StartDate, req.EndDate)
+	if req.UserID != nil && *req.UserID != "" {
+		collectionFilter["collected_by.user_id"] = *req.UserID
+	}
+
+	cursor, err := paymentCollection.Find(ctx, collectionFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collections []models.PaymentScanner
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range collections {
+		cashier := row(payment.CollectedBy)
+		cashier.Items++
+		cashier.Collected += payment.Amount
+
+		mode := strings.ToLower(strings.TrimSpace(payment.PaymentMethod))
+		if mode == "" {
+			mode = "unknown"
+		}
+		cashier.ByPaymentMode[mode] += payment.Amount
+
+		checkoutID := payment.PaymentID
+		if checkoutID == "" {
+			checkoutID = payment.EntityID
+		}
+		checkouts[cashier.UserID][checkoutID] = true
+		cashier.Track(payment.PaymentDate)
+	}
+
+	// Reversals: voids and refunds issued in the range, credited to the issuing user
+	reversalFilter := withSession(bson.M{
+		"is_deleted": false,
+		"status":     bson.M{"$in": bson.A{"void", "refunded"}},
+	}, session)
+	start, end := parseDateRange(req.StartDate, req.EndDate)
+	reversedAt := bson.M{"$ne": nil}
+	if !start.IsZero() {
+		reversedAt["$gte"] = start
+	}
+	if !end.IsZero() {
+		reversedAt["$lt"] = end
+	}
+	reversalFilter["reversed_at"] = reversedAt
+	if req.UserID != nil && *req.UserID != "" {
+		reversalFilter["reversed_by.user_id"] = *req.UserID
+	}
+
+	reversalCursor, err := paymentCollection.Find(ctx, reversalFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer reversalCursor.Close(ctx)
+
+	var reversals []models.PaymentScanner
+	if err := reversalCursor.All(ctx, &reversals); err != nil {
+		return nil, err
+	}
+
+	for _, payment := range reversals {
+		cashier := row(payment.ReversedBy)
+		if payment.Status == "void" {
+			cashier.Voids++
+			cashier.VoidedAmount += payment.Amount
+		} else {
+			cashier.Refunds++
+			cashier.RefundedAmount += payment.Amount
+		}
+		cashier.Track(*payment.ReversedAt)
+	}
+
+	response := &models.CashierActivityResponse{Cashiers: []models.CashierActivityRow{}}
+	for userID, cashier := range rows {
+		cashier.Checkouts = len(checkouts[userID])
+		response.Cashiers = append(response.Cashiers, *cashier)
+		response.TotalCollected += cashier.Collected
+		response.TotalRefunded += cashier.RefundedAmount
+		response.TotalVoided += cashier.VoidedAmount
+	}
+
+	sort.Slice(response.Cashiers, func(i, j int) bool {
+		if response.Cashiers[i].Collected != response.Cashiers[j].Collected {
+			return response.Cashiers[i].Collected > response.Cashiers[j].Collected
+		}
+		return response.Cashiers[i].Name < response.Cashiers[j].Name
+	})
+
+	return response, nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type CollectionSummaryService interface {
+	GetCollectionSummary(ctx context.Context, companyCode string, req *requests.CollectionSummaryRequest) (*models.CollectionSummaryResponse, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type collectionSummaryService struct{}
+
+func NewCollectionSummaryService() CollectionSummaryService {
+	return &collectionSummaryService{}
+}
+
+//
+// ================= GET COLLECTION SUMMARY =================
+//
+
+func (s *collectionSummaryService) GetCollectionSummary(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CollectionSummaryRequest,
+) (*models.CollectionSummaryResponse, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Students in scope
+	studentFilter := bson.M{"is_deleted": false}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		studentFilter["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		studentFilter["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		studentFilter["div"] = divisionMatch(*req.Div)
+	}
+
+	students, err := findSessionStudents(ctx, database, session, studentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dues come from the shared ledger, carried-forward dues included, so the summary
+	// agrees with the unpaid list and the receipt
+	ledger, itemsByID, err := loadDuesLedger(ctx, database, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payments taken in the session, of which only those inside the requested window
+	// and mode count as collected
+	paymentCursor, err := database.Collection("payment_scanners").Find(ctx, withSession(bson.M{
+		"is_deleted": false,
+		"status":     "paid",
+	}, session))
+	if err != nil {
+		return nil, err
+	}
+	defer paymentCursor.Close(ctx)
+
+	var payments []models.PaymentScanner
+	if err := paymentCursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	start, end := parseDateRange(req.StartDate, req.EndDate)
+	mode := ""
+	if req.PaymentMode != nil && *req.PaymentMode != "all" {
+		mode = strings.ToLower(*req.PaymentMode)
+	}
+
+	studentCollected := make(map[string][]models.PaymentScanner)
+	for _, payment := range payments {
+		if !start.IsZero() && payment.PaymentDate.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !payment.PaymentDate.Before(end) {
+			continue
+		}
+		if mode != "" && strings.ToLower(payment.PaymentMethod) != mode {
+			continue
+		}
+		studentCollected[payment.StudentEntityID] = append(studentCollected[payment.StudentEntityID], payment)
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*models.CollectionSummaryGroup)
+	totals := models.CollectionSummaryGroup{}
+
+	for _, student := range students {
+		key := student.BoardEntityID + "|" + student.ClassEntityID + "|" + student.Div
+		group, exists := groups[key]
+		if !exists {
+			group = &models.CollectionSummaryGroup{
+				BoardEntityID: student.BoardEntityID,
+				BoardName:     boardNames[student.BoardEntityID],
+				ClassEntityID: student.ClassEntityID,
+				ClassName:     classNames[student.ClassEntityID],
+				Div:           student.Div,
+			}
+			groups[key] = group
+		}
+
+		// Students who left still show what was collected from them, but owe nothing
+		if student.IsActive() {
+			group.StudentCount++
+
+			// Outstanding is split by compulsory and optional, so optional items are included
+			dues := ledger.Dues(student, duePolicy{Optional: OptionalIncluded})
+			for _, item := range dues.Pending {
+				group.Outstanding.Add(item.ItemType, item.IsCompulsory, item.Amount)
+			}
+			if dues.Settled() {
+				group.FullyPaidCount++
+			}
+		}
+
+		// Collected
+		for _, payment := range studentCollected[student.EntityID] {
+			item, exists := itemsByID[payment.ExamEntityID]
+			if !exists {
+				// A carried-forward due of an earlier session, paid in this one
+				item, _ = ledger.CarriedItem(student.EntityID, payment.ExamEntityID)
+			}
+			group.Collected.Add(item.ItemType, item.IsCompulsory, payment.Amount)
+		}
+	}
+
+	result := make([]models.CollectionSummaryGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+
+		totals.StudentCount += group.StudentCount
+		totals.FullyPaidCount += group.FullyPaidCount
+		addSplit(&totals.Collected, group.Collected)
+		addSplit(&totals.Outstanding, group.Outstanding)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].BoardName != result[j].BoardName {
+			return result[i].BoardName < result[j].BoardName
+		}
+		if result[i].ClassName != result[j].ClassName {
+			return result[i].ClassName < result[j].ClassName
+		}
+		return result[i].Div < result[j].Div
+	})
+
+	return &models.CollectionSummaryResponse{
+		Groups: result,
+		Totals: totals,
+	}, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// loadBoardAndClassNames maps board and class entity IDs to their display names
+func loadBoardAndClassNames(ctx context.Context, database *mongo.Database) (map[string]string, map[string]string, error) {
+	boardCursor, err := database.Collection(BoardCollection).Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer boardCursor.Close(ctx)
+
+	var boards []models.Board
+	if err := boardCursor.All(ctx, &boards); err != nil {
+		return nil, nil, err
+	}
+
+	boardNames := make(map[string]string)
+	for _, board := range boards {
+		boardNames[board.EntityID] = board.BoardName
+	}
+
+	classCursor, err := database.Collection(ClassCollection).Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer classCursor.Close(ctx)
+
+	var classes []models.Class
+	if err := classCursor.All(ctx, &classes); err != nil {
+		return nil, nil, err
+	}
+
+	classNames := make(map[string]string)
+	for _, class := range classes {
+		classNames[class.EntityID] = class.ClassName
+	}
+
+	return boardNames, classNames, nil
+}
+
+// parseDateRange turns optional YYYY-MM-DD bounds into [start, end) times.
+// The end date is inclusive, so the returned end is the following midnight.
+func parseDateRange(startDate, endDate *string) (time.Time, time.Time) {
+	var start, end time.Time
+	if startDate != nil && *startDate != "" {
+		start, _ = time.Parse("2006-01-02", *startDate)
+	}
+	if endDate != nil && *endDate != "" {
+		end, _ = time.Parse("2006-01-02", *endDate)
+		if !end.IsZero() {
+			end = end.Add(24 * time.Hour)
+		}
+	}
+	return start, end
+}
+
+func addSplit(dst *models.AmountSplit, src models.AmountSplit) {
+	dst.ExamCompulsory += src.ExamCompulsory
+	dst.ExamOptional += src.ExamOptional
+	dst.BookCompulsory += src.BookCompulsory
+	dst.BookOptional += src.BookOptional
+	dst.Total += src.Total
+}
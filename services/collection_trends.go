@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxTrendBuckets guards against day-level series over many years
+const maxTrendBuckets = 1000
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type CollectionTrendsService interface {
+	GetCollectionTrends(ctx context.Context, companyCode string, req *requests.CollectionTrendsRequest) (*models.CollectionTrendsResponse, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type collectionTrendsService struct{}
+
+func NewCollectionTrendsService() CollectionTrendsService {
+	return &collectionTrendsService{}
+}
+
+//
+// ================= GET COLLECTION TRENDS =================
+//
+
+func (s *collectionTrendsService) GetCollectionTrends(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CollectionTrendsRequest,
+) (*models.CollectionTrendsResponse, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Range defaults to the academic session, or the current financial year (April to
+	// March) for companies without sessions
+	now := time.Now().In(loc)
+	fyStartYear := now.Year()
+	if now.Month() < time.April {
+		fyStartYear--
+	}
+	start := time.Date(fyStartYear, time.April, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+	if session != nil {
+		start, end = sessionRange(session, loc)
+	}
+
+	customRange := false
+	if req.StartDate != nil && *req.StartDate != "" {
+		start, _ = time.ParseInLocation("2006-01-02", *req.StartDate, loc)
+		customRange = true
+	}
+	if req.EndDate != nil && *req.EndDate != "" {
+		end, _ = time.ParseInLocation("2006-01-02", *req.EndDate, loc)
+		end = end.AddDate(0, 0, 1)
+		customRange = true
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
+
+	buckets := trendBucketStarts(start, end, req.Interval)
+	if len(buckets) > maxTrendBuckets {
+		return nil, fmt.Errorf("range too large for interval %s, use a wider interval", req.Interval)
+	}
+
+	series, err := s.aggregateTrend(ctx, database, start, end, buckets, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.CollectionTrendsResponse{
+		Interval:  req.Interval,
+		Timezone:  req.Timezone,
+		StartDate: start,
+		EndDate:   end.AddDate(0, 0, -1),
+		Series:    series,
+	}
+	for _, point := range series {
+		response.Totals.Current += point.Total
+	}
+
+	if req.ComparePreviousYear {
+		// A whole session compares with the whole previous session, whose dates need not
+		// line up with this one's a year earlier. Other ranges shift back a year.
+		var previousRange *models.AcademicSession
+		if session != nil && !customRange {
+			previousRange, err = previousSession(ctx, database, session)
+			if err != nil {
+				return nil, err
+			}
+		}
+		prevStart, prevEnd := start.AddDate(-1, 0, 0), end.AddDate(-1, 0, 0)
+		if previousRange != nil {
+			prevStart, prevEnd = sessionRange(previousRange, loc)
+		}
+		prevLast := prevEnd.AddDate(0, 0, -1)
+		response.ComparisonStartDate = &prevStart
+		response.ComparisonEndDate = &prevLast
+
+		comparison, err := s.aggregateTrend(ctx, database, prevStart, prevEnd, trendBucketStarts(prevStart, prevEnd, req.Interval), req)
+		if err != nil {
+			return nil, err
+		}
+		response.Comparison = comparison
+
+		previous := 0.0
+		for _, point := range comparison {
+			previous += point.Total
+		}
+		response.Totals.Previous = &previous
+		if previous > 0 {
+			change := (response.Totals.Current - previous) / previous * 100
+			response.Totals.ChangePercent = &change
+		}
+	}
+
+	return response, nil
+}
+
+// aggregateTrend buckets settled payments by date, payment mode and item type in Mongo
+// and lays the result over the full list of bucket starts so empty periods show as zero.
+func (s *collectionTrendsService) aggregateTrend(
+	ctx context.Context,
+	database *mongo.Database,
+	start, end time.Time,
+	buckets []time.Time,
+	req *requests.CollectionTrendsRequest,
+) ([]models.TrendPoint, error) {
+
+	match := bson.M{
+		"is_deleted":   false,
+		"status":       "paid",
+		"payment_date": bson.M{"$gte": start.UTC(), "$lt": end.UTC()},
+	}
+	paymentModeFilter(match, req.PaymentMode)
+
+	dateTrunc := bson.M{
+		"date":     "$payment_date",
+		"unit":     req.Interval,
+		"timezone": req.Timezone,
+	}
+	if req.Interval == "week" {
+		dateTrunc["startOfWeek"] = "monday"
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{
+			"$lookup": bson.M{
+				"from":         ExamCollection,
+				"localField":   "exam_entity_id",
+				"foreignField": "entity_id",
+				"as":           "exam",
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"bucket": bson.M{"$dateTrunc": dateTrunc},
+					"mode":   bson.M{"$toLower": "$payment_method"},
+					"item_type": bson.M{
+						"$cond": bson.A{
+							bson.M{"$gt": bson.A{bson.M{"$size": "$exam"}, 0}},
+							"exam",
+							"book",
+						},
+					},
+				},
+				"amount": bson.M{"$sum": "$amount"},
+				"count":  bson.M{"$sum": 1},
+			},
+		},
+		{"$sort": bson.M{"_id.bucket": 1}},
+	}
+
+	cursor, err := database.Collection("payment_scanners").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		ID struct {
+			Bucket   time.Time `bson:"bucket"`
+			Mode     string    `bson:"mode"`
+			ItemType string    `bson:"item_type"`
+		} `bson:"_id"`
+		Amount float64 `bson:"amount"`
+		Count  int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	totals := make([]trendRow, 0, len(rows))
+	for _, row := range rows {
+		totals = append(totals, trendRow{
+			Bucket:   row.ID.Bucket,
+			Mode:     row.ID.Mode,
+			ItemType: row.ID.ItemType,
+			Amount:   row.Amount,
+			Count:    row.Count,
+		})
+	}
+	return layTrendRows(buckets, totals), nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// trendRow is one bucket, payment mode and item type total of the trend aggregation
+type trendRow struct {
+	Bucket   time.Time
+	Mode     string
+	ItemType string
+	Amount   float64
+	Count    int
+}
+
+// layTrendRows lays the aggregated totals over the full list of bucket starts, so empty
+// periods show as zero and every point splits into exam and book collections
+func layTrendRows(buckets []time.Time, rows []trendRow) []models.TrendPoint {
+	points := make([]models.TrendPoint, len(buckets))
+	index := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		points[i] = models.TrendPoint{
+			BucketStart:   bucket,
+			ByPaymentMode: map[string]float64{},
+			ByItemType:    map[string]float64{"exam": 0, "book": 0},
+		}
+		index[bucket.Unix()] = i
+	}
+
+	for _, row := range rows {
+		i, exists := index[row.Bucket.Unix()]
+		if !exists {
+			continue
+		}
+		mode := strings.TrimSpace(row.Mode)
+		if mode == "" {
+			mode = "unknown"
+		}
+		points[i].Total += row.Amount
+		points[i].Payments += row.Count
+		points[i].ByPaymentMode[mode] += row.Amount
+		points[i].ByItemType[row.ItemType] += row.Amount
+	}
+
+	return points
+}
+
+// sessionRange returns the session's dates as the half-open range [start, end) of local days
+func sessionRange(session *models.AcademicSession, loc *time.Location) (time.Time, time.Time) {
+	start := time.Date(session.StartDate.Year(), session.StartDate.Month(), session.StartDate.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(session.EndDate.Year(), session.EndDate.Month(), session.EndDate.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	return start, end
+}
+
+// trendBucketStarts lists the start of every day, week (Monday) or month bucket overlapping [start, end)
+func trendBucketStarts(start, end time.Time, interval string) []time.Time {
+	loc := start.Location()
+	var current time.Time
+
+	switch interval {
+	case "day":
+		current = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	case "week":
+		current = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		offset := (int(current.Weekday()) + 6) % 7 // days since Monday
+		current = current.AddDate(0, 0, -offset)
+	default:
+		current = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, loc)
+	}
+
+	var buckets []time.Time
+	for current.Before(end) {
+		buckets = append(buckets, current)
+		if len(buckets) > maxTrendBuckets {
+			break
+		}
+		switch interval {
+		case "day":
+			current = current.AddDate(0, 0, 1)
+		case "week":
+			current = current.AddDate(0, 0, 7)
+		default:
+			current = current.AddDate(0, 1, 0)
+		}
+	}
+
+	return buckets
+}
+
+// paymentModeFilter adds a case-insensitive payment_method match to a payment filter
+func paymentModeFilter(filter bson.M, paymentMode *string) {
+	if paymentMode == nil || *paymentMode == "" || *paymentMode == "all" {
+		return
+	}
+	filter["payment_method"] = bson.M{
+		"$regex":   "^" + regexp.QuoteMeta(*paymentMode) + "$",
+		"$options": "i",
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestTrendBucketStarts(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, loc) }
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		interval   string
+		want       []time.Time
+	}{
+		{
+			name:     "days cover the range end-exclusive",
+			start:    day(2025, time.March, 30),
+			end:      day(2025, time.April, 2),
+			interval: "day",
+			want:     []time.Time{day(2025, time.March, 30), day(2025, time.March, 31), day(2025, time.April, 1)},
+		},
+		{
+			name:     "weeks start on the Monday before the range",
+			start:    day(2025, time.April, 3), // Thursday
+			end:      day(2025, time.April, 15),
+			interval: "week",
+			want:     []time.Time{day(2025, time.March, 31), day(2025, time.April, 7), day(2025, time.April, 14)},
+		},
+		{
+			name:     "months start on the first of the month",
+			start:    day(2025, time.January, 15),
+			end:      day(2025, time.April, 1),
+			interval: "month",
+			want:     []time.Time{day(2025, time.January, 1), day(2025, time.February, 1), day(2025, time.March, 1)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := trendBucketStarts(tc.start, tc.end, tc.interval)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d buckets %v, want %v", len(got), got, tc.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tc.want[i]) {
+					t.Errorf("bucket %d = %v, want %v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLayTrendRows(t *testing.T) {
+	april := time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)
+	may := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	points := layTrendRows([]time.Time{april, may, june}, []trendRow{
+		{Bucket: april, Mode: "upi", ItemType: "exam", Amount: 500, Count: 1},
+		{Bucket: april, Mode: "cash", ItemType: "book", Amount: 300, Count: 2},
+		{Bucket: april, Mode: "upi", ItemType: "book", Amount: 200, Count: 1},
+		{Bucket: june, Mode: " ", ItemType: "exam", Amount: 400, Count: 1},
+		// Outside the requested buckets
+		{Bucket: june.AddDate(0, 1, 0), Mode: "cash", ItemType: "exam", Amount: 900, Count: 1},
+	})
+
+	if len(points) != 3 {
+		t.Fatalf("got %d points, want 3", len(points))
+	}
+
+	first := points[0]
+	if first.Total != 1000 || first.Payments != 4 {
+		t.Errorf("april total %v over %d payments, want 1000 over 4", first.Total, first.Payments)
+	}
+	if first.ByItemType["exam"] != 500 || first.ByItemType["book"] != 500 {
+		t.Errorf("april split %v, want 500 exam and 500 book", first.ByItemType)
+	}
+	if first.ByPaymentMode["upi"] != 700 || first.ByPaymentMode["cash"] != 300 {
+		t.Errorf("april modes %v, want 700 upi and 300 cash", first.ByPaymentMode)
+	}
+
+	empty := points[1]
+	if empty.Total != 0 || empty.ByItemType["exam"] != 0 || empty.ByItemType["book"] != 0 {
+		t.Errorf("may should be an empty exam and book bucket, got %+v", empty)
+	}
+	if _, ok := empty.ByItemType["book"]; !ok {
+		t.Error("empty buckets still report the book split")
+	}
+
+	last := points[2]
+	if last.Total != 400 || last.ByPaymentMode["unknown"] != 400 || last.ByItemType["exam"] != 400 {
+		t.Errorf("june %+v, want 400 exam under an unknown mode", last)
+	}
+}
+
+func TestSessionRange(t *testing.T) {
+	session := &models.AcademicSession{
+		StartDate: time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.April, 30, 0, 0, 0, 0, time.UTC),
+	}
+
+	start, end := sessionRange(session, time.UTC)
+	if !start.Equal(session.StartDate) {
+		t.Errorf("start = %v, want %v", start, session.StartDate)
+	}
+	if want := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("end = %v, want the day after the session's last day %v", end, want)
+	}
+}
@@ -40,11 +40,6 @@ func (s *dailyReportService) GetDailyReports(
 		Database(fmt.Sprintf("company_%s", companyCode)).
 		Collection("payment_scanners")
 
-	// Get student collection for student details
-	studentCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("students")
-
 	// Get exam collection for exam details
 	examCollection := db.GetClient().
 		Database(fmt.Sprintf("company_%s", companyCode)).
@@ -55,8 +50,14 @@ func (s *dailyReportService) GetDailyReports(
 		Database(fmt.Sprintf("company_%s", companyCode)).
 		Collection("books")
 
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build filter
-	filter := bson.M{"is_deleted": false}
+	filter := withSession(bson.M{"is_deleted": false}, session)
 
 	// Date range filter
 	if req.StartDate != nil && req.EndDate != nil {
@@ -99,14 +100,12 @@ func (s *dailyReportService) GetDailyReports(
 	}
 
 	if len(studentIDs) > 0 {
-		studentCursor, err := studentCollection.Find(ctx, bson.M{
-			"entity_id":  bson.M{"$in": studentIDs},
-			"is_deleted": false,
+		// Students carry the class they were enrolled in during the session. Deleted and
+		// departed students are included so every payment of the day keeps its student.
+		students, err := findSessionStudents(ctx, database, session, bson.M{
+			"entity_id": bson.M{"$in": studentIDs},
 		})
 		if err == nil {
-			defer studentCursor.Close(ctx)
-			var students []models.Student
-			studentCursor.All(ctx, &students)
 			for _, student := range students {
 				studentMap[student.EntityID] = student
 			}
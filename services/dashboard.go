@@ -8,11 +8,17 @@ import (
 	"shared/infra/db/mdb"
 
 	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// maxDashboardUpcoming caps the mixed list of upcoming holidays, exams and events
+const maxDashboardUpcoming = 10
+
 type DashboardService interface {
-	GetDashboardStats(ctx context.Context, companyCode string) (*models.DashboardStats, error)
+	GetDashboardStats(ctx context.Context, companyCode string, req *requests.DashboardStatsRequest) (*models.DashboardStats, error)
 }
 
 type dashboardService struct{}
@@ -21,117 +27,94 @@ func NewDashboardService() DashboardService {
 	return &dashboardService{}
 }
 
-func (s *dashboardService) GetDashboardStats(ctx context.Context, companyCode string) (*models.DashboardStats, error) {
+func (s *dashboardService) GetDashboardStats(ctx context.Context, companyCode string, req *requests.DashboardStatsRequest) (*models.DashboardStats, error) {
 	db := mdb.GetMongo()
 	dbName := fmt.Sprintf("company_%s", companyCode)
+	database := db.GetClient().Database(dbName)
 
-	// Get payment scanner collection for collection stats
-	paymentCollection := db.GetClient().Database(dbName).Collection("payment_scanners")
-
-	// Calculate collection stats
-	pipeline := []bson.M{
-		{
-			"$group": bson.M{
-				"_id":       nil,
-				"totalPaid": bson.M{"$sum": "$amount"},
-				"cashAmount": bson.M{
-					"$sum": bson.M{
-						"$cond": bson.A{
-							bson.M{"$eq": bson.A{"$payment_method", "cash"}},
-							"$amount",
-							0,
-						},
-					},
-				},
-				"upiAmount": bson.M{
-					"$sum": bson.M{
-						"$cond": bson.A{
-							bson.M{"$eq": bson.A{"$payment_method", "upi"}},
-							"$amount",
-							0,
-						},
-					},
-				},
-			},
-		},
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return nil, err
 	}
 
-	cursor, err := paymentCollection.Aggregate(ctx, pipeline)
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var collectionResults []struct {
-		TotalPaid  float64 `bson:"totalPaid"`
-		CashAmount float64 `bson:"cashAmount"`
-		UPIAmount  float64 `bson:"upiAmount"`
+	// 1. Fetch the students in scope
+	studentFilter := bson.M{"is_deleted": false}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		studentFilter["board_entity_id"] = *req.BoardEntityID
 	}
-
-	if err := cursor.All(ctx, &collectionResults); err != nil {
-		return nil, err
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		studentFilter["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		studentFilter["div"] = divisionMatch(*req.Div)
 	}
+	scoped := len(studentFilter) > 1
 
-	collectionStats := models.CollectionStats{
-		TotalPaidAmount: 0,
-		CashAmount:      0,
-		UPIAmount:       0,
+	students, err := findSessionStudents(ctx, database, session, studentFilter)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(collectionResults) > 0 {
-		collectionStats.TotalPaidAmount = collectionResults[0].TotalPaid
-		collectionStats.CashAmount = collectionResults[0].CashAmount
-		collectionStats.UPIAmount = collectionResults[0].UPIAmount
+	// Only settled, non-deleted payments of the session count as collected
+	paymentMatch := withSession(bson.M{"is_deleted": false, "status": "paid"}, session)
+	if scoped {
+		studentIDs := make([]string, 0, len(students))
+		for _, student := range students {
+			studentIDs = append(studentIDs, student.EntityID)
+		}
+		paymentMatch["student_entity_id"] = bson.M{"$in": studentIDs}
 	}
 
-	// Get student stats
-	studentCollection := db.GetClient().Database(dbName).Collection("students")
-	examCollection := db.GetClient().Database(dbName).Collection("exams")
-	bookCollection := db.GetClient().Database(dbName).Collection("books")
-	paymentScannerCollection := db.GetClient().Database(dbName).Collection("payment_scanners")
+	// 2. Collection stats for the requested range, today and this month
+	paymentCollection := database.Collection("payment_scanners")
 
-	// 1. Fetch all students
-	cursorStudents, err := studentCollection.Find(ctx, bson.M{"is_deleted": false})
+	rangeMatch := copyFilter(paymentMatch)
+	paymentDateFilter(rangeMatch, req.StartDate, req.EndDate)
+	overall, err := sumCollection(ctx, paymentCollection, rangeMatch)
 	if err != nil {
 		return nil, err
 	}
-	defer cursorStudents.Close(ctx)
-	var students []models.Student
-	if err := cursorStudents.All(ctx, &students); err != nil {
-		return nil, err
-	}
 
-	// 2. Fetch all exams and books to build required items map per class
-	cursorExams, err := examCollection.Find(ctx, bson.M{"is_deleted": false})
+	now := time.Now().In(loc)
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+
+	todayMatch := copyFilter(paymentMatch)
+	todayMatch["payment_date"] = bson.M{"$gte": todayStart.UTC(), "$lt": todayStart.AddDate(0, 0, 1).UTC()}
+	today, err := sumCollection(ctx, paymentCollection, todayMatch)
 	if err != nil {
 		return nil, err
 	}
-	defer cursorExams.Close(ctx)
-	var exams []models.Exam
-	if err := cursorExams.All(ctx, &exams); err != nil {
-		return nil, err
-	}
 
-	cursorBooks, err := bookCollection.Find(ctx, bson.M{"is_deleted": false})
+	monthMatch := copyFilter(paymentMatch)
+	monthMatch["payment_date"] = bson.M{"$gte": monthStart.UTC(), "$lt": monthStart.AddDate(0, 1, 0).UTC()}
+	thisMonth, err := sumCollection(ctx, paymentCollection, monthMatch)
 	if err != nil {
 		return nil, err
 	}
-	defer cursorBooks.Close(ctx)
-	var books []models.Book
-	if err := cursorBooks.All(ctx, &books); err != nil {
-		return nil, err
-	}
 
-	classRequiredItems := make(map[string][]string)
-	for _, exam := range exams {
-		classRequiredItems[exam.ClassEntityID] = append(classRequiredItems[exam.ClassEntityID], exam.EntityID)
+	collectionStats := models.CollectionStats{
+		TotalPaidAmount: overall.TotalAmount,
+		CashAmount:      overall.CashAmount,
+		UPIAmount:       overall.UPIAmount,
+		Today:           today,
+		ThisMonth:       thisMonth,
 	}
-	for _, book := range books {
-		classRequiredItems[book.ClassEntityID] = append(classRequiredItems[book.ClassEntityID], book.EntityID)
+
+	// 3. Dues per student from the shared ledger, carried-forward dues included; payments
+	// are matched by item there, so dues settled in a later session count as paid
+	ledger, itemsByID, err := loadDuesLedger(ctx, database, session)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. Fetch all payments to build paid items map per student
-	cursorPayments, err := paymentScannerCollection.Find(ctx, bson.M{"is_deleted": false, "status": "paid"})
+	// 4. Optional fees collected in the session
+	cursorPayments, err := paymentCollection.Find(ctx, paymentMatch)
 	if err != nil {
 		return nil, err
 	}
@@ -141,75 +124,157 @@ func (s *dashboardService) GetDashboardStats(ctx context.Context, companyCode st
 		return nil, err
 	}
 
-	studentPaidItems := make(map[string]map[string]bool)
+	start, end := parseDateRange(req.StartDate, req.EndDate)
+	optionalStats := models.OptionalFeesStats{}
+
 	for _, payment := range payments {
-		if studentPaidItems[payment.StudentEntityID] == nil {
-			studentPaidItems[payment.StudentEntityID] = make(map[string]bool)
+		item, exists := itemsByID[payment.ExamEntityID]
+		if !exists || item.IsCompulsory {
+			continue
+		}
+		if !start.IsZero() && payment.PaymentDate.Before(start) {
+			continue
 		}
-		studentPaidItems[payment.StudentEntityID][payment.ExamEntityID] = true
+		if !end.IsZero() && !payment.PaymentDate.Before(end) {
+			continue
+		}
+		optionalStats.CollectedAmount += payment.Amount
 	}
 
-	// 4. Calculate paid/unpaid students
+	// 5. Paid/unpaid students on compulsory items only; optional items reported separately
 	paidStudentsCount := 0
 	unpaidStudentsCount := 0
 
-	for _, student := range students {
-		requiredItems := classRequiredItems[student.ClassEntityID]
-		paidItems := studentPaidItems[student.EntityID]
+	// Payments of students who left are collected above; only active students have fees due
+	active := activeStudents(students)
+	for _, student := range active {
+		dues := ledger.Dues(student, duePolicy{Optional: OptionalExcluded})
 
-		allPaid := true
-		for _, itemID := range requiredItems {
-			if !paidItems[itemID] {
-				allPaid = false
-				break
+		takesOptional := false
+		for _, item := range dues.Offered {
+			if item.IsCompulsory {
+				continue
+			}
+			optionalStats.OfferedItems++
+			if ledger.IsPaid(student.EntityID, item.EntityID) {
+				optionalStats.PaidItems++
+				takesOptional = true
 			}
 		}
 
-		if allPaid {
+		if dues.Settled() {
 			paidStudentsCount++
 		} else {
 			unpaidStudentsCount++
 		}
+		if takesOptional {
+			optionalStats.StudentsTakingOptional++
+		}
 	}
 
 	feesStatus := models.FeesStatusStats{
 		PaidStudents:   paidStudentsCount,
 		UnpaidStudents: unpaidStudentsCount,
-		TotalStudents:  len(students),
+		TotalStudents:  len(active),
 	}
 
-	// Create holidays list (you can make this dynamic from DB if needed)
-	holidays := []models.Holiday{
-		{
-			Name: "Republic Day",
-			Date: time.Date(2026, 1, 26, 0, 0, 0, 0, time.UTC),
-			Flag: "🇮🇳",
-		},
-		{
-			Name: "Holi",
-			Date: time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
-			Flag: "🎨",
-		},
-		{
-			Name: "Independence Day",
-			Date: time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC),
-			Flag: "🇮🇳",
-		},
-		{
-			Name: "Gandhi Jayanti",
-			Date: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC),
-			Flag: "🕊️",
-		},
-		{
-			Name: "Diwali",
-			Date: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
-			Flag: "🪔",
-		},
+	// Upcoming calendar entries for the next year, limited to the student's board when filtered
+	calendarFrom := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	boardEntityID := ""
+	if req.BoardEntityID != nil {
+		boardEntityID = *req.BoardEntityID
+	}
+	occurrences, err := NewCalendarService().GetOccurrences(ctx, companyCode, calendarFrom, calendarFrom.AddDate(1, 0, 0), boardEntityID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := []models.Holiday{}
+	upcoming := []models.CalendarOccurrence{}
+	for _, occurrence := range occurrences {
+		if occurrence.EntryType == "holiday" {
+			holidays = append(holidays, models.Holiday{
+				Name: occurrence.Title,
+				Date: occurrence.StartDate,
+				Flag: occurrence.Flag,
+			})
+		}
+		if len(upcoming) < maxDashboardUpcoming {
+			upcoming = append(upcoming, occurrence)
+		}
 	}
 
 	return &models.DashboardStats{
-		Collection: collectionStats,
-		FeesStatus: feesStatus,
-		Holidays:   holidays,
+		Collection:   collectionStats,
+		FeesStatus:   feesStatus,
+		OptionalFees: optionalStats,
+		Holidays:     holidays,
+		Upcoming:     upcoming,
 	}, nil
 }
+
+// sumCollection totals the matching payments, splitting out cash and UPI
+func sumCollection(ctx context.Context, collection *mongo.Collection, match bson.M) (models.PeriodCollection, error) {
+	pipeline := []bson.M{
+		{"$match": match},
+		{
+			"$group": bson.M{
+				"_id":       nil,
+				"totalPaid": bson.M{"$sum": "$amount"},
+				"payments":  bson.M{"$sum": 1},
+				"cashAmount": bson.M{
+					"$sum": bson.M{
+						"$cond": bson.A{
+							bson.M{"$eq": bson.A{bson.M{"$toLower": "$payment_method"}, "cash"}},
+							"$amount",
+							0,
+						},
+					},
+				},
+				"upiAmount": bson.M{
+					"$sum": bson.M{
+						"$cond": bson.A{
+							bson.M{"$eq": bson.A{bson.M{"$toLower": "$payment_method"}, "upi"}},
+							"$amount",
+							0,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return models.PeriodCollection{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		TotalPaid  float64 `bson:"totalPaid"`
+		Payments   int     `bson:"payments"`
+		CashAmount float64 `bson:"cashAmount"`
+		UPIAmount  float64 `bson:"upiAmount"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return models.PeriodCollection{}, err
+	}
+
+	period := models.PeriodCollection{}
+	if len(results) > 0 {
+		period.TotalAmount = results[0].TotalPaid
+		period.CashAmount = results[0].CashAmount
+		period.UPIAmount = results[0].UPIAmount
+		period.Payments = results[0].Payments
+	}
+
+	return period, nil
+}
+
+func copyFilter(filter bson.M) bson.M {
+	copied := make(bson.M, len(filter))
+	for key, value := range filter {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type DefaulterAgingService interface {
+	GetDefaulterAging(ctx context.Context, companyCode string, req *requests.DefaulterAgingRequest) (*models.DefaulterAgingResponse, error)
+	ExportDefaulterAgingCSV(ctx context.Context, companyCode string, req *requests.DefaulterAgingRequest) ([]byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type defaulterAgingService struct{}
+
+func NewDefaulterAgingService() DefaulterAgingService {
+	return &defaulterAgingService{}
+}
+
+//
+// ================= GET DEFAULTER AGING =================
+//
+
+func (s *defaulterAgingService) GetDefaulterAging(
+	ctx context.Context,
+	companyCode string,
+	req *requests.DefaulterAgingRequest,
+) (*models.DefaulterAgingResponse, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.AsOfDate != nil && *req.AsOfDate != "" {
+		asOf, _ = time.Parse("2006-01-02", *req.AsOfDate)
+	}
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Students in scope
+	studentFilter := bson.M{"is_deleted": false}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		studentFilter["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		studentFilter["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		studentFilter["div"] = divisionMatch(*req.Div)
+	}
+
+	// Students who left are not defaulters; only active students owe dues
+	students, err := findSessionStudents(ctx, database, session, studentFilter)
+	if err != nil {
+		return nil, err
+	}
+	students = activeStudents(students)
+
+	ledger, _, err := loadDuesLedger(ctx, database, session)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := duePolicy{Optional: OptionalExcluded}
+	if req.IncludeOptional {
+		policy.Optional = OptionalIncluded
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.DefaulterAgingResponse{
+		AsOfDate: asOf,
+		Students: []models.DefaulterAgingStudent{},
+		Rollups:  []models.DefaulterAgingRollup{},
+	}
+	rollups := make(map[string]*models.DefaulterAgingRollup)
+
+	for _, student := range students {
+		row := models.DefaulterAgingStudent{
+			EntityID:      student.EntityID,
+			RefNo:         student.RefNo,
+			StudentName:   studentFullName(student),
+			BoardEntityID: student.BoardEntityID,
+			BoardName:     boardNames[student.BoardEntityID],
+			ClassEntityID: student.ClassEntityID,
+			ClassName:     classNames[student.ClassEntityID],
+			Div:           student.Div,
+		}
+
+		for _, item := range ledger.Dues(student, policy).Pending {
+			daysOverdue := daysBetween(item.DueDate, asOf)
+			row.Buckets.Add(daysOverdue, item.Amount)
+			if row.OldestDueDate.IsZero() || item.DueDate.Before(row.OldestDueDate) {
+				row.OldestDueDate = item.DueDate
+			}
+			if daysOverdue > row.MaxDaysOverdue {
+				row.MaxDaysOverdue = daysOverdue
+			}
+		}
+
+		if row.Buckets.Total == 0 {
+			continue
+		}
+
+		response.Students = append(response.Students, row)
+		response.Totals.Merge(row.Buckets)
+
+		key := boardClassKey(student.BoardEntityID, student.ClassEntityID)
+		rollup, exists := rollups[key]
+		if !exists {
+			rollup = &models.DefaulterAgingRollup{
+				BoardEntityID: row.BoardEntityID,
+				BoardName:     row.BoardName,
+				ClassEntityID: row.ClassEntityID,
+				ClassName:     row.ClassName,
+			}
+			rollups[key] = rollup
+		}
+		rollup.StudentCount++
+		rollup.Buckets.Merge(row.Buckets)
+	}
+
+	for _, rollup := range rollups {
+		response.Rollups = append(response.Rollups, *rollup)
+	}
+
+	// Longest overdue first
+	sort.Slice(response.Students, func(i, j int) bool {
+		if response.Students[i].MaxDaysOverdue != response.Students[j].MaxDaysOverdue {
+			return response.Students[i].MaxDaysOverdue > response.Students[j].MaxDaysOverdue
+		}
+		return response.Students[i].Buckets.Total > response.Students[j].Buckets.Total
+	})
+	sort.Slice(response.Rollups, func(i, j int) bool {
+		if response.Rollups[i].BoardName != response.Rollups[j].BoardName {
+			return response.Rollups[i].BoardName < response.Rollups[j].BoardName
+		}
+		return response.Rollups[i].ClassName < response.Rollups[j].ClassName
+	})
+
+	return response, nil
+}
+
+//
+// ================= EXPORT CSV =================
+//
+
+func (s *defaulterAgingService) ExportDefaulterAgingCSV(
+	ctx context.Context,
+	companyCode string,
+	req *requests.DefaulterAgingRequest,
+) ([]byte, error) {
+
+	report, err := s.GetDefaulterAging(ctx, companyCode, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{
+		"Ref No", "Student Name", "Board", "Class", "Div", "Oldest Due Date", "Max Days Overdue",
+		"Current", "1-30 Days", "31-60 Days", "61-90 Days", "90+ Days", "Total",
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, student := range report.Students {
+		record := []string{
+			student.RefNo,
+			student.StudentName,
+			student.BoardName,
+			student.ClassName,
+			student.Div,
+			student.OldestDueDate.Format("2006-01-02"),
+			fmt.Sprintf("%d", student.MaxDaysOverdue),
+		}
+		record = append(record, agingAmounts(student.Buckets)...)
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	// Class / board roll-ups follow the student rows
+	for _, rollup := range report.Rollups {
+		record := []string{
+			"",
+			fmt.Sprintf("Subtotal (%d students)", rollup.StudentCount),
+			rollup.BoardName,
+			rollup.ClassName,
+			"", "", "",
+		}
+		record = append(record, agingAmounts(rollup.Buckets)...)
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	record := []string{"", "Grand Total", "", "", "", "", ""}
+	record = append(record, agingAmounts(report.Totals)...)
+	if err := writer.Write(record); err != nil {
+		return nil, err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func agingAmounts(buckets models.AgingBuckets) []string {
+	return []string{
+		fmt.Sprintf("%.2f", buckets.Current),
+		fmt.Sprintf("%.2f", buckets.Days1To30),
+		fmt.Sprintf("%.2f", buckets.Days31To60),
+		fmt.Sprintf("%.2f", buckets.Days61To90),
+		fmt.Sprintf("%.2f", buckets.Days90Plus),
+		fmt.Sprintf("%.2f", buckets.Total),
+	}
+}
+
+// daysBetween counts whole calendar days from one date to another (negative if to is earlier)
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+func studentFullName(student models.Student) string {
+	parts := []string{student.FirstName, student.MiddleName, student.LastName}
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			names = append(names, strings.TrimSpace(part))
+		}
+	}
+	return strings.Join(names, " ")
+}
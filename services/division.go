@@ -0,0 +1,612 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DivisionCollection = "divisions"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type DivisionService interface {
+	Create(ctx context.Context, companyCode string, classID string, req *requests.CreateDivisionRequest) (*models.Division, error)
+	GetByClass(ctx context.Context, companyCode string, classID string) ([]*models.Division, error)
+	GetByID(ctx context.Context, companyCode string, classID string, id string) (*models.Division, error)
+	Update(ctx context.Context, companyCode string, classID string, id string, req *requests.UpdateDivisionRequest) (*models.Division, error)
+	Delete(ctx context.Context, companyCode string, classID string, id string) error
+	Normalise(ctx context.Context, companyCode string, req *requests.NormaliseDivisionsRequest) (*models.DivisionMigration, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type divisionService struct{}
+
+func NewDivisionService() DivisionService {
+	return &divisionService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+func (s *divisionService) Create(
+	ctx context.Context,
+	companyCode string,
+	classID string,
+	req *requests.CreateDivisionRequest,
+) (*models.Division, error) {
+
+	class, err := NewClassService().GetByID(ctx, companyCode, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	division := models.NewDivision()
+	division.Bind(req)
+	division.ClassEntityID = class.EntityID
+
+	if err := checkDivisionName(ctx, database, class.EntityID, division.EntityID, division.Name); err != nil {
+		return nil, err
+	}
+	division.ClassTeacherEntityID, err = resolveClassTeacher(ctx, companyCode, division.ClassTeacherEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Collection(DivisionCollection).InsertOne(ctx, division); err != nil {
+		return nil, err
+	}
+
+	return division, nil
+}
+
+//
+// ================= GET BY CLASS =================
+//
+
+// GetByClass lists the class's divisions by name with how many students each holds
+func (s *divisionService) GetByClass(
+	ctx context.Context,
+	companyCode string,
+	classID string,
+) ([]*models.Division, error) {
+
+	class, err := NewClassService().GetByID(ctx, companyCode, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := database.Collection(DivisionCollection).Find(ctx, bson.M{
+		"class_entity_id": class.EntityID,
+		"is_deleted":      false,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	divisions := []*models.Division{}
+	if err := cursor.All(ctx, &divisions); err != nil {
+		return nil, err
+	}
+
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	for _, division := range divisions {
+		enrolled, err := countDivision(ctx, database, sessionEntityID, division.ClassEntityID, division.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		division.Enrolled = int(enrolled)
+	}
+
+	return divisions, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *divisionService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	classID string,
+	id string,
+) (*models.Division, error) {
+
+	class, err := NewClassService().GetByID(ctx, companyCode, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	division, err := findDivision(ctx, database, class.EntityID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	enrolled, err := countDivision(ctx, database, sessionEntityID, division.ClassEntityID, division.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	division.Enrolled = int(enrolled)
+
+	return division, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+// Update changes a division. A rename relabels the division on every student and
+// enrolment of the class, and the capacity cannot drop below the students it holds.
+func (s *divisionService) Update(
+	ctx context.Context,
+	companyCode string,
+	classID string,
+	id string,
+	req *requests.UpdateDivisionRequest,
+) (*models.Division, error) {
+
+	class, err := NewClassService().GetByID(ctx, companyCode, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(DivisionCollection)
+
+	current, err := findDivision(ctx, database, class.EntityID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.NewUpdateDivision()
+	update.Bind(req)
+
+	updateFields := bson.M{}
+	renamed := false
+
+	if update.Name != nil && *update.Name != current.Name {
+		if err := checkDivisionName(ctx, database, class.EntityID, current.EntityID, *update.Name); err != nil {
+			return nil, err
+		}
+		updateFields["name"] = *update.Name
+		renamed = true
+	}
+	if update.Capacity != nil {
+		if *update.Capacity > 0 {
+			sessionEntityID, err := activeSessionEntityID(ctx, database)
+			if err != nil {
+				return nil, err
+			}
+			enrolled, err := countDivision(ctx, database, sessionEntityID, class.EntityID, current.Name, "")
+			if err != nil {
+				return nil, err
+			}
+			if enrolled > int64(*update.Capacity) {
+				return nil, fmt.Errorf("division %s already has %d students", current.Name, enrolled)
+			}
+		}
+		updateFields["capacity"] = *update.Capacity
+	}
+	if update.ClassTeacherEntityID != nil {
+		teacher, err := resolveClassTeacher(ctx, companyCode, *update.ClassTeacherEntityID)
+		if err != nil {
+			return nil, err
+		}
+		updateFields["class_teacher_entity_id"] = teacher
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	now := time.Now()
+	updateFields["updated_at"] = now
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Division
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID}, bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	if renamed {
+		relabel := bson.M{"$set": bson.M{"div": updated.Name, "updated_at": now}}
+		match := bson.M{"class_entity_id": class.EntityID, "div": current.Name}
+		if _, err := database.Collection(StudentCollection).UpdateMany(ctx, match, relabel); err != nil {
+			return nil, err
+		}
+		if _, err := database.Collection(EnrolmentCollection).UpdateMany(ctx, match, relabel); err != nil {
+			return nil, err
+		}
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+// Delete removes a division no student of the active session is in
+func (s *divisionService) Delete(
+	ctx context.Context,
+	companyCode string,
+	classID string,
+	id string,
+) error {
+
+	class, err := NewClassService().GetByID(ctx, companyCode, classID)
+	if err != nil {
+		return err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	division, err := findDivision(ctx, database, class.EntityID, id)
+	if err != nil {
+		return err
+	}
+
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return err
+	}
+	enrolled, err := countDivision(ctx, database, sessionEntityID, class.EntityID, division.Name, "")
+	if err != nil {
+		return err
+	}
+	if enrolled > 0 {
+		return fmt.Errorf("division %s has %d students and cannot be deleted", division.Name, enrolled)
+	}
+
+	_, err = database.Collection(DivisionCollection).UpdateOne(ctx, bson.M{"_id": division.ID}, bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
+//
+// ================= NORMALISE =================
+//
+
+// Normalise rewrites every free-text div of students and enrolments to the name of the
+// division it matches, ignoring case and stray whitespace. Values matching no division
+// are reported, or become new divisions when create_missing is set.
+func (s *divisionService) Normalise(
+	ctx context.Context,
+	companyCode string,
+	req *requests.NormaliseDivisionsRequest,
+) (*models.DivisionMigration, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	roster, err := loadDivisionRoster(ctx, database, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DivisionMigration{
+		DryRun:           req.DryRun,
+		DivisionsCreated: []models.Division{},
+		Unmatched:        []models.DivisionMismatch{},
+	}
+
+	type classDiv struct {
+		ClassEntityID string `bson:"class_entity_id"`
+		Div           string `bson:"div"`
+	}
+	values := make(map[classDiv]int64)
+	for _, name := range []string{StudentCollection, EnrolmentCollection} {
+		cursor, err := database.Collection(name).Aggregate(ctx, []bson.M{
+			{"$match": bson.M{"div": bson.M{"$exists": true, "$ne": ""}}},
+			{"$group": bson.M{
+				"_id":   bson.M{"class_entity_id": "$class_entity_id", "div": "$div"},
+				"count": bson.M{"$sum": 1},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var groups []struct {
+			ID    classDiv `bson:"_id"`
+			Count int64    `bson:"count"`
+		}
+		err = cursor.All(ctx, &groups)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range groups {
+			if name == StudentCollection {
+				values[group.ID] += group.Count
+			} else if _, ok := values[group.ID]; !ok {
+				values[group.ID] = 0
+			}
+		}
+	}
+
+	// Work through the values in a stable order so created divisions are predictable
+	keys := make([]classDiv, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ClassEntityID != keys[j].ClassEntityID {
+			return keys[i].ClassEntityID < keys[j].ClassEntityID
+		}
+		return keys[i].Div < keys[j].Div
+	})
+
+	now := time.Now()
+	for _, key := range keys {
+		division := roster.Match(key.ClassEntityID, key.Div)
+
+		if division == nil && req.CreateMissing && key.ClassEntityID != "" {
+			// Letters are the usual division names, so "a" becomes "A"
+			division = models.NewDivision()
+			division.ClassEntityID = key.ClassEntityID
+			division.Name = strings.ToUpper(requests.NormaliseDivision(key.Div))
+			if !req.DryRun {
+				if _, err := database.Collection(DivisionCollection).InsertOne(ctx, division); err != nil {
+					return nil, err
+				}
+			}
+			roster.Add(*division)
+			result.DivisionsCreated = append(result.DivisionsCreated, *division)
+		}
+
+		if division == nil {
+			result.Unmatched = append(result.Unmatched, models.DivisionMismatch{
+				ClassEntityID: key.ClassEntityID,
+				Div:           key.Div,
+				Students:      values[key],
+			})
+			continue
+		}
+		if division.Name == key.Div {
+			continue
+		}
+
+		match := bson.M{"class_entity_id": key.ClassEntityID, "div": key.Div}
+		relabel := bson.M{"$set": bson.M{"div": division.Name, "updated_at": now}}
+		counts := []struct {
+			collection string
+			count      *int64
+		}{
+			{StudentCollection, &result.Students},
+			{EnrolmentCollection, &result.Enrolments},
+		}
+		for _, c := range counts {
+			if req.DryRun {
+				count, err := database.Collection(c.collection).CountDocuments(ctx, match)
+				if err != nil {
+					return nil, err
+				}
+				*c.count += count
+				continue
+			}
+			updated, err := database.Collection(c.collection).UpdateMany(ctx, match, relabel)
+			if err != nil {
+				return nil, err
+			}
+			*c.count += updated.ModifiedCount
+		}
+	}
+
+	return result, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func divisionFilter(classEntityID string, id string) bson.M {
+	filter := bson.M{"class_entity_id": classEntityID, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter["_id"] = oid
+	} else {
+		filter["entity_id"] = id
+	}
+	return filter
+}
+
+func findDivision(ctx context.Context, database *mongo.Database, classEntityID string, id string) (*models.Division, error) {
+	var division models.Division
+	err := database.Collection(DivisionCollection).FindOne(ctx, divisionFilter(classEntityID, id)).Decode(&division)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("division not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &division, nil
+}
+
+// checkDivisionName keeps division names of a class unique regardless of case
+func checkDivisionName(ctx context.Context, database *mongo.Database, classEntityID, entityID, name string) error {
+	count, err := database.Collection(DivisionCollection).CountDocuments(ctx, bson.M{
+		"class_entity_id": classEntityID,
+		"entity_id":       bson.M{"$ne": entityID},
+		"name":            divisionMatch(name),
+		"is_deleted":      false,
+	})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("division %s already exists in this class", name)
+	}
+	return nil
+}
+
+// resolveClassTeacher returns the entity id of the class teacher's user, or "" for none
+func resolveClassTeacher(ctx context.Context, companyCode string, userID string) (string, error) {
+	if userID == "" {
+		return "", nil
+	}
+	user, err := NewUserService().GetByID(ctx, companyCode, userID)
+	if err != nil {
+		return "", fmt.Errorf("class teacher: %v", err)
+	}
+	return user.EntityID, nil
+}
+
+// divisionMatch matches a stored div regardless of case and surrounding whitespace, so
+// report filters also find values written before divisions were normalised
+func divisionMatch(div string) bson.M {
+	return bson.M{"$regex": "^\\s*" + regexp.QuoteMeta(requests.NormaliseDivision(div)) + "\\s*$", "$options": "i"}
+}
+
+// countDivision counts the students in a division of a class, within the session's
+// enrolments when there is a session, leaving out one student when given
+func countDivision(ctx context.Context, database *mongo.Database, sessionEntityID, classEntityID, div, exceptStudentEntityID string) (int64, error) {
+	if sessionEntityID == "" {
+		filter := bson.M{"class_entity_id": classEntityID, "div": div, "is_deleted": false}
+		if exceptStudentEntityID != "" {
+			filter["entity_id"] = bson.M{"$ne": exceptStudentEntityID}
+		}
+		return database.Collection(StudentCollection).CountDocuments(ctx, filter)
+	}
+
+	filter := bson.M{
+		"session_entity_id": sessionEntityID,
+		"class_entity_id":   classEntityID,
+		"div":               div,
+		"is_deleted":        false,
+	}
+	if exceptStudentEntityID != "" {
+		filter["student_entity_id"] = bson.M{"$ne": exceptStudentEntityID}
+	}
+	return database.Collection(EnrolmentCollection).CountDocuments(ctx, filter)
+}
+
+// divisionRoster checks div values against the divisions of each class and keeps count
+// of the places taken, so a batch of students can be placed without overfilling
+type divisionRoster struct {
+	database        *mongo.Database
+	sessionEntityID string
+	divisions       map[string][]models.Division
+	taken           map[string]int64
+}
+
+// loadDivisionRoster reads every division of the company. Places are counted within
+// the given session.
+func loadDivisionRoster(ctx context.Context, database *mongo.Database, sessionEntityID string) (*divisionRoster, error) {
+	cursor, err := database.Collection(DivisionCollection).Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var divisions []models.Division
+	if err := cursor.All(ctx, &divisions); err != nil {
+		return nil, err
+	}
+
+	roster := &divisionRoster{
+		database:        database,
+		sessionEntityID: sessionEntityID,
+		divisions:       make(map[string][]models.Division),
+		taken:           make(map[string]int64),
+	}
+	for _, division := range divisions {
+		roster.Add(division)
+	}
+	return roster, nil
+}
+
+// Add makes a division known to the roster
+func (r *divisionRoster) Add(division models.Division) {
+	r.divisions[division.ClassEntityID] = append(r.divisions[division.ClassEntityID], division)
+}
+
+// Match returns the class's division the div refers to, or nil
+func (r *divisionRoster) Match(classEntityID, div string) *models.Division {
+	div = requests.NormaliseDivision(div)
+	for i, division := range r.divisions[classEntityID] {
+		if strings.EqualFold(division.Name, div) {
+			return &r.divisions[classEntityID][i]
+		}
+	}
+	return nil
+}
+
+// Place resolves the div a student is put in to the division's name and takes a place
+// in it. Classes without divisions keep free-text values, trimmed.
+func (r *divisionRoster) Place(ctx context.Context, classEntityID, div, studentEntityID string) (string, error) {
+	divisions := r.divisions[classEntityID]
+	if len(divisions) == 0 {
+		return requests.NormaliseDivision(div), nil
+	}
+
+	division := r.Match(classEntityID, div)
+	if division == nil {
+		names := make([]string, 0, len(divisions))
+		for _, d := range divisions {
+			names = append(names, d.Name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("div %q is not a division of this class; expected one of %s", div, strings.Join(names, ", "))
+	}
+
+	if division.Capacity > 0 {
+		key := boardClassKey(classEntityID, division.Name)
+		taken, counted := r.taken[key]
+		if !counted {
+			var err error
+			taken, err = countDivision(ctx, r.database, r.sessionEntityID, classEntityID, division.Name, studentEntityID)
+			if err != nil {
+				return "", err
+			}
+		}
+		if taken >= int64(division.Capacity) {
+			return "", fmt.Errorf("division %s is full (%d of %d places taken)", division.Name, taken, division.Capacity)
+		}
+		r.taken[key] = taken + 1
+	}
+
+	return division.Name, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestDivisionRosterPlace(t *testing.T) {
+	roster := &divisionRoster{divisions: make(map[string][]models.Division), taken: make(map[string]int64)}
+	roster.Add(models.Division{ClassEntityID: "c10", Name: "A"})
+	roster.Add(models.Division{ClassEntityID: "c10", Name: "Rose Wing"})
+
+	tests := []struct {
+		name    string
+		class   string
+		div     string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact name", class: "c10", div: "A", want: "A"},
+		{name: "lower case", class: "c10", div: "a", want: "A"},
+		{name: "stray whitespace", class: "c10", div: "  rose   wing ", want: "Rose Wing"},
+		{name: "unknown division", class: "c10", div: "B", wantErr: true},
+		{name: "empty division", class: "c10", div: "", wantErr: true},
+		{name: "class without divisions keeps free text", class: "c9", div: " b ", want: "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := roster.Place(context.Background(), tt.class, tt.div, "s1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Place(%q) = %q, want an error", tt.div, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Place(%q): %v", tt.div, err)
+			}
+			if got != tt.want {
+				t.Errorf("Place(%q) = %q, want %q", tt.div, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDivisionRosterCapacity(t *testing.T) {
+	roster := &divisionRoster{divisions: make(map[string][]models.Division), taken: make(map[string]int64)}
+	roster.Add(models.Division{ClassEntityID: "c10", Name: "A", Capacity: 2})
+
+	// Places already counted for the division, as after the first database count
+	roster.taken[boardClassKey("c10", "A")] = 1
+
+	if _, err := roster.Place(context.Background(), "c10", "a", "s1"); err != nil {
+		t.Fatalf("placing into the last free place: %v", err)
+	}
+	if _, err := roster.Place(context.Background(), "c10", "A", "s2"); err == nil {
+		t.Fatal("expected a full division to reject another student")
+	}
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Dues are worked out in one place so the receipt, unpaid students, dashboard and
+// reports all agree on what a student owes. Items are matched on the student's
+// board and class together, and only settled ("paid") payments clear an item.
+
+// feeItem is the common shape of an exam or a book when computing dues
+type feeItem struct {
+	ItemType     string
+	EntityID     string
+	Name         string
+	Amount       float64
+	IsCompulsory bool
+	DueDate      time.Time // due_date, or created_at when no due date is set
+
+	CarriedFrom string // session the item was charged in, for dues carried forward by a promotion
+}
+
+// OptionalPolicy decides whether unpaid optional items count towards a student's dues
+type OptionalPolicy int
+
+const (
+	// OptionalExcluded owes compulsory items only; optional items are a choice, not a debt
+	OptionalExcluded OptionalPolicy = iota
+	// OptionalIncluded owes every item offered to the student's board and class
+	OptionalIncluded
+)
+
+// duePolicy narrows which unpaid items are owed
+type duePolicy struct {
+	Optional OptionalPolicy
+	ItemType string // "exam", "book", or "" / "all" for both
+}
+
+// studentDues is one student's position against the items offered to their board and class
+type studentDues struct {
+	Offered  []feeItem // items matching the policy's item type, paid or not
+	Paid     []feeItem
+	Pending  []feeItem // unpaid items owed under the policy
+	TotalDue float64
+}
+
+// Settled reports whether every compulsory item is paid. This is what makes a
+// student "paid", whatever the optional policy.
+func (d studentDues) Settled() bool {
+	for _, item := range d.Pending {
+		if item.IsCompulsory {
+			return false
+		}
+	}
+	return true
+}
+
+// duesLedger holds the fee items per board and class, the dues each student carried
+// forward from an earlier session and the items each student has paid
+type duesLedger struct {
+	classItems   map[string][]feeItem
+	carriedItems map[string][]feeItem
+	paidItems    map[string]map[string]bool
+}
+
+func newDuesLedger(classItems map[string][]feeItem, payments []models.PaymentScanner) *duesLedger {
+	paidItems := make(map[string]map[string]bool)
+	for _, payment := range payments {
+		if payment.IsDeleted || payment.Status != "paid" {
+			continue
+		}
+		if paidItems[payment.StudentEntityID] == nil {
+			paidItems[payment.StudentEntityID] = make(map[string]bool)
+		}
+		paidItems[payment.StudentEntityID][payment.ExamEntityID] = true
+	}
+
+	return &duesLedger{classItems: classItems, carriedItems: make(map[string][]feeItem), paidItems: paidItems}
+}
+
+// Carry adds dues the student brought forward from an earlier session
+func (l *duesLedger) Carry(studentEntityID string, items []feeItem) {
+	l.carriedItems[studentEntityID] = append(l.carriedItems[studentEntityID], items...)
+}
+
+// Dues computes what the student owes under the policy
+func (l *duesLedger) Dues(student models.Student, policy duePolicy) studentDues {
+	dues := studentDues{}
+	paid := l.paidItems[student.EntityID]
+
+	items := append([]feeItem{}, l.classItems[boardClassKey(student.BoardEntityID, student.ClassEntityID)]...)
+	items = append(items, l.carriedItems[student.EntityID]...)
+
+	for _, item := range items {
+		if policy.ItemType != "" && policy.ItemType != "all" && policy.ItemType != item.ItemType {
+			continue
+		}
+		dues.Offered = append(dues.Offered, item)
+
+		if paid[item.EntityID] {
+			dues.Paid = append(dues.Paid, item)
+			continue
+		}
+		if !item.IsCompulsory && policy.Optional != OptionalIncluded {
+			continue
+		}
+		dues.Pending = append(dues.Pending, item)
+		dues.TotalDue += item.Amount
+	}
+
+	return dues
+}
+
+// IsPaid reports whether the student has a settled payment for the item
+func (l *duesLedger) IsPaid(studentEntityID, itemEntityID string) bool {
+	return l.paidItems[studentEntityID][itemEntityID]
+}
+
+// CarriedItem finds an item the student carried forward from an earlier session
+func (l *duesLedger) CarriedItem(studentEntityID, itemEntityID string) (feeItem, bool) {
+	for _, item := range l.carriedItems[studentEntityID] {
+		if item.EntityID == itemEntityID {
+			return item, true
+		}
+	}
+	return feeItem{}, false
+}
+
+// loadDuesLedger reads the fee items and carried dues of the session, or every fee item
+// of the company when session is nil, with the settled payments against them
+func loadDuesLedger(ctx context.Context, database *mongo.Database, session *models.AcademicSession) (*duesLedger, map[string]feeItem, error) {
+	classItems, itemsByID, err := loadFeeItems(ctx, database, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	carried := make(map[string][]feeItem)
+	if session != nil {
+		carried, err = loadCarriedDues(ctx, database, session.EntityID, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Items belong to one session, so matching payments by item also finds carried
+	// dues settled after the session they were charged in
+	itemIDs := make([]string, 0, len(itemsByID))
+	for id := range itemsByID {
+		itemIDs = append(itemIDs, id)
+	}
+	for _, items := range carried {
+		for _, item := range items {
+			itemIDs = append(itemIDs, item.EntityID)
+		}
+	}
+
+	paymentFilter := bson.M{"is_deleted": false, "status": "paid"}
+	if session != nil {
+		paymentFilter["exam_entity_id"] = bson.M{"$in": itemIDs}
+	}
+
+	cursor, err := database.Collection("payment_scanners").Find(ctx, paymentFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []models.PaymentScanner
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, nil, err
+	}
+
+	ledger := newDuesLedger(classItems, payments)
+	for studentEntityID, items := range carried {
+		ledger.Carry(studentEntityID, items)
+	}
+
+	return ledger, itemsByID, nil
+}
+
+// loadCarriedDues returns the live carried-forward dues of the session per student,
+// limited to the given students when any are passed
+func loadCarriedDues(ctx context.Context, database *mongo.Database, sessionEntityID string, studentEntityIDs []string) (map[string][]feeItem, error) {
+	filter := bson.M{"to_session_entity_id": sessionEntityID, "is_deleted": false}
+	if len(studentEntityIDs) > 0 {
+		filter["student_entity_id"] = bson.M{"$in": studentEntityIDs}
+	}
+
+	cursor, err := database.Collection(CarriedDueCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var dues []models.CarriedDue
+	if err := cursor.All(ctx, &dues); err != nil {
+		return nil, err
+	}
+
+	carried := make(map[string][]feeItem)
+	for _, due := range dues {
+		carried[due.StudentEntityID] = append(carried[due.StudentEntityID], carriedFeeItem(due))
+	}
+
+	return carried, nil
+}
+
+// carriedFeeItem turns a carried-forward due back into a fee item. Only compulsory
+// items are carried, so it is always owed.
+func carriedFeeItem(due models.CarriedDue) feeItem {
+	return feeItem{
+		ItemType:     due.ItemType,
+		EntityID:     due.ItemEntityID,
+		Name:         due.ItemName,
+		Amount:       due.Amount,
+		IsCompulsory: true,
+		DueDate:      due.DueDate,
+		CarriedFrom:  due.FromSessionEntityID,
+	}
+}
+
+func examFeeItem(exam models.Exam) feeItem {
+	return feeItem{
+		ItemType:     "exam",
+		EntityID:     exam.EntityID,
+		Name:         exam.ExamName,
+		Amount:       exam.ExamAmount,
+		IsCompulsory: isCompulsoryFee(exam.FeesType, exam.FeesPaid),
+		DueDate:      effectiveDueDate(exam.DueDate, exam.CreatedAt),
+	}
+}
+
+func bookFeeItem(book models.Book) feeItem {
+	return feeItem{
+		ItemType:     "book",
+		EntityID:     book.EntityID,
+		Name:         book.BookName,
+		Amount:       book.Amount,
+		IsCompulsory: isCompulsoryFee(book.FeesType, book.FeesPaid),
+		DueDate:      effectiveDueDate(book.DueDate, book.CreatedAt),
+	}
+}
+
+// isCompulsoryFee handles both the old fees_paid (boolean) and new fees_type (string) fields.
+// fees_paid is the item's legacy compulsory flag, set only when the item is saved; it does
+// not record whether anyone paid.
+func isCompulsoryFee(feesType string, feesPaid bool) bool {
+	if feesType != "" {
+		return feesType == "compulsory"
+	}
+	return feesPaid
+}
+
+// effectiveDueDate falls back to the creation date for items without a due date
+func effectiveDueDate(dueDate *time.Time, createdAt time.Time) time.Time {
+	if dueDate != nil && !dueDate.IsZero() {
+		return *dueDate
+	}
+	return createdAt
+}
+
+func boardClassKey(boardEntityID, classEntityID string) string {
+	return boardEntityID + "|" + classEntityID
+}
+
+// loadFeeItems returns the session's exams and books grouped by board and class, plus a lookup by entity ID
+func loadFeeItems(ctx context.Context, database *mongo.Database, session *models.AcademicSession) (map[string][]feeItem, map[string]feeItem, error) {
+	classItems := make(map[string][]feeItem)
+	itemsByID := make(map[string]feeItem)
+
+	examCursor, err := database.Collection(ExamCollection).Find(ctx, withSession(bson.M{"is_deleted": false}, session))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer examCursor.Close(ctx)
+
+	var exams []models.Exam
+	if err := examCursor.All(ctx, &exams); err != nil {
+		return nil, nil, err
+	}
+
+	for _, exam := range exams {
+		item := examFeeItem(exam)
+		key := boardClassKey(exam.BoardEntityID, exam.ClassEntityID)
+		classItems[key] = append(classItems[key], item)
+		itemsByID[exam.EntityID] = item
+	}
+
+	bookCursor, err := database.Collection(BookCollection).Find(ctx, withSession(bson.M{"is_deleted": false}, session))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer bookCursor.Close(ctx)
+
+	var books []models.Book
+	if err := bookCursor.All(ctx, &books); err != nil {
+		return nil, nil, err
+	}
+
+	for _, book := range books {
+		item := bookFeeItem(book)
+		key := boardClassKey(book.BoardEntityID, book.ClassEntityID)
+		classItems[key] = append(classItems[key], item)
+		itemsByID[book.EntityID] = item
+	}
+
+	return classItems, itemsByID, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestDuesLedger(t *testing.T) {
+	// Class "c10" is shared by both boards; each board has its own items
+	classItems := map[string][]feeItem{
+		boardClassKey("cbse", "c10"): {
+			{ItemType: "exam", EntityID: "cbse-term", Name: "CBSE Term", Amount: 500, IsCompulsory: true},
+			{ItemType: "book", EntityID: "cbse-maths", Name: "CBSE Maths", Amount: 300, IsCompulsory: true},
+			{ItemType: "book", EntityID: "cbse-atlas", Name: "Atlas", Amount: 200, IsCompulsory: false},
+		},
+		boardClassKey("ssc", "c10"): {
+			{ItemType: "exam", EntityID: "ssc-term", Name: "SSC Term", Amount: 400, IsCompulsory: true},
+		},
+	}
+
+	paid := func(student, item, status string) models.PaymentScanner {
+		return models.PaymentScanner{StudentEntityID: student, ExamEntityID: item, Status: status}
+	}
+
+	tests := []struct {
+		name        string
+		student     models.Student
+		payments    []models.PaymentScanner
+		policy      duePolicy
+		wantPending []string
+		wantDue     float64
+		wantSettled bool
+	}{
+		{
+			name:        "nothing paid owes only own board's compulsory items",
+			student:     models.Student{EntityID: "s1", BoardEntityID: "cbse", ClassEntityID: "c10"},
+			policy:      duePolicy{Optional: OptionalExcluded},
+			wantPending: []string{"cbse-term", "cbse-maths"},
+			wantDue:     800,
+		},
+		{
+			name:        "shared class does not leak the other board's fees",
+			student:     models.Student{EntityID: "s2", BoardEntityID: "ssc", ClassEntityID: "c10"},
+			policy:      duePolicy{Optional: OptionalExcluded},
+			wantPending: []string{"ssc-term"},
+			wantDue:     400,
+		},
+		{
+			name:    "unpaid optional book does not make a student unpaid",
+			student: models.Student{EntityID: "s1", BoardEntityID: "cbse", ClassEntityID: "c10"},
+			payments: []models.PaymentScanner{
+				paid("s1", "cbse-term", "paid"),
+				paid("s1", "cbse-maths", "paid"),
+			},
+			policy:      duePolicy{Optional: OptionalExcluded},
+			wantPending: nil,
+			wantDue:     0,
+			wantSettled: true,
+		},
+		{
+			name:    "included optional items are owed but the student stays settled",
+			student: models.Student{EntityID: "s1", BoardEntityID: "cbse", ClassEntityID: "c10"},
+			payments: []models.PaymentScanner{
+				paid("s1", "cbse-term", "paid"),
+				paid("s1", "cbse-maths", "paid"),
+			},
+			policy:      duePolicy{Optional: OptionalIncluded},
+			wantPending: []string{"cbse-atlas"},
+			wantDue:     200,
+			wantSettled: true,
+		},
+		{
+			name:    "void, refunded and pending payments do not clear an item",
+			student: models.Student{EntityID: "s1", BoardEntityID: "cbse", ClassEntityID: "c10"},
+			payments: []models.PaymentScanner{
+				paid("s1", "cbse-term", "void"),
+				paid("s1", "cbse-maths", "refunded"),
+				paid("s1", "cbse-maths", "pending"),
+			},
+			policy:      duePolicy{Optional: OptionalExcluded},
+			wantPending: []string{"cbse-term", "cbse-maths"},
+			wantDue:     800,
+		},
+		{
+			name:        "another student's payment does not count",
+			student:     models.Student{EntityID: "s2", BoardEntityID: "ssc", ClassEntityID: "c10"},
+			payments:    []models.PaymentScanner{paid("s1", "ssc-term", "paid")},
+			policy:      duePolicy{Optional: OptionalExcluded},
+			wantPending: []string{"ssc-term"},
+			wantDue:     400,
+		},
+		{
+			name:        "item type filter narrows pending items",
+			student:     models.Student{EntityID: "s1", BoardEntityID: "cbse", ClassEntityID: "c10"},
+			policy:      duePolicy{Optional: OptionalIncluded, ItemType: "book"},
+			wantPending: []string{"cbse-maths", "cbse-atlas"},
+			wantDue:     500,
+		},
+		{
+			name:        "board without items owes nothing",
+			student:     models.Student{EntityID: "s3", BoardEntityID: "icse", ClassEntityID: "c10"},
+			policy:      duePolicy{Optional: OptionalIncluded},
+			wantPending: nil,
+			wantDue:     0,
+			wantSettled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dues := newDuesLedger(classItems, tt.payments).Dues(tt.student, tt.policy)
+
+			var pending []string
+			for _, item := range dues.Pending {
+				pending = append(pending, item.EntityID)
+			}
+			if len(pending) != len(tt.wantPending) {
+				t.Fatalf("pending = %v, want %v", pending, tt.wantPending)
+			}
+			for i := range pending {
+				if pending[i] != tt.wantPending[i] {
+					t.Fatalf("pending = %v, want %v", pending, tt.wantPending)
+				}
+			}
+			if dues.TotalDue != tt.wantDue {
+				t.Fatalf("total due = %.2f, want %.2f", dues.TotalDue, tt.wantDue)
+			}
+			if dues.Settled() != tt.wantSettled {
+				t.Fatalf("settled = %t, want %t", dues.Settled(), tt.wantSettled)
+			}
+		})
+	}
+}
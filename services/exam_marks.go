@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ExamMarkCollection      = "exam_marks"
+	ExamMarkStateCollection = "exam_mark_states"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ExamMarksService interface {
+	Submit(ctx context.Context, companyCode string, examID string, paperID string, req *requests.SubmitExamMarksRequest, enteredBy models.PaymentActor) (*models.ExamMarkSheet, error)
+	GetSheet(ctx context.Context, companyCode string, examID string, paperID string, req *requests.ExamMarkSheetRequest) (*models.ExamMarkSheet, error)
+	Moderate(ctx context.Context, companyCode string, examID string, paperID string, req *requests.ModerateExamMarksRequest, moderatedBy models.PaymentActor) (*models.ExamMarkSheet, error)
+	ChangeStatus(ctx context.Context, companyCode string, examID string, paperID string, req *requests.ChangeExamMarksStatusRequest, changedBy models.PaymentActor) (*models.ExamMarkState, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type examMarksService struct{}
+
+func NewExamMarksService() ExamMarksService {
+	return &examMarksService{}
+}
+
+//
+// ================= SUBMIT =================
+//
+
+// Submit enters the marks of one paper for a division. Marks can only be entered while
+// the paper is in draft; entering them again clears any earlier moderation.
+func (s *examMarksService) Submit(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+	req *requests.SubmitExamMarksRequest,
+	enteredBy models.PaymentActor,
+) (*models.ExamMarkSheet, error) {
+
+	exam, paper, database, err := loadWritableExamPaper(ctx, companyCode, examID, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadExamMarkState(ctx, database, exam.EntityID, paper.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status != models.ExamMarksDraft {
+		return nil, fmt.Errorf("marks for %s are %s; reopen them to make changes", paper.Subject, state.Status)
+	}
+
+	roster, err := examMarksRoster(ctx, database, exam, &req.Div)
+	if err != nil {
+		return nil, err
+	}
+	onRoster := make(map[string]models.Student, len(roster))
+	for _, student := range roster {
+		onRoster[student.EntityID] = student
+	}
+
+	maxMarks := paperMaxMarks(*paper)
+	now := time.Now()
+
+	writes := make([]mongo.WriteModel, 0, len(req.Entries))
+	for i, entry := range req.Entries {
+		student, ok := onRoster[entry.StudentEntityID]
+		if !ok {
+			return nil, fmt.Errorf("entries[%d]: student %s does not sit %s in division %s", i, entry.StudentEntityID, paper.Subject, req.Div)
+		}
+
+		marks := 0.0
+		if entry.Marks != nil {
+			marks = *entry.Marks
+		}
+		if marks > maxMarks {
+			return nil, fmt.Errorf("entries[%d]: %g is more than the %g marks %s is out of", i, marks, maxMarks, paper.Subject)
+		}
+
+		fresh := models.NewExamMark()
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"exam_entity_id":    exam.EntityID,
+				"paper_entity_id":   paper.EntityID,
+				"student_entity_id": student.EntityID,
+				"is_deleted":        false,
+			}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"session_entity_id": exam.SessionEntityID,
+					"subject":           paper.Subject,
+					"class_entity_id":   student.ClassEntityID,
+					"div":               student.Div,
+					"marks":             marks,
+					"absent":            entry.Absent,
+					"remarks":           entry.Remarks,
+					"entered_by":        enteredBy,
+					"updated_at":        now,
+				},
+				"$unset": bson.M{
+					"moderated_from":    "",
+					"moderation_reason": "",
+					"moderated_by":      "",
+				},
+				"$setOnInsert": bson.M{
+					"_id":        fresh.ID,
+					"entity_id":  fresh.EntityID,
+					"created_at": fresh.CreatedAt,
+				},
+			}).
+			SetUpsert(true))
+	}
+
+	if _, err := database.Collection(ExamMarkCollection).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return nil, err
+	}
+
+	return buildExamMarkSheet(ctx, database, exam, paper, state.Status, &req.Div)
+}
+
+//
+// ================= SHEET =================
+//
+
+func (s *examMarksService) GetSheet(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+	req *requests.ExamMarkSheetRequest,
+) (*models.ExamMarkSheet, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+	paper, err := findExamPaper(exam, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	state, err := loadExamMarkState(ctx, database, exam.EntityID, paper.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildExamMarkSheet(ctx, database, exam, paper, state.Status, req.Div)
+}
+
+//
+// ================= MODERATION =================
+//
+
+// Moderate closes entry for a paper once every student has marks, applying the
+// moderator's adjustments. The marks as first entered are kept alongside.
+func (s *examMarksService) Moderate(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+	req *requests.ModerateExamMarksRequest,
+	moderatedBy models.PaymentActor,
+) (*models.ExamMarkSheet, error) {
+
+	exam, paper, database, err := loadWritableExamPaper(ctx, companyCode, examID, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadExamMarkState(ctx, database, exam.EntityID, paper.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Status == models.ExamMarksLocked {
+		return nil, fmt.Errorf("marks for %s are locked; unlock them to moderate", paper.Subject)
+	}
+
+	sheet, err := buildExamMarkSheet(ctx, database, exam, paper, state.Status, nil)
+	if err != nil {
+		return nil, err
+	}
+	if sheet.Pending > 0 {
+		return nil, fmt.Errorf("marks for %s are still missing for %d students", paper.Subject, sheet.Pending)
+	}
+
+	marks, err := loadExamMarks(ctx, database, bson.M{"exam_entity_id": exam.EntityID, "paper_entity_id": paper.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	byStudent := make(map[string]models.ExamMark, len(marks))
+	for _, mark := range marks {
+		byStudent[mark.StudentEntityID] = mark
+	}
+
+	maxMarks := paperMaxMarks(*paper)
+	now := time.Now()
+
+	writes := make([]mongo.WriteModel, 0, len(req.Adjustments))
+	for i, adjustment := range req.Adjustments {
+		mark, ok := byStudent[adjustment.StudentEntityID]
+		if !ok {
+			return nil, fmt.Errorf("adjustments[%d]: student %s has no marks for %s", i, adjustment.StudentEntityID, paper.Subject)
+		}
+		if mark.Absent {
+			return nil, fmt.Errorf("adjustments[%d]: student %s was absent for %s", i, adjustment.StudentEntityID, paper.Subject)
+		}
+		if adjustment.Marks > maxMarks {
+			return nil, fmt.Errorf("adjustments[%d]: %g is more than the %g marks %s is out of", i, adjustment.Marks, maxMarks, paper.Subject)
+		}
+
+		// Moderating twice still remembers the marks as entered
+		original := mark.Marks
+		if mark.ModeratedFrom != nil {
+			original = *mark.ModeratedFrom
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": mark.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"marks":             adjustment.Marks,
+				"moderated_from":    original,
+				"moderation_reason": adjustment.Reason,
+				"moderated_by":      moderatedBy,
+				"updated_at":        now,
+			}}))
+	}
+	if len(writes) > 0 {
+		if _, err := database.Collection(ExamMarkCollection).BulkWrite(ctx, writes); err != nil {
+			return nil, err
+		}
+	}
+
+	reason := "marks accepted as entered"
+	if len(writes) > 0 {
+		reason = fmt.Sprintf("%d marks adjusted", len(writes))
+	}
+	if _, err := changeExamMarkState(ctx, database, exam, paper, state.Status, models.ExamMarksModerated, reason, moderatedBy); err != nil {
+		return nil, err
+	}
+
+	return buildExamMarkSheet(ctx, database, exam, paper, models.ExamMarksModerated, nil)
+}
+
+//
+// ================= STATUS =================
+//
+
+// ChangeStatus locks moderated marks, reopens them for entry, or unlocks locked marks
+// for another round of moderation
+func (s *examMarksService) ChangeStatus(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+	req *requests.ChangeExamMarksStatusRequest,
+	changedBy models.PaymentActor,
+) (*models.ExamMarkState, error) {
+
+	exam, paper, database, err := loadWritableExamPaper(ctx, companyCode, examID, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadExamMarkState(ctx, database, exam.EntityID, paper.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExamMarkTransition(state.Status, req.Status, req.Reason); err != nil {
+		return nil, err
+	}
+
+	return changeExamMarkState(ctx, database, exam, paper, state.Status, req.Status, req.Reason, changedBy)
+}
+
+//
+// ================= HELPERS =================
+//
+
+// examMarkIndexes gives a student one mark per paper and a paper one state, so
+// concurrent entry and status changes cannot create duplicates
+var examMarkIndexes = []companyIndex{
+	{
+		Collection: ExamMarkCollection,
+		Model: mongo.IndexModel{
+			Keys: bson.D{{Key: "exam_entity_id", Value: 1}, {Key: "paper_entity_id", Value: 1}, {Key: "student_entity_id", Value: 1}},
+			Options: options.Index().
+				SetName("exam_paper_student_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"is_deleted": false}),
+		},
+	},
+	{
+		Collection: ExamMarkStateCollection,
+		Model: mongo.IndexModel{
+			Keys:    bson.D{{Key: "exam_entity_id", Value: 1}, {Key: "paper_entity_id", Value: 1}},
+			Options: options.Index().SetName("exam_paper_unique").SetUnique(true),
+		},
+	},
+}
+
+// loadWritableExamPaper finds a paper of an exam whose session still takes changes
+func loadWritableExamPaper(ctx context.Context, companyCode string, examID string, paperID string) (*models.Exam, *models.ExamPaper, *mongo.Database, error) {
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	paper, err := findExamPaper(exam, paperID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, exam.SessionEntityID); err != nil {
+		return nil, nil, nil, err
+	}
+	return exam, paper, database, nil
+}
+
+func loadExamMarkState(ctx context.Context, database *mongo.Database, examEntityID, paperEntityID string) (*models.ExamMarkState, error) {
+	var state models.ExamMarkState
+	err := database.Collection(ExamMarkStateCollection).FindOne(ctx, bson.M{
+		"exam_entity_id":  examEntityID,
+		"paper_entity_id": paperEntityID,
+	}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		state := models.NewExamMarkState()
+		state.ExamEntityID = examEntityID
+		state.PaperEntityID = paperEntityID
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// loadExamMarkStatuses maps each paper of an exam to the status of its marks
+func loadExamMarkStatuses(ctx context.Context, database *mongo.Database, exam *models.Exam) (map[string]string, error) {
+	cursor, err := database.Collection(ExamMarkStateCollection).Find(ctx, bson.M{"exam_entity_id": exam.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var states []models.ExamMarkState
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string, len(exam.Schedule))
+	for _, paper := range exam.Schedule {
+		statuses[paper.EntityID] = models.ExamMarksDraft
+	}
+	for _, state := range states {
+		statuses[state.PaperEntityID] = state.Status
+	}
+	return statuses, nil
+}
+
+// changeExamMarkState moves a paper's marks from one status to another. The update only
+// matches while the status is still from, so a concurrent change fails on the unique
+// index instead of being overwritten.
+func changeExamMarkState(
+	ctx context.Context,
+	database *mongo.Database,
+	exam *models.Exam,
+	paper *models.ExamPaper,
+	from, to, reason string,
+	changedBy models.PaymentActor,
+) (*models.ExamMarkState, error) {
+
+	defaults := models.NewExamMarkState()
+	now := time.Now()
+	change := models.ExamMarkStateChange{
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		ChangedBy: &changedBy,
+		ChangedAt: now,
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var state models.ExamMarkState
+	err := database.Collection(ExamMarkStateCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"exam_entity_id":  exam.EntityID,
+			"paper_entity_id": paper.EntityID,
+			"status":          from,
+		},
+		bson.M{
+			"$set":  bson.M{"status": to, "updated_at": now},
+			"$push": bson.M{"history": change},
+			"$setOnInsert": bson.M{
+				"_id":        defaults.ID,
+				"entity_id":  defaults.EntityID,
+				"created_at": now,
+			},
+		}, opts).Decode(&state)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("marks for %s were changed by someone else; reload and try again", paper.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// checkExamMarkTransition allows locking or reopening moderated marks, and unlocking
+// locked marks with a reason. Draft marks move on only through moderation.
+func checkExamMarkTransition(from, to, reason string) error {
+	switch {
+	case from == models.ExamMarksModerated && (to == models.ExamMarksDraft || to == models.ExamMarksLocked):
+		return nil
+	case from == models.ExamMarksLocked && to == models.ExamMarksModerated:
+		if reason == "" {
+			return errors.New("a reason is required to unlock marks")
+		}
+		return nil
+	case from == models.ExamMarksDraft && to == models.ExamMarksModerated:
+		return errors.New("draft marks are moderated through the moderation endpoint")
+	case from == to:
+		return fmt.Errorf("marks are already %s", to)
+	}
+	return fmt.Errorf("marks cannot move from %s to %s", from, to)
+}
+
+// examMarksRoster lists the students who sit an exam, in one division when div is set:
+// every active student of the class for compulsory exams, only those who paid otherwise
+func examMarksRoster(ctx context.Context, database *mongo.Database, exam *models.Exam, div *string) ([]models.Student, error) {
+	students, err := examCandidates(ctx, database, exam, !isCompulsoryFee(exam.FeesType, exam.FeesPaid))
+	if err != nil {
+		return nil, err
+	}
+	if div == nil || *div == "" {
+		return students, nil
+	}
+
+	kept := students[:0]
+	for _, student := range students {
+		if strings.EqualFold(requests.NormaliseDivision(student.Div), *div) {
+			kept = append(kept, student)
+		}
+	}
+	return kept, nil
+}
+
+func loadExamMarks(ctx context.Context, database *mongo.Database, filter bson.M) ([]models.ExamMark, error) {
+	filter["is_deleted"] = false
+
+	cursor, err := database.Collection(ExamMarkCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var marks []models.ExamMark
+	if err := cursor.All(ctx, &marks); err != nil {
+		return nil, err
+	}
+	return marks, nil
+}
+
+func buildExamMarkSheet(
+	ctx context.Context,
+	database *mongo.Database,
+	exam *models.Exam,
+	paper *models.ExamPaper,
+	status string,
+	div *string,
+) (*models.ExamMarkSheet, error) {
+
+	roster, err := examMarksRoster(ctx, database, exam, div)
+	if err != nil {
+		return nil, err
+	}
+
+	marks, err := loadExamMarks(ctx, database, bson.M{
+		"exam_entity_id":    exam.EntityID,
+		"paper_entity_id":   paper.EntityID,
+		"student_entity_id": bson.M{"$in": studentEntityIDs(roster)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scale, err := loadGradingScale(ctx, database, exam.BoardEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &models.ExamMarkSheet{
+		ExamEntityID:  exam.EntityID,
+		ExamName:      exam.ExamName,
+		PaperEntityID: paper.EntityID,
+		Subject:       paper.Subject,
+		MaxMarks:      paperMaxMarks(*paper),
+		PassMarks:     paperPassMarks(*paper, scale),
+		Status:        status,
+		Entries:       fillExamMarkEntries(roster, marks),
+	}
+	if div != nil {
+		sheet.Div = *div
+	}
+	for _, entry := range sheet.Entries {
+		if entry.Entered {
+			sheet.Entered++
+		} else {
+			sheet.Pending++
+		}
+	}
+	return sheet, nil
+}
+
+// fillExamMarkEntries gives every student on the roster a row, entered or not
+func fillExamMarkEntries(roster []models.Student, marks []models.ExamMark) []models.ExamMarkEntry {
+	byStudent := make(map[string]models.ExamMark, len(marks))
+	for _, mark := range marks {
+		byStudent[mark.StudentEntityID] = mark
+	}
+
+	entries := make([]models.ExamMarkEntry, 0, len(roster))
+	for _, student := range roster {
+		entry := models.ExamMarkEntry{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+			Div:             student.Div,
+		}
+		if mark, ok := byStudent[student.EntityID]; ok {
+			entry.Entered = true
+			entry.Absent = mark.Absent
+			entry.Remarks = mark.Remarks
+			entry.ModeratedFrom = mark.ModeratedFrom
+			if !mark.Absent {
+				value := mark.Marks
+				entry.Marks = &value
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// paperMaxMarks treats papers scheduled before marks were tracked as out of 100
+func paperMaxMarks(paper models.ExamPaper) float64 {
+	if paper.MaxMarks > 0 {
+		return paper.MaxMarks
+	}
+	return 100
+}
+
+// paperPassMarks is the paper's own pass mark, or the board's pass percentage of its
+// maximum
+func paperPassMarks(paper models.ExamPaper, scale *models.GradingScale) float64 {
+	if paper.PassMarks > 0 {
+		return paper.PassMarks
+	}
+	return math.Round(paperMaxMarks(paper)*scale.PassPercent) / 100
+}
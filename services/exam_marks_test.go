@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestCheckExamMarkTransition(t *testing.T) {
+	cases := []struct {
+		from, to, reason string
+		ok               bool
+	}{
+		{models.ExamMarksModerated, models.ExamMarksLocked, "", true},
+		{models.ExamMarksModerated, models.ExamMarksDraft, "", true},
+		{models.ExamMarksLocked, models.ExamMarksModerated, "re-check of paper 2", true},
+		{models.ExamMarksLocked, models.ExamMarksModerated, "", false},
+		{models.ExamMarksLocked, models.ExamMarksDraft, "typo", false},
+		{models.ExamMarksDraft, models.ExamMarksModerated, "", false},
+		{models.ExamMarksDraft, models.ExamMarksLocked, "", false},
+		{models.ExamMarksLocked, models.ExamMarksLocked, "", false},
+	}
+	for _, tc := range cases {
+		err := checkExamMarkTransition(tc.from, tc.to, tc.reason)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s -> %s (reason %q): err = %v, want ok %v", tc.from, tc.to, tc.reason, err, tc.ok)
+		}
+	}
+}
+
+func TestFillExamMarkEntries(t *testing.T) {
+	roster := []models.Student{
+		{EntityID: "s1", RefNo: "R1", Div: "A"},
+		{EntityID: "s2", RefNo: "R2", Div: "A"},
+		{EntityID: "s3", RefNo: "R3", Div: "A"},
+	}
+	original := 38.0
+	marks := []models.ExamMark{
+		{StudentEntityID: "s1", Marks: 40, ModeratedFrom: &original},
+		{StudentEntityID: "s2", Absent: true},
+	}
+
+	entries := fillExamMarkEntries(roster, marks)
+	if len(entries) != 3 {
+		t.Fatalf("expected a row per student, got %d", len(entries))
+	}
+	if !entries[0].Entered || entries[0].Marks == nil || *entries[0].Marks != 40 || *entries[0].ModeratedFrom != 38 {
+		t.Errorf("unexpected moderated entry %+v", entries[0])
+	}
+	if !entries[1].Entered || !entries[1].Absent || entries[1].Marks != nil {
+		t.Errorf("an absent student should have no marks, got %+v", entries[1])
+	}
+	if entries[2].Entered {
+		t.Errorf("a student without marks should be pending, got %+v", entries[2])
+	}
+}
+
+func TestPaperPassMarks(t *testing.T) {
+	scale := models.NewGradingScale("board")
+
+	if got := paperPassMarks(models.ExamPaper{MaxMarks: 80}, scale); got != 26.4 {
+		t.Errorf("pass marks = %g, want 33%% of 80", got)
+	}
+	if got := paperPassMarks(models.ExamPaper{MaxMarks: 80, PassMarks: 28}, scale); got != 28 {
+		t.Errorf("pass marks = %g, want the paper's own 28", got)
+	}
+	// Papers scheduled before marks were tracked are out of 100
+	if got := paperPassMarks(models.ExamPaper{}, scale); got != 33 {
+		t.Errorf("pass marks = %g, want 33", got)
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ExamRoomCollection = "exam_rooms"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ExamRoomService interface {
+	Create(ctx context.Context, companyCode string, req *requests.CreateExamRoomRequest) (*models.ExamRoom, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.ExamRoom, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.ExamRoom, error)
+	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateExamRoomRequest) (*models.ExamRoom, error)
+	Delete(ctx context.Context, companyCode string, id string) error
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type examRoomService struct{}
+
+func NewExamRoomService() ExamRoomService {
+	return &examRoomService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+func (s *examRoomService) Create(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateExamRoomRequest,
+) (*models.ExamRoom, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	room := models.NewExamRoom()
+	room.Bind(req)
+
+	if err := checkExamRoomName(ctx, database, room.EntityID, room.Name); err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Collection(ExamRoomCollection).InsertOne(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+func (s *examRoomService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.ExamRoom, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := database.Collection(ExamRoomCollection).Find(ctx, bson.M{"is_deleted": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rooms := []*models.ExamRoom{}
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *examRoomService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.ExamRoom, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return findExamRoom(ctx, database, id)
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *examRoomService) Update(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.UpdateExamRoomRequest,
+) (*models.ExamRoom, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	current, err := findExamRoom(ctx, database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.NewUpdateExamRoom()
+	update.Bind(req)
+
+	updateFields := bson.M{}
+	if update.Name != nil && *update.Name != current.Name {
+		if err := checkExamRoomName(ctx, database, current.EntityID, *update.Name); err != nil {
+			return nil, err
+		}
+		updateFields["name"] = *update.Name
+	}
+	if update.Building != nil {
+		updateFields["building"] = *update.Building
+	}
+	if update.Capacity != nil {
+		updateFields["capacity"] = *update.Capacity
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.ExamRoom
+	err = database.Collection(ExamRoomCollection).
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID}, bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+// Delete removes a room. Seating plans already made keep the room's name.
+func (s *examRoomService) Delete(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	room, err := findExamRoom(ctx, database, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Collection(ExamRoomCollection).UpdateOne(ctx, bson.M{"_id": room.ID}, bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
+//
+// ================= HELPERS =================
+//
+
+func findExamRoom(ctx context.Context, database *mongo.Database, id string) (*models.ExamRoom, error) {
+	filter := bson.M{"entity_id": id, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": oid, "is_deleted": false}
+	}
+
+	var room models.ExamRoom
+	err := database.Collection(ExamRoomCollection).FindOne(ctx, filter).Decode(&room)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("exam room not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// checkExamRoomName keeps room names unique, ignoring case
+func checkExamRoomName(ctx context.Context, database *mongo.Database, entityID, name string) error {
+	cursor, err := database.Collection(ExamRoomCollection).Find(ctx, bson.M{
+		"entity_id":  bson.M{"$ne": entityID},
+		"is_deleted": false,
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var rooms []models.ExamRoom
+	if err := cursor.All(ctx, &rooms); err != nil {
+		return err
+	}
+	for _, room := range rooms {
+		if strings.EqualFold(room.Name, name) {
+			return fmt.Errorf("exam room %s already exists", name)
+		}
+	}
+	return nil
+}
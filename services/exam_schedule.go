@@ -0,0 +1,672 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ExamSeatingPlanCollection = "exam_seating_plans"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ExamScheduleService interface {
+	UpdateSchedule(ctx context.Context, companyCode string, examID string, req *requests.UpdateExamScheduleRequest) (*models.Exam, error)
+	GenerateSeatingPlan(ctx context.Context, companyCode string, examID string, paperID string, req *requests.GenerateSeatingPlanRequest, generatedBy models.PaymentActor) (*models.ExamSeatingPlan, error)
+	GetSeatingPlan(ctx context.Context, companyCode string, examID string, paperID string) (*models.ExamSeatingPlan, error)
+	GetConflicts(ctx context.Context, companyCode string, req *requests.ExamConflictsRequest) (*models.ExamConflictReport, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type examScheduleService struct{}
+
+func NewExamScheduleService() ExamScheduleService {
+	return &examScheduleService{}
+}
+
+//
+// ================= SCHEDULE =================
+//
+
+// UpdateSchedule replaces the papers of an exam. Seating plans of papers that were
+// dropped or moved to another time are removed, since their rooms may no longer be free.
+func (s *examScheduleService) UpdateSchedule(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	req *requests.UpdateExamScheduleRequest,
+) (*models.Exam, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, exam.SessionEntityID); err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]models.ExamPaper, len(exam.Schedule))
+	for _, paper := range exam.Schedule {
+		current[paper.EntityID] = paper
+	}
+
+	papers := make([]models.ExamPaper, 0, len(req.Papers))
+	for _, paperReq := range req.Papers {
+		if paperReq.EntityID != "" {
+			if _, ok := current[paperReq.EntityID]; !ok {
+				return nil, fmt.Errorf("paper %s is not part of this exam", paperReq.EntityID)
+			}
+		}
+		papers = append(papers, models.NewExamPaper(paperReq))
+	}
+	sortExamPapers(papers)
+
+	// Every student of the exam sits all of its papers, so they cannot overlap
+	for i := range papers {
+		for j := i + 1; j < len(papers); j++ {
+			if papersOverlap(papers[i], papers[j]) {
+				return nil, fmt.Errorf("papers %s and %s overlap on %s", papers[i].Subject, papers[j].Subject, papers[i].Date)
+			}
+		}
+	}
+
+	// Moderated and locked marks were checked against the paper as it is
+	statuses, err := loadExamMarkStatuses(ctx, database, exam)
+	if err != nil {
+		return nil, err
+	}
+	for id, before := range current {
+		if statuses[id] == models.ExamMarksDraft {
+			continue
+		}
+		after, ok := findPaper(papers, id)
+		if !ok {
+			return nil, fmt.Errorf("marks for %s are %s; reopen them before removing the paper", before.Subject, statuses[id])
+		}
+		if paperMaxMarks(after) != paperMaxMarks(before) || after.PassMarks != before.PassMarks {
+			return nil, fmt.Errorf("marks for %s are %s; reopen them before changing its marks", before.Subject, statuses[id])
+		}
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Exam
+	err = database.Collection(ExamCollection).
+		FindOneAndUpdate(ctx, bson.M{"_id": exam.ID}, bson.M{"$set": bson.M{"schedule": papers, "updated_at": now}}, opts).
+		Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]models.ExamPaper, len(papers))
+	for _, paper := range papers {
+		kept[paper.EntityID] = paper
+	}
+	var stale []string
+	for id, before := range current {
+		after, ok := kept[id]
+		if !ok || after.Date != before.Date || after.StartTime != before.StartTime || after.EndTime != before.EndTime {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		_, err = database.Collection(ExamSeatingPlanCollection).UpdateMany(ctx, bson.M{
+			"exam_entity_id":  exam.EntityID,
+			"paper_entity_id": bson.M{"$in": stale},
+			"is_deleted":      false,
+		}, bson.M{"$set": bson.M{"is_deleted": true, "updated_at": now}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= SEATING PLAN =================
+//
+
+// GenerateSeatingPlan seats every active student of the exam's class who has paid for it,
+// filling the rooms in order. Seats taken in the same rooms by overlapping papers are
+// left free, and students already seated for an overlapping paper are reported instead.
+func (s *examScheduleService) GenerateSeatingPlan(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+	req *requests.GenerateSeatingPlanRequest,
+	generatedBy models.PaymentActor,
+) (*models.ExamSeatingPlan, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+	paper, err := findExamPaper(exam, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, exam.SessionEntityID); err != nil {
+		return nil, err
+	}
+
+	students, err := examCandidates(ctx, database, exam, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(students) == 0 {
+		return nil, errors.New("no active students have paid for this exam")
+	}
+
+	// Plans of other papers sat at the same time hold seats and students
+	overlapping, err := overlappingSeatingPlans(ctx, database, paper)
+	if err != nil {
+		return nil, err
+	}
+	seatedElsewhere := make(map[string]string)
+	takenSeats := make(map[string]map[int]bool)
+	for _, other := range overlapping {
+		for _, seat := range other.Seats {
+			seatedElsewhere[seat.StudentEntityID] = fmt.Sprintf("%s (%s-%s)", other.Subject, other.StartTime, other.EndTime)
+			if takenSeats[seat.RoomEntityID] == nil {
+				takenSeats[seat.RoomEntityID] = make(map[int]bool)
+			}
+			takenSeats[seat.RoomEntityID][seat.SeatNo] = true
+		}
+	}
+
+	plan := models.NewExamSeatingPlan()
+	plan.SessionEntityID = exam.SessionEntityID
+	plan.ExamEntityID = exam.EntityID
+	plan.PaperEntityID = paper.EntityID
+	plan.Subject = paper.Subject
+	plan.Date = paper.Date
+	plan.StartTime = paper.StartTime
+	plan.EndTime = paper.EndTime
+	plan.GeneratedBy = &generatedBy
+	plan.Conflicts = make([]models.ExamSeat, 0)
+
+	candidates := make([]models.ExamSeat, 0, len(students))
+	for _, student := range students {
+		seat := models.ExamSeat{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+			Div:             student.Div,
+		}
+		if other, ok := seatedElsewhere[student.EntityID]; ok {
+			seat.ConflictsWith = other
+			plan.Conflicts = append(plan.Conflicts, seat)
+			continue
+		}
+		candidates = append(candidates, seat)
+	}
+
+	rooms, err := seatingRooms(ctx, database, req.RoomEntityIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rooms {
+		rooms[i].taken = takenSeats[rooms[i].usage.RoomEntityID]
+	}
+
+	plan.Seats, plan.Rooms, err = allocateSeats(candidates, rooms)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = database.Collection(ExamSeatingPlanCollection).UpdateMany(ctx, bson.M{
+		"exam_entity_id":  exam.EntityID,
+		"paper_entity_id": paper.EntityID,
+		"is_deleted":      false,
+	}, bson.M{"$set": bson.M{"is_deleted": true, "updated_at": now}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := database.Collection(ExamSeatingPlanCollection).InsertOne(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func (s *examScheduleService) GetSeatingPlan(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	paperID string,
+) (*models.ExamSeatingPlan, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	var plan models.ExamSeatingPlan
+	err = database.Collection(ExamSeatingPlanCollection).FindOne(ctx, bson.M{
+		"exam_entity_id":  exam.EntityID,
+		"paper_entity_id": paperID,
+		"is_deleted":      false,
+	}).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("no seating plan has been generated for this paper")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+//
+// ================= CONFLICTS =================
+//
+
+// GetConflicts lists students due to sit two papers whose times overlap. Compulsory
+// exams count for every active student of their class, optional ones only once paid.
+func (s *examScheduleService) GetConflicts(
+	ctx context.Context,
+	companyCode string,
+	req *requests.ExamConflictsRequest,
+) (*models.ExamConflictReport, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := database.Collection(ExamCollection).Find(ctx, withSession(bson.M{
+		"is_deleted":    false,
+		"schedule.0":    bson.M{"$exists": true},
+		"schedule.date": examDateRange(req.From, req.To),
+	}, session))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var exams []models.Exam
+	if err := cursor.All(ctx, &exams); err != nil {
+		return nil, err
+	}
+
+	report := &models.ExamConflictReport{Conflicts: make([]models.ExamConflict, 0)}
+	if session != nil {
+		report.SessionEntityID = session.EntityID
+	}
+
+	sittings := make(map[string][]models.ExamPaperRef)
+	students := make(map[string]models.Student)
+	for i := range exams {
+		exam := &exams[i]
+
+		var refs []models.ExamPaperRef
+		for _, paper := range exam.Schedule {
+			if !dateWithin(paper.Date, req.From, req.To) {
+				continue
+			}
+			refs = append(refs, models.ExamPaperRef{
+				ExamEntityID:  exam.EntityID,
+				ExamName:      exam.ExamName,
+				PaperEntityID: paper.EntityID,
+				Subject:       paper.Subject,
+				Date:          paper.Date,
+				StartTime:     paper.StartTime,
+				EndTime:       paper.EndTime,
+			})
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		report.PapersChecked += len(refs)
+
+		candidates, err := examCandidates(ctx, database, exam, !isCompulsoryFee(exam.FeesType, exam.FeesPaid))
+		if err != nil {
+			return nil, err
+		}
+		for _, student := range candidates {
+			students[student.EntityID] = student
+			sittings[student.EntityID] = append(sittings[student.EntityID], refs...)
+		}
+	}
+	report.StudentsChecked = len(students)
+
+	for studentEntityID, refs := range sittings {
+		student := students[studentEntityID]
+		for _, pair := range findPaperOverlaps(refs) {
+			report.Conflicts = append(report.Conflicts, models.ExamConflict{
+				StudentEntityID: student.EntityID,
+				RefNo:           student.RefNo,
+				Name:            studentFullName(student),
+				ClassEntityID:   student.ClassEntityID,
+				Div:             student.Div,
+				Papers:          []models.ExamPaperRef{refs[pair[0]], refs[pair[1]]},
+			})
+		}
+	}
+
+	sort.Slice(report.Conflicts, func(i, j int) bool {
+		a, b := report.Conflicts[i], report.Conflicts[j]
+		if a.Papers[0].Date != b.Papers[0].Date {
+			return a.Papers[0].Date < b.Papers[0].Date
+		}
+		if a.Papers[0].StartTime != b.Papers[0].StartTime {
+			return a.Papers[0].StartTime < b.Papers[0].StartTime
+		}
+		return a.RefNo < b.RefNo
+	})
+
+	return report, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// seatingRoom is a room being filled, with the seat numbers other papers already hold
+type seatingRoom struct {
+	usage models.ExamRoomUsage
+	taken map[int]bool
+}
+
+// allocateSeats fills the rooms in order, giving each student the lowest free seat
+// number. It fails when the rooms cannot hold everyone.
+func allocateSeats(students []models.ExamSeat, rooms []seatingRoom) ([]models.ExamSeat, []models.ExamRoomUsage, error) {
+	free := 0
+	for i := range rooms {
+		rooms[i].usage.Available = rooms[i].usage.Capacity
+		for seatNo := range rooms[i].taken {
+			if seatNo <= rooms[i].usage.Capacity {
+				rooms[i].usage.Available--
+			}
+		}
+		free += rooms[i].usage.Available
+	}
+	if free < len(students) {
+		return nil, nil, fmt.Errorf("the rooms have %d free seats for %d students; add %d more seats", free, len(students), len(students)-free)
+	}
+
+	seats := make([]models.ExamSeat, 0, len(students))
+	room, seatNo := 0, 0
+	for _, student := range students {
+		for {
+			seatNo++
+			if seatNo > rooms[room].usage.Capacity {
+				room++
+				seatNo = 0
+				continue
+			}
+			if !rooms[room].taken[seatNo] {
+				break
+			}
+		}
+		student.RoomEntityID = rooms[room].usage.RoomEntityID
+		student.RoomName = rooms[room].usage.Name
+		student.SeatNo = seatNo
+		rooms[room].usage.Allocated++
+		seats = append(seats, student)
+	}
+
+	usage := make([]models.ExamRoomUsage, 0, len(rooms))
+	for _, r := range rooms {
+		if r.usage.Allocated > 0 {
+			usage = append(usage, r.usage)
+		}
+	}
+	return seats, usage, nil
+}
+
+// seatingRooms returns the requested rooms in the order given, or every room with the
+// largest first
+func seatingRooms(ctx context.Context, database *mongo.Database, roomEntityIDs []string) ([]seatingRoom, error) {
+	filter := bson.M{"is_deleted": false}
+	if len(roomEntityIDs) > 0 {
+		filter["entity_id"] = bson.M{"$in": roomEntityIDs}
+	}
+
+	cursor, err := database.Collection(ExamRoomCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.ExamRoom
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, errors.New("no exam rooms to seat students in")
+	}
+
+	if len(roomEntityIDs) > 0 {
+		byID := make(map[string]models.ExamRoom, len(found))
+		for _, room := range found {
+			byID[room.EntityID] = room
+		}
+		ordered := make([]models.ExamRoom, 0, len(roomEntityIDs))
+		var missing []string
+		for _, id := range roomEntityIDs {
+			room, ok := byID[id]
+			if !ok {
+				missing = append(missing, id)
+				continue
+			}
+			ordered = append(ordered, room)
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("exam rooms not found: %s", strings.Join(missing, ", "))
+		}
+		found = ordered
+	} else {
+		sort.SliceStable(found, func(i, j int) bool {
+			if found[i].Capacity != found[j].Capacity {
+				return found[i].Capacity > found[j].Capacity
+			}
+			return found[i].Name < found[j].Name
+		})
+	}
+
+	rooms := make([]seatingRoom, 0, len(found))
+	for _, room := range found {
+		rooms = append(rooms, seatingRoom{usage: models.ExamRoomUsage{
+			RoomEntityID: room.EntityID,
+			Name:         room.Name,
+			Capacity:     room.Capacity,
+		}})
+	}
+	return rooms, nil
+}
+
+// overlappingSeatingPlans returns the plans of other papers sat at the same time
+func overlappingSeatingPlans(ctx context.Context, database *mongo.Database, paper *models.ExamPaper) ([]models.ExamSeatingPlan, error) {
+	cursor, err := database.Collection(ExamSeatingPlanCollection).Find(ctx, bson.M{
+		"date":            paper.Date,
+		"paper_entity_id": bson.M{"$ne": paper.EntityID},
+		"is_deleted":      false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var plans []models.ExamSeatingPlan
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+
+	overlapping := make([]models.ExamSeatingPlan, 0, len(plans))
+	for _, plan := range plans {
+		other := models.ExamPaper{Date: plan.Date, StartTime: plan.StartTime, EndTime: plan.EndTime}
+		if papersOverlap(*paper, other) {
+			overlapping = append(overlapping, plan)
+		}
+	}
+	return overlapping, nil
+}
+
+// examCandidates lists the active students of the exam's board and class in its session,
+// sorted by ref no, keeping only those with a settled payment for it when paidOnly is set
+func examCandidates(ctx context.Context, database *mongo.Database, exam *models.Exam, paidOnly bool) ([]models.Student, error) {
+	var session *models.AcademicSession
+	if exam.SessionEntityID != "" {
+		var err error
+		session, err = findAcademicSession(ctx, database, exam.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	students, err := findSessionStudents(ctx, database, session, bson.M{
+		"board_entity_id": exam.BoardEntityID,
+		"class_entity_id": exam.ClassEntityID,
+		"is_deleted":      false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	students = activeStudents(students)
+
+	if paidOnly {
+		paid, err := database.Collection("payment_scanners").Distinct(ctx, "student_entity_id", bson.M{
+			"exam_entity_id": exam.EntityID,
+			"status":         "paid",
+			"is_deleted":     false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		paidIDs := make(map[string]bool, len(paid))
+		for _, id := range paid {
+			if value, ok := id.(string); ok {
+				paidIDs[value] = true
+			}
+		}
+
+		kept := students[:0]
+		for _, student := range students {
+			if paidIDs[student.EntityID] {
+				kept = append(kept, student)
+			}
+		}
+		students = kept
+	}
+
+	sort.SliceStable(students, func(i, j int) bool {
+		if students[i].RefNo != students[j].RefNo {
+			return students[i].RefNo < students[j].RefNo
+		}
+		return studentFullName(students[i]) < studentFullName(students[j])
+	})
+	return students, nil
+}
+
+func findExamPaper(exam *models.Exam, paperID string) (*models.ExamPaper, error) {
+	for i := range exam.Schedule {
+		if exam.Schedule[i].EntityID == paperID {
+			return &exam.Schedule[i], nil
+		}
+	}
+	return nil, errors.New("paper not found in this exam's schedule")
+}
+
+func findPaper(papers []models.ExamPaper, entityID string) (models.ExamPaper, bool) {
+	for _, paper := range papers {
+		if paper.EntityID == entityID {
+			return paper, true
+		}
+	}
+	return models.ExamPaper{}, false
+}
+
+func sortExamPapers(papers []models.ExamPaper) {
+	sort.SliceStable(papers, func(i, j int) bool {
+		if papers[i].Date != papers[j].Date {
+			return papers[i].Date < papers[j].Date
+		}
+		return papers[i].StartTime < papers[j].StartTime
+	})
+}
+
+// papersOverlap reports whether two papers are sat at the same time. A paper ending
+// as another starts does not overlap it.
+func papersOverlap(a, b models.ExamPaper) bool {
+	if a.Date != b.Date {
+		return false
+	}
+	aStart, errA := requests.ParseClock(a.StartTime)
+	aEnd, errB := requests.ParseClock(a.EndTime)
+	bStart, errC := requests.ParseClock(b.StartTime)
+	bEnd, errD := requests.ParseClock(b.EndTime)
+	if errA != nil || errB != nil || errC != nil || errD != nil {
+		return false
+	}
+	return aStart < bEnd && bStart < aEnd
+}
+
+// findPaperOverlaps returns the index pairs of overlapping papers
+func findPaperOverlaps(refs []models.ExamPaperRef) [][2]int {
+	var pairs [][2]int
+	for i := range refs {
+		a := models.ExamPaper{Date: refs[i].Date, StartTime: refs[i].StartTime, EndTime: refs[i].EndTime}
+		for j := i + 1; j < len(refs); j++ {
+			if refs[i].PaperEntityID == refs[j].PaperEntityID {
+				continue
+			}
+			b := models.ExamPaper{Date: refs[j].Date, StartTime: refs[j].StartTime, EndTime: refs[j].EndTime}
+			if papersOverlap(a, b) {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
+
+// examDateRange matches exams with a paper between from and to, either bound optional
+func examDateRange(from, to *string) bson.M {
+	dates := bson.M{"$exists": true}
+	if from != nil && *from != "" {
+		dates["$gte"] = *from
+	}
+	if to != nil && *to != "" {
+		dates["$lte"] = *to
+	}
+	return dates
+}
+
+func dateWithin(date string, from, to *string) bool {
+	if from != nil && *from != "" && date < *from {
+		return false
+	}
+	if to != nil && *to != "" && date > *to {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestPapersOverlap(t *testing.T) {
+	maths := models.ExamPaper{Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00"}
+
+	cases := []struct {
+		name  string
+		other models.ExamPaper
+		want  bool
+	}{
+		{"same slot", models.ExamPaper{Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00"}, true},
+		{"starts inside", models.ExamPaper{Date: "2026-11-02", StartTime: "11:30", EndTime: "13:00"}, true},
+		{"contains", models.ExamPaper{Date: "2026-11-02", StartTime: "08:00", EndTime: "13:00"}, true},
+		{"back to back", models.ExamPaper{Date: "2026-11-02", StartTime: "12:00", EndTime: "14:00"}, false},
+		{"another day", models.ExamPaper{Date: "2026-11-03", StartTime: "09:00", EndTime: "12:00"}, false},
+	}
+	for _, tc := range cases {
+		if got := papersOverlap(maths, tc.other); got != tc.want {
+			t.Errorf("%s: papersOverlap = %v, want %v", tc.name, got, tc.want)
+		}
+		if got := papersOverlap(tc.other, maths); got != tc.want {
+			t.Errorf("%s: papersOverlap is not symmetric", tc.name)
+		}
+	}
+}
+
+func TestAllocateSeats(t *testing.T) {
+	students := []models.ExamSeat{
+		{StudentEntityID: "s1"}, {StudentEntityID: "s2"}, {StudentEntityID: "s3"}, {StudentEntityID: "s4"},
+	}
+	rooms := []seatingRoom{
+		{usage: models.ExamRoomUsage{RoomEntityID: "r1", Name: "Hall A", Capacity: 3}, taken: map[int]bool{2: true}},
+		{usage: models.ExamRoomUsage{RoomEntityID: "r2", Name: "Hall B", Capacity: 5}},
+		{usage: models.ExamRoomUsage{RoomEntityID: "r3", Name: "Hall C", Capacity: 5}},
+	}
+
+	seats, usage, err := allocateSeats(students, rooms)
+	if err != nil {
+		t.Fatalf("allocateSeats: %v", err)
+	}
+
+	want := []struct {
+		room string
+		seat int
+	}{{"r1", 1}, {"r1", 3}, {"r2", 1}, {"r2", 2}}
+	for i, seat := range seats {
+		if seat.RoomEntityID != want[i].room || seat.SeatNo != want[i].seat {
+			t.Errorf("student %s seated in %s/%d, want %s/%d", seat.StudentEntityID, seat.RoomEntityID, seat.SeatNo, want[i].room, want[i].seat)
+		}
+	}
+
+	// Unused rooms are left out of the plan
+	if len(usage) != 2 {
+		t.Fatalf("expected 2 rooms in use, got %d", len(usage))
+	}
+	if usage[0].Available != 2 || usage[0].Allocated != 2 || usage[1].Allocated != 2 {
+		t.Errorf("unexpected room usage %+v", usage)
+	}
+}
+
+func TestAllocateSeatsShortfall(t *testing.T) {
+	students := []models.ExamSeat{{StudentEntityID: "s1"}, {StudentEntityID: "s2"}, {StudentEntityID: "s3"}}
+	rooms := []seatingRoom{
+		// A seat number beyond the room's current capacity does not reduce it further
+		{usage: models.ExamRoomUsage{RoomEntityID: "r1", Capacity: 2}, taken: map[int]bool{1: true, 4: true}},
+		{usage: models.ExamRoomUsage{RoomEntityID: "r2", Capacity: 1}},
+	}
+
+	if _, _, err := allocateSeats(students, rooms); err == nil {
+		t.Fatal("expected a shortfall of seats to be refused")
+	}
+}
+
+func TestFindPaperOverlaps(t *testing.T) {
+	refs := []models.ExamPaperRef{
+		{PaperEntityID: "p1", Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00"},
+		{PaperEntityID: "p2", Date: "2026-11-02", StartTime: "14:00", EndTime: "16:00"},
+		{PaperEntityID: "p3", Date: "2026-11-02", StartTime: "11:00", EndTime: "13:00"},
+		// The same paper reached through two routes is not a conflict
+		{PaperEntityID: "p1", Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00"},
+	}
+
+	pairs := findPaperOverlaps(refs)
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 overlapping pairs, got %v", pairs)
+	}
+	if pairs[0] != [2]int{0, 2} || pairs[1] != [2]int{2, 3} {
+		t.Errorf("unexpected pairs %v", pairs)
+	}
+}
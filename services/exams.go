@@ -25,7 +25,7 @@ const ExamCollection = "exams"
 
 type ExamService interface {
 	Create(ctx context.Context, companyCode string, req *requests.CreateExamRequest) (*models.Exam, error)
-	GetAll(ctx context.Context, companyCode string) ([]*models.Exam, error)
+	GetAll(ctx context.Context, companyCode string, sessionID string) ([]*models.Exam, error)
 	GetByID(ctx context.Context, companyCode string, id string) (*models.Exam, error)
 	GetByUUIDs(ctx context.Context, companyCode string, ids []string) ([]*models.Exam, error)
 	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateExamRequest) (*models.Exam, error)
@@ -52,15 +52,19 @@ func (s *examService) Create(
 	req *requests.CreateExamRequest,
 ) (*models.Exam, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(ExamCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(ExamCollection)
 
 	exam := models.NewExam()
 	exam.Bind(req)
 
-	_, err := collection.InsertOne(ctx, exam)
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	exam.SessionEntityID = sessionEntityID
+
+	_, err = collection.InsertOne(ctx, exam)
 	if err != nil {
 		return nil, err
 	}
@@ -72,17 +76,22 @@ func (s *examService) Create(
 // ================= GET ALL =================
 //
 
+// GetAll lists the exams of the given session, or of the active session when sessionID is empty
 func (s *examService) GetAll(
 	ctx context.Context,
 	companyCode string,
+	sessionID string,
 ) ([]*models.Exam, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(ExamCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(ExamCollection)
+
+	session, err := resolveSession(ctx, database, sessionID)
+	if err != nil {
+		return nil, err
+	}
 
-	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	cursor, err := collection.Find(ctx, withSession(bson.M{"is_deleted": false}, session))
 	if err != nil {
 		return nil, err
 	}
@@ -171,10 +180,16 @@ func (s *examService) Update(
 	req *requests.UpdateExamRequest,
 ) (*models.Exam, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(ExamCollection)
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, current.SessionEntityID); err != nil {
+		return nil, err
+	}
+	collection := database.Collection(ExamCollection)
 
 	updateFields := bson.M{}
 
@@ -196,6 +211,13 @@ func (s *examService) Update(
 	if req.FeesType != nil {
 		updateFields["fees_type"] = *req.FeesType
 	}
+	if req.DueDate != nil {
+		if *req.DueDate == "" {
+			updateFields["due_date"] = nil
+		} else if dueDate, err := time.Parse("2006-01-02", *req.DueDate); err == nil {
+			updateFields["due_date"] = dueDate
+		}
+	}
 	if req.IsDeleted != nil {
 		updateFields["is_deleted"] = *req.IsDeleted
 	}
@@ -208,14 +230,9 @@ func (s *examService) Update(
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
-	}
-
 	var updated models.Exam
-	err := collection.
-		FindOneAndUpdate(ctx, filter, bson.M{"$set": updateFields}, opts).
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{"$set": updateFields}, opts).
 		Decode(&updated)
 
 	if err == mongo.ErrNoDocuments {
@@ -238,17 +255,18 @@ func (s *examService) Delete(
 	id string,
 ) error {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(ExamCollection)
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return err
+	}
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, current.SessionEntityID); err != nil {
+		return err
 	}
+	collection := database.Collection(ExamCollection)
 
-	result, err := collection.UpdateOne(ctx, filter, bson.M{
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{
 		"$set": bson.M{
 			"is_deleted": true,
 			"updated_at": time.Now(),
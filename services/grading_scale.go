@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const GradingScaleCollection = "grading_scales"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type GradingScaleService interface {
+	Get(ctx context.Context, companyCode string, boardID string) (*models.GradingScale, error)
+	Update(ctx context.Context, companyCode string, boardID string, req *requests.UpdateGradingScaleRequest) (*models.GradingScale, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type gradingScaleService struct{}
+
+func NewGradingScaleService() GradingScaleService {
+	return &gradingScaleService{}
+}
+
+//
+// ================= GET =================
+//
+
+// Get returns the board's scale, or the default one while it has not saved its own
+func (s *gradingScaleService) Get(
+	ctx context.Context,
+	companyCode string,
+	boardID string,
+) (*models.GradingScale, error) {
+
+	board, err := NewBoardService().GetByID(ctx, companyCode, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return loadGradingScale(ctx, database, board.EntityID)
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *gradingScaleService) Update(
+	ctx context.Context,
+	companyCode string,
+	boardID string,
+	req *requests.UpdateGradingScaleRequest,
+) (*models.GradingScale, error) {
+
+	board, err := NewBoardService().GetByID(ctx, companyCode, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	current, err := loadGradingScale(ctx, database, board.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	current.Bind(req)
+	sortGradeBands(current.Bands)
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var scale models.GradingScale
+	err = database.Collection(GradingScaleCollection).FindOneAndUpdate(ctx,
+		bson.M{"board_entity_id": board.EntityID},
+		bson.M{
+			"$set": bson.M{
+				"bands":        current.Bands,
+				"pass_percent": current.PassPercent,
+				"updated_at":   time.Now(),
+			},
+			"$setOnInsert": bson.M{
+				"_id":        current.ID,
+				"entity_id":  current.EntityID,
+				"created_at": current.CreatedAt,
+			},
+		}, opts).Decode(&scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scale, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func loadGradingScale(ctx context.Context, database *mongo.Database, boardEntityID string) (*models.GradingScale, error) {
+	var scale models.GradingScale
+	err := database.Collection(GradingScaleCollection).FindOne(ctx, bson.M{"board_entity_id": boardEntityID}).Decode(&scale)
+	if err == mongo.ErrNoDocuments {
+		return models.NewGradingScale(boardEntityID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &scale, nil
+}
+
+// sortGradeBands puts the highest band first, the order gradeFor reads them in
+func sortGradeBands(bands []models.GradeBand) {
+	sort.SliceStable(bands, func(i, j int) bool {
+		return bands[i].MinPercent > bands[j].MinPercent
+	})
+}
+
+// gradeFor returns the first band whose minimum the percentage reaches
+func gradeFor(bands []models.GradeBand, percent float64) *models.GradeBand {
+	for i := range bands {
+		if percent >= bands[i].MinPercent {
+			return &bands[i]
+		}
+	}
+	return nil
+}
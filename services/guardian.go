@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const GuardianCollection = "guardians"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type GuardianService interface {
+	Create(ctx context.Context, companyCode string, studentID string, req *requests.CreateGuardianRequest) (*models.Guardian, error)
+	GetByStudent(ctx context.Context, companyCode string, studentID string) ([]*models.Guardian, error)
+	GetByID(ctx context.Context, companyCode string, studentID string, id string) (*models.Guardian, error)
+	Update(ctx context.Context, companyCode string, studentID string, id string, req *requests.UpdateGuardianRequest) (*models.Guardian, error)
+	Delete(ctx context.Context, companyCode string, studentID string, id string) error
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type guardianService struct{}
+
+func NewGuardianService() GuardianService {
+	return &guardianService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+// Create adds a guardian to the student. The first guardian of a student is always primary.
+func (s *guardianService) Create(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	req *requests.CreateGuardianRequest,
+) (*models.Guardian, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(GuardianCollection)
+
+	guardian := models.NewGuardian()
+	guardian.Bind(req)
+	guardian.StudentEntityID = student.EntityID
+	guardian.Phone = normalisePhone(guardian.Phone)
+	guardian.PhoneTokens = guardianPhoneTokens(guardian.Phone)
+	guardian.Email = strings.TrimSpace(guardian.Email)
+
+	existing, err := collection.CountDocuments(ctx, bson.M{"student_entity_id": student.EntityID, "is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	if existing == 0 {
+		guardian.IsPrimary = true
+	}
+
+	if guardian.IsPrimary {
+		if err := clearPrimaryGuardian(ctx, collection, student.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := collection.InsertOne(ctx, guardian); err != nil {
+		return nil, err
+	}
+
+	return guardian, nil
+}
+
+//
+// ================= GET BY STUDENT =================
+//
+
+// GetByStudent lists the student's guardians, primary first
+func (s *guardianService) GetByStudent(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+) ([]*models.Guardian, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	guardians, err := loadGuardians(ctx, database, []string{student.EntityID})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Guardian, 0, len(guardians[student.EntityID]))
+	for i := range guardians[student.EntityID] {
+		result = append(result, &guardians[student.EntityID][i])
+	}
+
+	return result, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *guardianService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+) (*models.Guardian, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(GuardianCollection)
+
+	var guardian models.Guardian
+	err = collection.FindOne(ctx, guardianFilter(student.EntityID, id)).Decode(&guardian)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("guardian not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &guardian, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *guardianService) Update(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+	req *requests.UpdateGuardianRequest,
+) (*models.Guardian, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(GuardianCollection)
+
+	update := models.NewUpdateGuardian()
+	update.Bind(req)
+
+	updateFields := bson.M{}
+
+	if update.Name != nil {
+		updateFields["name"] = *update.Name
+	}
+	if update.Relationship != nil {
+		updateFields["relationship"] = *update.Relationship
+	}
+	if update.Phone != nil {
+		updateFields["phone"] = normalisePhone(*update.Phone)
+		updateFields["phone_tokens"] = guardianPhoneTokens(*update.Phone)
+	}
+	if update.Email != nil {
+		updateFields["email"] = strings.TrimSpace(*update.Email)
+	}
+	if update.Address != nil {
+		updateFields["address"] = *update.Address
+	}
+	if update.IsPrimary != nil {
+		updateFields["is_primary"] = *update.IsPrimary
+	}
+	if update.IsDeleted != nil {
+		updateFields["is_deleted"] = *update.IsDeleted
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = time.Now()
+
+	// Resolve the guardian before touching the primary flag of the others
+	current, err := findGuardian(ctx, collection, student.EntityID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.IsPrimary != nil && *update.IsPrimary {
+		if err := clearPrimaryGuardian(ctx, collection, student.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.Guardian
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID}, bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("guardian not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if updated.IsDeleted && current.IsPrimary {
+		if err := promotePrimaryGuardian(ctx, collection, student.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+func (s *guardianService) Delete(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+	id string,
+) error {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(GuardianCollection)
+
+	guardian, err := findGuardian(ctx, collection, student.EntityID, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": guardian.ID, "is_deleted": false}, bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("guardian not found")
+	}
+
+	// The student keeps a primary contact while any guardian is left
+	if guardian.IsPrimary {
+		return promotePrimaryGuardian(ctx, collection, student.EntityID)
+	}
+
+	return nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func guardianFilter(studentEntityID string, id string) bson.M {
+	filter := bson.M{"student_entity_id": studentEntityID, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter["_id"] = oid
+	} else {
+		filter["entity_id"] = id
+	}
+	return filter
+}
+
+func findGuardian(ctx context.Context, collection *mongo.Collection, studentEntityID string, id string) (*models.Guardian, error) {
+	var guardian models.Guardian
+	err := collection.FindOne(ctx, guardianFilter(studentEntityID, id)).Decode(&guardian)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("guardian not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &guardian, nil
+}
+
+// promotePrimaryGuardian makes the student's longest-standing guardian primary when none
+// of the remaining guardians is
+func promotePrimaryGuardian(ctx context.Context, collection *mongo.Collection, studentEntityID string) error {
+	primaries, err := collection.CountDocuments(ctx, bson.M{"student_entity_id": studentEntityID, "is_primary": true, "is_deleted": false})
+	if err != nil || primaries > 0 {
+		return err
+	}
+
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	err = collection.FindOneAndUpdate(ctx,
+		bson.M{"student_entity_id": studentEntityID, "is_deleted": false},
+		bson.M{"$set": bson.M{"is_primary": true, "updated_at": time.Now()}},
+		opts,
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	return err
+}
+
+// clearPrimaryGuardian unsets the primary flag on the student's guardians before another one takes it
+func clearPrimaryGuardian(ctx context.Context, collection *mongo.Collection, studentEntityID string) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"student_entity_id": studentEntityID, "is_primary": true, "is_deleted": false},
+		bson.M{"$set": bson.M{"is_primary": false, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// loadGuardians returns the live guardians of the given students, primary first
+func loadGuardians(ctx context.Context, database *mongo.Database, studentEntityIDs []string) (map[string][]models.Guardian, error) {
+	result := make(map[string][]models.Guardian)
+	if len(studentEntityIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := database.Collection(GuardianCollection).Find(ctx, bson.M{
+		"student_entity_id": bson.M{"$in": studentEntityIDs},
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var guardians []models.Guardian
+	if err := cursor.All(ctx, &guardians); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(guardians, func(i, j int) bool {
+		if guardians[i].IsPrimary != guardians[j].IsPrimary {
+			return guardians[i].IsPrimary
+		}
+		return guardians[i].CreatedAt.Before(guardians[j].CreatedAt)
+	})
+
+	for _, guardian := range guardians {
+		result[guardian.StudentEntityID] = append(result[guardian.StudentEntityID], guardian)
+	}
+
+	return result, nil
+}
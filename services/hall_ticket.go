@@ -0,0 +1,668 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"shared/infra/db/mdb"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// hallTicketCodePrefix versions the hall ticket QR format:
+// SBH1:<company>:<exam_entity_id>:<student_entity_id>:<signature>. It is signed with the
+// receipt signing key; the prefix keeps the two kinds of code from standing in for each other.
+const hallTicketCodePrefix = "SBH1"
+
+// Two tickets to an A4 page, cut along the dashed line between them
+const (
+	hallTicketWidth  = 180.0
+	hallTicketHeight = 135.0
+	hallTicketGap    = 3.0
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type HallTicketService interface {
+	Render(ctx context.Context, companyCode string, examID string, req *requests.HallTicketRequest) ([]byte, error)
+	GetBlocked(ctx context.Context, companyCode string, examID string, req *requests.HallTicketRequest) (*models.HallTicketBlockedList, error)
+	Verify(ctx context.Context, companyCode string, req *requests.VerifyHallTicketRequest) (*models.HallTicketVerification, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type hallTicketService struct{}
+
+func NewHallTicketService() HallTicketService {
+	return &hallTicketService{}
+}
+
+// hallTicket is everything printed on one ticket
+type hallTicket struct {
+	Name     string
+	RefNo    string
+	Class    string
+	Board    string
+	Code     string
+	Photo    []byte
+	PhotoExt string
+	Papers   []models.HallTicketPaper
+}
+
+// hallTicketSheet is the school branding and exam shared by every ticket on a sheet
+type hallTicketSheet struct {
+	SchoolName string
+	Logo       []byte
+	LogoExt    string
+	ExamName   string
+	Session    string
+}
+
+// hallTicketPayment is where a student stands with the fee for an exam
+type hallTicketPayment struct {
+	Paid    bool
+	Status  string
+	Payment *models.PaymentScanner
+}
+
+//
+// ================= RENDER =================
+//
+
+// Render prints the hall tickets of a division, the whole class, or one student as an
+// A4 PDF. Only students whose exam fee is paid get a ticket; GetBlocked lists the rest.
+func (s *hallTicketService) Render(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	req *requests.HallTicketRequest,
+) ([]byte, error) {
+
+	key := getReceiptSigningKey()
+	if key == nil {
+		return nil, errors.New("receipt signing key is not configured")
+	}
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+	if len(exam.Schedule) == 0 {
+		return nil, errors.New("exam has no papers scheduled")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	students, payments, err := hallTicketStudents(ctx, database, exam, req)
+	if err != nil {
+		return nil, err
+	}
+
+	paid := make([]models.Student, 0, len(students))
+	for _, student := range students {
+		if payments[student.EntityID].Paid {
+			paid = append(paid, student)
+		}
+	}
+	if len(paid) == 0 {
+		if req.StudentEntityID != nil && *req.StudentEntityID != "" {
+			return nil, errors.New("hall ticket withheld: the exam fee has not been paid")
+		}
+		return nil, errors.New("no students have paid for this exam yet")
+	}
+
+	seats, err := loadExamSeats(ctx, database, exam.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := loadStudentPhotos(ctx, database, paid)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := loadSchoolProfile(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	sheet := hallTicketSheet{SchoolName: profile.Name, ExamName: exam.ExamName}
+	if logo, err := loadSchoolLogo(ctx, profile); err != nil {
+		fmt.Printf("School logo not loaded for hall tickets: %v\n", err)
+	} else if logo != nil {
+		sheet.Logo = logo
+		sheet.LogoExt = idCardImageType(profile.LogoContentType)
+	}
+	if exam.SessionEntityID != "" {
+		session, err := findAcademicSession(ctx, database, exam.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+		sheet.Session = session.Name
+	}
+
+	tickets := make([]hallTicket, 0, len(paid))
+	for _, student := range paid {
+		class := classNames[student.ClassEntityID]
+		if student.Div != "" {
+			class = fmt.Sprintf("%s - %s", class, student.Div)
+		}
+		ticket := hallTicket{
+			Name:   studentFullName(student),
+			RefNo:  student.RefNo,
+			Class:  class,
+			Board:  boardNames[student.BoardEntityID],
+			Code:   signHallTicketCode(key, companyCode, exam.EntityID, student.EntityID),
+			Papers: hallTicketPapers(exam.Schedule, seats, student.EntityID),
+		}
+		if photo, ok := photos[student.EntityID]; ok {
+			ticket.Photo = photo.data
+			ticket.PhotoExt = photo.ext
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return renderHallTickets(sheet, tickets)
+}
+
+//
+// ================= BLOCKED =================
+//
+
+// GetBlocked lists the students of the exam who get no hall ticket until they pay
+func (s *hallTicketService) GetBlocked(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	req *requests.HallTicketRequest,
+) (*models.HallTicketBlockedList, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	students, payments, err := hallTicketStudents(ctx, database, exam, req)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.HallTicketBlockedList{
+		ExamEntityID: exam.EntityID,
+		ExamName:     exam.ExamName,
+		Blocked:      make([]models.HallTicketBlockedStudent, 0),
+	}
+	if req.Div != nil {
+		list.Div = *req.Div
+	}
+
+	for _, student := range students {
+		payment := payments[student.EntityID]
+		if payment.Paid {
+			list.Eligible++
+			continue
+		}
+		list.Blocked = append(list.Blocked, models.HallTicketBlockedStudent{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+			Div:             student.Div,
+			PaymentStatus:   payment.Status,
+			AmountDue:       exam.ExamAmount,
+		})
+	}
+
+	return list, nil
+}
+
+//
+// ================= VERIFY =================
+//
+
+// Verify checks a scanned hall ticket's signature, then looks up the student's payment
+// and status as they are now, so a refunded fee or a student who has left is caught
+func (s *hallTicketService) Verify(
+	ctx context.Context,
+	companyCode string,
+	req *requests.VerifyHallTicketRequest,
+) (*models.HallTicketVerification, error) {
+
+	key := getReceiptSigningKey()
+	if key == nil {
+		return nil, errors.New("receipt signing key is not configured")
+	}
+
+	examEntityID, studentEntityID, err := parseHallTicketCode(key, companyCode, normaliseScanPayload(req.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	var student models.Student
+	err = database.Collection(StudentCollection).FindOne(ctx, bson.M{"entity_id": studentEntityID, "is_deleted": false}).Decode(&student)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("student on this hall ticket was not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := loadHallTicketPayments(ctx, database, exam.EntityID, []string{student.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	payment := payments[student.EntityID]
+
+	seats, err := loadExamSeats(ctx, database, exam.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	_, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &models.HallTicketVerification{
+		ExamEntityID:    exam.EntityID,
+		ExamName:        exam.ExamName,
+		StudentEntityID: student.EntityID,
+		RefNo:           student.RefNo,
+		Name:            studentFullName(student),
+		ClassName:       classNames[student.ClassEntityID],
+		Div:             student.Div,
+		StudentStatus:   studentStatus(&student),
+		Paid:            payment.Paid,
+		PaymentStatus:   payment.Status,
+		Papers:          hallTicketPapers(exam.Schedule, seats, student.EntityID),
+	}
+	if payment.Payment != nil && payment.Paid {
+		verification.PaymentID = payment.Payment.PaymentID
+		paidAt := payment.Payment.PaymentDate
+		verification.PaidAt = &paidAt
+	}
+
+	switch {
+	case !student.IsActive():
+		verification.Message = fmt.Sprintf("Do not admit: student is %s", verification.StudentStatus)
+	case !payment.Paid:
+		verification.Message = fmt.Sprintf("Do not admit: exam fee is %s", payment.Status)
+	default:
+		verification.Admit = true
+		verification.Message = "Genuine hall ticket; exam fee paid"
+	}
+
+	return verification, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// hallTicketStudents lists the active students of the exam's class, or the division or
+// student asked for, with where each stands with the fee
+func hallTicketStudents(
+	ctx context.Context,
+	database *mongo.Database,
+	exam *models.Exam,
+	req *requests.HallTicketRequest,
+) ([]models.Student, map[string]hallTicketPayment, error) {
+
+	students, err := examCandidates(ctx, database, exam, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kept := students[:0]
+	for _, student := range students {
+		if req.Div != nil && *req.Div != "" && !strings.EqualFold(requests.NormaliseDivision(student.Div), *req.Div) {
+			continue
+		}
+		if req.StudentEntityID != nil && *req.StudentEntityID != "" && student.EntityID != *req.StudentEntityID {
+			continue
+		}
+		kept = append(kept, student)
+	}
+	if len(kept) == 0 {
+		if req.StudentEntityID != nil && *req.StudentEntityID != "" {
+			return nil, nil, errors.New("student does not sit this exam")
+		}
+		return nil, nil, errors.New("no active students sit this exam")
+	}
+
+	payments, err := loadHallTicketPayments(ctx, database, exam.EntityID, studentEntityIDs(kept))
+	if err != nil {
+		return nil, nil, err
+	}
+	return kept, payments, nil
+}
+
+// loadHallTicketPayments looks up each student's payments for the exam. Students
+// without any are unpaid.
+func loadHallTicketPayments(ctx context.Context, database *mongo.Database, examEntityID string, studentIDs []string) (map[string]hallTicketPayment, error) {
+	cursor, err := database.Collection("payment_scanners").Find(ctx, bson.M{
+		"exam_entity_id":    examEntityID,
+		"student_entity_id": bson.M{"$in": studentIDs},
+		"is_deleted":        false,
+	}, options.Find().SetSort(bson.D{{Key: "payment_date", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []models.PaymentScanner
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	byStudent := make(map[string][]models.PaymentScanner, len(studentIDs))
+	for _, record := range records {
+		byStudent[record.StudentEntityID] = append(byStudent[record.StudentEntityID], record)
+	}
+
+	payments := make(map[string]hallTicketPayment, len(studentIDs))
+	for _, id := range studentIDs {
+		payments[id] = hallTicketPaymentStatus(byStudent[id])
+	}
+	return payments, nil
+}
+
+// hallTicketPaymentStatus takes a student's payments for an exam, newest first. Any
+// payment still marked paid settles the fee; otherwise the newest one says why not.
+func hallTicketPaymentStatus(records []models.PaymentScanner) hallTicketPayment {
+	for i := range records {
+		if records[i].Status == "paid" {
+			return hallTicketPayment{Paid: true, Status: "paid", Payment: &records[i]}
+		}
+	}
+	if len(records) == 0 {
+		return hallTicketPayment{Status: "unpaid"}
+	}
+	status := records[0].Status
+	if status == "" {
+		status = "unpaid"
+	}
+	return hallTicketPayment{Status: status, Payment: &records[0]}
+}
+
+// loadExamSeats maps each paper of an exam to the seats of its current seating plan
+func loadExamSeats(ctx context.Context, database *mongo.Database, examEntityID string) (map[string]map[string]models.ExamSeat, error) {
+	cursor, err := database.Collection(ExamSeatingPlanCollection).Find(ctx, bson.M{
+		"exam_entity_id": examEntityID,
+		"is_deleted":     false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var plans []models.ExamSeatingPlan
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+
+	seats := make(map[string]map[string]models.ExamSeat, len(plans))
+	for _, plan := range plans {
+		bySeat := make(map[string]models.ExamSeat, len(plan.Seats))
+		for _, seat := range plan.Seats {
+			bySeat[seat.StudentEntityID] = seat
+		}
+		seats[plan.PaperEntityID] = bySeat
+	}
+	return seats, nil
+}
+
+// hallTicketPapers is the student's timetable in date order, seated where a plan exists
+func hallTicketPapers(schedule []models.ExamPaper, seats map[string]map[string]models.ExamSeat, studentEntityID string) []models.HallTicketPaper {
+	papers := make([]models.HallTicketPaper, 0, len(schedule))
+	for _, paper := range schedule {
+		line := models.HallTicketPaper{
+			PaperEntityID: paper.EntityID,
+			Subject:       paper.Subject,
+			Date:          paper.Date,
+			StartTime:     paper.StartTime,
+			EndTime:       paper.EndTime,
+			Venue:         paper.Venue,
+		}
+		if seat, ok := seats[paper.EntityID][studentEntityID]; ok {
+			line.RoomName = seat.RoomName
+			line.SeatNo = seat.SeatNo
+		}
+		papers = append(papers, line)
+	}
+	sort.SliceStable(papers, func(i, j int) bool {
+		if papers[i].Date != papers[j].Date {
+			return papers[i].Date < papers[j].Date
+		}
+		return papers[i].StartTime < papers[j].StartTime
+	})
+	return papers
+}
+
+// signHallTicketCode binds the student and exam to the company so a ticket from one
+// school cannot be replayed at another
+func signHallTicketCode(key []byte, companyCode, examEntityID, studentEntityID string) string {
+	payload := strings.Join([]string{hallTicketCodePrefix, companyCode, examEntityID, studentEntityID}, ":")
+	return payload + ":" + receiptCodeSignature(key, payload)
+}
+
+// parseHallTicketCode verifies a hall ticket code and returns its exam and student
+func parseHallTicketCode(key []byte, companyCode string, code string) (string, string, error) {
+	parts := strings.Split(code, ":")
+	if len(parts) != 5 || parts[0] != hallTicketCodePrefix || parts[1] == "" || parts[2] == "" || parts[3] == "" {
+		return "", "", errors.New("not a hall ticket code")
+	}
+
+	payload := strings.Join(parts[:4], ":")
+	if !hmac.Equal([]byte(parts[4]), []byte(receiptCodeSignature(key, payload))) {
+		return "", "", errors.New("hall ticket signature is invalid; the ticket may be forged")
+	}
+	if parts[1] != companyCode {
+		return "", "", errors.New("hall ticket was issued by another school")
+	}
+	return parts[2], parts[3], nil
+}
+
+func hallTicketQRCode(code string) ([]byte, error) {
+	encoded, err := qr.Encode(code, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	scaled, err := barcode.Scale(encoded, 256, 256)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(scaled)
+}
+
+// renderHallTickets lays the tickets out two to an A4 page
+func renderHallTickets(sheet hallTicketSheet, tickets []hallTicket) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left := (pageWidth - hallTicketWidth) / 2
+	top := (pageHeight - 2*hallTicketHeight - hallTicketGap) / 2
+
+	logoName := ""
+	if len(sheet.Logo) > 0 {
+		logoName = "school-logo"
+		pdf.RegisterImageOptionsReader(logoName, gofpdf.ImageOptions{ImageType: sheet.LogoExt}, bytes.NewReader(sheet.Logo))
+		if pdf.Err() {
+			// An unreadable logo should not stop the tickets from printing
+			pdf.ClearError()
+			logoName = ""
+		}
+	}
+
+	for i, ticket := range tickets {
+		y := top
+		if i%2 == 0 {
+			pdf.AddPage()
+			// Cut line between the two tickets
+			pdf.SetDrawColor(160, 160, 160)
+			pdf.SetLineWidth(0.2)
+			pdf.SetDashPattern([]float64{2, 2}, 0)
+			pdf.Line(left, top+hallTicketHeight+hallTicketGap/2, left+hallTicketWidth, top+hallTicketHeight+hallTicketGap/2)
+			pdf.SetDashPattern([]float64{}, 0)
+		} else {
+			y = top + hallTicketHeight + hallTicketGap
+		}
+
+		drawHallTicket(pdf, tr, sheet, logoName, ticket, fmt.Sprintf("ticket-%d", i), left, y)
+	}
+
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawHallTicket(pdf *gofpdf.Fpdf, tr func(string) string, sheet hallTicketSheet, logoName string, ticket hallTicket, name string, x, y float64) {
+	// A failed photo is cleared below, so stop before that could hide an earlier error
+	if pdf.Err() {
+		return
+	}
+
+	pdf.SetDrawColor(120, 120, 120)
+	pdf.SetLineWidth(0.3)
+	pdf.Rect(x, y, hallTicketWidth, hallTicketHeight, "D")
+
+	// Header band with the logo, school name and title
+	pdf.SetFillColor(31, 78, 121)
+	pdf.Rect(x, y, hallTicketWidth, 14, "F")
+	textX := x + 4
+	if logoName != "" {
+		pdf.ImageOptions(logoName, x+2, y+1.5, 11, 11, false, gofpdf.ImageOptions{}, 0, "")
+		textX = x + 15
+	}
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.SetXY(textX, y+3)
+	pdf.CellFormat(x+hallTicketWidth-40-textX, 8, tr(fitText(pdf, tr, sheet.SchoolName, x+hallTicketWidth-40-textX)), "", 0, "L", false, 0, "")
+	pdf.SetXY(x+hallTicketWidth-40, y+3)
+	pdf.CellFormat(36, 8, "HALL TICKET", "", 0, "R", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	title := sheet.ExamName
+	if sheet.Session != "" {
+		title = fmt.Sprintf("%s - %s", title, sheet.Session)
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.SetXY(x+4, y+16)
+	pdf.CellFormat(hallTicketWidth-8, 7, tr(fitText(pdf, tr, title, hallTicketWidth-8)), "", 0, "L", false, 0, "")
+
+	// Photo, or an empty box to paste one in
+	photoX, photoY := x+hallTicketWidth-31, y+24
+	pdf.SetLineWidth(0.2)
+	pdf.Rect(photoX, photoY, 27, 33, "D")
+	if len(ticket.Photo) > 0 {
+		pdf.RegisterImageOptionsReader(name+"-photo", gofpdf.ImageOptions{ImageType: ticket.PhotoExt}, bytes.NewReader(ticket.Photo))
+		if pdf.Err() {
+			pdf.ClearError()
+		} else {
+			pdf.ImageOptions(name+"-photo", photoX, photoY, 27, 33, false, gofpdf.ImageOptions{}, 0, "")
+		}
+	}
+
+	// Verification QR beside the photo
+	qrX := photoX - 31
+	if img, err := hallTicketQRCode(ticket.Code); err == nil {
+		pdf.RegisterImageOptionsReader(name+"-qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(img))
+		pdf.ImageOptions(name+"-qr", qrX, photoY, 27, 27, false, gofpdf.ImageOptions{}, 0, "")
+		pdf.SetFont("Helvetica", "", 6)
+		pdf.SetXY(qrX, photoY+27)
+		pdf.CellFormat(27, 4, "Scan to verify", "", 0, "C", false, 0, "")
+	}
+
+	// Student details
+	detailWidth := qrX - x - 8
+	details := [][2]string{{"Name", ticket.Name}, {"Ref No", ticket.RefNo}, {"Class", ticket.Class}, {"Board", ticket.Board}}
+	for i, detail := range details {
+		pdf.SetXY(x+4, photoY+float64(i)*7)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(20, 7, detail[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(detailWidth-20, 7, tr(fitText(pdf, tr, detail[1], detailWidth-20)), "", 0, "L", false, 0, "")
+	}
+
+	// Timetable, with rows shrinking to fit long schedules
+	columns := []struct {
+		title string
+		width float64
+	}{
+		{"Date", 26}, {"Time", 26}, {"Subject", 56}, {"Room", 46}, {"Seat", 18},
+	}
+	tableY := y + 61
+	available := y + hallTicketHeight - 18 - (tableY + 7)
+	rowHeight := 6.0
+	if len(ticket.Papers) > 0 && available/float64(len(ticket.Papers)) < rowHeight {
+		rowHeight = available / float64(len(ticket.Papers))
+	}
+
+	pdf.SetXY(x+4, tableY)
+	pdf.SetFillColor(243, 243, 243)
+	pdf.SetFont("Helvetica", "B", 9)
+	for _, column := range columns {
+		pdf.CellFormat(column.width, 7, column.title, "1", 0, "L", true, 0, "")
+	}
+	pdf.SetFont("Helvetica", "", 9)
+	for i, paper := range ticket.Papers {
+		room, seat := paper.RoomName, ""
+		if room == "" {
+			room = paper.Venue
+		}
+		if paper.SeatNo > 0 {
+			seat = fmt.Sprintf("%d", paper.SeatNo)
+		}
+		values := []string{paper.Date, paper.StartTime + "-" + paper.EndTime, paper.Subject, room, seat}
+		pdf.SetXY(x+4, tableY+7+float64(i)*rowHeight)
+		for j, column := range columns {
+			pdf.CellFormat(column.width, rowHeight, tr(fitText(pdf, tr, values[j], column.width-2)), "1", 0, "L", false, 0, "")
+		}
+	}
+
+	// Signatures
+	lineY := y + hallTicketHeight - 9
+	pdf.SetLineWidth(0.2)
+	pdf.Line(x+6, lineY, x+56, lineY)
+	pdf.Line(x+hallTicketWidth-56, lineY, x+hallTicketWidth-6, lineY)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetXY(x+6, lineY+1)
+	pdf.CellFormat(50, 4, "Student's signature", "", 0, "C", false, 0, "")
+	pdf.SetXY(x+hallTicketWidth-56, lineY+1)
+	pdf.CellFormat(50, 4, "Principal", "", 0, "C", false, 0, "")
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestHallTicketCode(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	code := signHallTicketCode(key, "SCH01", "exam-1", "student-1")
+
+	examID, studentID, err := parseHallTicketCode(key, "SCH01", code)
+	if err != nil {
+		t.Fatalf("parseHallTicketCode: %v", err)
+	}
+	if examID != "exam-1" || studentID != "student-1" {
+		t.Errorf("parsed %s/%s, want exam-1/student-1", examID, studentID)
+	}
+
+	if _, _, err := parseHallTicketCode(key, "SCH02", code); err == nil {
+		t.Error("expected a ticket from another school to be refused")
+	}
+	if _, _, err := parseHallTicketCode([]byte("another key of thirty-two bytes!"), "SCH01", code); err == nil {
+		t.Error("expected a ticket signed with another key to be refused")
+	}
+	forged := signHallTicketCode(key, "SCH01", "exam-1", "student-2")
+	if _, _, err := parseHallTicketCode(key, "SCH01", forged[:len(forged)-22]+code[len(code)-22:]); err == nil {
+		t.Error("expected a ticket with a copied signature to be refused")
+	}
+
+	// A receipt code is signed with the same key but is not a hall ticket
+	if _, _, err := parseHallTicketCode(key, "SCH01", signReceiptCode(key, "SCH01", "PAY-1")); err == nil {
+		t.Error("expected a receipt code to be refused")
+	}
+}
+
+func TestHallTicketPaymentStatus(t *testing.T) {
+	now := time.Now()
+
+	if got := hallTicketPaymentStatus(nil); got.Paid || got.Status != "unpaid" {
+		t.Errorf("no payments: got %+v", got)
+	}
+
+	// A refund after an earlier failed attempt leaves the fee unpaid
+	refunded := []models.PaymentScanner{
+		{PaymentID: "P2", Status: "refunded", PaymentDate: now},
+		{PaymentID: "P1", Status: "failed", PaymentDate: now.Add(-time.Hour)},
+	}
+	if got := hallTicketPaymentStatus(refunded); got.Paid || got.Status != "refunded" {
+		t.Errorf("refunded: got %+v", got)
+	}
+
+	// A later failed attempt does not undo an earlier payment
+	paid := []models.PaymentScanner{
+		{PaymentID: "P2", Status: "failed", PaymentDate: now},
+		{PaymentID: "P1", Status: "paid", PaymentDate: now.Add(-time.Hour)},
+	}
+	got := hallTicketPaymentStatus(paid)
+	if !got.Paid || got.Payment == nil || got.Payment.PaymentID != "P1" {
+		t.Errorf("paid: got %+v", got)
+	}
+}
+
+func TestHallTicketPapers(t *testing.T) {
+	schedule := []models.ExamPaper{
+		{EntityID: "science", Subject: "Science", Date: "2026-11-03", StartTime: "09:00", EndTime: "12:00", Venue: "Block B"},
+		{EntityID: "maths", Subject: "Mathematics", Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00"},
+	}
+	seats := map[string]map[string]models.ExamSeat{
+		"maths": {"s1": {StudentEntityID: "s1", RoomName: "Hall A", SeatNo: 12}},
+	}
+
+	papers := hallTicketPapers(schedule, seats, "s1")
+	if papers[0].Subject != "Mathematics" || papers[0].RoomName != "Hall A" || papers[0].SeatNo != 12 {
+		t.Errorf("unexpected first paper %+v", papers[0])
+	}
+	if papers[1].SeatNo != 0 || papers[1].Venue != "Block B" {
+		t.Errorf("a paper without a seating plan should keep its venue, got %+v", papers[1])
+	}
+}
+
+func TestRenderHallTickets(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	papers := []models.HallTicketPaper{
+		{Subject: "Mathematics", Date: "2026-11-02", StartTime: "09:00", EndTime: "12:00", RoomName: "Hall A", SeatNo: 1},
+	}
+	tickets := []hallTicket{
+		{Name: "Asha Rao", RefNo: "R1", Class: "Class 10 - A", Board: "CBSE", Code: signHallTicketCode(key, "SCH01", "exam-1", "s1"), Papers: papers},
+		{Name: "Ravi Kumar", RefNo: "R2", Class: "Class 10 - A", Board: "CBSE", Code: signHallTicketCode(key, "SCH01", "exam-1", "s2"), Papers: papers},
+		{Name: "Meena Iyer", RefNo: "R3", Class: "Class 10 - B", Board: "CBSE", Code: signHallTicketCode(key, "SCH01", "exam-1", "s3"), Papers: papers},
+	}
+
+	pdf, err := renderHallTickets(hallTicketSheet{SchoolName: "Greenfield School", ExamName: "Term 1", Session: "2026-27"}, tickets)
+	if err != nil {
+		t.Fatalf("renderHallTickets: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Error("expected a PDF document")
+	}
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"sort"
+
+	"shared/infra/db/mdb"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cards are CR80 sized, ten to an A4 sheet
+const (
+	idCardWidth   = 85.6
+	idCardHeight  = 54.0
+	idCardColumns = 2
+	idCardRows    = 5
+	idCardGap     = 4.0
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type IDCardService interface {
+	Render(ctx context.Context, companyCode string, req *requests.IDCardRequest) ([]byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type idCardService struct{}
+
+func NewIDCardService() IDCardService {
+	return &idCardService{}
+}
+
+// idCard is everything printed on one card
+type idCard struct {
+	Name     string
+	Class    string
+	Board    string
+	Session  string
+	RefNo    string
+	Photo    []byte
+	PhotoExt string // "JPG" or "PNG" as gofpdf names them
+}
+
+// idCardSheet is the school branding shared by every card on a sheet
+type idCardSheet struct {
+	SchoolName string
+	Logo       []byte
+	LogoExt    string
+	Code       string // "code128" or "qr"
+}
+
+//
+// ================= RENDER =================
+//
+
+// Render prints the cards of the active students of a class, or one division of it,
+// as an A4 PDF. The barcode holds the ref_no exactly as the receipt lookup expects it.
+func (s *idCardService) Render(
+	ctx context.Context,
+	companyCode string,
+	req *requests.IDCardRequest,
+) ([]byte, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"class_entity_id": req.ClassEntityID, "is_deleted": false}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		filter["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		filter["div"] = divisionMatch(*req.Div)
+	}
+
+	students, err := findSessionStudents(ctx, database, session, filter)
+	if err != nil {
+		return nil, err
+	}
+	students = activeStudents(students)
+	if len(students) == 0 {
+		return nil, errors.New("no active students found for the class")
+	}
+
+	sort.SliceStable(students, func(i, j int) bool {
+		if students[i].Div != students[j].Div {
+			return students[i].Div < students[j].Div
+		}
+		return studentFullName(students[i]) < studentFullName(students[j])
+	})
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := loadSchoolProfile(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	sheet := idCardSheet{SchoolName: profile.Name, Code: req.Code}
+	if logo, err := loadSchoolLogo(ctx, profile); err != nil {
+		fmt.Printf("School logo not loaded for ID cards: %v\n", err)
+	} else if logo != nil {
+		sheet.Logo = logo
+		sheet.LogoExt = idCardImageType(profile.LogoContentType)
+	}
+
+	photos, err := loadStudentPhotos(ctx, database, students)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName := ""
+	if session != nil {
+		sessionName = session.Name
+	}
+
+	cards := make([]idCard, 0, len(students))
+	for _, student := range students {
+		class := classNames[student.ClassEntityID]
+		if student.Div != "" {
+			class = fmt.Sprintf("%s - %s", class, student.Div)
+		}
+		card := idCard{
+			Name:    studentFullName(student),
+			Class:   class,
+			Board:   boardNames[student.BoardEntityID],
+			Session: sessionName,
+			RefNo:   student.RefNo,
+		}
+		if photo, ok := photos[student.EntityID]; ok {
+			card.Photo = photo.data
+			card.PhotoExt = photo.ext
+		}
+		cards = append(cards, card)
+	}
+
+	return renderIDCards(sheet, cards)
+}
+
+//
+// ================= HELPERS =================
+//
+
+type studentPhoto struct {
+	data []byte
+	ext  string
+}
+
+// loadStudentPhotos reads the current photo of each student. Photos gofpdf cannot embed
+// or that fail to load are left out, so the card prints with an empty photo box.
+func loadStudentPhotos(ctx context.Context, database *mongo.Database, students []models.Student) (map[string]studentPhoto, error) {
+	photos := make(map[string]studentPhoto)
+
+	storage := GetAttachmentStorage()
+	if storage == nil {
+		return photos, nil
+	}
+
+	ids := make([]string, 0, len(students))
+	for _, student := range students {
+		ids = append(ids, student.EntityID)
+	}
+
+	cursor, err := database.Collection(AttachmentCollection).Find(ctx, bson.M{
+		"student_entity_id": bson.M{"$in": ids},
+		"kind":              "photo",
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var attachments []models.Attachment
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		ext := idCardImageType(attachment.ContentType)
+		if ext == "" {
+			continue
+		}
+		data, err := readStoredFile(ctx, storage, attachment.StorageKey, attachment.SHA256)
+		if err != nil {
+			fmt.Printf("Photo %s not loaded for ID card: %v\n", attachment.EntityID, err)
+			continue
+		}
+		photos[attachment.StudentEntityID] = studentPhoto{data: data, ext: ext}
+	}
+
+	return photos, nil
+}
+
+// idCardImageType names the image types gofpdf can embed
+func idCardImageType(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return "JPG"
+	case "image/png":
+		return "PNG"
+	}
+	return ""
+}
+
+// renderIDCards lays the cards out on A4 pages, two columns of five
+func renderIDCards(sheet idCardSheet, cards []idCard) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left := (pageWidth - idCardColumns*idCardWidth - (idCardColumns-1)*idCardGap) / 2
+	top := (pageHeight - idCardRows*idCardHeight - (idCardRows-1)*idCardGap) / 2
+
+	logoName := ""
+	if len(sheet.Logo) > 0 {
+		logoName = "school-logo"
+		pdf.RegisterImageOptionsReader(logoName, gofpdf.ImageOptions{ImageType: sheet.LogoExt}, bytes.NewReader(sheet.Logo))
+		if pdf.Err() {
+			// An unreadable logo should not stop the cards from printing
+			pdf.ClearError()
+			logoName = ""
+		}
+	}
+
+	perPage := idCardColumns * idCardRows
+	for i, card := range cards {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		slot := i % perPage
+		x := left + float64(slot%idCardColumns)*(idCardWidth+idCardGap)
+		y := top + float64(slot/idCardColumns)*(idCardHeight+idCardGap)
+
+		drawIDCard(pdf, tr, sheet, logoName, card, fmt.Sprintf("card-%d", i), x, y)
+	}
+
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawIDCard(pdf *gofpdf.Fpdf, tr func(string) string, sheet idCardSheet, logoName string, card idCard, name string, x, y float64) {
+	// A failed photo is cleared below, so stop before that could hide an earlier error
+	if pdf.Err() {
+		return
+	}
+
+	pdf.SetDrawColor(120, 120, 120)
+	pdf.SetLineWidth(0.2)
+	pdf.Rect(x, y, idCardWidth, idCardHeight, "D")
+
+	// Header band with the logo and school name
+	pdf.SetFillColor(31, 78, 121)
+	pdf.Rect(x, y, idCardWidth, 11, "F")
+	textX := x + 3
+	if logoName != "" {
+		pdf.ImageOptions(logoName, x+2, y+1.5, 8, 8, false, gofpdf.ImageOptions{}, 0, "")
+		textX = x + 12
+	}
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetXY(textX, y+1.5)
+	pdf.CellFormat(x+idCardWidth-2-textX, 8, tr(fitText(pdf, tr, sheet.SchoolName, x+idCardWidth-2-textX)), "", 0, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+
+	// Photo, or an empty box to paste one in
+	photoX, photoY, photoW, photoH := x+3, y+14, 20.0, 25.0
+	drawn := false
+	if len(card.Photo) > 0 {
+		pdf.RegisterImageOptionsReader(name+"-photo", gofpdf.ImageOptions{ImageType: card.PhotoExt}, bytes.NewReader(card.Photo))
+		if pdf.Err() {
+			pdf.ClearError()
+		} else {
+			pdf.ImageOptions(name+"-photo", photoX, photoY, photoW, photoH, false, gofpdf.ImageOptions{}, 0, "")
+			drawn = true
+		}
+	}
+	pdf.Rect(photoX, photoY, photoW, photoH, "D")
+	if !drawn {
+		pdf.SetFont("Helvetica", "", 6)
+		pdf.SetXY(photoX, photoY+photoH/2-2)
+		pdf.CellFormat(photoW, 4, "PHOTO", "", 0, "C", false, 0, "")
+	}
+
+	// Details
+	detailX := x + 26
+	detailW := idCardWidth - 28
+	if sheet.Code == "qr" {
+		detailW -= 18
+	}
+	lines := []struct {
+		label string
+		value string
+	}{
+		{"", card.Name},
+		{"Class", card.Class},
+		{"Board", card.Board},
+		{"Session", card.Session},
+		{"Ref No", card.RefNo},
+	}
+	lineY := y + 13.5
+	for i, line := range lines {
+		if line.value == "" {
+			continue
+		}
+		text := line.value
+		if i == 0 {
+			pdf.SetFont("Helvetica", "B", 9)
+		} else {
+			pdf.SetFont("Helvetica", "", 7)
+			text = line.label + ": " + line.value
+		}
+		pdf.SetXY(detailX, lineY)
+		pdf.CellFormat(detailW, 4.5, tr(fitText(pdf, tr, text, detailW)), "", 0, "L", false, 0, "")
+		lineY += 4.5
+	}
+
+	drawIDCardCode(pdf, sheet.Code, card.RefNo, name, x, y)
+}
+
+// drawIDCardCode prints the ref_no as a Code128 barcode along the bottom of the card,
+// or as a QR code in the lower right corner
+func drawIDCardCode(pdf *gofpdf.Fpdf, code string, refNo string, name string, x, y float64) {
+	if refNo == "" {
+		pdf.SetFont("Helvetica", "I", 7)
+		pdf.SetXY(x+26, y+idCardHeight-9)
+		pdf.CellFormat(idCardWidth-29, 5, "No ref no", "", 0, "C", false, 0, "")
+		return
+	}
+
+	var img []byte
+	var err error
+	if code == "qr" {
+		img, err = idCardQRCode(refNo)
+	} else {
+		img, err = idCardBarcode(refNo)
+	}
+	if err != nil {
+		// Ref numbers Code128 cannot carry still print as text
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetXY(x+26, y+idCardHeight-9)
+		pdf.CellFormat(idCardWidth-29, 5, refNo, "", 0, "C", false, 0, "")
+		return
+	}
+
+	pdf.RegisterImageOptionsReader(name+"-code", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(img))
+	if code == "qr" {
+		pdf.ImageOptions(name+"-code", x+idCardWidth-19, y+13.5, 16, 16, false, gofpdf.ImageOptions{}, 0, "")
+		return
+	}
+	pdf.ImageOptions(name+"-code", x+26, y+idCardHeight-12, idCardWidth-29, 9, false, gofpdf.ImageOptions{}, 0, "")
+}
+
+func idCardBarcode(refNo string) ([]byte, error) {
+	code, err := code128.Encode(refNo)
+	if err != nil {
+		return nil, err
+	}
+	// Two pixels a module keeps the bars crisp when the image is scaled onto the card
+	scaled, err := barcode.Scale(code, code.Bounds().Dx()*2, 40)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(scaled)
+}
+
+func idCardQRCode(refNo string) ([]byte, error) {
+	code, err := qr.Encode(refNo, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	scaled, err := barcode.Scale(code, 200, 200)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(scaled)
+}
+
+// encodePNG writes the code as an 8-bit greyscale PNG; gofpdf cannot embed the 16-bit
+// images the barcode package produces
+func encodePNG(code barcode.Barcode) ([]byte, error) {
+	bounds := code.Bounds()
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, code, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitText shortens text with an ellipsis until it fits the width in the current font
+func fitText(pdf *gofpdf.Fpdf, tr func(string) string, text string, width float64) string {
+	if pdf.GetStringWidth(tr(text)) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(tr(string(runes)+"...")) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
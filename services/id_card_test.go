@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 10))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 10; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 120, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderIDCards(t *testing.T) {
+	photo := testPNG(t)
+
+	cards := make([]idCard, 0, 11)
+	for i := 0; i < 11; i++ {
+		card := idCard{
+			Name:    "Aarav Shirish Deshpande",
+			Class:   "Class 5 - A",
+			Board:   "CBSE",
+			Session: "2026-27",
+			RefNo:   "REF-2026-0042",
+		}
+		if i%2 == 0 {
+			card.Photo = photo
+			card.PhotoExt = "PNG"
+		}
+		cards = append(cards, card)
+	}
+	// A card without a ref_no and one Code128 cannot encode still print
+	cards[9].RefNo = ""
+	cards[10].RefNo = "Réf-1"
+
+	for _, code := range []string{"code128", "qr"} {
+		sheet := idCardSheet{SchoolName: "Saraswati Vidya Mandir", Logo: photo, LogoExt: "PNG", Code: code}
+		out, err := renderIDCards(sheet, cards)
+		if err != nil {
+			t.Fatalf("%s: renderIDCards: %v", code, err)
+		}
+		if !bytes.HasPrefix(out, []byte("%PDF")) {
+			t.Errorf("%s: output is not a PDF", code)
+		}
+		if pages := bytes.Count(out, []byte("/Type /Page\n")); pages != 2 {
+			t.Errorf("%s: %d pages, want 2 for 11 cards", code, pages)
+		}
+	}
+}
+
+func TestIDCardBarcodes(t *testing.T) {
+	for name, encode := range map[string]func(string) ([]byte, error){
+		"code128": idCardBarcode,
+		"qr":      idCardQRCode,
+	} {
+		data, err := encode("STU-0007")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: not a png: %v", name, err)
+		}
+		if img.Bounds().Dx() == 0 || img.Bounds().Dy() == 0 {
+			t.Errorf("%s: empty image", name)
+		}
+	}
+
+	if _, err := idCardBarcode("Réf-1"); err == nil {
+		t.Error("expected Code128 to refuse non-ASCII ref numbers")
+	}
+}
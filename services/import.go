@@ -3,14 +3,19 @@ package services
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"shared/infra/db/mdb"
 
 	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -89,6 +94,13 @@ func (s *importService) ImportBooks(ctx context.Context, companyCode string, fil
 		return 0, err
 	}
 
+	// Imported rows belong to the active session
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+
 	var books []interface{}
 
 	for {
@@ -100,7 +112,7 @@ func (s *importService) ImportBooks(ctx context.Context, companyCode string, fil
 			return 0, err
 		}
 
-		// Expected CSV Format: BookName, Amount, FeesType(compulsory/optional), BoardName, ClassName
+		// Expected CSV Format: BookName, Amount, FeesType(compulsory/optional), BoardName, ClassName[, DueDate(YYYY-MM-DD)]
 		if len(record) < 5 {
 			continue
 		}
@@ -130,6 +142,8 @@ func (s *importService) ImportBooks(ctx context.Context, companyCode string, fil
 		newBook.FeesPaid = (feesType == "compulsory")
 		newBook.BoardEntityID = boardID
 		newBook.ClassEntityID = classID
+		newBook.DueDate = parseImportDate(record, 5)
+		newBook.SessionEntityID = sessionEntityID
 
 		books = append(books, newBook)
 	}
@@ -138,10 +152,7 @@ func (s *importService) ImportBooks(ctx context.Context, companyCode string, fil
 		return 0, nil
 	}
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode)).Collection("books")
-
-	_, err = collection.InsertMany(ctx, books)
+	_, err = database.Collection("books").InsertMany(ctx, books)
 	return len(books), err
 }
 
@@ -159,6 +170,13 @@ func (s *importService) ImportExams(ctx context.Context, companyCode string, fil
 		return 0, err
 	}
 
+	// Imported rows belong to the active session
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+
 	var exams []interface{}
 
 	for {
@@ -170,7 +188,7 @@ func (s *importService) ImportExams(ctx context.Context, companyCode string, fil
 			return 0, err
 		}
 
-		// Expected CSV: ExamName, Amount, FeesType, BoardName, ClassName
+		// Expected CSV: ExamName, Amount, FeesType, BoardName, ClassName[, DueDate(YYYY-MM-DD)]
 		if len(record) < 5 {
 			continue
 		}
@@ -199,6 +217,8 @@ func (s *importService) ImportExams(ctx context.Context, companyCode string, fil
 		newExam.FeesPaid = (feesType == "compulsory")
 		newExam.BoardEntityID = boardID
 		newExam.ClassEntityID = classID
+		newExam.DueDate = parseImportDate(record, 5)
+		newExam.SessionEntityID = sessionEntityID
 
 		exams = append(exams, newExam)
 	}
@@ -207,10 +227,7 @@ func (s *importService) ImportExams(ctx context.Context, companyCode string, fil
 		return 0, nil
 	}
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode)).Collection("exams")
-
-	_, err = collection.InsertMany(ctx, exams)
+	_, err = database.Collection("exams").InsertMany(ctx, exams)
 	return len(exams), err
 }
 
@@ -228,14 +245,29 @@ func (s *importService) ImportStudents(ctx context.Context, companyCode string,
 		return 0, err
 	}
 
-	var students []interface{}
+	// Imported rows belong to the active session
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+
+	roster, err := loadDivisionRoster(ctx, database, sessionEntityID)
+	if err != nil {
+		return 0, err
+	}
 
-	// We need to check for existing RefNos to avoid duplicates?
-	// For now, simpler implementation: just insert.
-	// Ideally we'd upsert or check existence.
+	var students []interface{}
+	var enrolled []*models.Student
+	var guardians []interface{}
+	row := 1
 
-	// Pre-fetch all students RefNo map to check duplicates in memory?
-	// Or try/catch insert errors if unique index exists. (RefNo is not unique index in schema currently?)
+	// Ref numbers must be unique among active students, within the file and against the
+	// database; rows without one get generated numbers
+	refNoRows := make(map[string]int)
+	var refNos []string
+	var conflicts []models.RefNoConflict
+	var unnumbered []*models.Student
 
 	for {
 		record, err := reader.Read()
@@ -245,8 +277,10 @@ func (s *importService) ImportStudents(ctx context.Context, companyCode string,
 		if err != nil {
 			return 0, err
 		}
+		row++
 
 		// Expected CSV: FirstName, MiddleName, LastName, RefNo, Div, BoardName, ClassName
+		// [, GuardianName, Relationship, Phone, Email, Address]
 		if len(record) < 7 {
 			continue
 		}
@@ -254,7 +288,7 @@ func (s *importService) ImportStudents(ctx context.Context, companyCode string,
 		firstName := record[0]
 		middleName := record[1]
 		lastName := record[2]
-		refNo := record[3]
+		refNo := strings.TrimSpace(record[3])
 		div := record[4]
 		boardName := record[5]
 		className := record[6]
@@ -273,20 +307,126 @@ func (s *importService) ImportStudents(ctx context.Context, companyCode string,
 		newStudent.MiddleName = middleName
 		newStudent.LastName = lastName
 		newStudent.RefNo = refNo
-		newStudent.Div = div
+		if refNo == "" {
+			unnumbered = append(unnumbered, newStudent)
+		} else if _, seen := refNoRows[refNo]; seen {
+			conflicts = append(conflicts, models.RefNoConflict{RefNo: refNo, Row: row})
+		} else {
+			refNoRows[refNo] = row
+			refNos = append(refNos, refNo)
+		}
+		newStudent.Div, err = roster.Place(ctx, classID, div, newStudent.EntityID)
+		if err != nil {
+			return 0, fmt.Errorf("row %d: %v", row, err)
+		}
 		newStudent.BoardEntityID = boardID
 		newStudent.ClassEntityID = classID
+		newStudent.SessionEntityID = sessionEntityID
 
 		students = append(students, newStudent)
+		enrolled = append(enrolled, newStudent)
+
+		if guardian := parseImportGuardian(record, 7); guardian != nil {
+			guardian.StudentEntityID = newStudent.EntityID
+			guardians = append(guardians, guardian)
+		}
 	}
 
 	if len(students) == 0 {
 		return 0, nil
 	}
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode)).Collection("students")
+	var taken *RefNoConflictError
+	if err := checkRefNosAvailable(ctx, database, refNos, ""); errors.As(err, &taken) {
+		for _, conflict := range taken.Conflicts {
+			conflict.Row = refNoRows[conflict.RefNo]
+			conflicts = append(conflicts, conflict)
+		}
+	} else if err != nil {
+		return 0, err
+	}
+	if len(conflicts) > 0 {
+		sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Row < conflicts[j].Row })
+		return 0, &RefNoConflictError{Conflicts: conflicts}
+	}
+
+	generated, err := generateRefNos(ctx, database, len(unnumbered))
+	if err != nil {
+		return 0, err
+	}
+	for i, student := range unnumbered {
+		student.RefNo = generated[i]
+	}
+	for _, student := range enrolled {
+		student.SearchTokens = studentSearchTokens(student)
+	}
+
+	if _, err := database.Collection("students").InsertMany(ctx, students); err != nil {
+		return 0, refNoWriteError(err, "")
+	}
+
+	if err := enrolStudents(ctx, database, enrolled, sessionEntityID); err != nil {
+		return len(students), err
+	}
+
+	if len(guardians) > 0 {
+		if _, err := database.Collection(GuardianCollection).InsertMany(ctx, guardians); err != nil {
+			return len(students), err
+		}
+	}
+
+	return len(students), nil
+}
+
+// parseImportGuardian reads the optional guardian columns starting at index. The guardian
+// becomes the student's primary contact; rows with no name and no phone, or with an
+// invalid relationship, phone or email, import the student without a guardian.
+func parseImportGuardian(record []string, index int) *models.Guardian {
+	column := func(offset int) string {
+		if len(record) <= index+offset {
+			return ""
+		}
+		return strings.TrimSpace(record[index+offset])
+	}
 
-	_, err = collection.InsertMany(ctx, students)
-	return len(students), err
+	name := column(0)
+	relationship := strings.ToLower(column(1))
+	phone := column(2)
+	email := column(3)
+	address := column(4)
+
+	if name == "" && phone == "" {
+		return nil
+	}
+	if relationship == "" {
+		relationship = "guardian"
+	}
+	if requests.ValidateGuardianRelationship(relationship) != nil ||
+		requests.ValidateGuardianPhone(phone) != nil ||
+		requests.ValidateGuardianEmail(email) != nil {
+		return nil
+	}
+
+	guardian := models.NewGuardian()
+	guardian.Name = name
+	guardian.Relationship = relationship
+	guardian.Phone = normalisePhone(phone)
+	guardian.PhoneTokens = guardianPhoneTokens(guardian.Phone)
+	guardian.Email = email
+	guardian.Address = address
+	guardian.IsPrimary = true
+
+	return guardian
+}
+
+// parseImportDate reads an optional YYYY-MM-DD column, returning nil when absent or invalid
+func parseImportDate(record []string, index int) *time.Time {
+	if len(record) <= index || strings.TrimSpace(record[index]) == "" {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(record[index]))
+	if err != nil {
+		return nil
+	}
+	return &date
 }
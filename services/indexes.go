@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// companyIndex is an index every company database carries. The indexes are built once at
+// startup and on demand through POST /companies/:company_code/indexes, never on request paths.
+type companyIndex struct {
+	Collection string
+	Model      mongo.IndexModel
+	// Duplicates explains a unique index that failed on existing duplicates
+	Duplicates func(ctx context.Context, database *mongo.Database) (string, error)
+}
+
+// companyIndexes lists the indexes of a company database, one group per feature
+func companyIndexes() []companyIndex {
+	var indexes []companyIndex
+	indexes = append(indexes, refNoIndexes...)
+	indexes = append(indexes, studentSearchIndexes...)
+	indexes = append(indexes, attendanceIndexes...)
+	indexes = append(indexes, examMarkIndexes...)
+	return indexes
+}
+
+// EnsureCompanyIndexes builds every index of the company database. Each index is built on
+// its own, so one failure does not hold back the others; all of them are reported.
+func EnsureCompanyIndexes(ctx context.Context, companyCode string) ([]models.CompanyIndex, error) {
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return ensureIndexes(ctx, database)
+}
+
+// EnsureAllCompanyIndexes builds the indexes of every company database, keyed by company code
+func EnsureAllCompanyIndexes(ctx context.Context) (map[string][]models.CompanyIndex, error) {
+	client := mdb.GetMongo().GetClient()
+	databases, err := client.ListDatabaseNames(ctx, bson.M{"name": bson.M{"$regex": "^company_"}})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]models.CompanyIndex)
+	for _, database := range databases {
+		built, err := ensureIndexes(ctx, client.Database(database))
+		if err != nil {
+			return results, err
+		}
+		results[strings.TrimPrefix(database, "company_")] = built
+	}
+	return results, nil
+}
+
+func ensureIndexes(ctx context.Context, database *mongo.Database) ([]models.CompanyIndex, error) {
+	results := []models.CompanyIndex{}
+
+	for _, index := range companyIndexes() {
+		result := models.CompanyIndex{Collection: index.Collection, Built: true}
+		if index.Model.Options != nil && index.Model.Options.Name != nil {
+			result.Name = *index.Model.Options.Name
+		}
+
+		_, err := database.Collection(index.Collection).Indexes().CreateOne(ctx, index.Model)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			result.Built = false
+			result.Error = err.Error()
+			if index.Duplicates != nil && mongo.IsDuplicateKeyError(err) {
+				detail, err := index.Duplicates(ctx, database)
+				if err != nil {
+					return results, err
+				}
+				result.Detail = detail
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
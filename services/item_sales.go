@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ItemSalesService interface {
+	GetItemSales(ctx context.Context, companyCode string, req *requests.ItemSalesRequest) (*models.ItemSalesResponse, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type itemSalesService struct{}
+
+func NewItemSalesService() ItemSalesService {
+	return &itemSalesService{}
+}
+
+//
+// ================= GET ITEM SALES =================
+//
+
+func (s *itemSalesService) GetItemSales(
+	ctx context.Context,
+	companyCode string,
+	req *requests.ItemSalesRequest,
+) (*models.ItemSalesResponse, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	itemFilter := withSession(bson.M{"is_deleted": false}, session)
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		itemFilter["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		itemFilter["class_entity_id"] = *req.ClassEntityID
+	}
+
+	includeExams := req.ItemType == nil || *req.ItemType == "all" || *req.ItemType == "exam"
+	includeBooks := req.ItemType == nil || *req.ItemType == "all" || *req.ItemType == "book"
+
+	var rows []models.ItemSalesRow
+
+	if includeExams {
+		cursor, err := database.Collection(ExamCollection).Find(ctx, itemFilter)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		var exams []models.Exam
+		if err := cursor.All(ctx, &exams); err != nil {
+			return nil, err
+		}
+
+		for _, exam := range exams {
+			rows = append(rows, models.ItemSalesRow{
+				ItemType:      "exam",
+				ItemEntityID:  exam.EntityID,
+				ItemName:      exam.ExamName,
+				FeesType:      feesTypeLabel(isCompulsoryFee(exam.FeesType, exam.FeesPaid)),
+				BoardEntityID: exam.BoardEntityID,
+				ClassEntityID: exam.ClassEntityID,
+				UnitPrice:     exam.ExamAmount,
+			})
+		}
+	}
+
+	if includeBooks {
+		cursor, err := database.Collection(BookCollection).Find(ctx, itemFilter)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		var books []models.Book
+		if err := cursor.All(ctx, &books); err != nil {
+			return nil, err
+		}
+
+		for _, book := range books {
+			rows = append(rows, models.ItemSalesRow{
+				ItemType:      "book",
+				ItemEntityID:  book.EntityID,
+				ItemName:      book.BookName,
+				FeesType:      feesTypeLabel(isCompulsoryFee(book.FeesType, book.FeesPaid)),
+				BoardEntityID: book.BoardEntityID,
+				ClassEntityID: book.ClassEntityID,
+				UnitPrice:     book.Amount,
+			})
+		}
+	}
+
+	if len(rows) == 0 {
+		return &models.ItemSalesResponse{Items: []models.ItemSalesRow{}}, nil
+	}
+
+	itemIDs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		itemIDs = append(itemIDs, row.ItemEntityID)
+	}
+
+	// Units and revenue per item from settled payments
+	paymentMatch := bson.M{
+		"is_deleted":     false,
+		"status":         "paid",
+		"exam_entity_id": bson.M{"$in": itemIDs},
+	}
+	paymentDateFilter(paymentMatch, req.StartDate, req.EndDate)
+
+	// A division narrows the sales to what its students bought
+	if req.Div != nil && *req.Div != "" {
+		studentFilter := bson.M{"is_deleted": false, "div": divisionMatch(*req.Div)}
+		if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+			studentFilter["board_entity_id"] = *req.BoardEntityID
+		}
+		if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+			studentFilter["class_entity_id"] = *req.ClassEntityID
+		}
+		students, err := findSessionStudents(ctx, database, session, studentFilter)
+		if err != nil {
+			return nil, err
+		}
+		studentIDs := make([]string, 0, len(students))
+		for _, student := range students {
+			studentIDs = append(studentIDs, student.EntityID)
+		}
+		paymentMatch["student_entity_id"] = bson.M{"$in": studentIDs}
+	}
+
+	salesCursor, err := database.Collection("payment_scanners").Aggregate(ctx, []bson.M{
+		{"$match": paymentMatch},
+		{
+			"$group": bson.M{
+				"_id":     "$exam_entity_id",
+				"units":   bson.M{"$sum": 1},
+				"revenue": bson.M{"$sum": "$amount"},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer salesCursor.Close(ctx)
+
+	var sales []struct {
+		ItemEntityID string  `bson:"_id"`
+		Units        int     `bson:"units"`
+		Revenue      float64 `bson:"revenue"`
+	}
+	if err := salesCursor.All(ctx, &sales); err != nil {
+		return nil, err
+	}
+
+	salesByItem := make(map[string]int)
+	for i, sale := range sales {
+		salesByItem[sale.ItemEntityID] = i
+	}
+
+	// Eligible students per board and class; a session counts its enrolments. Students
+	// who have left are not expected to buy anything, so only active ones count.
+	studentMatch := withSession(bson.M{"is_deleted": false, "status": activeStatusMatch()}, session)
+	studentSource := StudentCollection
+	if session != nil {
+		studentSource = EnrolmentCollection
+	}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		studentMatch["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		studentMatch["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		studentMatch["div"] = divisionMatch(*req.Div)
+	}
+
+	studentCursor, err := database.Collection(studentSource).Aggregate(ctx, []bson.M{
+		{"$match": studentMatch},
+		{
+			"$group": bson.M{
+				"_id": bson.M{
+					"board_entity_id": "$board_entity_id",
+					"class_entity_id": "$class_entity_id",
+				},
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer studentCursor.Close(ctx)
+
+	var classCounts []struct {
+		ID struct {
+			BoardEntityID string `bson:"board_entity_id"`
+			ClassEntityID string `bson:"class_entity_id"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := studentCursor.All(ctx, &classCounts); err != nil {
+		return nil, err
+	}
+
+	eligible := make(map[string]int)
+	for _, classCount := range classCounts {
+		eligible[boardClassKey(classCount.ID.BoardEntityID, classCount.ID.ClassEntityID)] = classCount.Count
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ItemSalesResponse{}
+	for i := range rows {
+		row := &rows[i]
+		row.BoardName = boardNames[row.BoardEntityID]
+		row.ClassName = classNames[row.ClassEntityID]
+		row.EligibleStudents = eligible[boardClassKey(row.BoardEntityID, row.ClassEntityID)]
+
+		if idx, exists := salesByItem[row.ItemEntityID]; exists {
+			row.UnitsPaid = sales[idx].Units
+			row.Revenue = sales[idx].Revenue
+		}
+
+		if row.FeesType == "optional" {
+			rate := 0.0
+			if row.EligibleStudents > 0 {
+				rate = float64(row.UnitsPaid) / float64(row.EligibleStudents) * 100
+			}
+			row.TakeUpRate = &rate
+		}
+
+		response.TotalUnits += row.UnitsPaid
+		response.TotalRevenue += row.Revenue
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ClassName != rows[j].ClassName {
+			return rows[i].ClassName < rows[j].ClassName
+		}
+		if rows[i].ItemType != rows[j].ItemType {
+			return rows[i].ItemType < rows[j].ItemType
+		}
+		return rows[i].ItemName < rows[j].ItemName
+	})
+
+	response.Items = rows
+	return response, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func feesTypeLabel(isCompulsory bool) string {
+	if isCompulsory {
+		return "compulsory"
+	}
+	return "optional"
+}
+
+// paymentDateFilter adds a payment_date range to a payment filter
+func paymentDateFilter(filter bson.M, startDate, endDate *string) {
+	start, end := parseDateRange(startDate, endDate)
+	dateFilter := bson.M{}
+	if !start.IsZero() {
+		dateFilter["$gte"] = start
+	}
+	if !end.IsZero() {
+		dateFilter["$lt"] = end
+	}
+	if len(dateFilter) > 0 {
+		filter["payment_date"] = dateFilter
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accepts mail on a local port and keeps every message it receives.
+// Recipients containing "reject" are refused at RCPT TO.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []fakeSMTPMessage
+}
+
+type fakeSMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.handle(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeSMTPServer) config() SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "reports@school.test"}
+}
+
+func (s *fakeSMTPServer) received() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var current fakeSMTPMessage
+	reply("220 fake.smtp ready")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(command)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 fake.smtp")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			current = fakeSMTPMessage{From: strings.Trim(command[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			recipient := strings.Trim(command[len("RCPT TO:"):], "<> ")
+			if strings.Contains(recipient, "reject") {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			current.To = append(current.To, recipient)
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			current.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "RSET", upper == "NOOP":
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSendsReportWithAttachment(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.config())
+
+	table := &reportTable{
+		Title:   "Daily Collection",
+		Period:  "2026-06-01",
+		Summary: [][2]string{{"Total Collected", "1500.00"}},
+		Headers: []string{"Ref No", "Student", "Amount"},
+		Rows:    [][]string{{"R001", "Asha Patil", "1500.00"}},
+	}
+	html, err := renderReportHTML(table)
+	if err != nil {
+		t.Fatalf("render html: %v", err)
+	}
+	csvData, err := renderReportCSV(table)
+	if err != nil {
+		t.Fatalf("render csv: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = mailer.Send(ctx, &MailMessage{
+		To:          []string{"principal@school.test"},
+		Subject:     "Daily Collection - 2026-06-01",
+		HTMLBody:    html,
+		Attachments: []MailAttachment{{Filename: "daily-collection-2026-06-01.csv", ContentType: "text/csv", Data: csvData}},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if got := messages[0].To; len(got) != 1 || got[0] != "principal@school.test" {
+		t.Fatalf("unexpected recipients %v", got)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "Daily Collection - 2026-06-01" {
+		t.Fatalf("unexpected subject %q", subject)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("content type: %v", err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	// multipart.Reader decodes quoted-printable parts transparently
+	htmlPart, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("html part: %v", err)
+	}
+	body, _ := io.ReadAll(htmlPart)
+	if !strings.Contains(string(body), "Asha Patil") || !strings.Contains(string(body), "1500.00") {
+		t.Fatalf("html body missing report rows: %s", body)
+	}
+
+	attachmentPart, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+	if attachmentPart.FileName() != "daily-collection-2026-06-01.csv" {
+		t.Fatalf("unexpected attachment name %q", attachmentPart.FileName())
+	}
+	encoded, _ := io.ReadAll(attachmentPart)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("decode attachment: %v", err)
+	}
+	if string(decoded) != string(csvData) {
+		t.Fatalf("attachment mismatch: %q", decoded)
+	}
+}
+
+func TestDeliverReportLogsEveryAttempt(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	mailer := NewSMTPMailer(server.config())
+
+	previousDelay := deliveryRetryDelay
+	deliveryRetryDelay = time.Millisecond
+	defer func() { deliveryRetryDelay = previousDelay }()
+
+	rendered := &renderedReport{
+		Subject:    "Unpaid Students - 2026-06-01",
+		HTML:       "<p>report</p>",
+		Attachment: MailAttachment{Filename: "unpaid.csv", ContentType: "text/csv", Data: []byte("a,b\n")},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deliveries := deliverReport(ctx, mailer, []string{"office@school.test", "reject@school.test"}, rendered)
+
+	if len(deliveries) != 1+maxDeliveryAttempts {
+		t.Fatalf("expected %d delivery log entries, got %d", 1+maxDeliveryAttempts, len(deliveries))
+	}
+	if deliveries[0].Recipient != "office@school.test" || deliveries[0].Status != "sent" {
+		t.Fatalf("first recipient should be sent on the first attempt: %+v", deliveries[0])
+	}
+	for i, delivery := range deliveries[1:] {
+		if delivery.Recipient != "reject@school.test" || delivery.Status != "failed" || delivery.Attempt != i+1 {
+			t.Fatalf("unexpected failed attempt log: %+v", delivery)
+		}
+		if delivery.Error == "" {
+			t.Fatalf("failed attempt should record the SMTP error")
+		}
+	}
+
+	if status := reportRunStatus(deliveries); status != "partial" {
+		t.Fatalf("expected partial run, got %s", status)
+	}
+	if got := len(server.received()); got != 1 {
+		t.Fatalf("expected 1 delivered message, got %d", got)
+	}
+}
@@ -0,0 +1,669 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	NotificationMessageCollection = "notification_messages"
+	NotificationOptOutCollection  = "notification_opt_outs"
+)
+
+// defaultReminderTemplate is used when a send or schedule has no template of its own
+const defaultReminderTemplate = "Dear {{.GuardianName}}, fees of Rs. {{.TotalDue}} are pending for " +
+	"{{.StudentName}} (Ref {{.RefNo}}): {{.Items}}. Please pay at the school office."
+
+// notificationSendInterval paces provider calls so a large batch stays under gateway rate limits
+var notificationSendInterval = 200 * time.Millisecond
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type NotificationService interface {
+	SendReminders(ctx context.Context, companyCode string, req *requests.SendRemindersRequest, triggeredBy models.PaymentActor) (*models.ReminderBatch, error)
+	GetMessages(ctx context.Context, companyCode string, filter NotificationMessageFilter) ([]*models.NotificationMessage, error)
+	UpdateDeliveryStatus(ctx context.Context, companyCode string, req *requests.NotificationDeliveryStatusRequest) (*models.NotificationMessage, error)
+	CreateOptOut(ctx context.Context, companyCode string, req *requests.CreateNotificationOptOutRequest) (*models.NotificationOptOut, error)
+	GetOptOuts(ctx context.Context, companyCode string) ([]*models.NotificationOptOut, error)
+	DeleteOptOut(ctx context.Context, companyCode string, id string) error
+}
+
+// NotificationMessageFilter narrows the message log; empty fields are ignored
+type NotificationMessageFilter struct {
+	StudentEntityID string
+	BatchID         string
+	Channel         string
+	Status          string
+	Limit           int64
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type notificationService struct {
+	contacts ContactResolver
+}
+
+func NewNotificationService() NotificationService {
+	return &notificationService{contacts: NewGuardianContactResolver()}
+}
+
+//
+// ================= CONTACTS =================
+//
+
+// NotificationContact is who a student's reminders go to
+type NotificationContact struct {
+	Name      string
+	Phone     string
+	IsPrimary bool
+}
+
+// ContactResolver finds the contact for each student, keyed by student entity ID.
+// Students without a usable phone number are left out.
+type ContactResolver interface {
+	ResolveContacts(ctx context.Context, companyCode string, studentEntityIDs []string) (map[string]NotificationContact, error)
+}
+
+type guardianContactResolver struct{}
+
+// NewGuardianContactResolver reads contacts from the guardians collection, preferring
+// the primary guardian and falling back to any guardian with a phone number
+func NewGuardianContactResolver() ContactResolver {
+	return &guardianContactResolver{}
+}
+
+func (r *guardianContactResolver) ResolveContacts(
+	ctx context.Context,
+	companyCode string,
+	studentEntityIDs []string,
+) (map[string]NotificationContact, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	guardians, err := loadGuardians(ctx, database, studentEntityIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Guardians come primary first, so the first one with a phone number wins
+	contacts := make(map[string]NotificationContact)
+	for studentEntityID, studentGuardians := range guardians {
+		for _, guardian := range studentGuardians {
+			phone := normalisePhone(guardian.Phone)
+			if phone == "" {
+				continue
+			}
+			contacts[studentEntityID] = NotificationContact{
+				Name:      guardian.Name,
+				Phone:     phone,
+				IsPrimary: guardian.IsPrimary,
+			}
+			break
+		}
+	}
+
+	return contacts, nil
+}
+
+//
+// ================= SEND REMINDERS =================
+//
+
+func (s *notificationService) SendReminders(
+	ctx context.Context,
+	companyCode string,
+	req *requests.SendRemindersRequest,
+	triggeredBy models.PaymentActor,
+) (*models.ReminderBatch, error) {
+
+	throttleHours := models.DefaultReminderThrottleHours
+	if req.ThrottleHours != nil {
+		throttleHours = *req.ThrottleHours
+	}
+
+	return sendReminders(ctx, s.contacts, companyCode, reminderJob{
+		Channel:     req.Channel,
+		Template:    req.Template,
+		Throttle:    time.Duration(throttleHours) * time.Hour,
+		Filter:      models.NewReminderFilter(&req.Filter),
+		Trigger:     "manual",
+		TriggeredBy: &triggeredBy,
+	})
+}
+
+// reminderJob is one manual or scheduled send
+type reminderJob struct {
+	Channel     string
+	Template    string
+	Throttle    time.Duration
+	Filter      models.ReminderFilter
+	Trigger     string
+	TriggeredBy *models.PaymentActor
+}
+
+// sendReminders messages the guardians of every unpaid student matching the job's
+// filter and stores one message per student, including the ones that were skipped
+func sendReminders(
+	ctx context.Context,
+	contacts ContactResolver,
+	companyCode string,
+	job reminderJob,
+) (*models.ReminderBatch, error) {
+
+	provider := GetNotificationProvider(job.Channel)
+	if provider == nil {
+		return nil, fmt.Errorf("%s notifications are not configured", job.Channel)
+	}
+
+	body := job.Template
+	if body == "" {
+		body = defaultReminderTemplate
+	}
+	tmpl, err := template.New("reminder").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	unpaid, err := findUnpaidStudents(ctx, companyCode, reminderUnpaidRequest(job.Filter))
+	if err != nil {
+		return nil, err
+	}
+	if len(job.Filter.StudentEntityIDs) > 0 {
+		selected := make(map[string]bool)
+		for _, id := range job.Filter.StudentEntityIDs {
+			selected[id] = true
+		}
+		kept := unpaid[:0]
+		for _, student := range unpaid {
+			if selected[student.EntityID] {
+				kept = append(kept, student)
+			}
+		}
+		unpaid = kept
+	}
+
+	studentIDs := make([]string, 0, len(unpaid))
+	for _, student := range unpaid {
+		studentIDs = append(studentIDs, student.EntityID)
+	}
+
+	studentContacts, err := contacts.ResolveContacts(ctx, companyCode, studentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	optedOutPhones, optedOutStudents, err := loadOptOuts(ctx, database, job.Channel)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	recent, err := recentlyReminded(ctx, database, job.Channel, studentIDs, now.Add(-job.Throttle))
+	if err != nil {
+		return nil, err
+	}
+
+	batchID := primitive.NewObjectID().Hex()
+	dispatcher := &reminderDispatcher{
+		Provider:         provider,
+		Channel:          job.Channel,
+		Template:         tmpl,
+		OptedOutPhones:   optedOutPhones,
+		OptedOutStudents: optedOutStudents,
+		Recent:           recent,
+		BatchID:          batchID,
+		Trigger:          job.Trigger,
+		TriggeredBy:      job.TriggeredBy,
+	}
+	messages := dispatcher.Run(ctx, unpaid, studentContacts)
+
+	if len(messages) > 0 {
+		documents := make([]interface{}, 0, len(messages))
+		for _, message := range messages {
+			documents = append(documents, message)
+		}
+		if _, err := database.Collection(NotificationMessageCollection).InsertMany(ctx, documents); err != nil {
+			return nil, err
+		}
+	}
+
+	batch := &models.ReminderBatch{
+		BatchID:  batchID,
+		Channel:  job.Channel,
+		Students: len(unpaid),
+		Messages: messages,
+	}
+	for _, message := range messages {
+		batch.Count(message)
+	}
+
+	return batch, nil
+}
+
+// reminderDispatcher decides per student whether to send, and records the outcome
+type reminderDispatcher struct {
+	Provider         NotificationProvider
+	Channel          string
+	Template         *template.Template
+	OptedOutPhones   map[string]bool // keyed by phoneKey
+	OptedOutStudents map[string]bool
+	Recent           map[string]bool // students reminded within the throttle window
+	BatchID          string
+	Trigger          string
+	TriggeredBy      *models.PaymentActor
+}
+
+type reminderTemplateData struct {
+	StudentName  string
+	GuardianName string
+	RefNo        string
+	Div          string
+	TotalDue     string
+	Items        string
+}
+
+func (d *reminderDispatcher) Run(
+	ctx context.Context,
+	students []models.UnpaidStudent,
+	contacts map[string]NotificationContact,
+) []*models.NotificationMessage {
+
+	messages := make([]*models.NotificationMessage, 0, len(students))
+	sentAny := false
+
+	for _, student := range students {
+		message := models.NewNotificationMessage()
+		message.BatchID = d.BatchID
+		message.StudentEntityID = student.EntityID
+		message.RefNo = student.RefNo
+		message.Channel = d.Channel
+		message.Provider = d.Provider.Name()
+		message.TotalDue = student.TotalDue
+		message.Trigger = d.Trigger
+		message.TriggeredBy = d.TriggeredBy
+		messages = append(messages, message)
+
+		contact, hasContact := contacts[student.EntityID]
+		if !hasContact || contact.Phone == "" {
+			message.Status = "no_contact"
+			continue
+		}
+		message.RecipientName = contact.Name
+		message.Recipient = contact.Phone
+
+		if d.OptedOutStudents[student.EntityID] || d.OptedOutPhones[phoneKey(contact.Phone)] {
+			message.Status = "opted_out"
+			continue
+		}
+		if d.Recent[student.EntityID] {
+			message.Status = "throttled"
+			continue
+		}
+
+		body, err := renderReminder(d.Template, student, contact)
+		if err != nil {
+			message.Status = "failed"
+			message.Error = err.Error()
+			continue
+		}
+		message.Body = body
+
+		if sentAny && notificationSendInterval > 0 {
+			select {
+			case <-ctx.Done():
+				message.Status = "failed"
+				message.Error = ctx.Err().Error()
+				continue
+			case <-time.After(notificationSendInterval):
+			}
+		}
+		sentAny = true
+
+		providerID, err := d.Provider.Send(ctx, &OutboundMessage{To: contact.Phone, Body: body})
+		if err != nil {
+			message.Status = "failed"
+			message.Error = err.Error()
+			continue
+		}
+		sentAt := time.Now().UTC()
+		message.Status = "sent"
+		message.ProviderMessageID = providerID
+		message.SentAt = &sentAt
+	}
+
+	return messages
+}
+
+func renderReminder(tmpl *template.Template, student models.UnpaidStudent, contact NotificationContact) (string, error) {
+	items := make([]string, 0, len(student.PendingItems))
+	for _, item := range student.PendingItems {
+		items = append(items, fmt.Sprintf("%s (%.2f)", item.ItemName, item.DueAmount))
+	}
+
+	guardianName := contact.Name
+	if guardianName == "" {
+		guardianName = "Parent"
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, reminderTemplateData{
+		StudentName:  unpaidStudentName(student),
+		GuardianName: guardianName,
+		RefNo:        student.RefNo,
+		Div:          student.Div,
+		TotalDue:     fmt.Sprintf("%.2f", student.TotalDue),
+		Items:        strings.Join(items, ", "),
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+//
+// ================= MESSAGE LOG =================
+//
+
+func (s *notificationService) GetMessages(
+	ctx context.Context,
+	companyCode string,
+	filter NotificationMessageFilter,
+) ([]*models.NotificationMessage, error) {
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(NotificationMessageCollection)
+
+	query := bson.M{}
+	if filter.StudentEntityID != "" {
+		query["student_entity_id"] = filter.StudentEntityID
+	}
+	if filter.BatchID != "" {
+		query["batch_id"] = filter.BatchID
+	}
+	if filter.Channel != "" {
+		query["channel"] = filter.Channel
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.NotificationMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// UpdateDeliveryStatus applies a delivery report from the provider to the stored message
+func (s *notificationService) UpdateDeliveryStatus(
+	ctx context.Context,
+	companyCode string,
+	req *requests.NotificationDeliveryStatusRequest,
+) (*models.NotificationMessage, error) {
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(NotificationMessageCollection)
+
+	now := time.Now().UTC()
+	updateFields := bson.M{
+		"status":     req.Status,
+		"updated_at": now,
+	}
+	if req.Status == "delivered" {
+		updateFields["delivered_at"] = now
+	} else {
+		updateFields["error"] = req.Error
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.NotificationMessage
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{"provider_message_id": req.ProviderMessageID},
+		bson.M{"$set": updateFields},
+		opts,
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("notification message not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= OPT-OUTS =================
+//
+
+func (s *notificationService) CreateOptOut(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateNotificationOptOutRequest,
+) (*models.NotificationOptOut, error) {
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(NotificationOptOutCollection)
+
+	optOut := models.NewNotificationOptOut()
+	optOut.Bind(req)
+	optOut.Phone = normalisePhone(optOut.Phone)
+	if optOut.Phone == "" {
+		return nil, errors.New("phone must contain digits")
+	}
+
+	if _, err := collection.InsertOne(ctx, optOut); err != nil {
+		return nil, err
+	}
+
+	return optOut, nil
+}
+
+func (s *notificationService) GetOptOuts(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.NotificationOptOut, error) {
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(NotificationOptOutCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	optOuts := []*models.NotificationOptOut{}
+	if err := cursor.All(ctx, &optOuts); err != nil {
+		return nil, err
+	}
+
+	return optOuts, nil
+}
+
+// DeleteOptOut lifts an opt-out so reminders resume
+func (s *notificationService) DeleteOptOut(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(NotificationOptOutCollection)
+
+	result, err := collection.UpdateOne(ctx, notificationOptOutFilter(id), bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("opt-out not found")
+	}
+
+	return nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func notificationOptOutFilter(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": oid, "is_deleted": false}
+	}
+	return bson.M{"entity_id": id, "is_deleted": false}
+}
+
+func reminderUnpaidRequest(filter models.ReminderFilter) *requests.GetUnpaidStudentsRequest {
+	req := &requests.GetUnpaidStudentsRequest{
+		IncludeOptional: filter.IncludeOptional,
+		MinDue:          filter.MinDue,
+	}
+	if filter.BoardEntityID != "" {
+		req.BoardEntityID = &filter.BoardEntityID
+	}
+	if filter.ClassEntityID != "" {
+		req.ClassEntityID = &filter.ClassEntityID
+	}
+	if filter.Div != "" {
+		req.Div = &filter.Div
+	}
+	if filter.ItemType != "" {
+		req.ItemType = &filter.ItemType
+	}
+	return req
+}
+
+// loadOptOuts returns opted-out phones (by phoneKey) and students for the channel
+func loadOptOuts(ctx context.Context, database *mongo.Database, channel string) (map[string]bool, map[string]bool, error) {
+	cursor, err := database.Collection(NotificationOptOutCollection).Find(ctx, bson.M{
+		"is_deleted": false,
+		"channel":    bson.M{"$in": bson.A{channel, "all"}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var optOuts []models.NotificationOptOut
+	if err := cursor.All(ctx, &optOuts); err != nil {
+		return nil, nil, err
+	}
+
+	phones := make(map[string]bool)
+	students := make(map[string]bool)
+	for _, optOut := range optOuts {
+		if optOut.StudentEntityID != "" {
+			students[optOut.StudentEntityID] = true
+			continue
+		}
+		phones[phoneKey(optOut.Phone)] = true
+	}
+
+	return phones, students, nil
+}
+
+// recentlyReminded returns the students already sent a reminder on the channel since the given time
+func recentlyReminded(
+	ctx context.Context,
+	database *mongo.Database,
+	channel string,
+	studentEntityIDs []string,
+	since time.Time,
+) (map[string]bool, error) {
+
+	recent := make(map[string]bool)
+	if len(studentEntityIDs) == 0 {
+		return recent, nil
+	}
+
+	values, err := database.Collection(NotificationMessageCollection).Distinct(ctx, "student_entity_id", bson.M{
+		"student_entity_id": bson.M{"$in": studentEntityIDs},
+		"channel":           channel,
+		"status":            bson.M{"$in": bson.A{"sent", "delivered"}},
+		"sent_at":           bson.M{"$gt": since},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		if id, ok := value.(string); ok {
+			recent[id] = true
+		}
+	}
+	return recent, nil
+}
+
+// normalisePhone keeps the digits of a phone number and a leading +
+func normalisePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	var digits strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits.WriteRune(r)
+		}
+	}
+	if digits.Len() == 0 {
+		return ""
+	}
+	if strings.HasPrefix(phone, "+") {
+		return "+" + digits.String()
+	}
+	return digits.String()
+}
+
+// phoneKey compares numbers on their last ten digits, so "+91 98765 43210" and
+// "9876543210" are the same number
+func phoneKey(phone string) string {
+	digits := strings.TrimPrefix(normalisePhone(phone), "+")
+	if len(digits) > 10 {
+		return digits[len(digits)-10:]
+	}
+	return digits
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotificationProvider delivers one text message on one channel. Reminders only depend
+// on this interface, so an SMS gateway, the WhatsApp Business API or the local fake
+// provider can be plugged in per channel.
+type NotificationProvider interface {
+	Name() string
+	Send(ctx context.Context, msg *OutboundMessage) (providerMessageID string, err error)
+}
+
+type OutboundMessage struct {
+	To   string // phone number, digits with an optional leading +
+	Body string
+}
+
+var (
+	notificationProvidersMu sync.RWMutex
+	notificationProviders   = map[string]NotificationProvider{}
+)
+
+// SetNotificationProvider sets the provider used for a channel ("sms" or "whatsapp")
+func SetNotificationProvider(channel string, provider NotificationProvider) {
+	notificationProvidersMu.Lock()
+	defer notificationProvidersMu.Unlock()
+	if provider == nil {
+		delete(notificationProviders, channel)
+		return
+	}
+	notificationProviders[channel] = provider
+}
+
+func GetNotificationProvider(channel string) NotificationProvider {
+	notificationProvidersMu.RLock()
+	defer notificationProvidersMu.RUnlock()
+	return notificationProviders[channel]
+}
+
+// NewNotificationProvidersFromEnv builds the configured providers keyed by channel.
+// NOTIFICATION_PROVIDER=fake registers the fake provider on both channels; otherwise
+// SMS needs SMS_GATEWAY_URL and WhatsApp needs WHATSAPP_TOKEN and WHATSAPP_PHONE_NUMBER_ID.
+func NewNotificationProvidersFromEnv() map[string]NotificationProvider {
+	providers := make(map[string]NotificationProvider)
+
+	if os.Getenv("NOTIFICATION_PROVIDER") == "fake" {
+		fake := NewFakeNotificationProvider()
+		providers["sms"] = fake
+		providers["whatsapp"] = fake
+		return providers
+	}
+
+	if url := os.Getenv("SMS_GATEWAY_URL"); url != "" {
+		providers["sms"] = NewSMSGatewayProvider(SMSGatewayConfig{
+			URL:    url,
+			APIKey: os.Getenv("SMS_GATEWAY_API_KEY"),
+			Sender: os.Getenv("SMS_SENDER_ID"),
+		})
+	}
+
+	token := os.Getenv("WHATSAPP_TOKEN")
+	phoneNumberID := os.Getenv("WHATSAPP_PHONE_NUMBER_ID")
+	if token != "" && phoneNumberID != "" {
+		providers["whatsapp"] = NewWhatsAppProvider(WhatsAppConfig{
+			APIURL:        os.Getenv("WHATSAPP_API_URL"),
+			Token:         token,
+			PhoneNumberID: phoneNumberID,
+		})
+	}
+
+	return providers
+}
+
+//
+// ================= SMS GATEWAY =================
+//
+
+// SMSGatewayConfig describes a generic HTTP SMS gateway that accepts
+// {"sender", "to", "message"} as JSON and answers with a message id
+type SMSGatewayConfig struct {
+	URL    string
+	APIKey string
+	Sender string
+}
+
+type smsGatewayProvider struct {
+	config SMSGatewayConfig
+	client *http.Client
+}
+
+func NewSMSGatewayProvider(config SMSGatewayConfig) NotificationProvider {
+	return &smsGatewayProvider{config: config, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *smsGatewayProvider) Name() string {
+	return "sms_gateway"
+}
+
+func (p *smsGatewayProvider) Send(ctx context.Context, msg *OutboundMessage) (string, error) {
+	payload := map[string]string{
+		"sender":  p.config.Sender,
+		"to":      msg.To,
+		"message": msg.Body,
+	}
+
+	var response struct {
+		MessageID string `json:"message_id"`
+		ID        string `json:"id"`
+	}
+	if err := postProviderJSON(ctx, p.client, p.config.URL, p.config.APIKey, payload, &response); err != nil {
+		return "", err
+	}
+
+	if response.MessageID != "" {
+		return response.MessageID, nil
+	}
+	return response.ID, nil
+}
+
+//
+// ================= WHATSAPP BUSINESS =================
+//
+
+type WhatsAppConfig struct {
+	APIURL        string // defaults to the Graph API
+	Token         string
+	PhoneNumberID string
+}
+
+type whatsAppProvider struct {
+	config WhatsAppConfig
+	client *http.Client
+}
+
+func NewWhatsAppProvider(config WhatsAppConfig) NotificationProvider {
+	if config.APIURL == "" {
+		config.APIURL = "https://graph.facebook.com/v19.0"
+	}
+	return &whatsAppProvider{config: config, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+func (p *whatsAppProvider) Name() string {
+	return "whatsapp_business"
+}
+
+func (p *whatsAppProvider) Send(ctx context.Context, msg *OutboundMessage) (string, error) {
+	payload := map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"to":                strings.TrimPrefix(msg.To, "+"),
+		"type":              "text",
+		"text":              map[string]string{"body": msg.Body},
+	}
+
+	var response struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	url := strings.TrimRight(p.config.APIURL, "/") + "/" + p.config.PhoneNumberID + "/messages"
+	if err := postProviderJSON(ctx, p.client, url, p.config.Token, payload, &response); err != nil {
+		return "", err
+	}
+
+	if len(response.Messages) == 0 {
+		return "", errors.New("whatsapp: no message id in response")
+	}
+	return response.Messages[0].ID, nil
+}
+
+// postProviderJSON posts payload with a bearer token and decodes a 2xx JSON answer
+func postProviderJSON(ctx context.Context, client *http.Client, url, token string, payload, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+//
+// ================= FAKE PROVIDER =================
+//
+
+// FakeNotificationProvider keeps messages in memory instead of sending them. It is used
+// in tests and for local runs with NOTIFICATION_PROVIDER=fake. Numbers listed in Fail
+// are rejected.
+type FakeNotificationProvider struct {
+	mu   sync.Mutex
+	sent []OutboundMessage
+	Fail map[string]bool
+}
+
+func NewFakeNotificationProvider() *FakeNotificationProvider {
+	return &FakeNotificationProvider{Fail: map[string]bool{}}
+}
+
+func (p *FakeNotificationProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeNotificationProvider) Send(ctx context.Context, msg *OutboundMessage) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Fail[msg.To] {
+		return "", fmt.Errorf("fake: number %s rejected", msg.To)
+	}
+	p.sent = append(p.sent, *msg)
+	return fmt.Sprintf("fake-%d", len(p.sent)), nil
+}
+
+// Sent returns a copy of every accepted message
+func (p *FakeNotificationProvider) Sent() []OutboundMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]OutboundMessage(nil), p.sent...)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func newTestDispatcher(t *testing.T, provider NotificationProvider) *reminderDispatcher {
+	t.Helper()
+
+	previousInterval := notificationSendInterval
+	notificationSendInterval = 0
+	t.Cleanup(func() { notificationSendInterval = previousInterval })
+
+	return &reminderDispatcher{
+		Provider:         provider,
+		Channel:          "sms",
+		Template:         template.Must(template.New("reminder").Parse(defaultReminderTemplate)),
+		OptedOutPhones:   map[string]bool{},
+		OptedOutStudents: map[string]bool{},
+		Recent:           map[string]bool{},
+		BatchID:          "batch-1",
+		Trigger:          "manual",
+	}
+}
+
+func unpaidFixture(entityID, refNo, firstName string, due float64) models.UnpaidStudent {
+	return models.UnpaidStudent{
+		EntityID:  entityID,
+		RefNo:     refNo,
+		FirstName: firstName,
+		LastName:  "Patil",
+		PendingItems: []models.PendingItem{
+			{ItemType: "exam", ItemName: "Term 1", DueAmount: due},
+		},
+		TotalDue: due,
+	}
+}
+
+func TestReminderDispatcherOutcomes(t *testing.T) {
+	provider := NewFakeNotificationProvider()
+	provider.Fail["+919800000004"] = true
+
+	dispatcher := newTestDispatcher(t, provider)
+	dispatcher.OptedOutPhones[phoneKey("98000 00002")] = true
+	dispatcher.Recent["s3"] = true
+
+	students := []models.UnpaidStudent{
+		unpaidFixture("s1", "R001", "Asha", 500),
+		unpaidFixture("s2", "R002", "Ravi", 300),
+		unpaidFixture("s3", "R003", "Meera", 200),
+		unpaidFixture("s4", "R004", "Kiran", 100),
+		unpaidFixture("s5", "R005", "Neha", 400),
+	}
+	contacts := map[string]NotificationContact{
+		"s1": {Name: "Sunita Patil", Phone: "+919800000001", IsPrimary: true},
+		"s2": {Name: "Mohan Patil", Phone: "+919800000002"},
+		"s3": {Name: "Leela Patil", Phone: "+919800000003"},
+		"s4": {Name: "Anil Patil", Phone: "+919800000004"},
+	}
+
+	messages := dispatcher.Run(context.Background(), students, contacts)
+
+	want := map[string]string{
+		"s1": "sent",
+		"s2": "opted_out",
+		"s3": "throttled",
+		"s4": "failed",
+		"s5": "no_contact",
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("expected %d messages, got %d", len(want), len(messages))
+	}
+	for _, message := range messages {
+		if message.Status != want[message.StudentEntityID] {
+			t.Errorf("student %s: status %q, want %q", message.StudentEntityID, message.Status, want[message.StudentEntityID])
+		}
+		if message.BatchID != "batch-1" || message.Channel != "sms" || message.Provider != "fake" {
+			t.Errorf("student %s: batch, channel or provider not recorded: %+v", message.StudentEntityID, message)
+		}
+	}
+
+	sent := messages[0]
+	if sent.ProviderMessageID == "" || sent.SentAt == nil {
+		t.Fatalf("sent message should record the provider id and time: %+v", sent)
+	}
+	if !strings.Contains(sent.Body, "Sunita Patil") || !strings.Contains(sent.Body, "500.00") || !strings.Contains(sent.Body, "R001") {
+		t.Fatalf("unexpected body %q", sent.Body)
+	}
+	if messages[3].Error == "" {
+		t.Fatalf("failed message should record the provider error")
+	}
+
+	delivered := provider.Sent()
+	if len(delivered) != 1 || delivered[0].To != "+919800000001" {
+		t.Fatalf("provider should only receive the first student's reminder, got %+v", delivered)
+	}
+
+	batch := &models.ReminderBatch{}
+	for _, message := range messages {
+		batch.Count(message)
+	}
+	if batch.Sent != 1 || batch.OptedOut != 1 || batch.Throttled != 1 || batch.Failed != 1 || batch.NoContact != 1 {
+		t.Fatalf("unexpected batch totals %+v", batch)
+	}
+}
+
+func TestReminderDispatcherStudentOptOutAndCustomTemplate(t *testing.T) {
+	provider := NewFakeNotificationProvider()
+	dispatcher := newTestDispatcher(t, provider)
+	dispatcher.Template = template.Must(template.New("reminder").Parse("{{.StudentName}} owes {{.TotalDue}} for {{.Items}}"))
+	dispatcher.OptedOutStudents["s2"] = true
+
+	students := []models.UnpaidStudent{
+		unpaidFixture("s1", "R001", "Asha", 500),
+		unpaidFixture("s2", "R002", "Ravi", 300),
+	}
+	contacts := map[string]NotificationContact{
+		"s1": {Phone: "9800000001"},
+		"s2": {Phone: "9800000002"},
+	}
+
+	messages := dispatcher.Run(context.Background(), students, contacts)
+
+	if messages[0].Status != "sent" || messages[1].Status != "opted_out" {
+		t.Fatalf("unexpected statuses %q, %q", messages[0].Status, messages[1].Status)
+	}
+	if got := provider.Sent()[0].Body; got != "Asha Patil owes 500.00 for Term 1 (500.00)" {
+		t.Fatalf("unexpected body %q", got)
+	}
+}
+
+func TestReminderDispatcherStopsWhenCancelled(t *testing.T) {
+	provider := NewFakeNotificationProvider()
+	dispatcher := newTestDispatcher(t, provider)
+	notificationSendInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	students := []models.UnpaidStudent{
+		unpaidFixture("s1", "R001", "Asha", 500),
+		unpaidFixture("s2", "R002", "Ravi", 300),
+	}
+	contacts := map[string]NotificationContact{
+		"s1": {Phone: "9800000001"},
+		"s2": {Phone: "9800000002"},
+	}
+
+	messages := dispatcher.Run(ctx, students, contacts)
+
+	if messages[0].Status != "sent" || messages[1].Status != "failed" {
+		t.Fatalf("expected the paced second send to fail on cancel, got %q, %q", messages[0].Status, messages[1].Status)
+	}
+	if len(provider.Sent()) != 1 {
+		t.Fatalf("expected 1 delivered message, got %d", len(provider.Sent()))
+	}
+}
+
+func TestPhoneKeyMatchesFormats(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"+91 98765 43210", "9876543210"},
+		{"098765-43210", "(98765) 43210"},
+	}
+	for _, tt := range tests {
+		if phoneKey(tt.a) != phoneKey(tt.b) {
+			t.Errorf("phoneKey(%q) = %q, phoneKey(%q) = %q", tt.a, phoneKey(tt.a), tt.b, phoneKey(tt.b))
+		}
+	}
+	if normalisePhone("call me") != "" {
+		t.Errorf("a number without digits should normalise to empty")
+	}
+}
@@ -17,7 +17,8 @@ import (
 )
 
 type PaymentConfirmationService interface {
-	ConfirmPayment(ctx context.Context, companyCode string, req *requests.ConfirmPaymentRequest) error
+	ConfirmPayment(ctx context.Context, companyCode string, req *requests.ConfirmPaymentRequest, collectedBy models.PaymentActor) (string, error)
+	ReversePayment(ctx context.Context, companyCode string, req *requests.ReversePaymentRequest, reversedBy models.PaymentActor) (int, error)
 }
 
 type paymentConfirmationService struct{}
@@ -30,9 +31,8 @@ func (s *paymentConfirmationService) ConfirmPayment(
 	ctx context.Context,
 	companyCode string,
 	req *requests.ConfirmPaymentRequest,
-) error {
-	fmt.Printf("ConfirmPayment called with: %+v\n", req)
-
+	collectedBy models.PaymentActor,
+) (string, error) {
 	db := mdb.GetMongo()
 
 	// Get student collection
@@ -44,10 +44,10 @@ func (s *paymentConfirmationService) ConfirmPayment(
 	var student models.Student
 	err := studentCollection.FindOne(ctx, bson.M{"ref_no": req.StudentRefNo, "is_deleted": false}).Decode(&student)
 	if err == mongo.ErrNoDocuments {
-		return fmt.Errorf("student not found with ref no: %s", req.StudentRefNo)
+		return "", fmt.Errorf("student not found with ref no: %s", req.StudentRefNo)
 	}
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// Get payment scanner collection
@@ -65,6 +65,33 @@ func (s *paymentConfirmationService) ConfirmPayment(
 		Database(fmt.Sprintf("company_%s", companyCode)).
 		Collection("books")
 
+	// Payments are recorded against the active session; fees of a closed session are
+	// read-only unless the student carried them forward
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return "", err
+	}
+
+	// A UTR identifies one transfer, so it can settle only one checkout
+	if req.UPIReference != "" {
+		used, err := paymentCollection.CountDocuments(ctx, bson.M{
+			"upi_reference": req.UPIReference,
+			"status":        bson.M{"$nin": reversedPaymentStatuses},
+			"is_deleted":    false,
+		})
+		if err != nil {
+			return "", err
+		}
+		if used > 0 {
+			return "", fmt.Errorf("upi reference %s is already recorded against another payment", req.UPIReference)
+		}
+	}
+
+	// Every item in one checkout shares a payment_id
+	paymentID := generatePaymentID()
+	collected := 0
+
 	// Process selected exams
 	for _, examEntityID := range req.SelectedExams {
 		// Check if payment already exists for this exam
@@ -72,6 +99,7 @@ func (s *paymentConfirmationService) ConfirmPayment(
 		err := paymentCollection.FindOne(ctx, bson.M{
 			"student_entity_id": student.EntityID,
 			"exam_entity_id":    examEntityID,
+			"status":            bson.M{"$nin": reversedPaymentStatuses},
 			"is_deleted":        false,
 		}).Decode(&existingPayment)
 
@@ -85,38 +113,29 @@ func (s *paymentConfirmationService) ConfirmPayment(
 			if err != nil {
 				continue // Skip if exam not found
 			}
+			if err := ensureItemPayable(ctx, database, student.EntityID, examEntityID, exam.SessionEntityID); err != nil {
+				return "", fmt.Errorf("exam %s: %v", exam.ExamName, err)
+			}
 
 			// Create new payment record
 			paymentScanner := models.NewPaymentScanner()
+			paymentScanner.SessionEntityID = sessionEntityID
 			paymentScanner.StudentEntityID = student.EntityID
 			paymentScanner.ExamEntityID = examEntityID
-			paymentScanner.PaymentID = generatePaymentID()
+			paymentScanner.PaymentID = paymentID
 			paymentScanner.PaymentDate = time.Now()
 			paymentScanner.PaymentMethod = req.PaymentMode
 			paymentScanner.Amount = exam.ExamAmount
 			paymentScanner.Status = "paid"
 			paymentScanner.TransactionID = generateTransactionID()
+			paymentScanner.UPIReference = req.UPIReference
+			paymentScanner.CollectedBy = &collectedBy
 
 			_, err = paymentCollection.InsertOne(ctx, paymentScanner)
 			if err != nil {
-				return fmt.Errorf("failed to create payment for exam %s: %v", examEntityID, err)
-			}
-
-			// Update exam fees_paid status to true
-			_, err = examCollection.UpdateOne(ctx, bson.M{
-				"entity_id":  examEntityID,
-				"is_deleted": false,
-			}, bson.M{
-				"$set": bson.M{
-					"fees_paid":  true,
-					"updated_at": time.Now(),
-				},
-			})
-			if err != nil {
-				fmt.Printf("Error updating exam fees_paid for %s: %v\n", examEntityID, err)
-				return fmt.Errorf("failed to update exam fees_paid status for %s: %v", examEntityID, err)
+				return "", fmt.Errorf("failed to create payment for exam %s: %v", examEntityID, err)
 			}
-			fmt.Printf("Updated exam %s fees_paid to true\n", examEntityID)
+			collected++
 		}
 	}
 
@@ -127,6 +146,7 @@ func (s *paymentConfirmationService) ConfirmPayment(
 		err := paymentCollection.FindOne(ctx, bson.M{
 			"student_entity_id": student.EntityID,
 			"exam_entity_id":    bookEntityID, // Using exam_entity_id field for books as well
+			"status":            bson.M{"$nin": reversedPaymentStatuses},
 			"is_deleted":        false,
 		}).Decode(&existingPayment)
 
@@ -140,44 +160,152 @@ func (s *paymentConfirmationService) ConfirmPayment(
 			if err != nil {
 				continue // Skip if book not found
 			}
+			if err := ensureItemPayable(ctx, database, student.EntityID, bookEntityID, book.SessionEntityID); err != nil {
+				return "", fmt.Errorf("book %s: %v", book.BookName, err)
+			}
 
 			// Create new payment record
 			paymentScanner := models.NewPaymentScanner()
+			paymentScanner.SessionEntityID = sessionEntityID
 			paymentScanner.StudentEntityID = student.EntityID
 			paymentScanner.ExamEntityID = bookEntityID // Using exam_entity_id field for books
-			paymentScanner.PaymentID = generatePaymentID()
+			paymentScanner.PaymentID = paymentID
 			paymentScanner.PaymentDate = time.Now()
 			paymentScanner.PaymentMethod = req.PaymentMode
 			paymentScanner.Amount = book.Amount
 			paymentScanner.Status = "paid"
 			paymentScanner.TransactionID = generateTransactionID()
+			paymentScanner.UPIReference = req.UPIReference
+			paymentScanner.CollectedBy = &collectedBy
 
 			_, err = paymentCollection.InsertOne(ctx, paymentScanner)
 			if err != nil {
-				return fmt.Errorf("failed to create payment for book %s: %v", bookEntityID, err)
+				return "", fmt.Errorf("failed to create payment for book %s: %v", bookEntityID, err)
 			}
+			collected++
+		}
+	}
 
-			// Update book fees_paid status to true
-			_, err = bookCollection.UpdateOne(ctx, bson.M{
-				"entity_id":  bookEntityID,
-				"is_deleted": false,
-			}, bson.M{
-				"$set": bson.M{
-					"fees_paid":  true,
-					"updated_at": time.Now(),
-				},
-			})
-			if err != nil {
-				return fmt.Errorf("failed to update book fees_paid status for %s: %v", bookEntityID, err)
+	// Nothing new was collected when every item was already paid
+	if collected == 0 {
+		return "", nil
+	}
+	return paymentID, nil
+}
+
+//
+// ================= VOID / REFUND =================
+//
+
+// ReversePayment voids or refunds paid items of one checkout. A void cancels a
+// collection on the day it was taken; a refund returns money for an older one.
+// Reversed items count as unpaid again and can be collected afresh.
+func (s *paymentConfirmationService) ReversePayment(
+	ctx context.Context,
+	companyCode string,
+	req *requests.ReversePaymentRequest,
+	reversedBy models.PaymentActor,
+) (int, error) {
+
+	db := mdb.GetMongo()
+	paymentCollection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection("payment_scanners")
+
+	filter := bson.M{
+		"payment_id": req.PaymentID,
+		"status":     "paid",
+		"is_deleted": false,
+	}
+	if len(req.EntityIDs) > 0 {
+		filter["entity_id"] = bson.M{"$in": req.EntityIDs}
+	}
+
+	cursor, err := paymentCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []models.PaymentScanner
+	if err := cursor.All(ctx, &payments); err != nil {
+		return 0, err
+	}
+	if len(payments) == 0 {
+		return 0, fmt.Errorf("no paid items found for payment id: %s", req.PaymentID)
+	}
+
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	if err := ensureSessionWritable(ctx, database, payments[0].SessionEntityID); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	status := "refunded"
+	if req.Action == "void" {
+		status = "void"
+		today := now.Format("2006-01-02")
+		for _, payment := range payments {
+			if payment.PaymentDate.In(now.Location()).Format("2006-01-02") != today {
+				return 0, fmt.Errorf("payment %s was not taken today, refund it instead of voiding", req.PaymentID)
 			}
 		}
 	}
 
-	return nil
+	ids := make([]primitive.ObjectID, 0, len(payments))
+	for _, payment := range payments {
+		ids = append(ids, payment.ID)
+	}
+
+	result, err := paymentCollection.UpdateMany(ctx, bson.M{
+		"_id":    bson.M{"$in": ids},
+		"status": "paid",
+	}, bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"reversed_by":     reversedBy,
+			"reversed_at":     now,
+			"reversal_reason": req.Reason,
+			"updated_at":      now,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.ModifiedCount), nil
+}
+
+// ensureItemPayable allows collecting an item of the active or an upcoming session, or
+// an item of a closed session the student still owes through a promotion
+func ensureItemPayable(ctx context.Context, database *mongo.Database, studentEntityID, itemEntityID, sessionEntityID string) error {
+	err := ensureSessionWritable(ctx, database, sessionEntityID)
+	if err == nil {
+		return nil
+	}
+
+	carried, countErr := database.Collection(CarriedDueCollection).CountDocuments(ctx, bson.M{
+		"student_entity_id": studentEntityID,
+		"item_entity_id":    itemEntityID,
+		"is_deleted":        false,
+	})
+	if countErr != nil {
+		return countErr
+	}
+	if carried > 0 {
+		return nil
+	}
+	return err
 }
 
+// reversedPaymentStatuses no longer count as a payment for the item
+var reversedPaymentStatuses = bson.A{"void", "refunded"}
+
+// generatePaymentID identifies one checkout; the suffix keeps two checkouts in the same second apart
 func generatePaymentID() string {
-	return fmt.Sprintf("PAY_%d", time.Now().Unix())
+	id := primitive.NewObjectID()
+	entityID, _ := uuids.NewUUID5(id.Hex(), uuids.OidNamespace)
+	return fmt.Sprintf("PAY_%d_%s", time.Now().Unix(), entityID[:6])
 }
 
 func generateTransactionID() string {
@@ -0,0 +1,719 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	PromotionBatchCollection = "promotion_batches"
+	CarriedDueCollection     = "carried_dues"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type PromotionService interface {
+	Preview(ctx context.Context, companyCode string, req *requests.PromotionRequest) (*models.PromotionPreview, error)
+	Apply(ctx context.Context, companyCode string, req *requests.PromotionRequest, appliedBy models.PaymentActor) (*models.PromotionBatch, error)
+	Undo(ctx context.Context, companyCode string, id string, undoneBy models.PaymentActor) (*models.PromotionBatch, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.PromotionBatch, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.PromotionBatch, error)
+	GetStudentHistory(ctx context.Context, companyCode string, studentID string) ([]models.ClassHistoryEntry, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type promotionService struct{}
+
+func NewPromotionService() PromotionService {
+	return &promotionService{}
+}
+
+//
+// ================= PREVIEW =================
+//
+
+// Preview shows where every student of the source session would go and what they
+// would carry forward, without writing anything
+func (s *promotionService) Preview(
+	ctx context.Context,
+	companyCode string,
+	req *requests.PromotionRequest,
+) (*models.PromotionPreview, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	preview, _, _, err := preparePromotion(ctx, database, req)
+	return preview, err
+}
+
+//
+// ================= APPLY =================
+//
+
+// Apply moves the students in one batch. Promoted and detained students are enrolled
+// in the target session and take their compulsory dues with them; graduated students
+// become alumni and stay in the source session with their dues reported in the batch.
+func (s *promotionService) Apply(
+	ctx context.Context,
+	companyCode string,
+	req *requests.PromotionRequest,
+	appliedBy models.PaymentActor,
+) (*models.PromotionBatch, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	preview, source, target, err := preparePromotion(ctx, database, req)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := models.NewPromotionBatch()
+	batch.FromSessionEntityID = source.EntityID
+	batch.ToSessionEntityID = target.EntityID
+	batch.AppliedBy = &appliedBy
+	batch.Totals = preview.Totals
+
+	var enrolments []interface{}
+	var carriedDues []interface{}
+	outcomes := make(map[string][]string)
+
+	for _, student := range preview.Students {
+		outcomes[student.Outcome] = append(outcomes[student.Outcome], student.StudentEntityID)
+
+		if student.Outcome != "graduated" {
+			enrolment := models.NewEnrolment(&models.Student{
+				EntityID:      student.StudentEntityID,
+				BoardEntityID: student.ToBoardEntityID,
+				ClassEntityID: student.ToClassEntityID,
+				Div:           student.ToDiv,
+			}, target.EntityID)
+			enrolment.PromotionEntityID = batch.EntityID
+			enrolments = append(enrolments, enrolment)
+			student.EnrolmentEntityID = enrolment.EntityID
+
+			for _, item := range student.CarriedItems {
+				due := models.NewCarriedDue()
+				due.StudentEntityID = student.StudentEntityID
+				due.PromotionEntityID = batch.EntityID
+				due.FromSessionEntityID = item.FromSessionEntityID
+				due.ToSessionEntityID = target.EntityID
+				due.ItemType = item.ItemType
+				due.ItemEntityID = item.ItemEntityID
+				due.ItemName = item.ItemName
+				due.Amount = item.Amount
+				due.DueDate = item.DueDate
+				carriedDues = append(carriedDues, due)
+			}
+		}
+
+		batch.Students = append(batch.Students, student)
+	}
+
+	// The batch is recorded before any student moves, so a failure partway through
+	// leaves a pending batch that Undo can revert
+	batch.Status = "pending"
+	if _, err := database.Collection(PromotionBatchCollection).InsertOne(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	if len(enrolments) > 0 {
+		if _, err := database.Collection(EnrolmentCollection).InsertMany(ctx, enrolments); err != nil {
+			return nil, err
+		}
+	}
+	if len(carriedDues) > 0 {
+		if _, err := database.Collection(CarriedDueCollection).InsertMany(ctx, carriedDues); err != nil {
+			return nil, err
+		}
+	}
+
+	// The student document follows the latest enrolment
+	studentCollection := database.Collection(StudentCollection)
+	for _, student := range batch.Students {
+		if student.Outcome == "graduated" {
+			continue
+		}
+		if _, err := studentCollection.UpdateOne(ctx,
+			bson.M{"entity_id": student.StudentEntityID, "is_deleted": false},
+			bson.M{"$set": bson.M{
+				"session_entity_id": target.EntityID,
+				"board_entity_id":   student.ToBoardEntityID,
+				"class_entity_id":   student.ToClassEntityID,
+				"div":               student.ToDiv,
+				"updated_at":        batch.AppliedAt,
+			}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	for outcome, studentIDs := range outcomes {
+		if _, err := database.Collection(EnrolmentCollection).UpdateMany(ctx,
+			bson.M{"session_entity_id": source.EntityID, "student_entity_id": bson.M{"$in": studentIDs}, "is_deleted": false},
+			bson.M{"$set": bson.M{"outcome": outcome, "updated_at": batch.AppliedAt}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	// Graduates become alumni. Their enrolment in the source session keeps its status, so
+	// that session still reports what they owe.
+	if graduated := outcomes["graduated"]; len(graduated) > 0 {
+		if err := applyStudentStatus(ctx, database, graduated, "", "alumni", batch.AppliedAt, "Graduated"); err != nil {
+			return nil, err
+		}
+
+		changes := make([]interface{}, 0, len(graduated))
+		for _, studentID := range graduated {
+			change := models.NewStudentStatusChange()
+			change.StudentEntityID = studentID
+			change.SessionEntityID = source.EntityID
+			change.FromStatus = "active"
+			change.Status = "alumni"
+			change.EffectiveDate = batch.AppliedAt
+			change.Reason = "Graduated"
+			change.PromotionEntityID = batch.EntityID
+			change.ChangedBy = &appliedBy
+			changes = append(changes, change)
+		}
+		if _, err := database.Collection(StudentStatusChangeCollection).InsertMany(ctx, changes); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := database.Collection(PromotionBatchCollection).UpdateOne(ctx,
+		bson.M{"_id": batch.ID},
+		bson.M{"$set": bson.M{"status": "applied", "updated_at": batch.AppliedAt}},
+	); err != nil {
+		return nil, err
+	}
+
+	batch.Status = "applied"
+	return batch, nil
+}
+
+//
+// ================= UNDO =================
+//
+
+// Undo reverts an applied promotion within its undo window, provided nothing has been
+// collected from the moved students for the target session's fees or their carried dues.
+// A pending promotion, one whose Apply failed partway, can be reverted at any time.
+func (s *promotionService) Undo(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	undoneBy models.PaymentActor,
+) (*models.PromotionBatch, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	batch, err := findPromotionBatch(ctx, database, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status == "undone" {
+		return nil, errors.New("promotion has already been undone")
+	}
+
+	now := time.Now().UTC()
+	if batch.Status == "applied" && now.After(batch.UndoUntil) {
+		return nil, fmt.Errorf("promotion can only be undone until %s", batch.UndoUntil.Format(time.RFC3339))
+	}
+
+	target, err := findAcademicSession(ctx, database, batch.ToSessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	_, targetItems, err := loadFeeItems(ctx, database, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var movedIDs []string
+	itemIDs := make([]string, 0, len(targetItems))
+	for itemID := range targetItems {
+		itemIDs = append(itemIDs, itemID)
+	}
+	for _, student := range batch.Students {
+		if student.Outcome == "graduated" {
+			continue
+		}
+		movedIDs = append(movedIDs, student.StudentEntityID)
+		for _, item := range student.CarriedItems {
+			itemIDs = append(itemIDs, item.ItemEntityID)
+		}
+	}
+
+	if len(movedIDs) > 0 {
+		collected, err := database.Collection("payment_scanners").CountDocuments(ctx, bson.M{
+			"student_entity_id": bson.M{"$in": movedIDs},
+			"exam_entity_id":    bson.M{"$in": itemIDs},
+			"status":            bson.M{"$nin": reversedPaymentStatuses},
+			"payment_date":      bson.M{"$gte": batch.AppliedAt},
+			"is_deleted":        false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if collected > 0 {
+			return nil, fmt.Errorf("%d payments were collected from promoted students; reverse them before undoing the promotion", collected)
+		}
+	}
+
+	if _, err := database.Collection(EnrolmentCollection).UpdateMany(ctx,
+		bson.M{"promotion_entity_id": batch.EntityID, "is_deleted": false},
+		bson.M{"$set": bson.M{"is_deleted": true, "updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Collection(CarriedDueCollection).UpdateMany(ctx,
+		bson.M{"promotion_entity_id": batch.EntityID, "is_deleted": false},
+		bson.M{"$set": bson.M{"is_deleted": true, "updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	studentCollection := database.Collection(StudentCollection)
+	studentIDs := make([]string, 0, len(batch.Students))
+	var graduatedIDs []string
+	for _, student := range batch.Students {
+		studentIDs = append(studentIDs, student.StudentEntityID)
+		if student.Outcome == "graduated" {
+			graduatedIDs = append(graduatedIDs, student.StudentEntityID)
+			continue
+		}
+		if _, err := studentCollection.UpdateOne(ctx,
+			bson.M{"entity_id": student.StudentEntityID, "session_entity_id": batch.ToSessionEntityID},
+			bson.M{"$set": bson.M{
+				"session_entity_id": batch.FromSessionEntityID,
+				"board_entity_id":   student.FromBoardEntityID,
+				"class_entity_id":   student.FromClassEntityID,
+				"div":               student.FromDiv,
+				"updated_at":        now,
+			}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := database.Collection(EnrolmentCollection).UpdateMany(ctx,
+		bson.M{"session_entity_id": batch.FromSessionEntityID, "student_entity_id": bson.M{"$in": studentIDs}, "is_deleted": false},
+		bson.M{"$unset": bson.M{"outcome": ""}, "$set": bson.M{"updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	// Graduates the promotion made alumni are active again
+	if len(graduatedIDs) > 0 {
+		if _, err := studentCollection.UpdateMany(ctx,
+			bson.M{"entity_id": bson.M{"$in": graduatedIDs}, "status": "alumni", "is_deleted": false},
+			bson.M{
+				"$set":   bson.M{"status": "active", "updated_at": now},
+				"$unset": bson.M{"status_date": "", "status_reason": ""},
+			},
+		); err != nil {
+			return nil, err
+		}
+		if _, err := database.Collection(StudentStatusChangeCollection).UpdateMany(ctx,
+			bson.M{"promotion_entity_id": batch.EntityID, "is_deleted": false},
+			bson.M{"$set": bson.M{"is_deleted": true, "updated_at": now}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := database.Collection(PromotionBatchCollection).UpdateOne(ctx,
+		bson.M{"_id": batch.ID},
+		bson.M{"$set": bson.M{
+			"status":     "undone",
+			"undone_by":  undoneBy,
+			"undone_at":  now,
+			"updated_at": now,
+		}},
+	); err != nil {
+		return nil, err
+	}
+
+	batch.Status = "undone"
+	batch.UndoneBy = &undoneBy
+	batch.UndoneAt = &now
+	batch.UpdatedAt = now
+	return batch, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+// GetAll lists the promotions, most recent first
+func (s *promotionService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.PromotionBatch, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(PromotionBatchCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "applied_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	batches := []*models.PromotionBatch{}
+	if err := cursor.All(ctx, &batches); err != nil {
+		return nil, err
+	}
+
+	return batches, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *promotionService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.PromotionBatch, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return findPromotionBatch(ctx, database, id)
+}
+
+//
+// ================= STUDENT HISTORY =================
+//
+
+// GetStudentHistory lists the board, class and division the student was enrolled in
+// for each session, oldest first, with the promotion outcome of each session
+func (s *promotionService) GetStudentHistory(
+	ctx context.Context,
+	companyCode string,
+	studentID string,
+) ([]models.ClassHistoryEntry, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	cursor, err := database.Collection(EnrolmentCollection).Find(ctx, bson.M{
+		"student_entity_id": student.EntityID,
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var enrolments []models.Enrolment
+	if err := cursor.All(ctx, &enrolments); err != nil {
+		return nil, err
+	}
+
+	sessionCursor, err := database.Collection(AcademicSessionCollection).Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer sessionCursor.Close(ctx)
+
+	var sessions []models.AcademicSession
+	if err := sessionCursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	sessionsByID := make(map[string]models.AcademicSession, len(sessions))
+	for _, session := range sessions {
+		sessionsByID[session.EntityID] = session
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]models.ClassHistoryEntry, 0, len(enrolments))
+	for _, enrolment := range enrolments {
+		session := sessionsByID[enrolment.SessionEntityID]
+		history = append(history, models.ClassHistoryEntry{
+			SessionEntityID:   enrolment.SessionEntityID,
+			SessionName:       session.Name,
+			StartDate:         session.StartDate,
+			EndDate:           session.EndDate,
+			BoardEntityID:     enrolment.BoardEntityID,
+			BoardName:         boardNames[enrolment.BoardEntityID],
+			ClassEntityID:     enrolment.ClassEntityID,
+			ClassName:         classNames[enrolment.ClassEntityID],
+			Div:               enrolment.Div,
+			Outcome:           enrolment.Outcome,
+			PromotionEntityID: enrolment.PromotionEntityID,
+		})
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].StartDate.Before(history[j].StartDate)
+	})
+
+	return history, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func promotionBatchFilter(id string) bson.M {
+	filter := bson.M{"entity_id": id}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": oid}
+	}
+	return filter
+}
+
+func findPromotionBatch(ctx context.Context, database *mongo.Database, id string) (*models.PromotionBatch, error) {
+	var batch models.PromotionBatch
+	err := database.Collection(PromotionBatchCollection).FindOne(ctx, promotionBatchFilter(id)).Decode(&batch)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("promotion not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// preparePromotion resolves both sessions, checks the request against them and plans
+// the promotion. Preview and Apply share it so an applied promotion is exactly what
+// was previewed.
+func preparePromotion(
+	ctx context.Context,
+	database *mongo.Database,
+	req *requests.PromotionRequest,
+) (*models.PromotionPreview, *models.AcademicSession, *models.AcademicSession, error) {
+
+	source, err := resolveSessionPtr(ctx, database, req.FromSessionEntityID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if source == nil {
+		return nil, nil, nil, errors.New("no active academic session")
+	}
+
+	target, err := findAcademicSession(ctx, database, req.ToSessionEntityID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !target.StartDate.After(source.StartDate) {
+		return nil, nil, nil, fmt.Errorf("academic session %s does not follow %s", target.Name, source.Name)
+	}
+	if err := ensureSessionWritable(ctx, database, target.EntityID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, mapping := range req.Mappings {
+		if err := checkPromotionTarget(boardNames, classNames, mapping.ToBoardEntityID, mapping.ToClassEntityID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for _, override := range req.Overrides {
+		if err := checkPromotionTarget(boardNames, classNames, override.ToBoardEntityID, override.ToClassEntityID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// Students who left during the session are not moved on
+	students, err := findSessionStudents(ctx, database, source, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	students = activeStudents(students)
+
+	ledger, _, err := loadDuesLedger(ctx, database, source)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	preview, err := planPromotion(students, ledger, req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	preview.FromSessionEntityID = source.EntityID
+	preview.ToSessionEntityID = target.EntityID
+
+	// Divisions of the target class must exist and have room
+	roster, err := loadDivisionRoster(ctx, database, target.EntityID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i := range preview.Students {
+		student := &preview.Students[i]
+		if student.Outcome == "graduated" {
+			continue
+		}
+		student.ToDiv, err = roster.Place(ctx, student.ToClassEntityID, student.ToDiv, student.StudentEntityID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("student %s: %v", student.RefNo, err)
+		}
+	}
+
+	// A student is promoted out of a session once
+	movedIDs := make([]string, 0, len(preview.Students))
+	for _, student := range preview.Students {
+		movedIDs = append(movedIDs, student.StudentEntityID)
+	}
+	if len(movedIDs) > 0 {
+		enrolled, err := database.Collection(EnrolmentCollection).CountDocuments(ctx, bson.M{
+			"session_entity_id": target.EntityID,
+			"student_entity_id": bson.M{"$in": movedIDs},
+			"is_deleted":        false,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if enrolled > 0 {
+			return nil, nil, nil, fmt.Errorf("%d of these students are already enrolled in academic session %s", enrolled, target.Name)
+		}
+	}
+
+	return preview, source, target, nil
+}
+
+func checkPromotionTarget(boardNames, classNames map[string]string, boardEntityID, classEntityID string) error {
+	if boardEntityID != "" {
+		if _, ok := boardNames[boardEntityID]; !ok {
+			return fmt.Errorf("board %s not found", boardEntityID)
+		}
+	}
+	if classEntityID != "" {
+		if _, ok := classNames[classEntityID]; !ok {
+			return fmt.Errorf("class %s not found", classEntityID)
+		}
+	}
+	return nil
+}
+
+// planPromotion works out each student's outcome and target class from the class
+// mappings and per-student overrides, with the compulsory dues the student still owes.
+// Detained students stay in their class and everyone keeps their division unless
+// overridden. Students of unmapped classes are listed separately and left alone.
+func planPromotion(students []models.Student, ledger *duesLedger, req *requests.PromotionRequest) (*models.PromotionPreview, error) {
+	mappings := make(map[string]requests.PromotionMappingRequest, len(req.Mappings))
+	for _, mapping := range req.Mappings {
+		mappings[boardClassKey(mapping.BoardEntityID, mapping.FromClassEntityID)] = mapping
+	}
+
+	overrides := make(map[string]requests.PromotionOverrideRequest, len(req.Overrides))
+	for _, override := range req.Overrides {
+		overrides[override.StudentEntityID] = override
+	}
+
+	preview := &models.PromotionPreview{
+		Students: []models.PromotionStudent{},
+		Unmapped: []models.PromotionStudent{},
+	}
+	seen := make(map[string]bool, len(students))
+
+	for _, student := range students {
+		seen[student.EntityID] = true
+
+		planned := models.PromotionStudent{
+			StudentEntityID:   student.EntityID,
+			RefNo:             student.RefNo,
+			StudentName:       studentFullName(student),
+			FromBoardEntityID: student.BoardEntityID,
+			FromClassEntityID: student.ClassEntityID,
+			FromDiv:           student.Div,
+			CarriedItems:      []models.CarriedItem{},
+		}
+
+		var toBoard, toClass string
+		toDiv := student.Div
+
+		if override, ok := overrides[student.EntityID]; ok {
+			planned.Outcome = override.Outcome
+			planned.Overridden = true
+			toBoard, toClass = override.ToBoardEntityID, override.ToClassEntityID
+			if override.ToDiv != nil {
+				toDiv = *override.ToDiv
+			}
+		} else if mapping, ok := mappings[boardClassKey(student.BoardEntityID, student.ClassEntityID)]; ok {
+			planned.Outcome = mapping.Outcome
+			toBoard, toClass = mapping.ToBoardEntityID, mapping.ToClassEntityID
+		} else {
+			planned.Outcome = "unmapped"
+			preview.Totals.Count(planned)
+			preview.Unmapped = append(preview.Unmapped, planned)
+			continue
+		}
+
+		switch planned.Outcome {
+		case "promoted":
+			planned.ToBoardEntityID = toBoard
+			if planned.ToBoardEntityID == "" {
+				planned.ToBoardEntityID = student.BoardEntityID
+			}
+			planned.ToClassEntityID = toClass
+			planned.ToDiv = toDiv
+		case "detained":
+			planned.ToBoardEntityID = student.BoardEntityID
+			planned.ToClassEntityID = student.ClassEntityID
+			planned.ToDiv = toDiv
+		}
+
+		for _, item := range ledger.Dues(student, duePolicy{Optional: OptionalExcluded}).Pending {
+			fromSession := item.CarriedFrom
+			if fromSession == "" {
+				fromSession = student.SessionEntityID
+			}
+			planned.CarriedItems = append(planned.CarriedItems, models.CarriedItem{
+				ItemType:            item.ItemType,
+				ItemEntityID:        item.EntityID,
+				ItemName:            item.Name,
+				Amount:              item.Amount,
+				DueDate:             item.DueDate,
+				FromSessionEntityID: fromSession,
+			})
+			planned.CarriedDue += item.Amount
+		}
+
+		preview.Totals.Count(planned)
+		preview.Students = append(preview.Students, planned)
+	}
+
+	for _, override := range req.Overrides {
+		if !seen[override.StudentEntityID] {
+			return nil, fmt.Errorf("student %s is not enrolled in the source session", override.StudentEntityID)
+		}
+	}
+
+	return preview, nil
+}
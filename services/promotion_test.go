@@ -0,0 +1,107 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+)
+
+func TestPlanPromotion(t *testing.T) {
+	classItems := map[string][]feeItem{
+		boardClassKey("cbse", "c9"): {
+			{ItemType: "exam", EntityID: "c9-term", Name: "Term", Amount: 500, IsCompulsory: true},
+			{ItemType: "book", EntityID: "c9-atlas", Name: "Atlas", Amount: 200, IsCompulsory: false},
+		},
+	}
+	payments := []models.PaymentScanner{
+		{StudentEntityID: "paid", ExamEntityID: "c9-term", Status: "paid"},
+	}
+	ledger := newDuesLedger(classItems, payments)
+	ledger.Carry("owing", []feeItem{
+		{ItemType: "book", EntityID: "c8-maths", Name: "Maths", Amount: 300, IsCompulsory: true, CarriedFrom: "s0"},
+	})
+
+	student := func(id, class, div string) models.Student {
+		return models.Student{EntityID: id, SessionEntityID: "s1", BoardEntityID: "cbse", ClassEntityID: class, Div: div}
+	}
+	students := []models.Student{
+		student("paid", "c9", "A"),
+		student("owing", "c9", "B"),
+		student("held", "c9", "A"),
+		student("senior", "c10", "A"),
+		student("other", "c11", "A"),
+	}
+
+	div := "C"
+	req := &requests.PromotionRequest{
+		ToSessionEntityID: "s2",
+		Mappings: []requests.PromotionMappingRequest{
+			{BoardEntityID: "cbse", FromClassEntityID: "c9", Outcome: "promoted", ToClassEntityID: "c10"},
+			{BoardEntityID: "cbse", FromClassEntityID: "c10", Outcome: "graduated"},
+		},
+		Overrides: []requests.PromotionOverrideRequest{
+			{StudentEntityID: "held", Outcome: "detained", ToDiv: &div},
+		},
+	}
+
+	preview, err := planPromotion(students, ledger, req)
+	if err != nil {
+		t.Fatalf("planPromotion: %v", err)
+	}
+
+	planned := make(map[string]models.PromotionStudent)
+	for _, s := range preview.Students {
+		planned[s.StudentEntityID] = s
+	}
+
+	if got := planned["paid"]; got.Outcome != "promoted" || got.ToClassEntityID != "c10" || got.ToBoardEntityID != "cbse" || got.ToDiv != "A" || got.CarriedDue != 0 {
+		t.Errorf("paid student planned as %+v", got)
+	}
+
+	owing := planned["owing"]
+	if owing.CarriedDue != 800 || len(owing.CarriedItems) != 2 {
+		t.Fatalf("owing student carries %v in %d items, want 800 in 2", owing.CarriedDue, len(owing.CarriedItems))
+	}
+	for _, item := range owing.CarriedItems {
+		want := "s1"
+		if item.ItemEntityID == "c8-maths" {
+			want = "s0"
+		}
+		if item.FromSessionEntityID != want {
+			t.Errorf("item %s carried from %q, want %q", item.ItemEntityID, item.FromSessionEntityID, want)
+		}
+	}
+
+	if got := planned["held"]; got.Outcome != "detained" || !got.Overridden || got.ToClassEntityID != "c9" || got.ToDiv != "C" {
+		t.Errorf("detained student planned as %+v", got)
+	}
+	if got := planned["senior"]; got.Outcome != "graduated" || got.ToClassEntityID != "" {
+		t.Errorf("graduating student planned as %+v", got)
+	}
+
+	if len(preview.Unmapped) != 1 || preview.Unmapped[0].StudentEntityID != "other" {
+		t.Errorf("unmapped = %+v, want only %q", preview.Unmapped, "other")
+	}
+
+	want := models.PromotionTotals{Students: 4, Promoted: 2, Detained: 1, Graduated: 1, Unmapped: 1, StudentsWithDues: 2, CarriedDue: 1300}
+	if preview.Totals != want {
+		t.Errorf("totals = %+v, want %+v", preview.Totals, want)
+	}
+}
+
+func TestPlanPromotionRejectsUnknownOverride(t *testing.T) {
+	req := &requests.PromotionRequest{
+		ToSessionEntityID: "s2",
+		Mappings: []requests.PromotionMappingRequest{
+			{BoardEntityID: "cbse", FromClassEntityID: "c9", Outcome: "graduated"},
+		},
+		Overrides: []requests.PromotionOverrideRequest{
+			{StudentEntityID: "missing", Outcome: "detained"},
+		},
+	}
+
+	if _, err := planPromotion(nil, newDuesLedger(nil, nil), req); err == nil {
+		t.Fatal("expected an error for an override of a student outside the session")
+	}
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"shared/infra/db/mdb"
 
 	"github.com/nandani-y-meizo/school-backend/models"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 //
@@ -18,7 +18,7 @@ import (
 //
 
 type ReceiptService interface {
-	GetReceiptByRefNo(ctx context.Context, companyCode string, refNo string) (*models.Receipt, error)
+	GetReceiptByRefNo(ctx context.Context, companyCode string, refNo string, sessionID *string) (*models.Receipt, error)
 }
 
 //
@@ -39,24 +39,38 @@ func (s *receiptService) GetReceiptByRefNo(
 	ctx context.Context,
 	companyCode string,
 	refNo string,
+	sessionID *string,
 ) (*models.Receipt, error) {
 
 	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
 
-	// Get student collection
-	studentCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("students")
-
-	// Find student by refNo
-	var student models.Student
-	err := studentCollection.FindOne(ctx, bson.M{"ref_no": refNo, "is_deleted": false}).Decode(&student)
-	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("student not found with this ref no")
+	// The receipt shows one session's fees, the active one unless asked otherwise
+	session, err := resolveSessionPtr(ctx, database, sessionID)
+	if err != nil {
+		return nil, err
 	}
+
+	// Find student by refNo, with the class they were enrolled in during the session
+	students, err := findSessionStudents(ctx, database, session, bson.M{"ref_no": refNo, "is_deleted": false})
 	if err != nil {
 		return nil, err
 	}
+	if len(students) == 0 {
+		// Students deleted when they left keep their payment history; the latest one with the ref no wins
+		students, err = findSessionStudents(ctx, database, session, bson.M{"ref_no": refNo, "is_deleted": true})
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(students, func(i, j int) bool { return students[i].UpdatedAt.After(students[j].UpdatedAt) })
+	}
+	if len(students) == 0 {
+		if session != nil {
+			return nil, fmt.Errorf("student not found with this ref no in session %s", session.Name)
+		}
+		return nil, errors.New("student not found with this ref no")
+	}
+	student := students[0]
 
 	// Get payment scanner collection
 	paymentCollection := db.GetClient().
@@ -64,10 +78,10 @@ func (s *receiptService) GetReceiptByRefNo(
 		Collection("payment_scanners")
 
 	// Find all payments for this student
-	cursor, err := paymentCollection.Find(ctx, bson.M{
+	cursor, err := paymentCollection.Find(ctx, withSession(bson.M{
 		"student_entity_id": student.EntityID,
 		"is_deleted":        false,
-	})
+	}, session))
 	if err != nil {
 		return nil, err
 	}
@@ -83,53 +97,44 @@ func (s *receiptService) GetReceiptByRefNo(
 		Database(fmt.Sprintf("company_%s", companyCode)).
 		Collection("exams")
 
-	// Build payment history with exam details
-	paymentHistory := make([]models.PaymentHistoryItem, 0)
-	var totalPaid float64
-	examMap := make(map[string]models.Exam)
-
-	// First, get all exam details
+	// Build payment history; payments of exams and books share exam_entity_id
+	itemIDs := make([]string, 0, len(paymentScanners))
 	for _, payment := range paymentScanners {
-		if _, exists := examMap[payment.ExamEntityID]; !exists {
-			var exam models.Exam
-			err := examCollection.FindOne(ctx, bson.M{
-				"entity_id":  payment.ExamEntityID,
-				"is_deleted": false,
-			}).Decode(&exam)
-			if err == nil {
-				examMap[payment.ExamEntityID] = exam
-			}
-		}
+		itemIDs = append(itemIDs, payment.ExamEntityID)
+	}
+	itemNames, itemAmounts, err := loadItemNames(ctx, database, itemIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build payment history
+	paymentHistory := make([]models.PaymentHistoryItem, 0, len(paymentScanners))
+	var totalPaid float64
 	for _, payment := range paymentScanners {
-		if exam, exists := examMap[payment.ExamEntityID]; exists {
-			paymentHistory = append(paymentHistory, models.PaymentHistoryItem{
-				ID:            payment.ID,
-				EntityID:      payment.EntityID,
-				ExamEntityID:  payment.ExamEntityID,
-				PaymentID:     payment.PaymentID,
-				PaymentDate:   payment.PaymentDate,
-				PaymentMethod: payment.PaymentMethod,
-				Amount:        payment.Amount,
-				Status:        payment.Status,
-				TransactionID: payment.TransactionID,
-				ExamName:      exam.ExamName,
-				ExamAmount:    exam.ExamAmount,
-			})
-			if payment.Status == "paid" {
-				totalPaid += payment.Amount
-			}
+		paymentHistory = append(paymentHistory, models.PaymentHistoryItem{
+			ID:            payment.ID,
+			EntityID:      payment.EntityID,
+			ExamEntityID:  payment.ExamEntityID,
+			PaymentID:     payment.PaymentID,
+			PaymentDate:   payment.PaymentDate,
+			PaymentMethod: payment.PaymentMethod,
+			Amount:        payment.Amount,
+			Status:        payment.Status,
+			TransactionID: payment.TransactionID,
+			UPIReference:  payment.UPIReference,
+			ExamName:      itemNames[payment.ExamEntityID],
+			ExamAmount:    itemAmounts[payment.ExamEntityID],
+		})
+		if payment.Status == "paid" {
+			totalPaid += payment.Amount
 		}
 	}
 
 	// Get all exams for this student's class and board to determine pending payments
-	examCursor, err := examCollection.Find(ctx, bson.M{
+	examCursor, err := examCollection.Find(ctx, withSession(bson.M{
 		"class_entity_id": student.ClassEntityID,
 		"board_entity_id": student.BoardEntityID,
 		"is_deleted":      false,
-	})
+	}, session))
 	if err != nil {
 		return nil, err
 	}
@@ -146,11 +151,11 @@ func (s *receiptService) GetReceiptByRefNo(
 		Collection("books")
 
 	// Get all books for this student's class and board
-	bookCursor, err := bookCollection.Find(ctx, bson.M{
+	bookCursor, err := bookCollection.Find(ctx, withSession(bson.M{
 		"class_entity_id": student.ClassEntityID,
 		"board_entity_id": student.BoardEntityID,
 		"is_deleted":      false,
-	})
+	}, session))
 	if err != nil {
 		return nil, err
 	}
@@ -169,11 +174,8 @@ func (s *receiptService) GetReceiptByRefNo(
 	availableExams.Compulsory = make([]models.Exam, 0)
 	availableExams.Optional = make([]models.Exam, 0)
 
-	var totalDue float64
-	paidExamMap := make(map[string]bool)
-	paidBookMap := make(map[string]bool)
-
-	// Get all payment scanners for this student to check actual payment status
+	// Get all payment scanners for this student to check actual payment status. Items
+	// belong to one session, so carried-forward dues paid in a later session count too.
 	paymentCursor, err := paymentCollection.Find(ctx, bson.M{
 		"student_entity_id": student.EntityID,
 		"is_deleted":        false,
@@ -188,53 +190,54 @@ func (s *receiptService) GetReceiptByRefNo(
 		return nil, err
 	}
 
-	for _, payment := range paymentScannersForStatus {
-		if payment.Status == "paid" {
-			paidExamMap[payment.ExamEntityID] = true
-			paidBookMap[payment.ExamEntityID] = true
-			fmt.Printf("Found paid payment for entity_id: %s\n", payment.ExamEntityID)
+	// Dues come from the shared ledger so the receipt agrees with the unpaid list and dashboard
+	key := boardClassKey(student.BoardEntityID, student.ClassEntityID)
+	classItems := make(map[string][]feeItem)
+	for _, exam := range allExams {
+		classItems[key] = append(classItems[key], examFeeItem(exam))
+	}
+	for _, book := range allBooks {
+		classItems[key] = append(classItems[key], bookFeeItem(book))
+	}
+	ledger := newDuesLedger(classItems, paymentScannersForStatus)
+	if session != nil {
+		carried, err := loadCarriedDues(ctx, database, session.EntityID, []string{student.EntityID})
+		if err != nil {
+			return nil, err
 		}
+		ledger.Carry(student.EntityID, carried[student.EntityID])
+	}
+	dues := ledger.Dues(student, duePolicy{Optional: OptionalExcluded})
+	if !student.IsActive() {
+		// A student who left owes nothing further; their payments above still show
+		dues = studentDues{Offered: dues.Offered, Paid: dues.Paid}
+	}
+
+	for _, item := range dues.Pending {
+		pendingPayments = append(pendingPayments, models.PendingPayment{
+			ItemType:                   item.ItemType,
+			ExamEntityID:               item.EntityID,
+			ExamName:                   item.Name,
+			ExamAmount:                 item.Amount,
+			FeesPaid:                   false,
+			DueAmount:                  item.Amount,
+			IsCompulsory:               item.IsCompulsory,
+			CarriedFromSessionEntityID: item.CarriedFrom,
+		})
 	}
 
 	// Process exams
 	for _, exam := range allExams {
-		// Handle both old fees_paid (boolean) and new fees_type (string) fields
-		isCompulsory := false
-		if exam.FeesType != "" {
-			// New format: use fees_type string
-			isCompulsory = exam.FeesType == "compulsory"
-		} else {
-			// Old format: use fees_paid boolean
-			isCompulsory = exam.FeesPaid
-		}
-
-		// Check if this exam is actually paid
-		isPaid := paidExamMap[exam.EntityID]
+		isCompulsory := examFeeItem(exam).IsCompulsory
 
 		// Update the exam's fees_paid status to reflect actual payment status
-		exam.FeesPaid = isPaid
+		exam.FeesPaid = ledger.IsPaid(student.EntityID, exam.EntityID)
 
-		fmt.Printf("Exam %s (%s): isPaid=%t, original fees_paid=%t, new fees_paid=%t\n",
-			exam.ExamName, exam.EntityID, isPaid, exam.FeesPaid, exam.FeesPaid)
-
-		// Categorize for available exams
 		if isCompulsory {
 			availableExams.Compulsory = append(availableExams.Compulsory, exam)
 		} else {
 			availableExams.Optional = append(availableExams.Optional, exam)
 		}
-
-		// Calculate pending payments (unpaid exams)
-		if !isPaid {
-			pendingPayments = append(pendingPayments, models.PendingPayment{
-				ExamEntityID: exam.EntityID,
-				ExamName:     exam.ExamName,
-				ExamAmount:   exam.ExamAmount,
-				FeesPaid:     isPaid,
-				DueAmount:    exam.ExamAmount,
-			})
-			totalDue += exam.ExamAmount
-		}
 	}
 
 	// Process books
@@ -243,21 +246,10 @@ func (s *receiptService) GetReceiptByRefNo(
 	availableBooks.Optional = make([]models.Book, 0)
 
 	for _, book := range allBooks {
-		// Handle both old fees_paid (boolean) and new fees_type (string) fields
-		isCompulsory := false
-		if book.FeesType != "" {
-			// New format: use fees_type string
-			isCompulsory = book.FeesType == "compulsory"
-		} else {
-			// Old format: use fees_paid boolean
-			isCompulsory = book.FeesPaid
-		}
-
-		// Check if this book is actually paid
-		isPaid := paidBookMap[book.EntityID]
+		isCompulsory := bookFeeItem(book).IsCompulsory
 
 		// Update the book's fees_paid status to reflect actual payment status
-		book.FeesPaid = isPaid
+		book.FeesPaid = ledger.IsPaid(student.EntityID, book.EntityID)
 
 		if isCompulsory {
 			availableBooks.Compulsory = append(availableBooks.Compulsory, book)
@@ -298,6 +290,16 @@ func (s *receiptService) GetReceiptByRefNo(
 		className = class.ClassName
 	}
 
+	// Guardians, primary first
+	guardians, err := loadGuardians(ctx, database, []string{student.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	studentGuardians := guardians[student.EntityID]
+	if studentGuardians == nil {
+		studentGuardians = []models.Guardian{}
+	}
+
 	// Build receipt
 	receipt := &models.Receipt{
 		StudentDetails: models.StudentPaymentDetails{
@@ -310,12 +312,15 @@ func (s *receiptService) GetReceiptByRefNo(
 			Div:           student.Div,
 			BoardEntityID: student.BoardEntityID,
 			ClassEntityID: student.ClassEntityID,
+			Status:        studentStatus(&student),
 			BoardName:     boardName,
 			ClassName:     className,
 		},
+		Session:         session,
+		Guardians:       studentGuardians,
 		PaymentHistory:  paymentHistory,
 		TotalPaid:       totalPaid,
-		TotalDue:        totalDue,
+		TotalDue:        dues.TotalDue,
 		PendingPayments: pendingPayments,
 		AvailableBooks:  availableBooks,
 		AvailableExams:  availableExams,
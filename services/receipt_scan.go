@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"shared/infra/db/mdb"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// receiptCodePrefix versions the receipt QR format: SBR1:<company>:<payment_id>:<signature>
+const receiptCodePrefix = "SBR1"
+
+var (
+	receiptSigningKeyMu sync.RWMutex
+	receiptSigningKey   []byte
+)
+
+// SetReceiptSigningKey sets the HMAC key receipt and hall ticket QR codes are signed and
+// verified with
+func SetReceiptSigningKey(key []byte) {
+	receiptSigningKeyMu.Lock()
+	defer receiptSigningKeyMu.Unlock()
+	receiptSigningKey = key
+}
+
+func getReceiptSigningKey() []byte {
+	receiptSigningKeyMu.RLock()
+	defer receiptSigningKeyMu.RUnlock()
+	return receiptSigningKey
+}
+
+// ReceiptSigningKeyFromEnv reads RECEIPT_SIGNING_KEY, which must be at least 32 characters
+func ReceiptSigningKeyFromEnv() ([]byte, error) {
+	key := os.Getenv("RECEIPT_SIGNING_KEY")
+	if key == "" {
+		return nil, errors.New("RECEIPT_SIGNING_KEY is not set")
+	}
+	if len(key) < 32 {
+		return nil, errors.New("RECEIPT_SIGNING_KEY must be at least 32 characters")
+	}
+	return []byte(key), nil
+}
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ReceiptScanService interface {
+	Scan(ctx context.Context, companyCode string, req *requests.ScanReceiptRequest) (*models.ReceiptScanResult, error)
+	GetReceiptCode(ctx context.Context, companyCode string, paymentID string) (*models.ReceiptCode, error)
+	GetReceiptQRCode(ctx context.Context, companyCode string, paymentID string) ([]byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type receiptScanService struct{}
+
+func NewReceiptScanService() ReceiptScanService {
+	return &receiptScanService{}
+}
+
+//
+// ================= SCAN =================
+//
+
+// Scan resolves whatever the counter's scanner read: a signed receipt QR, a UPI
+// reference or the ref_no barcode of a student ID card
+func (s *receiptScanService) Scan(
+	ctx context.Context,
+	companyCode string,
+	req *requests.ScanReceiptRequest,
+) (*models.ReceiptScanResult, error) {
+
+	code := normaliseScanPayload(req.Payload)
+	if code == "" {
+		return nil, errors.New("scanned code is empty")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	if strings.HasPrefix(code, receiptCodePrefix+":") {
+		key := getReceiptSigningKey()
+		if key == nil {
+			return nil, errors.New("receipt codes cannot be verified: receipt signing key is not configured")
+		}
+		paymentID, err := parseReceiptCode(key, companyCode, code)
+		if err != nil {
+			return nil, err
+		}
+		checkout, err := loadCheckout(ctx, database, bson.M{"payment_id": paymentID})
+		if err != nil {
+			return nil, err
+		}
+		if checkout == nil {
+			return nil, fmt.Errorf("no payment found for receipt %s", paymentID)
+		}
+		return &models.ReceiptScanResult{Source: "receipt_code", Code: code, Checkout: checkout}, nil
+	}
+
+	if strings.HasPrefix(strings.ToLower(code), "upi://") {
+		return nil, errors.New("this is a UPI payment request QR, not a receipt; scan or type the UPI reference of the payment instead")
+	}
+
+	// A UTR is twelve digits, which a numeric ref_no could also be, so an unknown UTR
+	// still falls through to the student lookup
+	if requests.IsUPIReference(code) {
+		checkout, err := loadCheckout(ctx, database, bson.M{"upi_reference": code})
+		if err != nil {
+			return nil, err
+		}
+		if checkout != nil {
+			return &models.ReceiptScanResult{Source: "upi_reference", Code: code, Checkout: checkout}, nil
+		}
+	}
+
+	known, err := database.Collection(StudentCollection).CountDocuments(ctx, bson.M{"ref_no": code})
+	if err != nil {
+		return nil, err
+	}
+	if known == 0 {
+		return nil, fmt.Errorf("unrecognised code %q: not a student ref no, receipt QR or UPI reference", code)
+	}
+
+	receipt, err := NewReceiptService().GetReceiptByRefNo(ctx, companyCode, code, req.SessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReceiptScanResult{Source: "ref_no", Code: code, Receipt: receipt}, nil
+}
+
+//
+// ================= RECEIPT CODE =================
+//
+
+// GetReceiptCode returns the signed code printed on the receipt of a checkout
+func (s *receiptScanService) GetReceiptCode(
+	ctx context.Context,
+	companyCode string,
+	paymentID string,
+) (*models.ReceiptCode, error) {
+
+	key := getReceiptSigningKey()
+	if key == nil {
+		return nil, errors.New("receipt signing key is not configured")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	count, err := database.Collection("payment_scanners").CountDocuments(ctx, bson.M{
+		"payment_id": paymentID,
+		"is_deleted": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("no payment found with payment id: %s", paymentID)
+	}
+
+	return &models.ReceiptCode{PaymentID: paymentID, Code: signReceiptCode(key, companyCode, paymentID)}, nil
+}
+
+// GetReceiptQRCode renders the receipt code as a PNG QR code
+func (s *receiptScanService) GetReceiptQRCode(
+	ctx context.Context,
+	companyCode string,
+	paymentID string,
+) ([]byte, error) {
+
+	receiptCode, err := s.GetReceiptCode(ctx, companyCode, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qr.Encode(receiptCode.Code, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	scaled, err := barcode.Scale(code, 256, 256)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(scaled)
+}
+
+//
+// ================= HELPERS =================
+//
+
+// signReceiptCode binds the payment to the company so a receipt from one school
+// cannot be replayed at another
+func signReceiptCode(key []byte, companyCode string, paymentID string) string {
+	payload := receiptCodePrefix + ":" + companyCode + ":" + paymentID
+	return payload + ":" + receiptCodeSignature(key, payload)
+}
+
+// receiptCodeSignature is a truncated HMAC-SHA256, short enough to keep the QR small
+func receiptCodeSignature(key []byte, payload string) string {
+	return base64.RawURLEncoding.EncodeToString(hmacSHA256(key, payload)[:16])
+}
+
+// parseReceiptCode verifies a receipt code and returns its payment ID
+func parseReceiptCode(key []byte, companyCode string, code string) (string, error) {
+	parts := strings.Split(code, ":")
+	if len(parts) != 4 || parts[0] != receiptCodePrefix || parts[1] == "" || parts[2] == "" {
+		return "", errors.New("malformed receipt code")
+	}
+
+	payload := strings.Join(parts[:3], ":")
+	if !hmac.Equal([]byte(parts[3]), []byte(receiptCodeSignature(key, payload))) {
+		return "", errors.New("receipt code signature is invalid")
+	}
+	if parts[1] != companyCode {
+		return "", errors.New("receipt was issued by another school")
+	}
+	return parts[2], nil
+}
+
+// normaliseScanPayload strips what scanners add around the code: the AIM symbology
+// identifier some send first (e.g. "]C0" for Code128, "]Q1" for QR), the Enter or Tab
+// they end with and any other control characters
+func normaliseScanPayload(raw string) string {
+	code := strings.TrimSpace(raw)
+	if len(code) >= 3 && code[0] == ']' {
+		code = code[3:]
+	}
+	code = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, code)
+	return strings.TrimSpace(code)
+}
+
+// loadCheckout gathers the items of the latest checkout matching the filter, or
+// returns nil when there is none
+func loadCheckout(ctx context.Context, database *mongo.Database, filter bson.M) (*models.CheckoutReceipt, error) {
+	paymentCollection := database.Collection("payment_scanners")
+	filter["is_deleted"] = false
+
+	var latest models.PaymentScanner
+	err := paymentCollection.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "payment_date", Value: -1}})).Decode(&latest)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := paymentCollection.Find(ctx, bson.M{"payment_id": latest.PaymentID, "is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []models.PaymentScanner
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]string, 0, len(payments))
+	for _, payment := range payments {
+		itemIDs = append(itemIDs, payment.ExamEntityID)
+	}
+	itemNames, itemAmounts, err := loadItemNames(ctx, database, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	checkout := &models.CheckoutReceipt{
+		PaymentID:       latest.PaymentID,
+		SessionEntityID: latest.SessionEntityID,
+		PaymentDate:     latest.PaymentDate,
+		PaymentMethod:   latest.PaymentMethod,
+		UPIReference:    latest.UPIReference,
+		CollectedBy:     latest.CollectedBy,
+		Items:           make([]models.PaymentHistoryItem, 0, len(payments)),
+	}
+
+	reversed := 0
+	for _, payment := range payments {
+		checkout.Items = append(checkout.Items, models.PaymentHistoryItem{
+			ID:            payment.ID,
+			EntityID:      payment.EntityID,
+			ExamEntityID:  payment.ExamEntityID,
+			PaymentID:     payment.PaymentID,
+			PaymentDate:   payment.PaymentDate,
+			PaymentMethod: payment.PaymentMethod,
+			Amount:        payment.Amount,
+			Status:        payment.Status,
+			TransactionID: payment.TransactionID,
+			UPIReference:  payment.UPIReference,
+			ExamName:      itemNames[payment.ExamEntityID],
+			ExamAmount:    itemAmounts[payment.ExamEntityID],
+		})
+		if payment.Status == "paid" {
+			checkout.TotalPaid += payment.Amount
+		} else {
+			reversed++
+		}
+	}
+	sort.SliceStable(checkout.Items, func(i, j int) bool { return checkout.Items[i].ExamName < checkout.Items[j].ExamName })
+
+	switch {
+	case reversed == 0:
+		checkout.Status = "paid"
+	case reversed == len(payments):
+		checkout.Status = "reversed"
+	default:
+		checkout.Status = "partially_reversed"
+	}
+
+	// The student may have left since; their payments still resolve
+	var student models.Student
+	err = database.Collection(StudentCollection).FindOne(ctx, bson.M{"entity_id": latest.StudentEntityID}).Decode(&student)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil {
+		boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+		if err != nil {
+			return nil, err
+		}
+		checkout.StudentDetails = models.StudentPaymentDetails{
+			ID:            student.ID,
+			EntityID:      student.EntityID,
+			RefNo:         student.RefNo,
+			FirstName:     student.FirstName,
+			MiddleName:    student.MiddleName,
+			LastName:      student.LastName,
+			Div:           student.Div,
+			BoardEntityID: student.BoardEntityID,
+			ClassEntityID: student.ClassEntityID,
+			Status:        studentStatus(&student),
+			BoardName:     boardNames[student.BoardEntityID],
+			ClassName:     classNames[student.ClassEntityID],
+		}
+	}
+
+	return checkout, nil
+}
+
+// loadItemNames maps exam and book entity IDs, which payments share one field for,
+// to their names and amounts
+func loadItemNames(ctx context.Context, database *mongo.Database, ids []string) (map[string]string, map[string]float64, error) {
+	names := make(map[string]string)
+	amounts := make(map[string]float64)
+
+	examCursor, err := database.Collection(ExamCollection).Find(ctx, bson.M{"entity_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var exams []models.Exam
+	if err := examCursor.All(ctx, &exams); err != nil {
+		return nil, nil, err
+	}
+	for _, exam := range exams {
+		names[exam.EntityID] = exam.ExamName
+		amounts[exam.EntityID] = exam.ExamAmount
+	}
+
+	bookCursor, err := database.Collection(BookCollection).Find(ctx, bson.M{"entity_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var books []models.Book
+	if err := bookCursor.All(ctx, &books); err != nil {
+		return nil, nil, err
+	}
+	for _, book := range books {
+		names[book.EntityID] = book.BookName
+		amounts[book.EntityID] = book.Amount
+	}
+
+	return names, amounts, nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReceiptCodeRoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	code := signReceiptCode(key, "acme", "PAY_1760000000_a1b2c3")
+
+	if !strings.HasPrefix(code, "SBR1:acme:PAY_1760000000_a1b2c3:") {
+		t.Fatalf("unexpected code %q", code)
+	}
+	paymentID, err := parseReceiptCode(key, "acme", code)
+	if err != nil || paymentID != "PAY_1760000000_a1b2c3" {
+		t.Fatalf("parseReceiptCode = %q, %v", paymentID, err)
+	}
+
+	tampered := strings.Replace(code, "a1b2c3", "a1b2c4", 1)
+	if _, err := parseReceiptCode(key, "acme", tampered); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("tampered payment id: err = %v", err)
+	}
+	if _, err := parseReceiptCode([]byte("another-key-another-key-another-k"), "acme", code); err == nil {
+		t.Error("expected a code signed with another key to be refused")
+	}
+	if _, err := parseReceiptCode(key, "globex", code); err == nil || !strings.Contains(err.Error(), "another school") {
+		t.Errorf("other company: err = %v", err)
+	}
+	for _, malformed := range []string{"SBR1:acme:PAY_1", "SBR2:acme:PAY_1:sig", "SBR1::PAY_1:sig", "SBR1:acme:PAY:1:sig"} {
+		if _, err := parseReceiptCode(key, "acme", malformed); err == nil {
+			t.Errorf("expected %q to be refused", malformed)
+		}
+	}
+}
+
+func TestNormaliseScanPayload(t *testing.T) {
+	cases := map[string]string{
+		"STU-0007\r\n":         "STU-0007",
+		"  STU-0007\t":         "STU-0007",
+		"]C0STU-0007\n":        "STU-0007",
+		"]Q1SBR1:acme:PAY:sig": "SBR1:acme:PAY:sig",
+		"STU\x00-0007":         "STU-0007",
+		"\r\n":                 "",
+	}
+	for raw, want := range cases {
+		if got := normaliseScanPayload(raw); got != want {
+			t.Errorf("normaliseScanPayload(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
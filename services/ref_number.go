@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	RefNumberSettingsCollection = "ref_number_settings"
+	RefNumberCounterCollection  = "ref_number_counters"
+)
+
+// RefNoConflictError is returned when ref numbers are already taken by active students.
+// Routes answer it with 409 Conflict and the conflicting numbers.
+type RefNoConflictError struct {
+	Conflicts []models.RefNoConflict
+}
+
+func (e *RefNoConflictError) Error() string {
+	if len(e.Conflicts) == 1 {
+		conflict := e.Conflicts[0]
+		if conflict.RefNo == "" {
+			return "ref_no is already in use"
+		}
+		if conflict.Row > 0 {
+			return fmt.Sprintf("row %d: ref_no %s is already in use", conflict.Row, conflict.RefNo)
+		}
+		return fmt.Sprintf("ref_no %s is already in use", conflict.RefNo)
+	}
+	return fmt.Sprintf("%d ref numbers are already in use", len(e.Conflicts))
+}
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type RefNumberService interface {
+	GetSettings(ctx context.Context, companyCode string) (*models.RefNumberSettings, error)
+	UpdateSettings(ctx context.Context, companyCode string, req *requests.UpdateRefNumberSettingsRequest) (*models.RefNumberSettings, error)
+	GetDuplicates(ctx context.Context, companyCode string) ([]models.DuplicateRefNo, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type refNumberService struct{}
+
+func NewRefNumberService() RefNumberService {
+	return &refNumberService{}
+}
+
+//
+// ================= GET SETTINGS =================
+//
+
+// GetSettings returns the company's ref number settings with the number the next
+// admission would get
+func (s *refNumberService) GetSettings(
+	ctx context.Context,
+	companyCode string,
+) (*models.RefNumberSettings, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	settings, err := loadRefNumberSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := previewNextRefNo(ctx, database, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+//
+// ================= UPDATE SETTINGS =================
+//
+
+func (s *refNumberService) UpdateSettings(
+	ctx context.Context,
+	companyCode string,
+	req *requests.UpdateRefNumberSettingsRequest,
+) (*models.RefNumberSettings, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	updateFields := bson.M{}
+	if req.IsEnabled != nil {
+		updateFields["is_enabled"] = *req.IsEnabled
+	}
+	if req.Prefix != nil {
+		updateFields["prefix"] = *req.Prefix
+	}
+	if req.Separator != nil {
+		updateFields["separator"] = *req.Separator
+	}
+	if req.IncludeYear != nil {
+		updateFields["include_year"] = *req.IncludeYear
+	}
+	if req.Padding != nil {
+		updateFields["padding"] = *req.Padding
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	// The first save starts from the defaults for whatever was not given
+	defaults := models.NewRefNumberSettings()
+	insertFields := bson.M{
+		"_id":        defaults.ID,
+		"entity_id":  defaults.EntityID,
+		"created_at": defaults.CreatedAt,
+	}
+	for key, value := range map[string]interface{}{
+		"is_enabled":   defaults.IsEnabled,
+		"prefix":       defaults.Prefix,
+		"separator":    defaults.Separator,
+		"include_year": defaults.IncludeYear,
+		"padding":      defaults.Padding,
+	} {
+		if _, ok := updateFields[key]; !ok {
+			insertFields[key] = value
+		}
+	}
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var settings models.RefNumberSettings
+	err := database.Collection(RefNumberSettingsCollection).
+		FindOneAndUpdate(ctx, bson.M{}, bson.M{"$set": updateFields, "$setOnInsert": insertFields}, opts).
+		Decode(&settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := previewNextRefNo(ctx, database, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+//
+// ================= GET DUPLICATES =================
+//
+
+// GetDuplicates lists ref numbers shared by several active students. They have to be
+// resolved before the unique index on ref_no can be built.
+func (s *refNumberService) GetDuplicates(
+	ctx context.Context,
+	companyCode string,
+) ([]models.DuplicateRefNo, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return findDuplicateRefNos(ctx, database)
+}
+
+//
+// ================= HELPERS =================
+//
+
+// refNoIndexes keeps a ref_no on one active student. The index cannot be built while
+// duplicates exist; GET /ref-numbers/duplicates lists them, and checkRefNosAvailable gives
+// a friendlier error for the common case before the index rejects the write.
+var refNoIndexes = []companyIndex{
+	{
+		Collection: StudentCollection,
+		Model: mongo.IndexModel{
+			Keys: bson.D{{Key: "ref_no", Value: 1}},
+			Options: options.Index().
+				SetName("ref_no_active_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"is_deleted": false, "ref_no": bson.M{"$type": "string"}}),
+		},
+		Duplicates: describeDuplicateRefNos,
+	},
+}
+
+// findDuplicateRefNos lists ref numbers shared by several active students
+func findDuplicateRefNos(ctx context.Context, database *mongo.Database) ([]models.DuplicateRefNo, error) {
+	cursor, err := database.Collection(StudentCollection).Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"is_deleted": false, "ref_no": bson.M{"$type": "string"}}},
+		{"$group": bson.M{
+			"_id":                "$ref_no",
+			"student_entity_ids": bson.M{"$push": "$entity_id"},
+			"count":              bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	duplicates := []models.DuplicateRefNo{}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return nil, err
+	}
+
+	return duplicates, nil
+}
+
+// describeDuplicateRefNos names the ref numbers that keep the unique index from being built
+func describeDuplicateRefNos(ctx context.Context, database *mongo.Database) (string, error) {
+	duplicates, err := findDuplicateRefNos(ctx, database)
+	if err != nil {
+		return "", err
+	}
+
+	refNos := make([]string, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		refNos = append(refNos, fmt.Sprintf("%s (%d students)", duplicate.RefNo, len(duplicate.StudentEntityIDs)))
+	}
+	return fmt.Sprintf("%d ref numbers are held by more than one active student: %s",
+		len(duplicates), strings.Join(refNos, ", ")), nil
+}
+
+// checkRefNosAvailable reports which of the ref numbers active students other than
+// exceptEntityID already hold
+func checkRefNosAvailable(ctx context.Context, database *mongo.Database, refNos []string, exceptEntityID string) error {
+	if len(refNos) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"ref_no": bson.M{"$in": refNos}, "is_deleted": false}
+	if exceptEntityID != "" {
+		filter["entity_id"] = bson.M{"$ne": exceptEntityID}
+	}
+
+	cursor, err := database.Collection(StudentCollection).Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"ref_no": 1, "entity_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var taken []models.Student
+	if err := cursor.All(ctx, &taken); err != nil {
+		return err
+	}
+	if len(taken) == 0 {
+		return nil
+	}
+
+	conflicts := make([]models.RefNoConflict, 0, len(taken))
+	for _, student := range taken {
+		conflicts = append(conflicts, models.RefNoConflict{RefNo: student.RefNo, StudentEntityID: student.EntityID})
+	}
+	return &RefNoConflictError{Conflicts: conflicts}
+}
+
+// refNoWriteError turns a duplicate key error from the unique index into a conflict
+func refNoWriteError(err error, refNo string) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return &RefNoConflictError{Conflicts: []models.RefNoConflict{{RefNo: refNo}}}
+	}
+	return err
+}
+
+func loadRefNumberSettings(ctx context.Context, database *mongo.Database) (*models.RefNumberSettings, error) {
+	var settings models.RefNumberSettings
+	err := database.Collection(RefNumberSettingsCollection).FindOne(ctx, bson.M{}).Decode(&settings)
+	if err == mongo.ErrNoDocuments {
+		return models.NewRefNumberSettings(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// refNoYear is the admission year in generated numbers: the start year of the active
+// session, or the current year before sessions are set up
+func refNoYear(ctx context.Context, database *mongo.Database) (int, error) {
+	session, err := activeSession(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+	if session == nil {
+		return time.Now().Year(), nil
+	}
+	return session.StartDate.Year(), nil
+}
+
+// refNoCounterKey names the sequence a number is drawn from; each year restarts at 1
+func refNoCounterKey(settings *models.RefNumberSettings, year int) string {
+	if settings.IncludeYear {
+		return fmt.Sprintf("%s|%d", settings.Prefix, year)
+	}
+	return settings.Prefix
+}
+
+// formatRefNo joins the prefix, year and zero-padded sequence with the separator
+func formatRefNo(settings *models.RefNumberSettings, year int, sequence int64) string {
+	parts := []string{}
+	if settings.Prefix != "" {
+		parts = append(parts, settings.Prefix)
+	}
+	if settings.IncludeYear {
+		parts = append(parts, strconv.Itoa(year))
+	}
+	parts = append(parts, fmt.Sprintf("%0*d", settings.Padding, sequence))
+	return strings.Join(parts, settings.Separator)
+}
+
+// previewNextRefNo fills in the number the next admission would get, without using it up
+func previewNextRefNo(ctx context.Context, database *mongo.Database, settings *models.RefNumberSettings) error {
+	if !settings.IsEnabled {
+		return nil
+	}
+
+	year, err := refNoYear(ctx, database)
+	if err != nil {
+		return err
+	}
+
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err = database.Collection(RefNumberCounterCollection).
+		FindOne(ctx, bson.M{"_id": refNoCounterKey(settings, year)}).
+		Decode(&counter)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	settings.NextRefNo = formatRefNo(settings, year, counter.Sequence+1)
+	return nil
+}
+
+// generateRefNos hands out count new ref numbers, skipping any already taken by hand.
+// It fails when generation is not enabled.
+func generateRefNos(ctx context.Context, database *mongo.Database, count int) ([]string, error) {
+	if count == 0 {
+		return nil, nil
+	}
+
+	settings, err := loadRefNumberSettings(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.IsEnabled {
+		return nil, errors.New("ref_no is required; automatic ref numbers are not enabled")
+	}
+
+	year, err := refNoYear(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	key := refNoCounterKey(settings, year)
+
+	refNos := make([]string, 0, count)
+	for len(refNos) < count {
+		// Reserve the whole remaining block in one step
+		need := count - len(refNos)
+		var counter struct {
+			Sequence int64 `bson:"sequence"`
+		}
+		err := database.Collection(RefNumberCounterCollection).FindOneAndUpdate(ctx,
+			bson.M{"_id": key},
+			bson.M{"$inc": bson.M{"sequence": need}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&counter)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates := make([]string, 0, need)
+		for sequence := counter.Sequence - int64(need) + 1; sequence <= counter.Sequence; sequence++ {
+			candidates = append(candidates, formatRefNo(settings, year, sequence))
+		}
+
+		taken := make(map[string]bool)
+		var conflict *RefNoConflictError
+		if err := checkRefNosAvailable(ctx, database, candidates, ""); errors.As(err, &conflict) {
+			for _, c := range conflict.Conflicts {
+				taken[c.RefNo] = true
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, candidate := range candidates {
+			if !taken[candidate] {
+				refNos = append(refNos, candidate)
+			}
+		}
+	}
+
+	return refNos, nil
+}
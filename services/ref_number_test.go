@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestFormatRefNo(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.RefNumberSettings
+		sequence int64
+		want     string
+	}{
+		{
+			name:     "prefix, year and padded sequence",
+			settings: models.RefNumberSettings{Prefix: "ADM", Separator: "-", IncludeYear: true, Padding: 4},
+			sequence: 42,
+			want:     "ADM-2025-0042",
+		},
+		{
+			name:     "no prefix",
+			settings: models.RefNumberSettings{Separator: "/", IncludeYear: true, Padding: 3},
+			sequence: 7,
+			want:     "2025/007",
+		},
+		{
+			name:     "no year and no separator",
+			settings: models.RefNumberSettings{Prefix: "S", Padding: 5},
+			sequence: 123,
+			want:     "S00123",
+		},
+		{
+			name:     "sequence wider than the padding",
+			settings: models.RefNumberSettings{Prefix: "ADM", Separator: "-", Padding: 2},
+			sequence: 1234,
+			want:     "ADM-1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRefNo(&tt.settings, 2025, tt.sequence); got != tt.want {
+				t.Errorf("formatRefNo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefNoCounterKeyRestartsEachYear(t *testing.T) {
+	yearly := &models.RefNumberSettings{Prefix: "ADM", IncludeYear: true}
+	if refNoCounterKey(yearly, 2025) == refNoCounterKey(yearly, 2026) {
+		t.Error("sequences with the year in the number should restart each year")
+	}
+
+	running := &models.RefNumberSettings{Prefix: "ADM"}
+	if refNoCounterKey(running, 2025) != refNoCounterKey(running, 2026) {
+		t.Error("sequences without the year should keep running across years")
+	}
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ReminderScheduleCollection = "reminder_schedules"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ReminderScheduleService interface {
+	Create(ctx context.Context, companyCode string, req *requests.CreateReminderScheduleRequest) (*models.ReminderSchedule, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.ReminderSchedule, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.ReminderSchedule, error)
+	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateReminderScheduleRequest) (*models.ReminderSchedule, error)
+	Delete(ctx context.Context, companyCode string, id string) error
+	RunNow(ctx context.Context, companyCode string, id string, triggeredBy models.PaymentActor) (*models.ReminderBatch, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type reminderScheduleService struct{}
+
+func NewReminderScheduleService() ReminderScheduleService {
+	return &reminderScheduleService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+func (s *reminderScheduleService) Create(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateReminderScheduleRequest,
+) (*models.ReminderSchedule, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReminderScheduleCollection)
+
+	schedule := models.NewReminderSchedule()
+	schedule.Bind(req)
+
+	nextRun, err := nextReportRun(schedule.Schedule, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = nextRun
+
+	if _, err := collection.InsertOne(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+func (s *reminderScheduleService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.ReminderSchedule, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReminderScheduleCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var schedules []*models.ReminderSchedule
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *reminderScheduleService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.ReminderSchedule, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReminderScheduleCollection)
+
+	var schedule models.ReminderSchedule
+	err := collection.FindOne(ctx, reminderScheduleFilter(id)).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("reminder schedule not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *reminderScheduleService) Update(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.UpdateReminderScheduleRequest,
+) (*models.ReminderSchedule, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReminderScheduleCollection)
+
+	updateFields := bson.M{}
+
+	if req.Name != nil {
+		updateFields["name"] = *req.Name
+	}
+	if req.Channel != nil {
+		updateFields["channel"] = *req.Channel
+	}
+	if req.Template != nil {
+		updateFields["template"] = *req.Template
+	}
+	if req.ThrottleHours != nil {
+		updateFields["throttle_hours"] = *req.ThrottleHours
+	}
+	if req.Filter != nil {
+		updateFields["filter"] = models.NewReminderFilter(req.Filter)
+	}
+	if req.Schedule != nil {
+		schedule := models.ReportSchedule{
+			Frequency:  req.Schedule.Frequency,
+			TimeOfDay:  req.Schedule.TimeOfDay,
+			Weekday:    req.Schedule.Weekday,
+			DayOfMonth: req.Schedule.DayOfMonth,
+			Timezone:   req.Schedule.Timezone,
+		}
+		nextRun, err := nextReportRun(schedule, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		updateFields["schedule"] = schedule
+		updateFields["next_run_at"] = nextRun
+	}
+	if req.IsActive != nil {
+		updateFields["is_active"] = *req.IsActive
+	}
+	if req.IsDeleted != nil {
+		updateFields["is_deleted"] = *req.IsDeleted
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.ReminderSchedule
+	err := collection.
+		FindOneAndUpdate(ctx, reminderScheduleFilter(id), bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("reminder schedule not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+func (s *reminderScheduleService) Delete(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReminderScheduleCollection)
+
+	result, err := collection.UpdateOne(ctx, reminderScheduleFilter(id), bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("reminder schedule not found")
+	}
+
+	return nil
+}
+
+//
+// ================= RUN NOW =================
+//
+
+// RunNow sends the schedule's reminders immediately without moving the regular schedule
+func (s *reminderScheduleService) RunNow(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	triggeredBy models.PaymentActor,
+) (*models.ReminderBatch, error) {
+
+	schedule, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	job := reminderScheduleJob(schedule, "manual")
+	job.TriggeredBy = &triggeredBy
+
+	return sendReminders(ctx, NewGuardianContactResolver(), companyCode, job)
+}
+
+//
+// ================= HELPERS =================
+//
+
+func reminderScheduleFilter(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": oid, "is_deleted": false}
+	}
+	return bson.M{"entity_id": id, "is_deleted": false}
+}
+
+func reminderScheduleJob(schedule *models.ReminderSchedule, trigger string) reminderJob {
+	return reminderJob{
+		Channel:  schedule.Channel,
+		Template: schedule.Template,
+		Throttle: time.Duration(schedule.ThrottleHours) * time.Hour,
+		Filter:   schedule.Filter,
+		Trigger:  trigger,
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ReminderScheduler periodically sends every due fee reminder schedule across all company databases
+type ReminderScheduler struct {
+	interval time.Duration
+	contacts ContactResolver
+}
+
+func NewReminderScheduler(interval time.Duration) *ReminderScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ReminderScheduler{interval: interval, contacts: NewGuardianContactResolver()}
+}
+
+// Start blocks until ctx is cancelled, checking for due schedules every interval
+func (s *ReminderScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue sends the schedules whose next_run_at has passed. Schedules for a channel
+// without a provider are still moved forward, so they do not pile up.
+func (s *ReminderScheduler) RunDue(ctx context.Context, now time.Time) {
+	runDueSchedules(ctx, "reminder scheduler", ReminderScheduleCollection, now, bson.M{"last_run_at": now.UTC()},
+		func(companyCode string, raw bson.Raw) {
+			var schedule models.ReminderSchedule
+			if err := bson.Unmarshal(raw, &schedule); err != nil {
+				log.Printf("reminder scheduler: %s: decoding schedule failed: %v", companyCode, err)
+				return
+			}
+
+			batch, err := sendReminders(ctx, s.contacts, companyCode, reminderScheduleJob(&schedule, "schedule"))
+			if err != nil {
+				log.Printf("reminder scheduler: %s/%s failed: %v", companyCode, schedule.EntityID, err)
+				return
+			}
+			log.Printf("reminder scheduler: %s/%s sent %d, failed %d, throttled %d, opted out %d, no contact %d",
+				companyCode, schedule.EntityID, batch.Sent, batch.Failed, batch.Throttled, batch.OptedOut, batch.NoContact)
+		})
+}
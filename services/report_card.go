@@ -0,0 +1,461 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"shared/infra/db/mdb"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ReportCardService interface {
+	Get(ctx context.Context, companyCode string, examID string, req *requests.ReportCardRequest) (*models.ReportCardSet, error)
+	Render(ctx context.Context, companyCode string, examID string, req *requests.ReportCardRequest) ([]byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type reportCardService struct{}
+
+func NewReportCardService() ReportCardService {
+	return &reportCardService{}
+}
+
+//
+// ================= GET =================
+//
+
+// Get works out the report cards of everyone who sits the exam, so ranks are always
+// within the whole class, then returns the division or student asked for
+func (s *reportCardService) Get(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	req *requests.ReportCardRequest,
+) (*models.ReportCardSet, error) {
+
+	exam, err := NewExamService().GetByID(ctx, companyCode, examID)
+	if err != nil {
+		return nil, err
+	}
+	if len(exam.Schedule) == 0 {
+		return nil, errors.New("exam has no papers scheduled")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	set := &models.ReportCardSet{
+		SessionEntityID: exam.SessionEntityID,
+		ExamEntityID:    exam.EntityID,
+		ExamName:        exam.ExamName,
+	}
+	if exam.SessionEntityID != "" {
+		session, err := findAcademicSession(ctx, database, exam.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+		set.SessionName = session.Name
+	}
+
+	roster, err := examMarksRoster(ctx, database, exam, nil)
+	if err != nil {
+		return nil, err
+	}
+	marks, err := loadExamMarks(ctx, database, bson.M{"exam_entity_id": exam.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := loadExamMarkStatuses(ctx, database, exam)
+	if err != nil {
+		return nil, err
+	}
+	scale, err := loadGradingScale(ctx, database, exam.BoardEntityID)
+	if err != nil {
+		return nil, err
+	}
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := buildReportCards(exam.Schedule, statuses, scale, roster, marks)
+	rankReportCards(cards)
+
+	set.Cards = make([]models.ReportCard, 0, len(cards))
+	for _, card := range cards {
+		if req.Div != nil && *req.Div != "" && !strings.EqualFold(requests.NormaliseDivision(card.Div), *req.Div) {
+			continue
+		}
+		if req.StudentEntityID != nil && *req.StudentEntityID != "" && card.StudentEntityID != *req.StudentEntityID {
+			continue
+		}
+		card.BoardName = boardNames[exam.BoardEntityID]
+		card.ClassName = classNames[exam.ClassEntityID]
+		set.Cards = append(set.Cards, card)
+	}
+	if len(set.Cards) == 0 {
+		if req.StudentEntityID != nil && *req.StudentEntityID != "" {
+			return nil, errors.New("student does not sit this exam")
+		}
+		return nil, errors.New("no students sit this exam")
+	}
+
+	for _, status := range statuses {
+		if status != models.ExamMarksLocked {
+			set.Provisional = true
+		}
+	}
+
+	return set, nil
+}
+
+//
+// ================= RENDER =================
+//
+
+// Render prints the report cards as an A4 PDF, one student per page
+func (s *reportCardService) Render(
+	ctx context.Context,
+	companyCode string,
+	examID string,
+	req *requests.ReportCardRequest,
+) ([]byte, error) {
+
+	set, err := s.Get(ctx, companyCode, examID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	profile, err := loadSchoolProfile(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	sheet := reportCardSheet{SchoolName: profile.Name, Address: profile.Address}
+	if logo, err := loadSchoolLogo(ctx, profile); err != nil {
+		fmt.Printf("School logo not loaded for report cards: %v\n", err)
+	} else if logo != nil {
+		sheet.Logo = logo
+		sheet.LogoExt = idCardImageType(profile.LogoContentType)
+	}
+
+	return renderReportCards(sheet, set)
+}
+
+//
+// ================= HELPERS =================
+//
+
+// buildReportCards totals each student's papers. Absent papers count as zero and fail;
+// papers without marks leave the card incomplete.
+func buildReportCards(
+	papers []models.ExamPaper,
+	statuses map[string]string,
+	scale *models.GradingScale,
+	roster []models.Student,
+	marks []models.ExamMark,
+) []models.ReportCard {
+
+	byStudent := make(map[string]map[string]models.ExamMark, len(roster))
+	for _, mark := range marks {
+		if byStudent[mark.StudentEntityID] == nil {
+			byStudent[mark.StudentEntityID] = make(map[string]models.ExamMark)
+		}
+		byStudent[mark.StudentEntityID][mark.PaperEntityID] = mark
+	}
+
+	cards := make([]models.ReportCard, 0, len(roster))
+	for _, student := range roster {
+		card := models.ReportCard{
+			StudentEntityID: student.EntityID,
+			RefNo:           student.RefNo,
+			Name:            studentFullName(student),
+			Div:             student.Div,
+			Subjects:        make([]models.ReportCardSubject, 0, len(papers)),
+			Result:          "pass",
+		}
+
+		incomplete := false
+		for _, paper := range papers {
+			subject := models.ReportCardSubject{
+				PaperEntityID: paper.EntityID,
+				Subject:       paper.Subject,
+				MaxMarks:      paperMaxMarks(paper),
+				PassMarks:     paperPassMarks(paper, scale),
+				Status:        statuses[paper.EntityID],
+			}
+			card.MaxTotal += subject.MaxMarks
+
+			mark, ok := byStudent[student.EntityID][paper.EntityID]
+			switch {
+			case !ok:
+				incomplete = true
+			case mark.Absent:
+				subject.Absent = true
+				card.Result = "fail"
+			default:
+				value := mark.Marks
+				subject.Marks = &value
+				subject.Passed = value >= subject.PassMarks
+				if band := gradeFor(scale.Bands, value/subject.MaxMarks*100); band != nil {
+					subject.Grade = band.Grade
+				}
+				if !subject.Passed {
+					card.Result = "fail"
+				}
+				card.Total += value
+			}
+			card.Subjects = append(card.Subjects, subject)
+		}
+
+		card.Total = roundMarks(card.Total)
+		if card.MaxTotal > 0 {
+			card.Percentage = roundMarks(card.Total / card.MaxTotal * 100)
+		}
+		if incomplete {
+			card.Result = "incomplete"
+		} else if band := gradeFor(scale.Bands, card.Percentage); band != nil {
+			card.Grade = band.Grade
+			card.GradeRemark = band.Remark
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// rankReportCards ranks the complete cards by total, highest first. Equal totals share a
+// rank and the next one skips ahead, so two students at 2 are followed by 4.
+func rankReportCards(cards []models.ReportCard) {
+	ranked := make([]*models.ReportCard, 0, len(cards))
+	for i := range cards {
+		if cards[i].Result != "incomplete" {
+			ranked = append(ranked, &cards[i])
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Total > ranked[j].Total
+	})
+
+	for i, card := range ranked {
+		card.Rank = i + 1
+		if i > 0 && card.Total == ranked[i-1].Total {
+			card.Rank = ranked[i-1].Rank
+		}
+		card.RankOutOf = len(ranked)
+	}
+}
+
+func roundMarks(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func formatMarks(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// reportCardSheet is the school branding printed at the top of every report card
+type reportCardSheet struct {
+	SchoolName string
+	Address    string
+	Logo       []byte
+	LogoExt    string
+}
+
+func renderReportCards(sheet reportCardSheet, set *models.ReportCardSet) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 0)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	logoName := ""
+	if len(sheet.Logo) > 0 {
+		logoName = "school-logo"
+		pdf.RegisterImageOptionsReader(logoName, gofpdf.ImageOptions{ImageType: sheet.LogoExt}, bytes.NewReader(sheet.Logo))
+		if pdf.Err() {
+			// An unreadable logo should not stop the report cards from printing
+			pdf.ClearError()
+			logoName = ""
+		}
+	}
+
+	for _, card := range set.Cards {
+		pdf.AddPage()
+		drawReportCard(pdf, tr, sheet, logoName, set, card)
+	}
+
+	if pdf.Err() {
+		return nil, pdf.Error()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawReportCard(pdf *gofpdf.Fpdf, tr func(string) string, sheet reportCardSheet, logoName string, set *models.ReportCardSet, card models.ReportCard) {
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+	width := pageWidth - left - right
+
+	// School header
+	textX := left
+	if logoName != "" {
+		pdf.ImageOptions(logoName, left, top, 18, 18, false, gofpdf.ImageOptions{}, 0, "")
+		textX = left + 22
+	}
+	pdf.SetXY(textX, top+2)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(left+width-textX, 8, tr(sheet.SchoolName), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(left+width-textX, 5, tr(sheet.Address), "", 2, "L", false, 0, "")
+	pdf.SetDrawColor(31, 78, 121)
+	pdf.SetLineWidth(0.5)
+	pdf.Line(left, top+21, left+width, top+21)
+
+	// Title
+	pdf.SetXY(left, top+25)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(width, 8, "Report Card", "", 2, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	title := set.ExamName
+	if set.SessionName != "" {
+		title = fmt.Sprintf("%s - %s", title, set.SessionName)
+	}
+	pdf.CellFormat(width, 6, tr(title), "", 2, "C", false, 0, "")
+	if set.Provisional {
+		pdf.SetTextColor(180, 30, 30)
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(width, 5, "Provisional: marks are not yet locked", "", 2, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+	pdf.Ln(4)
+
+	// Student details, two to a line
+	class := card.ClassName
+	if card.Div != "" {
+		class = fmt.Sprintf("%s - %s", class, card.Div)
+	}
+	details := [][2]string{
+		{"Name", card.Name}, {"Ref No", card.RefNo},
+		{"Class", class}, {"Board", card.BoardName},
+	}
+	half := width / 2
+	for i, detail := range details {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(22, 6, detail[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		ln := 0
+		if i%2 == 1 {
+			ln = 1
+		}
+		pdf.CellFormat(half-22, 6, tr(detail[1]), "", ln, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Marks table
+	columns := []struct {
+		title string
+		width float64
+		align string
+	}{
+		{"Subject", width - 110, "L"},
+		{"Max", 20, "C"},
+		{"Pass", 20, "C"},
+		{"Obtained", 25, "C"},
+		{"Grade", 20, "C"},
+		{"Result", 25, "C"},
+	}
+	pdf.SetDrawColor(120, 120, 120)
+	pdf.SetLineWidth(0.2)
+	pdf.SetFillColor(243, 243, 243)
+	pdf.SetFont("Helvetica", "B", 10)
+	for _, column := range columns {
+		pdf.CellFormat(column.width, 8, column.title, "1", 0, column.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, subject := range card.Subjects {
+		obtained, result := "-", "-"
+		switch {
+		case subject.Absent:
+			obtained, result = "AB", "Fail"
+		case subject.Marks != nil:
+			obtained = formatMarks(*subject.Marks)
+			result = "Pass"
+			if !subject.Passed {
+				result = "Fail"
+			}
+		}
+		values := []string{subject.Subject, formatMarks(subject.MaxMarks), formatMarks(subject.PassMarks), obtained, subject.Grade, result}
+		for i, column := range columns {
+			pdf.CellFormat(column.width, 7, tr(fitText(pdf, tr, values[i], column.width-2)), "1", 0, column.align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(columns[0].width, 8, "Total", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(columns[1].width, 8, formatMarks(card.MaxTotal), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(columns[2].width, 8, "", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(columns[3].width, 8, formatMarks(card.Total), "1", 0, "C", true, 0, "")
+	pdf.CellFormat(columns[4].width+columns[5].width, 8, "", "1", 1, "C", true, 0, "")
+	pdf.Ln(6)
+
+	// Summary
+	rank := "-"
+	if card.Rank > 0 {
+		rank = fmt.Sprintf("%d of %d", card.Rank, card.RankOutOf)
+	}
+	grade := card.Grade
+	if card.GradeRemark != "" {
+		grade = fmt.Sprintf("%s (%s)", grade, card.GradeRemark)
+	}
+	if grade == "" {
+		grade = "-"
+	}
+	summary := [][2]string{
+		{"Percentage", formatMarks(card.Percentage) + "%"},
+		{"Grade", grade},
+		{"Result", strings.ToUpper(card.Result[:1]) + card.Result[1:]},
+		{"Class rank", rank},
+	}
+	for _, line := range summary {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(35, 7, line[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(width-35, 7, tr(line[1]), "", 1, "L", false, 0, "")
+	}
+
+	// Signatures
+	y := pageHeight - 35
+	pdf.SetLineWidth(0.2)
+	pdf.Line(left, y, left+50, y)
+	pdf.Line(left+width-50, y, left+width, y)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetXY(left, y+1)
+	pdf.CellFormat(50, 5, "Class Teacher", "", 0, "C", false, 0, "")
+	pdf.SetXY(left+width-50, y+1)
+	pdf.CellFormat(50, 5, "Principal", "", 0, "C", false, 0, "")
+}
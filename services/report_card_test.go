@@ -0,0 +1,102 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestGradeFor(t *testing.T) {
+	scale := models.NewGradingScale("board")
+	bands := append([]models.GradeBand(nil), scale.Bands...)
+	// Saved bands may arrive in any order
+	bands[0], bands[len(bands)-1] = bands[len(bands)-1], bands[0]
+	sortGradeBands(bands)
+
+	cases := map[float64]string{100: "A1", 91: "A1", 90.99: "A2", 33: "D", 32.5: "E", 0: "E"}
+	for percent, want := range cases {
+		band := gradeFor(bands, percent)
+		if band == nil || band.Grade != want {
+			t.Errorf("gradeFor(%g) = %v, want %s", percent, band, want)
+		}
+	}
+}
+
+func reportCardFixture() ([]models.ExamPaper, []models.Student, []models.ExamMark) {
+	papers := []models.ExamPaper{
+		{EntityID: "maths", Subject: "Mathematics", MaxMarks: 100},
+		{EntityID: "science", Subject: "Science", MaxMarks: 50, PassMarks: 20},
+	}
+	students := []models.Student{
+		{EntityID: "s1", RefNo: "R1", Div: "A"},
+		{EntityID: "s2", RefNo: "R2", Div: "A"},
+		{EntityID: "s3", RefNo: "R3", Div: "B"},
+		{EntityID: "s4", RefNo: "R4", Div: "B"},
+		{EntityID: "s5", RefNo: "R5", Div: "B"},
+	}
+	marks := []models.ExamMark{
+		{StudentEntityID: "s1", PaperEntityID: "maths", Marks: 90},
+		{StudentEntityID: "s1", PaperEntityID: "science", Marks: 45},
+		{StudentEntityID: "s2", PaperEntityID: "maths", Marks: 80},
+		{StudentEntityID: "s2", PaperEntityID: "science", Marks: 40},
+		{StudentEntityID: "s3", PaperEntityID: "maths", Marks: 90},
+		{StudentEntityID: "s3", PaperEntityID: "science", Marks: 45},
+		{StudentEntityID: "s4", PaperEntityID: "maths", Marks: 95},
+		{StudentEntityID: "s4", PaperEntityID: "science", Absent: true},
+		{StudentEntityID: "s5", PaperEntityID: "maths", Marks: 70},
+	}
+	return papers, students, marks
+}
+
+func TestBuildReportCards(t *testing.T) {
+	papers, students, marks := reportCardFixture()
+	statuses := map[string]string{"maths": models.ExamMarksLocked, "science": models.ExamMarksModerated}
+
+	cards := buildReportCards(papers, statuses, models.NewGradingScale("board"), students, marks)
+	rankReportCards(cards)
+
+	first := cards[0]
+	if first.Total != 135 || first.MaxTotal != 150 || first.Percentage != 90 || first.Grade != "A2" || first.Result != "pass" {
+		t.Errorf("unexpected card %+v", first)
+	}
+	if first.Subjects[1].PassMarks != 20 || first.Subjects[1].Grade != "A2" || first.Subjects[1].Status != models.ExamMarksModerated {
+		t.Errorf("unexpected subject %+v", first.Subjects[1])
+	}
+
+	// An absent paper counts as zero and fails
+	if cards[3].Result != "fail" || cards[3].Total != 95 || !cards[3].Subjects[1].Absent {
+		t.Errorf("unexpected absent card %+v", cards[3])
+	}
+
+	// A missing paper leaves the card incomplete and unranked
+	if cards[4].Result != "incomplete" || cards[4].Grade != "" || cards[4].Rank != 0 {
+		t.Errorf("unexpected incomplete card %+v", cards[4])
+	}
+
+	// Ties share a rank and the next rank skips ahead
+	wantRanks := []int{1, 3, 1, 4, 0}
+	for i, card := range cards {
+		if card.Rank != wantRanks[i] {
+			t.Errorf("%s rank = %d, want %d", card.StudentEntityID, card.Rank, wantRanks[i])
+		}
+		if card.Rank > 0 && card.RankOutOf != 4 {
+			t.Errorf("%s ranked out of %d, want 4", card.StudentEntityID, card.RankOutOf)
+		}
+	}
+}
+
+func TestRenderReportCards(t *testing.T) {
+	papers, students, marks := reportCardFixture()
+	cards := buildReportCards(papers, map[string]string{}, models.NewGradingScale("board"), students, marks)
+	rankReportCards(cards)
+
+	set := &models.ReportCardSet{ExamName: "Term 1", SessionName: "2026-27", Provisional: true, Cards: cards}
+	pdf, err := renderReportCards(reportCardSheet{SchoolName: "Greenfield School", Address: "1 Main Road"}, set)
+	if err != nil {
+		t.Fatalf("renderReportCards: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF")) {
+		t.Error("expected a PDF document")
+	}
+}
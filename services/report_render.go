@@ -0,0 +1,317 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+)
+
+// reportTable is the format-independent shape every scheduled report is reduced to,
+// so the HTML body, CSV and PDF attachments always carry the same figures.
+type reportTable struct {
+	Title   string
+	Period  string
+	Summary [][2]string
+	Headers []string
+	Rows    [][]string
+}
+
+type renderedReport struct {
+	Subject    string
+	HTML       string
+	Attachment MailAttachment
+}
+
+// renderSubscriptionReport builds the report for a subscription as of runAt
+func renderSubscriptionReport(
+	ctx context.Context,
+	companyCode string,
+	subscription *models.ReportSubscription,
+	runAt time.Time,
+) (*renderedReport, error) {
+
+	loc, err := time.LoadLocation(subscription.Schedule.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	day := runAt.In(loc).Format("2006-01-02")
+
+	var table *reportTable
+	switch subscription.ReportType {
+	case "daily_collection":
+		table, err = dailyCollectionTable(ctx, companyCode, day)
+	case "unpaid_students":
+		table, err = unpaidStudentsTable(ctx, companyCode, subscription, day)
+	case "dashboard_stats":
+		table, err = dashboardStatsTable(ctx, companyCode, subscription, day)
+	default:
+		err = fmt.Errorf("unknown report type %s", subscription.ReportType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := renderReportHTML(table)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := fmt.Sprintf("%s-%s", strings.ReplaceAll(subscription.ReportType, "_", "-"), day)
+	attachment := MailAttachment{Filename: baseName + ".csv", ContentType: "text/csv"}
+	if subscription.Format == "pdf" {
+		attachment.Filename = baseName + ".pdf"
+		attachment.ContentType = "application/pdf"
+		attachment.Data, err = renderReportPDF(table)
+	} else {
+		attachment.Data, err = renderReportCSV(table)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("%s - %s", table.Title, table.Period)
+	if subscription.Name != "" {
+		subject = fmt.Sprintf("%s: %s", subscription.Name, subject)
+	}
+
+	return &renderedReport{Subject: subject, HTML: html, Attachment: attachment}, nil
+}
+
+//
+// ================= REPORT SOURCES =================
+//
+
+func dailyCollectionTable(ctx context.Context, companyCode string, day string) (*reportTable, error) {
+	status := "paid"
+	report, err := NewDailyReportService().GetDailyReports(ctx, companyCode, &requests.DailyReportRequest{
+		StartDate: &day,
+		EndDate:   &day,
+		Status:    &status,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	table := &reportTable{
+		Title:   "Daily Collection",
+		Period:  day,
+		Headers: []string{"Time", "Ref No", "Student", "Item", "Method", "Amount"},
+	}
+	for _, daily := range report.Reports {
+		for _, detail := range daily.PaymentDetails {
+			table.Rows = append(table.Rows, []string{
+				detail.PaymentTime.Format("15:04"),
+				detail.StudentRefNo,
+				detail.StudentName,
+				detail.ItemName,
+				detail.PaymentMethod,
+				fmt.Sprintf("%.2f", detail.Amount),
+			})
+		}
+	}
+	table.Summary = [][2]string{
+		{"Payments", fmt.Sprintf("%d", report.Summary.TotalPayments)},
+		{"Total Collected", fmt.Sprintf("%.2f", report.Summary.TotalAmount)},
+		{"Cash", fmt.Sprintf("%.2f", report.Summary.TotalCash)},
+		{"UPI", fmt.Sprintf("%.2f", report.Summary.TotalUPI)},
+	}
+
+	return table, nil
+}
+
+func unpaidStudentsTable(
+	ctx context.Context,
+	companyCode string,
+	subscription *models.ReportSubscription,
+	day string,
+) (*reportTable, error) {
+
+	req := requests.NewGetUnpaidStudentsRequest()
+	if subscription.BoardEntityID != "" {
+		req.BoardEntityID = &subscription.BoardEntityID
+	}
+	if subscription.ClassEntityID != "" {
+		req.ClassEntityID = &subscription.ClassEntityID
+	}
+
+	result, err := NewUnpaidStudentsService().GetUnpaidStudents(ctx, companyCode, req)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &reportTable{
+		Title:   "Unpaid Students",
+		Period:  day,
+		Headers: []string{"Ref No", "Student", "Div", "Pending Items", "Total Due"},
+	}
+	totalDue := 0.0
+	for _, student := range result.Students {
+		items := make([]string, 0, len(student.PendingItems))
+		for _, item := range student.PendingItems {
+			items = append(items, item.ItemName)
+		}
+		name := strings.Join(strings.Fields(student.FirstName+" "+student.MiddleName+" "+student.LastName), " ")
+		table.Rows = append(table.Rows, []string{
+			student.RefNo,
+			name,
+			student.Div,
+			strings.Join(items, ", "),
+			fmt.Sprintf("%.2f", student.TotalDue),
+		})
+		totalDue += student.TotalDue
+	}
+	table.Summary = [][2]string{
+		{"Students With Dues", fmt.Sprintf("%d", len(result.Students))},
+		{"Total Due", fmt.Sprintf("%.2f", totalDue)},
+	}
+
+	return table, nil
+}
+
+func dashboardStatsTable(
+	ctx context.Context,
+	companyCode string,
+	subscription *models.ReportSubscription,
+	day string,
+) (*reportTable, error) {
+
+	req := requests.NewDashboardStatsRequest()
+	req.Timezone = subscription.Schedule.Timezone
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if subscription.BoardEntityID != "" {
+		req.BoardEntityID = &subscription.BoardEntityID
+	}
+	if subscription.ClassEntityID != "" {
+		req.ClassEntityID = &subscription.ClassEntityID
+	}
+
+	stats, err := NewDashboardService().GetDashboardStats(ctx, companyCode, req)
+	if err != nil {
+		return nil, err
+	}
+
+	table := &reportTable{
+		Title:   "Dashboard Summary",
+		Period:  day,
+		Headers: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Collected Today", fmt.Sprintf("%.2f", stats.Collection.Today.TotalAmount)},
+			{"Payments Today", fmt.Sprintf("%d", stats.Collection.Today.Payments)},
+			{"Collected This Month", fmt.Sprintf("%.2f", stats.Collection.ThisMonth.TotalAmount)},
+			{"Collected Overall", fmt.Sprintf("%.2f", stats.Collection.TotalPaidAmount)},
+			{"Cash Overall", fmt.Sprintf("%.2f", stats.Collection.CashAmount)},
+			{"UPI Overall", fmt.Sprintf("%.2f", stats.Collection.UPIAmount)},
+			{"Students Fully Paid", fmt.Sprintf("%d", stats.FeesStatus.PaidStudents)},
+			{"Students With Dues", fmt.Sprintf("%d", stats.FeesStatus.UnpaidStudents)},
+			{"Total Students", fmt.Sprintf("%d", stats.FeesStatus.TotalStudents)},
+			{"Optional Fees Collected", fmt.Sprintf("%.2f", stats.OptionalFees.CollectedAmount)},
+		},
+	}
+
+	return table, nil
+}
+
+//
+// ================= RENDERERS =================
+//
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+<h2 style="margin-bottom: 4px;">{{.Title}}</h2>
+<p style="margin-top: 0; color: #666;">{{.Period}}</p>
+{{if .Summary}}<table cellpadding="4" style="border-collapse: collapse; margin-bottom: 16px;">
+{{range .Summary}}<tr><td><strong>{{index . 0}}</strong></td><td>{{index . 1}}</td></tr>
+{{end}}</table>{{end}}
+<table cellpadding="6" border="1" style="border-collapse: collapse; border-color: #ddd;">
+<tr style="background: #f3f3f3;">{{range .Headers}}<th align="left">{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{else}}<tr><td colspan="{{len .Headers}}">No records</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func renderReportHTML(table *reportTable) (string, error) {
+	var buf bytes.Buffer
+	if err := reportHTMLTemplate.Execute(&buf, table); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderReportCSV(table *reportTable) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(table.Headers); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func renderReportPDF(table *reportTable) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, tr(table.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr(table.Period), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	for _, line := range table.Summary {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(50, 6, tr(line[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, tr(line[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	colWidth := (pageWidth - left - right) / float64(len(table.Headers))
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(243, 243, 243)
+	for _, header := range table.Headers {
+		pdf.CellFormat(colWidth, 7, tr(header), "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, row := range table.Rows {
+		for _, value := range row {
+			// Long values are clipped to the column rather than wrapping the row
+			runes := []rune(value)
+			for len(runes) > 0 && pdf.GetStringWidth(tr(string(runes))) > colWidth-2 {
+				runes = runes[:len(runes)-1]
+			}
+			pdf.CellFormat(colWidth, 6, tr(string(runes)), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxDeliveryAttempts is how many times one recipient is tried within a run
+const maxDeliveryAttempts = 3
+
+var (
+	// deliveryRetryDelay is the pause before the second attempt; it doubles after that
+	deliveryRetryDelay = 2 * time.Second
+
+	reportMailerMu sync.RWMutex
+	reportMailer   Mailer
+)
+
+// SetReportMailer sets the transport used for scheduled and manual report emails
+func SetReportMailer(mailer Mailer) {
+	reportMailerMu.Lock()
+	defer reportMailerMu.Unlock()
+	reportMailer = mailer
+}
+
+func GetReportMailer() Mailer {
+	reportMailerMu.RLock()
+	defer reportMailerMu.RUnlock()
+	return reportMailer
+}
+
+//
+// ================= SCHEDULER =================
+//
+
+// ReportScheduler periodically sends every due report subscription across all company databases
+type ReportScheduler struct {
+	interval time.Duration
+}
+
+func NewReportScheduler(interval time.Duration) *ReportScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &ReportScheduler{interval: interval}
+}
+
+// Start blocks until ctx is cancelled, checking for due subscriptions every interval
+func (s *ReportScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs the subscriptions whose next_run_at has passed
+func (s *ReportScheduler) RunDue(ctx context.Context, now time.Time) {
+	mailer := GetReportMailer()
+	if mailer == nil {
+		return
+	}
+
+	runDueSchedules(ctx, "report scheduler", ReportSubscriptionCollection, now, nil,
+		func(companyCode string, raw bson.Raw) {
+			var subscription models.ReportSubscription
+			if err := bson.Unmarshal(raw, &subscription); err != nil {
+				log.Printf("report scheduler: %s: decoding subscription failed: %v", companyCode, err)
+				return
+			}
+			if _, err := runReportSubscription(ctx, mailer, companyCode, &subscription, "schedule"); err != nil {
+				log.Printf("report scheduler: %s/%s failed: %v", companyCode, subscription.EntityID, err)
+			}
+		})
+}
+
+// dueSchedule holds the fields runDueSchedules needs from a report subscription or
+// reminder schedule
+type dueSchedule struct {
+	ID        primitive.ObjectID    `bson:"_id"`
+	EntityID  string                `bson:"entity_id"`
+	Schedule  models.ReportSchedule `bson:"schedule"`
+	NextRunAt time.Time             `bson:"next_run_at"`
+}
+
+// runDueSchedules runs every active document in the collection of each company database
+// whose next_run_at has passed. Each one is claimed by moving next_run_at forward first
+// (with the extra claim fields), so a second scheduler instance never runs it twice.
+// A document with an invalid schedule is deactivated. When a claim or deactivation
+// cannot be written the rest of that database waits for the next tick, rather than
+// picking the same document up again.
+func runDueSchedules(
+	ctx context.Context,
+	name string,
+	collectionName string,
+	now time.Time,
+	claim bson.M,
+	run func(companyCode string, raw bson.Raw),
+) {
+	client := mdb.GetMongo().GetClient()
+	databases, err := client.ListDatabaseNames(ctx, bson.M{"name": bson.M{"$regex": "^company_"}})
+	if err != nil {
+		log.Printf("%s: listing company databases failed: %v", name, err)
+		return
+	}
+
+	for _, database := range databases {
+		companyCode := strings.TrimPrefix(database, "company_")
+		collection := client.Database(database).Collection(collectionName)
+
+		for {
+			raw, err := collection.FindOne(ctx, bson.M{
+				"is_deleted":  false,
+				"is_active":   true,
+				"next_run_at": bson.M{"$lte": now},
+			}).Raw()
+			if err != nil {
+				if err != mongo.ErrNoDocuments {
+					log.Printf("%s: %s: finding due schedules failed: %v", name, companyCode, err)
+				}
+				break
+			}
+
+			var due dueSchedule
+			if err := bson.Unmarshal(raw, &due); err != nil {
+				log.Printf("%s: %s: decoding a due schedule failed: %v", name, companyCode, err)
+				break
+			}
+
+			nextRun, err := nextReportRun(due.Schedule, now)
+			if err != nil {
+				log.Printf("%s: %s/%s has an invalid schedule: %v", name, companyCode, due.EntityID, err)
+				if _, err := collection.UpdateOne(ctx, bson.M{"_id": due.ID}, bson.M{"$set": bson.M{"is_active": false}}); err != nil {
+					log.Printf("%s: deactivating %s/%s failed: %v", name, companyCode, due.EntityID, err)
+					break
+				}
+				continue
+			}
+
+			set := bson.M{"next_run_at": nextRun}
+			for key, value := range claim {
+				set[key] = value
+			}
+			claimed, err := collection.UpdateOne(ctx, bson.M{
+				"_id":         due.ID,
+				"next_run_at": due.NextRunAt,
+			}, bson.M{"$set": set})
+			if err != nil {
+				log.Printf("%s: claiming %s/%s failed: %v", name, companyCode, due.EntityID, err)
+				break
+			}
+			if claimed.ModifiedCount == 0 {
+				continue
+			}
+
+			run(companyCode, raw)
+		}
+	}
+}
+
+//
+// ================= RUN =================
+//
+
+// runReportSubscription renders and emails one subscription, logging the run and
+// every delivery attempt to report_runs. A render failure is recorded as a failed run.
+func runReportSubscription(
+	ctx context.Context,
+	mailer Mailer,
+	companyCode string,
+	subscription *models.ReportSubscription,
+	trigger string,
+) (*models.ReportRun, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	runs := database.Collection(ReportRunCollection)
+
+	run := models.NewReportRun(subscription, trigger)
+	if _, err := runs.InsertOne(ctx, run); err != nil {
+		return nil, err
+	}
+
+	rendered, err := renderSubscriptionReport(ctx, companyCode, subscription, run.StartedAt)
+	if err != nil {
+		run.Status = "failed"
+		run.Error = err.Error()
+	} else {
+		run.AttachmentName = rendered.Attachment.Filename
+		run.Deliveries = deliverReport(ctx, mailer, subscription.Recipients, rendered)
+		run.Status = reportRunStatus(run.Deliveries)
+	}
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+
+	_, err = runs.UpdateOne(ctx, bson.M{"_id": run.ID}, bson.M{
+		"$set": bson.M{
+			"status":          run.Status,
+			"error":           run.Error,
+			"attachment_name": run.AttachmentName,
+			"deliveries":      run.Deliveries,
+			"finished_at":     run.FinishedAt,
+		},
+	})
+	if err != nil {
+		return run, err
+	}
+
+	// The report went out and its run is logged, so a failed stamp is not a failed run
+	if _, err := database.Collection(ReportSubscriptionCollection).UpdateOne(ctx,
+		bson.M{"_id": subscription.ID},
+		bson.M{"$set": bson.M{"last_run_at": run.StartedAt}},
+	); err != nil {
+		log.Printf("report scheduler: %s/%s: recording last_run_at failed: %v", companyCode, subscription.EntityID, err)
+	}
+
+	return run, nil
+}
+
+// deliverReport sends the report to each recipient separately, retrying failures,
+// and returns one log entry per attempt
+func deliverReport(
+	ctx context.Context,
+	mailer Mailer,
+	recipients []string,
+	rendered *renderedReport,
+) []models.ReportDelivery {
+
+	deliveries := []models.ReportDelivery{}
+
+	for _, recipient := range recipients {
+		delay := deliveryRetryDelay
+		for attempt := 1; attempt <= maxDeliveryAttempts; attempt++ {
+			err := mailer.Send(ctx, &MailMessage{
+				To:          []string{recipient},
+				Subject:     rendered.Subject,
+				HTMLBody:    rendered.HTML,
+				Attachments: []MailAttachment{rendered.Attachment},
+			})
+
+			delivery := models.ReportDelivery{
+				Recipient:   recipient,
+				Attempt:     attempt,
+				Status:      "sent",
+				AttemptedAt: time.Now().UTC(),
+			}
+			if err != nil {
+				delivery.Status = "failed"
+				delivery.Error = err.Error()
+			}
+			deliveries = append(deliveries, delivery)
+
+			if err == nil || attempt == maxDeliveryAttempts {
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				return deliveries
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	}
+
+	return deliveries
+}
+
+// reportRunStatus is success when every recipient eventually got the email
+func reportRunStatus(deliveries []models.ReportDelivery) string {
+	sent := make(map[string]bool)
+	recipients := make(map[string]bool)
+	for _, delivery := range deliveries {
+		recipients[delivery.Recipient] = true
+		if delivery.Status == "sent" {
+			sent[delivery.Recipient] = true
+		}
+	}
+
+	switch {
+	case len(recipients) == 0:
+		return "failed"
+	case len(sent) == len(recipients):
+		return "success"
+	case len(sent) == 0:
+		return "failed"
+	}
+	return "partial"
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ReportSubscriptionCollection = "report_subscriptions"
+	ReportRunCollection          = "report_runs"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type ReportSubscriptionService interface {
+	Create(ctx context.Context, companyCode string, req *requests.CreateReportSubscriptionRequest) (*models.ReportSubscription, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.ReportSubscription, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.ReportSubscription, error)
+	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateReportSubscriptionRequest) (*models.ReportSubscription, error)
+	Delete(ctx context.Context, companyCode string, id string) error
+	RunNow(ctx context.Context, companyCode string, id string) (*models.ReportRun, error)
+	GetRuns(ctx context.Context, companyCode string, id string, limit int64) ([]*models.ReportRun, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type reportSubscriptionService struct{}
+
+func NewReportSubscriptionService() ReportSubscriptionService {
+	return &reportSubscriptionService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+func (s *reportSubscriptionService) Create(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateReportSubscriptionRequest,
+) (*models.ReportSubscription, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportSubscriptionCollection)
+
+	subscription := models.NewReportSubscription()
+	subscription.Bind(req)
+
+	nextRun, err := nextReportRun(subscription.Schedule, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	subscription.NextRunAt = nextRun
+
+	if _, err := collection.InsertOne(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+func (s *reportSubscriptionService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.ReportSubscription, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportSubscriptionCollection)
+
+	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*models.ReportSubscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *reportSubscriptionService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.ReportSubscription, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportSubscriptionCollection)
+
+	var subscription models.ReportSubscription
+	err := collection.FindOne(ctx, reportSubscriptionFilter(id)).Decode(&subscription)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("report subscription not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *reportSubscriptionService) Update(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.UpdateReportSubscriptionRequest,
+) (*models.ReportSubscription, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportSubscriptionCollection)
+
+	updateFields := bson.M{}
+
+	if req.Name != nil {
+		updateFields["name"] = *req.Name
+	}
+	if req.Recipients != nil {
+		updateFields["recipients"] = *req.Recipients
+	}
+	if req.Format != nil {
+		updateFields["format"] = *req.Format
+	}
+	if req.Schedule != nil {
+		schedule := models.ReportSchedule{
+			Frequency:  req.Schedule.Frequency,
+			TimeOfDay:  req.Schedule.TimeOfDay,
+			Weekday:    req.Schedule.Weekday,
+			DayOfMonth: req.Schedule.DayOfMonth,
+			Timezone:   req.Schedule.Timezone,
+		}
+		nextRun, err := nextReportRun(schedule, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		updateFields["schedule"] = schedule
+		updateFields["next_run_at"] = nextRun
+	}
+	if req.BoardEntityID != nil {
+		updateFields["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil {
+		updateFields["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.IsActive != nil {
+		updateFields["is_active"] = *req.IsActive
+	}
+	if req.IsDeleted != nil {
+		updateFields["is_deleted"] = *req.IsDeleted
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.ReportSubscription
+	err := collection.
+		FindOneAndUpdate(ctx, reportSubscriptionFilter(id), bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("report subscription not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+func (s *reportSubscriptionService) Delete(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportSubscriptionCollection)
+
+	result, err := collection.UpdateOne(ctx, reportSubscriptionFilter(id), bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("report subscription not found")
+	}
+
+	return nil
+}
+
+//
+// ================= RUN NOW =================
+//
+
+// RunNow sends the report immediately without moving the regular schedule
+func (s *reportSubscriptionService) RunNow(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.ReportRun, error) {
+
+	subscription, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mailer := GetReportMailer()
+	if mailer == nil {
+		return nil, errors.New("email delivery is not configured")
+	}
+
+	return runReportSubscription(ctx, mailer, companyCode, subscription, "manual")
+}
+
+//
+// ================= RUN HISTORY =================
+//
+
+func (s *reportSubscriptionService) GetRuns(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	limit int64,
+) ([]*models.ReportRun, error) {
+
+	subscription, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(ReportRunCollection)
+
+	if limit <= 0 {
+		limit = 50
+	}
+	opts := options.Find().SetSort(bson.M{"started_at": -1}).SetLimit(limit)
+
+	cursor, err := collection.Find(ctx, bson.M{"subscription_entity_id": subscription.EntityID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	runs := []*models.ReportRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+func reportSubscriptionFilter(id string) bson.M {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"_id": oid, "is_deleted": false}
+	}
+	return bson.M{"entity_id": id, "is_deleted": false}
+}
+
+// nextReportRun returns the first scheduled time strictly after the given instant
+func nextReportRun(schedule models.ReportSchedule, after time.Time) (time.Time, error) {
+	loc := time.UTC
+	if schedule.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	clock, err := time.Parse("15:04", schedule.TimeOfDay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time_of_day %q", schedule.TimeOfDay)
+	}
+
+	local := after.In(loc)
+	candidate := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+
+	switch schedule.Frequency {
+	case "daily":
+		if !candidate.After(after) {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+	case "weekly":
+		offset := (schedule.Weekday - int(candidate.Weekday()) + 7) % 7
+		candidate = candidate.AddDate(0, 0, offset)
+		if !candidate.After(after) {
+			candidate = candidate.AddDate(0, 0, 7)
+		}
+	case "monthly":
+		candidate = time.Date(local.Year(), local.Month(), schedule.DayOfMonth, clock.Hour(), clock.Minute(), 0, 0, loc)
+		if !candidate.After(after) {
+			candidate = candidate.AddDate(0, 1, 0)
+		}
+	default:
+		return time.Time{}, fmt.Errorf("invalid frequency %q", schedule.Frequency)
+	}
+
+	return candidate.UTC(), nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const SchoolProfileCollection = "school_profile"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type SchoolProfileService interface {
+	Get(ctx context.Context, companyCode string) (*models.SchoolProfile, error)
+	Update(ctx context.Context, companyCode string, req *requests.UpdateSchoolProfileRequest) (*models.SchoolProfile, error)
+	UploadLogo(ctx context.Context, companyCode string, file io.Reader) (*models.SchoolProfile, error)
+	GetLogo(ctx context.Context, companyCode string) (*models.SchoolProfile, []byte, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type schoolProfileService struct{}
+
+func NewSchoolProfileService() SchoolProfileService {
+	return &schoolProfileService{}
+}
+
+//
+// ================= GET =================
+//
+
+func (s *schoolProfileService) Get(
+	ctx context.Context,
+	companyCode string,
+) (*models.SchoolProfile, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return loadSchoolProfile(ctx, database)
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *schoolProfileService) Update(
+	ctx context.Context,
+	companyCode string,
+	req *requests.UpdateSchoolProfileRequest,
+) (*models.SchoolProfile, error) {
+
+	updateFields := bson.M{}
+	if req.Name != nil {
+		updateFields["name"] = *req.Name
+	}
+	if req.Address != nil {
+		updateFields["address"] = *req.Address
+	}
+	if req.Phone != nil {
+		updateFields["phone"] = *req.Phone
+	}
+	if req.Email != nil {
+		updateFields["email"] = *req.Email
+	}
+	if req.Website != nil {
+		updateFields["website"] = *req.Website
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return upsertSchoolProfile(ctx, database, updateFields)
+}
+
+//
+// ================= LOGO =================
+//
+
+// UploadLogo stores a JPEG or PNG logo, replacing the previous one
+func (s *schoolProfileService) UploadLogo(
+	ctx context.Context,
+	companyCode string,
+	file io.Reader,
+) (*models.SchoolProfile, error) {
+
+	storage := GetAttachmentStorage()
+	if storage == nil {
+		return nil, errors.New("attachment storage is not configured")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, attachmentRules["logo"].MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := checkAttachmentContent("logo", data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	previous, err := loadSchoolProfile(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s/school/logo-%d%s", companyCode, time.Now().UnixNano(), attachmentExtensions[contentType])
+	if err := storage.Put(ctx, key, data, contentType); err != nil {
+		return nil, err
+	}
+
+	profile, err := upsertSchoolProfile(ctx, database, bson.M{
+		"has_logo":          true,
+		"logo_content_type": contentType,
+		"logo_sha256":       hex.EncodeToString(sum[:]),
+		"logo_storage_key":  key,
+	})
+	if err != nil {
+		storage.Delete(ctx, key)
+		return nil, err
+	}
+
+	if previous.LogoStorageKey != "" {
+		if err := storage.Delete(ctx, previous.LogoStorageKey); err != nil {
+			fmt.Printf("Previous logo %s not removed: %v\n", previous.LogoStorageKey, err)
+		}
+	}
+
+	return profile, nil
+}
+
+func (s *schoolProfileService) GetLogo(
+	ctx context.Context,
+	companyCode string,
+) (*models.SchoolProfile, []byte, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	profile, err := loadSchoolProfile(ctx, database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logo, err := loadSchoolLogo(ctx, profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if logo == nil {
+		return nil, nil, errors.New("school has no logo")
+	}
+
+	return profile, logo, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// loadSchoolProfile returns the company's profile, or an empty one before it is saved
+func loadSchoolProfile(ctx context.Context, database *mongo.Database) (*models.SchoolProfile, error) {
+	var profile models.SchoolProfile
+	err := database.Collection(SchoolProfileCollection).FindOne(ctx, bson.M{}).Decode(&profile)
+	if err == mongo.ErrNoDocuments {
+		return models.NewSchoolProfile(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// loadSchoolLogo reads the logo from storage, or returns nil when there is none
+func loadSchoolLogo(ctx context.Context, profile *models.SchoolProfile) ([]byte, error) {
+	if !profile.HasLogo || profile.LogoStorageKey == "" {
+		return nil, nil
+	}
+	storage := GetAttachmentStorage()
+	if storage == nil {
+		return nil, errors.New("attachment storage is not configured")
+	}
+	return readStoredFile(ctx, storage, profile.LogoStorageKey, profile.LogoSHA256)
+}
+
+func upsertSchoolProfile(ctx context.Context, database *mongo.Database, updateFields bson.M) (*models.SchoolProfile, error) {
+	defaults := models.NewSchoolProfile()
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var profile models.SchoolProfile
+	err := database.Collection(SchoolProfileCollection).
+		FindOneAndUpdate(ctx, bson.M{}, bson.M{
+			"$set": updateFields,
+			"$setOnInsert": bson.M{
+				"_id":        defaults.ID,
+				"entity_id":  defaults.EntityID,
+				"created_at": defaults.CreatedAt,
+			},
+		}, opts).
+		Decode(&profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,679 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AcademicSessionCollection = "academic_sessions"
+	EnrolmentCollection       = "enrolments"
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type AcademicSessionService interface {
+	Create(ctx context.Context, companyCode string, req *requests.CreateAcademicSessionRequest) (*models.AcademicSession, error)
+	GetAll(ctx context.Context, companyCode string) ([]*models.AcademicSession, error)
+	GetByID(ctx context.Context, companyCode string, id string) (*models.AcademicSession, error)
+	GetActive(ctx context.Context, companyCode string) (*models.AcademicSession, error)
+	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateAcademicSessionRequest) (*models.AcademicSession, error)
+	Delete(ctx context.Context, companyCode string, id string) error
+	Activate(ctx context.Context, companyCode string, id string) (*models.AcademicSession, error)
+	Backfill(ctx context.Context, companyCode string, id string) (*models.SessionBackfill, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type academicSessionService struct{}
+
+func NewAcademicSessionService() AcademicSessionService {
+	return &academicSessionService{}
+}
+
+//
+// ================= CREATE =================
+//
+
+// Create adds a session. The first session of a company becomes active and takes over
+// every exam, book, student and payment created before sessions existed.
+func (s *academicSessionService) Create(
+	ctx context.Context,
+	companyCode string,
+	req *requests.CreateAcademicSessionRequest,
+) (*models.AcademicSession, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(AcademicSessionCollection)
+
+	session := models.NewAcademicSession()
+	session.Bind(req)
+
+	if err := checkSessionOverlap(ctx, collection, session.EntityID, session.StartDate, session.EndDate); err != nil {
+		return nil, err
+	}
+
+	existing, err := collection.CountDocuments(ctx, bson.M{"is_deleted": false})
+	if err != nil {
+		return nil, err
+	}
+	first := existing == 0
+	if first {
+		session.IsActive = true
+	}
+
+	if session.IsActive {
+		if _, err := collection.UpdateMany(ctx,
+			bson.M{"is_active": true},
+			bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := collection.InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if first {
+		if _, err := backfillSession(ctx, database, session.EntityID); err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+//
+// ================= GET ALL =================
+//
+
+// GetAll lists the sessions, most recent first
+func (s *academicSessionService) GetAll(
+	ctx context.Context,
+	companyCode string,
+) ([]*models.AcademicSession, error) {
+
+	db := mdb.GetMongo()
+	collection := db.GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(AcademicSessionCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_date", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []*models.AcademicSession{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+//
+// ================= GET BY ID =================
+//
+
+func (s *academicSessionService) GetByID(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.AcademicSession, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	return findAcademicSession(ctx, database, id)
+}
+
+//
+// ================= GET ACTIVE =================
+//
+
+func (s *academicSessionService) GetActive(
+	ctx context.Context,
+	companyCode string,
+) (*models.AcademicSession, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	session, err := activeSession(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, errors.New("no active academic session")
+	}
+
+	return session, nil
+}
+
+//
+// ================= UPDATE =================
+//
+
+func (s *academicSessionService) Update(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.UpdateAcademicSessionRequest,
+) (*models.AcademicSession, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(AcademicSessionCollection)
+
+	current, err := findAcademicSession(ctx, database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.NewUpdateAcademicSession()
+	update.Bind(req)
+
+	updateFields := bson.M{}
+	startDate, endDate := current.StartDate, current.EndDate
+
+	if update.Name != nil {
+		updateFields["name"] = *update.Name
+	}
+	if update.StartDate != nil {
+		startDate = *update.StartDate
+		updateFields["start_date"] = startDate
+	}
+	if update.EndDate != nil {
+		endDate = *update.EndDate
+		updateFields["end_date"] = endDate
+	}
+
+	if len(updateFields) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	if endDate.Before(startDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+	if err := checkSessionOverlap(ctx, collection, current.EntityID, startDate, endDate); err != nil {
+		return nil, err
+	}
+
+	updateFields["updated_at"] = time.Now()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.AcademicSession
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID}, bson.M{"$set": updateFields}, opts).
+		Decode(&updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+//
+// ================= DELETE (SOFT DELETE) =================
+//
+
+// Delete removes a session that nothing has been recorded against yet
+func (s *academicSessionService) Delete(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) error {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := findAcademicSession(ctx, database, id)
+	if err != nil {
+		return err
+	}
+	if session.IsActive {
+		return errors.New("the active academic session cannot be deleted")
+	}
+
+	for _, name := range []string{ExamCollection, BookCollection, EnrolmentCollection, "payment_scanners"} {
+		count, err := database.Collection(name).CountDocuments(ctx, bson.M{"session_entity_id": session.EntityID})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("academic session %s has %s and cannot be deleted", session.Name, name)
+		}
+	}
+
+	_, err = database.Collection(AcademicSessionCollection).UpdateOne(ctx, bson.M{"_id": session.ID}, bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
+//
+// ================= ACTIVATE =================
+//
+
+// Activate makes the session the company's active session; every other session becomes read-only
+func (s *academicSessionService) Activate(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.AcademicSession, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(AcademicSessionCollection)
+
+	session, err := findAcademicSession(ctx, database, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.IsActive {
+		return session, nil
+	}
+
+	now := time.Now()
+	if _, err := collection.UpdateMany(ctx,
+		bson.M{"is_active": true, "_id": bson.M{"$ne": session.ID}},
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err := collection.UpdateOne(ctx,
+		bson.M{"_id": session.ID},
+		bson.M{"$set": bson.M{"is_active": true, "updated_at": now}},
+	); err != nil {
+		return nil, err
+	}
+
+	session.IsActive = true
+	session.UpdatedAt = now
+	return session, nil
+}
+
+//
+// ================= BACKFILL =================
+//
+
+// Backfill assigns documents that have no session yet to the given session
+func (s *academicSessionService) Backfill(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.SessionBackfill, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := findAcademicSession(ctx, database, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return backfillSession(ctx, database, session.EntityID)
+}
+
+//
+// ================= HELPERS =================
+//
+
+func academicSessionFilter(id string) bson.M {
+	filter := bson.M{"entity_id": id, "is_deleted": false}
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+		filter = bson.M{"_id": oid, "is_deleted": false}
+	}
+	return filter
+}
+
+func findAcademicSession(ctx context.Context, database *mongo.Database, id string) (*models.AcademicSession, error) {
+	var session models.AcademicSession
+	err := database.Collection(AcademicSessionCollection).FindOne(ctx, academicSessionFilter(id)).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("academic session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// activeSession returns the company's active session, or nil when it has not set up sessions yet
+func activeSession(ctx context.Context, database *mongo.Database) (*models.AcademicSession, error) {
+	var session models.AcademicSession
+	err := database.Collection(AcademicSessionCollection).
+		FindOne(ctx, bson.M{"is_active": true, "is_deleted": false}).
+		Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// sessionOn returns the session a date falls in, falling back to the active session when
+// no session covers it. A nil session means the company has not set up sessions yet.
+func sessionOn(ctx context.Context, database *mongo.Database, date time.Time) (*models.AcademicSession, error) {
+	var session models.AcademicSession
+	err := database.Collection(AcademicSessionCollection).
+		FindOne(ctx, bson.M{
+			"start_date": bson.M{"$lte": date},
+			"end_date":   bson.M{"$gte": date},
+			"is_deleted": false,
+		}).
+		Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return activeSession(ctx, database)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// previousSession returns the session that started last before the given one, or nil when
+// it is the first
+func previousSession(ctx context.Context, database *mongo.Database, session *models.AcademicSession) (*models.AcademicSession, error) {
+	var previous models.AcademicSession
+	err := database.Collection(AcademicSessionCollection).
+		FindOne(ctx,
+			bson.M{"start_date": bson.M{"$lt": session.StartDate}, "is_deleted": false},
+			options.FindOne().SetSort(bson.D{{Key: "start_date", Value: -1}}),
+		).
+		Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previous, nil
+}
+
+// resolveSession picks the session a read is scoped to: the requested one, or the active
+// session when none is given. A nil session means the company has no sessions and reads
+// stay unscoped.
+func resolveSession(ctx context.Context, database *mongo.Database, sessionID string) (*models.AcademicSession, error) {
+	if sessionID == "" {
+		return activeSession(ctx, database)
+	}
+	return findAcademicSession(ctx, database, sessionID)
+}
+
+// resolveSessionPtr is resolveSession for the optional session_entity_id of report requests
+func resolveSessionPtr(ctx context.Context, database *mongo.Database, sessionID *string) (*models.AcademicSession, error) {
+	if sessionID == nil {
+		return resolveSession(ctx, database, "")
+	}
+	return resolveSession(ctx, database, *sessionID)
+}
+
+// withSession scopes a filter to the session's documents
+func withSession(filter bson.M, session *models.AcademicSession) bson.M {
+	if session != nil {
+		filter["session_entity_id"] = session.EntityID
+	}
+	return filter
+}
+
+// activeSessionEntityID is stamped on new exams, books, students and payments
+func activeSessionEntityID(ctx context.Context, database *mongo.Database) (string, error) {
+	session, err := activeSession(ctx, database)
+	if err != nil || session == nil {
+		return "", err
+	}
+	return session.EntityID, nil
+}
+
+// ensureSessionWritable rejects writes to documents of a session that started before the
+// active one. Upcoming sessions stay writable so they can be prepared and promoted into.
+func ensureSessionWritable(ctx context.Context, database *mongo.Database, sessionEntityID string) error {
+	if sessionEntityID == "" {
+		return nil
+	}
+
+	active, err := activeSession(ctx, database)
+	if err != nil {
+		return err
+	}
+	if active == nil || active.EntityID == sessionEntityID {
+		return nil
+	}
+
+	session, err := findAcademicSession(ctx, database, sessionEntityID)
+	if err != nil {
+		return err
+	}
+	if !session.StartDate.Before(active.StartDate) {
+		return nil
+	}
+	return fmt.Errorf("academic session %s is closed and read-only", session.Name)
+}
+
+// checkSessionOverlap keeps session date ranges of a company disjoint
+func checkSessionOverlap(ctx context.Context, collection *mongo.Collection, entityID string, startDate, endDate time.Time) error {
+	var other models.AcademicSession
+	err := collection.FindOne(ctx, bson.M{
+		"entity_id":  bson.M{"$ne": entityID},
+		"is_deleted": false,
+		"start_date": bson.M{"$lte": endDate},
+		"end_date":   bson.M{"$gte": startDate},
+	}).Decode(&other)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("dates overlap with academic session %s", other.Name)
+}
+
+// enrolStudents records the students' current board, class and division in the session
+func enrolStudents(ctx context.Context, database *mongo.Database, students []*models.Student, sessionEntityID string) error {
+	if sessionEntityID == "" || len(students) == 0 {
+		return nil
+	}
+
+	enrolments := make([]interface{}, 0, len(students))
+	for _, student := range students {
+		enrolments = append(enrolments, models.NewEnrolment(student, sessionEntityID))
+	}
+
+	_, err := database.Collection(EnrolmentCollection).InsertMany(ctx, enrolments)
+	return err
+}
+
+// backfillSession stamps the session on every exam, book, student and payment without
+// one and enrols those students in it
+func backfillSession(ctx context.Context, database *mongo.Database, sessionEntityID string) (*models.SessionBackfill, error) {
+	result := &models.SessionBackfill{SessionEntityID: sessionEntityID}
+
+	unscoped := bson.M{"$or": bson.A{
+		bson.M{"session_entity_id": bson.M{"$exists": false}},
+		bson.M{"session_entity_id": ""},
+	}}
+	set := bson.M{"$set": bson.M{"session_entity_id": sessionEntityID}}
+
+	// Read the students first so the ones being moved into the session can be enrolled
+	cursor, err := database.Collection(StudentCollection).Find(ctx, unscoped)
+	if err != nil {
+		return nil, err
+	}
+	var students []*models.Student
+	if err := cursor.All(ctx, &students); err != nil {
+		return nil, err
+	}
+
+	counts := []struct {
+		collection string
+		count      *int64
+	}{
+		{ExamCollection, &result.Exams},
+		{BookCollection, &result.Books},
+		{StudentCollection, &result.Students},
+		{"payment_scanners", &result.Payments},
+	}
+	for _, c := range counts {
+		updated, err := database.Collection(c.collection).UpdateMany(ctx, unscoped, set)
+		if err != nil {
+			return nil, err
+		}
+		*c.count = updated.ModifiedCount
+	}
+
+	if err := enrolStudents(ctx, database, students, sessionEntityID); err != nil {
+		return nil, err
+	}
+	result.Enrolments = int64(len(students))
+
+	return result, nil
+}
+
+// findSessionStudents lists the students enrolled in the session with their board, class
+// and division as of that session. Filters on those three fields apply to the enrolment,
+// every other filter to the student. Without a session it is a plain student query.
+//
+// The student document follows the student's latest enrolment, which after a promotion
+// is the upcoming session, so every session is read through the enrolments.
+func findSessionStudents(
+	ctx context.Context,
+	database *mongo.Database,
+	session *models.AcademicSession,
+	filter bson.M,
+) ([]models.Student, error) {
+
+	studentFilter := bson.M{}
+	for key, value := range filter {
+		studentFilter[key] = value
+	}
+
+	var students []models.Student
+
+	if session == nil {
+		cursor, err := database.Collection(StudentCollection).Find(ctx, studentFilter)
+		if err != nil {
+			return nil, err
+		}
+		defer cursor.Close(ctx)
+
+		if err := cursor.All(ctx, &students); err != nil {
+			return nil, err
+		}
+		return students, nil
+	}
+
+	enrolmentFilter := bson.M{"session_entity_id": session.EntityID, "is_deleted": false}
+	for _, key := range []string{"board_entity_id", "class_entity_id", "div"} {
+		if value, ok := studentFilter[key]; ok {
+			enrolmentFilter[key] = value
+			delete(studentFilter, key)
+		}
+	}
+	if value, ok := studentFilter["entity_id"]; ok {
+		enrolmentFilter["student_entity_id"] = value
+	}
+
+	cursor, err := database.Collection(EnrolmentCollection).Find(ctx, enrolmentFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var enrolments []models.Enrolment
+	if err := cursor.All(ctx, &enrolments); err != nil {
+		return nil, err
+	}
+	if len(enrolments) == 0 {
+		return []models.Student{}, nil
+	}
+
+	byStudent := make(map[string]models.Enrolment, len(enrolments))
+	studentIDs := make([]string, 0, len(enrolments))
+	for _, enrolment := range enrolments {
+		byStudent[enrolment.StudentEntityID] = enrolment
+		studentIDs = append(studentIDs, enrolment.StudentEntityID)
+	}
+	studentFilter["entity_id"] = bson.M{"$in": studentIDs}
+
+	studentCursor, err := database.Collection(StudentCollection).Find(ctx, studentFilter)
+	if err != nil {
+		return nil, err
+	}
+	defer studentCursor.Close(ctx)
+
+	if err := studentCursor.All(ctx, &students); err != nil {
+		return nil, err
+	}
+
+	for i := range students {
+		withEnrolment(&students[i], byStudent[students[i].EntityID])
+	}
+
+	return students, nil
+}
+
+// withEnrolment sets the student's board, class, division and status to those of the enrolment
+func withEnrolment(student *models.Student, enrolment models.Enrolment) {
+	student.SessionEntityID = enrolment.SessionEntityID
+	student.BoardEntityID = enrolment.BoardEntityID
+	student.ClassEntityID = enrolment.ClassEntityID
+	student.Div = enrolment.Div
+
+	// Status is per session too: a student who left later was active in earlier sessions
+	status := enrolment.Status
+	if status == "" {
+		status = "active"
+	}
+	if status != studentStatus(student) {
+		student.Status = status
+		student.StatusDate = nil
+		student.StatusReason = ""
+	}
+}
+
+// syncEnrolment mirrors a change of the student's board, class or division onto the
+// enrolment of the student's session
+func syncEnrolment(ctx context.Context, database *mongo.Database, student *models.Student) error {
+	if student.SessionEntityID == "" {
+		return nil
+	}
+
+	enrolment := models.NewEnrolment(student, student.SessionEntityID)
+	_, err := database.Collection(EnrolmentCollection).UpdateOne(ctx,
+		bson.M{"student_entity_id": student.EntityID, "session_entity_id": student.SessionEntityID, "is_deleted": false},
+		bson.M{
+			"$set": bson.M{
+				"board_entity_id": student.BoardEntityID,
+				"class_entity_id": student.ClassEntityID,
+				"div":             student.Div,
+				"updated_at":      enrolment.UpdatedAt,
+			},
+			"$setOnInsert": bson.M{
+				"_id":        enrolment.ID,
+				"entity_id":  enrolment.EntityID,
+				"created_at": enrolment.CreatedAt,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,620 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// searchCandidateLimit caps how many matching students are loaded for ranking
+	searchCandidateLimit = 200
+	// searchPhoneMinDigits is the shortest digit run treated as part of a guardian phone
+	searchPhoneMinDigits = 4
+	searchReindexBatch   = 500
+)
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type StudentSearchService interface {
+	Search(ctx context.Context, companyCode string, req *requests.StudentSearchRequest) (*models.StudentSearchResponse, error)
+	Reindex(ctx context.Context, companyCode string) (*models.StudentSearchReindex, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type studentSearchService struct{}
+
+func NewStudentSearchService() StudentSearchService {
+	return &studentSearchService{}
+}
+
+//
+// ================= SEARCH =================
+//
+
+// Search finds students whose name words or ref_no start with every word of the query,
+// or whose guardian phone starts or ends with its digits. Matching ignores case and
+// diacritics and runs on the indexed search_tokens and phone_tokens. Candidates are
+// loaded best tier first (exact ref_no, guardian phone, whole words, then prefixes), so
+// the cap never drops a better match for a weaker one; the candidates are ranked here.
+// Board, class and division filter on the active session's enrolments.
+func (s *studentSearchService) Search(
+	ctx context.Context,
+	companyCode string,
+	req *requests.StudentSearchRequest,
+) (*models.StudentSearchResponse, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	placement := bson.M{}
+	if req.BoardEntityID != nil && *req.BoardEntityID != "" {
+		placement["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.ClassEntityID != nil && *req.ClassEntityID != "" {
+		placement["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		placement["div"] = divisionMatch(*req.Div)
+	}
+
+	var tiers []bson.M
+
+	if refNo := strings.TrimSpace(req.Query); refNo != "" {
+		refNos := bson.A{refNo}
+		if upper := strings.ToUpper(refNo); upper != refNo {
+			refNos = append(refNos, upper)
+		}
+		tiers = append(tiers, bson.M{"ref_no": bson.M{"$in": refNos}})
+	}
+
+	phoneMatched := make(map[string]bool)
+	if digits := searchPhoneDigits(req.Query); digits != "" {
+		studentIDs, err := findStudentsByGuardianPhone(ctx, database, digits)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range studentIDs {
+			phoneMatched[id] = true
+		}
+		if len(studentIDs) > 0 {
+			tiers = append(tiers, bson.M{"entity_id": bson.M{"$in": studentIDs}})
+		}
+	}
+
+	if terms := searchWords(req.Query); len(terms) > 0 {
+		words := make(bson.A, 0, len(terms))
+		prefixes := make(bson.A, 0, len(terms))
+		for _, term := range terms {
+			words = append(words, term)
+			prefixes = append(prefixes, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(term)})
+		}
+		tiers = append(tiers,
+			bson.M{"search_tokens": bson.M{"$all": words}},
+			bson.M{"search_tokens": bson.M{"$all": prefixes}},
+		)
+	}
+
+	response := &models.StudentSearchResponse{
+		Query:   req.Query,
+		Results: []models.StudentSearchResult{},
+	}
+
+	var candidates []models.Student
+	seen := []string{}
+	for _, tier := range tiers {
+		budget := searchCandidateLimit - len(candidates)
+		if budget <= 0 {
+			response.Truncated = true
+			break
+		}
+
+		filter := bson.M{"is_deleted": false}
+		for key, value := range placement {
+			filter[key] = value
+		}
+		for key, value := range tier {
+			filter[key] = value
+		}
+		if len(seen) > 0 {
+			ids, ok := filter["entity_id"].(bson.M)
+			if !ok {
+				ids = bson.M{}
+			}
+			ids["$nin"] = seen
+			filter["entity_id"] = ids
+		}
+
+		found, err := findSearchCandidates(ctx, database, session, filter, len(placement) > 0, budget+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > budget {
+			found = found[:budget]
+			response.Truncated = true
+		}
+		for _, student := range found {
+			seen = append(seen, student.EntityID)
+		}
+		candidates = append(candidates, found...)
+	}
+
+	response.Results = rankStudents(candidates, req.Query, phoneMatched, req.Limit)
+	return response, nil
+}
+
+//
+// ================= REINDEX =================
+//
+
+// Reindex rebuilds the search tokens of every student and the phone tokens of every
+// guardian, for documents written before search existed or after the tokenizer changed.
+// Only stale documents are rewritten.
+func (s *studentSearchService) Reindex(
+	ctx context.Context,
+	companyCode string,
+) (*models.StudentSearchReindex, error) {
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(StudentCollection)
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"first_name": 1, "middle_name": 1, "last_name": 1, "ref_no": 1, "search_tokens": 1,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &models.StudentSearchReindex{}
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		if _, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+		result.Updated += len(writes)
+		writes = writes[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var student models.Student
+		if err := cursor.Decode(&student); err != nil {
+			return nil, err
+		}
+		result.Students++
+
+		tokens := studentSearchTokens(&student)
+		if equalStrings(tokens, student.SearchTokens) {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": student.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"search_tokens": tokens}}))
+
+		if len(writes) == searchReindexBatch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if err := reindexGuardianPhones(ctx, database, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// reindexGuardianPhones rewrites the stale phone tokens of every guardian
+func reindexGuardianPhones(ctx context.Context, database *mongo.Database, result *models.StudentSearchReindex) error {
+	collection := database.Collection(GuardianCollection)
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{
+		"phone": 1, "phone_tokens": 1,
+	}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		if _, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+		result.GuardiansUpdated += len(writes)
+		writes = writes[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var guardian models.Guardian
+		if err := cursor.Decode(&guardian); err != nil {
+			return err
+		}
+		result.Guardians++
+
+		tokens := guardianPhoneTokens(guardian.Phone)
+		if equalStrings(tokens, guardian.PhoneTokens) {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": guardian.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"phone_tokens": tokens}}))
+
+		if len(writes) == searchReindexBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+//
+// ================= HELPERS =================
+//
+
+// studentSearchIndexes keeps the name, ref_no and guardian phone lookups of the search
+// on an index
+var studentSearchIndexes = []companyIndex{
+	{
+		Collection: StudentCollection,
+		Model: mongo.IndexModel{
+			Keys:    bson.D{{Key: "search_tokens", Value: 1}, {Key: "is_deleted", Value: 1}},
+			Options: options.Index().SetName("search_tokens"),
+		},
+	},
+	{
+		Collection: GuardianCollection,
+		Model: mongo.IndexModel{
+			Keys:    bson.D{{Key: "phone_tokens", Value: 1}, {Key: "is_deleted", Value: 1}},
+			Options: options.Index().SetName("phone_tokens"),
+		},
+	},
+	{
+		Collection: EnrolmentCollection,
+		Model: mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "session_entity_id", Value: 1},
+				{Key: "board_entity_id", Value: 1},
+				{Key: "class_entity_id", Value: 1},
+				{Key: "div", Value: 1},
+				{Key: "is_deleted", Value: 1},
+			},
+			Options: options.Index().SetName("session_placement"),
+		},
+	},
+}
+
+// findSearchCandidates loads up to limit students of one search tier in ref_no order.
+// With a board, class or division filter the students come through their enrolments in
+// the session, joined and limited in Mongo.
+func findSearchCandidates(
+	ctx context.Context,
+	database *mongo.Database,
+	session *models.AcademicSession,
+	filter bson.M,
+	placed bool,
+	limit int,
+) ([]models.Student, error) {
+
+	if placed && session != nil {
+		return findPlacedCandidates(ctx, database, session, filter, limit)
+	}
+
+	cursor, err := database.Collection(StudentCollection).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "ref_no", Value: 1}}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	students := []models.Student{}
+	if err := cursor.All(ctx, &students); err != nil {
+		return nil, err
+	}
+	return students, nil
+}
+
+// findPlacedCandidates is findSearchCandidates for a placement within a session. The
+// placement picks the enrolments on the session_placement index and every other filter
+// applies to the joined student.
+func findPlacedCandidates(
+	ctx context.Context,
+	database *mongo.Database,
+	session *models.AcademicSession,
+	filter bson.M,
+	limit int,
+) ([]models.Student, error) {
+
+	enrolmentFilter := bson.M{"session_entity_id": session.EntityID, "is_deleted": false}
+	studentFilter := bson.M{}
+	for key, value := range filter {
+		switch key {
+		case "board_entity_id", "class_entity_id", "div":
+			enrolmentFilter[key] = value
+		case "entity_id":
+			enrolmentFilter["student_entity_id"] = value
+		default:
+			studentFilter[key] = value
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: enrolmentFilter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": StudentCollection,
+			"let":  bson.M{"student_entity_id": "$student_entity_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$entity_id", "$$student_entity_id"}}}},
+				bson.M{"$match": studentFilter},
+			},
+			"as": "student",
+		}}},
+		{{Key: "$unwind", Value: "$student"}},
+		{{Key: "$sort", Value: bson.D{{Key: "student.ref_no", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := database.Collection(EnrolmentCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		models.Enrolment `bson:",inline"`
+		Student          models.Student `bson:"student"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	students := make([]models.Student, 0, len(rows))
+	for _, row := range rows {
+		withEnrolment(&row.Student, row.Enrolment)
+		students = append(students, row.Student)
+	}
+	return students, nil
+}
+
+// findStudentsByGuardianPhone returns the students with a guardian whose phone starts or
+// ends with digits, looked up on the indexed phone_tokens
+func findStudentsByGuardianPhone(ctx context.Context, database *mongo.Database, digits string) ([]string, error) {
+	cursor, err := database.Collection(GuardianCollection).Find(ctx, bson.M{
+		"phone_tokens": phoneKey(digits),
+		"is_deleted":   false,
+	}, options.Find().SetProjection(bson.M{"student_entity_id": 1}).SetLimit(searchCandidateLimit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var guardians []models.Guardian
+	if err := cursor.All(ctx, &guardians); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	ids := make([]string, 0, len(guardians))
+	for _, guardian := range guardians {
+		if guardian.StudentEntityID != "" && !seen[guardian.StudentEntityID] {
+			seen[guardian.StudentEntityID] = true
+			ids = append(ids, guardian.StudentEntityID)
+		}
+	}
+	return ids, nil
+}
+
+// guardianPhoneTokens returns the digit runs a guardian phone is found by: the leading and
+// trailing runs of its last ten digits, from searchPhoneMinDigits long up to the whole key
+func guardianPhoneTokens(phone string) []string {
+	key := phoneKey(phone)
+	if len(key) < searchPhoneMinDigits {
+		return nil
+	}
+
+	var tokens []string
+	seen := make(map[string]bool)
+	for length := searchPhoneMinDigits; length <= len(key); length++ {
+		for _, token := range []string{key[:length], key[len(key)-length:]} {
+			if !seen[token] {
+				seen[token] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// foldSearchText lowercases s and strips diacritics, so "Zoë" and "ZOE" compare equal
+func foldSearchText(s string) string {
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// searchWords splits folded text into its letter and digit runs
+func searchWords(s string) []string {
+	return strings.FieldsFunc(foldSearchText(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchPhoneDigits returns the digits of a query that looks like a phone number, or ""
+func searchPhoneDigits(query string) string {
+	var digits strings.Builder
+	for _, r := range query {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == '+' || r == '-' || r == ' ' || r == '(' || r == ')':
+		default:
+			return ""
+		}
+	}
+	if digits.Len() < searchPhoneMinDigits {
+		return ""
+	}
+	return digits.String()
+}
+
+// studentSearchTokens returns the words stored on a student for search: its name words,
+// the whole ref_no and the ref_no's own words, all folded
+func studentSearchTokens(student *models.Student) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if token != "" && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, word := range searchWords(student.FirstName + " " + student.MiddleName + " " + student.LastName) {
+		add(word)
+	}
+	if refNo := foldSearchText(strings.TrimSpace(student.RefNo)); refNo != "" {
+		add(refNo)
+		for _, word := range searchWords(refNo) {
+			add(word)
+		}
+	}
+	return tokens
+}
+
+// scoreStudentMatch ranks one candidate: an exact ref_no beats a ref_no prefix, which
+// beats a guardian phone, which beats name matches, where whole words count double
+func scoreStudentMatch(student *models.Student, query string, phoneMatched bool) (int, []string) {
+	score := 0
+	var matchedOn []string
+
+	refQuery := foldSearchText(strings.TrimSpace(query))
+	refNo := foldSearchText(strings.TrimSpace(student.RefNo))
+	switch {
+	case refNo == "" || refQuery == "":
+	case refNo == refQuery:
+		score += 100
+		matchedOn = append(matchedOn, "ref_no")
+	case strings.HasPrefix(refNo, refQuery):
+		score += 60
+		matchedOn = append(matchedOn, "ref_no")
+	}
+
+	if phoneMatched {
+		score += 50
+		matchedOn = append(matchedOn, "guardian_phone")
+	}
+
+	terms := searchWords(query)
+	names := searchWords(student.FirstName + " " + student.MiddleName + " " + student.LastName)
+	nameScore := 0
+	for _, term := range terms {
+		best := 0
+		for _, name := range names {
+			if name == term {
+				best = 10
+				break
+			}
+			if strings.HasPrefix(name, term) {
+				best = 5
+			}
+		}
+		if best == 0 {
+			nameScore = 0
+			break
+		}
+		nameScore += best
+	}
+	if nameScore > 0 {
+		score += nameScore
+		matchedOn = append(matchedOn, "name")
+	}
+
+	return score, matchedOn
+}
+
+// rankStudents scores the candidates and returns the best limit of them, ties broken by name
+func rankStudents(candidates []models.Student, query string, phoneMatched map[string]bool, limit int) []models.StudentSearchResult {
+	results := make([]models.StudentSearchResult, 0, len(candidates))
+	for _, student := range candidates {
+		score, matchedOn := scoreStudentMatch(&student, query, phoneMatched[student.EntityID])
+		if matchedOn == nil {
+			// Only the ref_no's inner words matched, e.g. "0042" of "ADM-2024-0042"
+			matchedOn = []string{"ref_no"}
+			score = 1
+		}
+		results = append(results, models.StudentSearchResult{Student: student, Score: score, MatchedOn: matchedOn})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		left, right := studentFullName(results[i].Student), studentFullName(results[j].Student)
+		if left != right {
+			return strings.ToLower(left) < strings.ToLower(right)
+		}
+		return results[i].RefNo < results[j].RefNo
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestStudentSearchTokens(t *testing.T) {
+	student := &models.Student{FirstName: "Zoë", MiddleName: "  ", LastName: "Ramírez-Núñez", RefNo: "ADM-2024-0042"}
+
+	got := studentSearchTokens(student)
+	want := []string{"zoe", "ramirez", "nunez", "adm-2024-0042", "adm", "2024", "0042"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+}
+
+func TestSearchPhoneDigits(t *testing.T) {
+	cases := map[string]string{
+		"98765":          "98765",
+		"+91 98765-4321": "91987654321",
+		"987":            "",
+		"asha 9876":      "",
+	}
+	for query, want := range cases {
+		if got := searchPhoneDigits(query); got != want {
+			t.Errorf("searchPhoneDigits(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestGuardianPhoneTokens(t *testing.T) {
+	tokens := guardianPhoneTokens("+919876543210")
+
+	has := make(map[string]bool)
+	for _, token := range tokens {
+		has[token] = true
+	}
+	for _, want := range []string{"9876", "98765", "3210", "43210", "9876543210"} {
+		if !has[want] {
+			t.Errorf("tokens %q are missing %q", tokens, want)
+		}
+	}
+	if has["9198"] || has["919876543210"] {
+		t.Errorf("tokens %q should leave out the country code", tokens)
+	}
+
+	// A query with the country code finds the number through its last ten digits
+	if !has[phoneKey(searchPhoneDigits("+91 98765-43210"))] {
+		t.Error("expected the full number with country code to match")
+	}
+
+	if got := guardianPhoneTokens("123"); got != nil {
+		t.Errorf("short phone tokens = %q, want none", got)
+	}
+}
+
+func TestRankStudents(t *testing.T) {
+	candidates := []models.Student{
+		{EntityID: "prefix", FirstName: "Ashaan", LastName: "Kumar", RefNo: "R-2"},
+		{EntityID: "exact", FirstName: "Asha", LastName: "Rao", RefNo: "R-3"},
+		{EntityID: "phone", FirstName: "Meera", LastName: "Iyer", RefNo: "R-4"},
+		{EntityID: "ref", FirstName: "Vikram", LastName: "Shah", RefNo: "ASHA"},
+		{EntityID: "accent", FirstName: "Åsha", LastName: "Bose", RefNo: "R-5"},
+	}
+
+	results := rankStudents(candidates, "asha", map[string]bool{"phone": true}, 0)
+
+	var order []string
+	for _, result := range results {
+		order = append(order, result.EntityID)
+	}
+	want := []string{"ref", "phone", "exact", "accent", "prefix"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	if limited := rankStudents(candidates, "asha", nil, 2); len(limited) != 2 {
+		t.Errorf("limit 2 returned %d results", len(limited))
+	}
+}
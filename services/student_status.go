@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"shared/infra/db/mdb"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const StudentStatusChangeCollection = "student_status_changes"
+
+//
+// ================= SERVICE INTERFACE =================
+//
+
+type StudentStatusService interface {
+	ChangeStatus(ctx context.Context, companyCode string, id string, req *requests.ChangeStudentStatusRequest, changedBy models.PaymentActor) (*models.StudentStatusChange, error)
+	GetHistory(ctx context.Context, companyCode string, id string) ([]models.StudentStatusChange, error)
+	GetLeavingCertificate(ctx context.Context, companyCode string, id string) (*models.LeavingCertificate, error)
+}
+
+//
+// ================= SERVICE STRUCT =================
+//
+
+type studentStatusService struct{}
+
+func NewStudentStatusService() StudentStatusService {
+	return &studentStatusService{}
+}
+
+//
+// ================= CHANGE STATUS =================
+//
+
+// ChangeStatus moves a student between active, transferred, withdrawn and alumni. The
+// student and the enrolment of their current session take the new status, so a student
+// who left mid-year drops out of that session's dues while their payments stay put.
+func (s *studentStatusService) ChangeStatus(
+	ctx context.Context,
+	companyCode string,
+	id string,
+	req *requests.ChangeStudentStatusRequest,
+	changedBy models.PaymentActor,
+) (*models.StudentStatusChange, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	fromStatus := studentStatus(student)
+	if fromStatus == req.Status {
+		return nil, fmt.Errorf("student is already %s", req.Status)
+	}
+
+	effective := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.EffectiveDate != nil && *req.EffectiveDate != "" {
+		effective, _ = time.Parse("2006-01-02", *req.EffectiveDate)
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	// The status belongs to the enrolment of the session the change takes effect in; after a
+	// promotion into an upcoming session the student document already points past it
+	sessionEntityID := student.SessionEntityID
+	session, err := sessionOn(ctx, database, effective)
+	if err != nil {
+		return nil, err
+	}
+	if session != nil {
+		sessionEntityID = session.EntityID
+	}
+
+	change := models.NewStudentStatusChange()
+	change.StudentEntityID = student.EntityID
+	change.SessionEntityID = sessionEntityID
+	change.FromStatus = fromStatus
+	change.Status = req.Status
+	change.EffectiveDate = effective
+	change.Reason = req.Reason
+	change.DestinationSchool = req.DestinationSchool
+	change.Remarks = req.Remarks
+	change.ChangedBy = &changedBy
+
+	if err := applyStudentStatus(ctx, database, []string{student.EntityID}, sessionEntityID, req.Status, effective, req.Reason); err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Collection(StudentStatusChangeCollection).InsertOne(ctx, change); err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+//
+// ================= GET HISTORY =================
+//
+
+// GetHistory lists a student's status changes, latest first
+func (s *studentStatusService) GetHistory(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) ([]models.StudentStatusChange, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := mdb.GetMongo().GetClient().
+		Database(fmt.Sprintf("company_%s", companyCode)).
+		Collection(StudentStatusChangeCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "effective_date", Value: -1}, {Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"student_entity_id": student.EntityID, "is_deleted": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.StudentStatusChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+//
+// ================= LEAVING CERTIFICATE =================
+//
+
+// GetLeavingCertificate gathers the data for the leaving certificate of a student who
+// has left: their last class, leaving date and reason, and whether their dues are clear
+func (s *studentStatusService) GetLeavingCertificate(
+	ctx context.Context,
+	companyCode string,
+	id string,
+) (*models.LeavingCertificate, error) {
+
+	student, err := NewStudentService().GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+	if student.IsActive() {
+		return nil, errors.New("leaving certificates are only issued to students who have left")
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	certificate := &models.LeavingCertificate{
+		StudentEntityID: student.EntityID,
+		RefNo:           student.RefNo,
+		FirstName:       student.FirstName,
+		MiddleName:      student.MiddleName,
+		LastName:        student.LastName,
+		Div:             student.Div,
+		AdmissionDate:   student.CreatedAt,
+		LeavingDate:     student.StatusDate,
+		Status:          student.Status,
+		Reason:          student.StatusReason,
+		GeneratedAt:     time.Now().UTC(),
+	}
+
+	// The change that made the student leave carries the destination school and remarks
+	var change models.StudentStatusChange
+	err = database.Collection(StudentStatusChangeCollection).FindOne(ctx,
+		bson.M{"student_entity_id": student.EntityID, "status": student.Status, "is_deleted": false},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	).Decode(&change)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil {
+		certificate.DestinationSchool = change.DestinationSchool
+		certificate.Remarks = change.Remarks
+	}
+
+	// Admission is the student's first enrolment
+	var first models.Enrolment
+	err = database.Collection(EnrolmentCollection).FindOne(ctx,
+		bson.M{"student_entity_id": student.EntityID, "is_deleted": false},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	).Decode(&first)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if err == nil && first.CreatedAt.Before(certificate.AdmissionDate) {
+		certificate.AdmissionDate = first.CreatedAt
+	}
+
+	guardians, err := loadGuardians(ctx, database, []string{student.EntityID})
+	if err != nil {
+		return nil, err
+	}
+	if list := guardians[student.EntityID]; len(list) > 0 {
+		certificate.GuardianName = list[0].Name
+		certificate.GuardianRelation = list[0].Relationship
+	}
+
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	certificate.BoardName = boardNames[student.BoardEntityID]
+	certificate.ClassName = classNames[student.ClassEntityID]
+
+	var session *models.AcademicSession
+	if student.SessionEntityID != "" {
+		session, err = findAcademicSession(ctx, database, student.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+		certificate.SessionName = session.Name
+	}
+
+	// Dues of the last session the student attended, including dues carried into it
+	ledger, _, err := loadDuesLedger(ctx, database, session)
+	if err != nil {
+		return nil, err
+	}
+	dues := ledger.Dues(*student, duePolicy{Optional: OptionalExcluded})
+	certificate.DuesOutstanding = dues.TotalDue
+	certificate.DuesCleared = dues.Settled()
+
+	cursor, err := database.Collection("payment_scanners").Find(ctx, bson.M{
+		"student_entity_id": student.EntityID,
+		"status":            "paid",
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var payments []models.PaymentScanner
+	if err := cursor.All(ctx, &payments); err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		certificate.TotalPaid += payment.Amount
+	}
+
+	return certificate, nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// studentStatus returns the student's status, "active" for students saved before
+// statuses existed
+func studentStatus(student *models.Student) string {
+	if student.Status == "" {
+		return "active"
+	}
+	return student.Status
+}
+
+// activeStudents drops the students who have left. They owe nothing further, so dues,
+// unpaid lists and promotions skip them; their payments are still reported.
+func activeStudents(students []models.Student) []models.Student {
+	active := make([]models.Student, 0, len(students))
+	for _, student := range students {
+		if student.IsActive() {
+			active = append(active, student)
+		}
+	}
+	return active
+}
+
+// activeStatusMatch matches the status of active students and enrolments in a query;
+// documents saved before statuses existed have none
+func activeStatusMatch() bson.M {
+	return bson.M{"$in": bson.A{nil, "", "active"}}
+}
+
+// applyStudentStatus sets the status on the students and on their enrolments in the session
+func applyStudentStatus(
+	ctx context.Context,
+	database *mongo.Database,
+	studentEntityIDs []string,
+	sessionEntityID string,
+	status string,
+	effective time.Time,
+	reason string,
+) error {
+
+	now := time.Now().UTC()
+	if _, err := database.Collection(StudentCollection).UpdateMany(ctx,
+		bson.M{"entity_id": bson.M{"$in": studentEntityIDs}, "is_deleted": false},
+		bson.M{"$set": bson.M{
+			"status":        status,
+			"status_date":   effective,
+			"status_reason": reason,
+			"updated_at":    now,
+		}},
+	); err != nil {
+		return err
+	}
+
+	if sessionEntityID == "" {
+		return nil
+	}
+	_, err := database.Collection(EnrolmentCollection).UpdateMany(ctx,
+		bson.M{"student_entity_id": bson.M{"$in": studentEntityIDs}, "session_entity_id": sessionEntityID, "is_deleted": false},
+		bson.M{"$set": bson.M{"status": status, "updated_at": now}},
+	)
+	return err
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+)
+
+func TestActiveStudents(t *testing.T) {
+	students := []models.Student{
+		{EntityID: "legacy"},
+		{EntityID: "active", Status: "active"},
+		{EntityID: "transferred", Status: "transferred"},
+		{EntityID: "withdrawn", Status: "withdrawn"},
+		{EntityID: "alumni", Status: "alumni"},
+	}
+
+	active := activeStudents(students)
+	if len(active) != 2 || active[0].EntityID != "legacy" || active[1].EntityID != "active" {
+		t.Errorf("active students = %+v, want legacy and active", active)
+	}
+
+	if got := studentStatus(&students[0]); got != "active" {
+		t.Errorf("status of a student without one = %q, want %q", got, "active")
+	}
+}
@@ -25,7 +25,7 @@ const StudentCollection = "students"
 
 type StudentService interface {
 	Create(ctx context.Context, companyCode string, req *requests.CreateStudentRequest) (*models.Student, error)
-	GetAll(ctx context.Context, companyCode string) ([]*models.Student, error)
+	GetAll(ctx context.Context, companyCode string, sessionID string) ([]*models.Student, error)
 	GetByID(ctx context.Context, companyCode string, id string) (*models.Student, error)
 	GetByUUIDs(ctx context.Context, companyCode string, ids []string) ([]*models.Student, error)
 	Update(ctx context.Context, companyCode string, id string, req *requests.UpdateStudentRequest) (*models.Student, error)
@@ -52,19 +52,49 @@ func (s *studentService) Create(
 	req *requests.CreateStudentRequest,
 ) (*models.Student, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(StudentCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(StudentCollection)
 
 	student := models.NewStudent()
 	student.Bind(req)
 
-	_, err := collection.InsertOne(ctx, student)
+	sessionEntityID, err := activeSessionEntityID(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	student.SessionEntityID = sessionEntityID
+
+	roster, err := loadDivisionRoster(ctx, database, sessionEntityID)
+	if err != nil {
+		return nil, err
+	}
+	student.Div, err = roster.Place(ctx, student.ClassEntityID, student.Div, student.EntityID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Ref numbers are unique among active students; new admissions without one get the next generated number
+	if student.RefNo == "" {
+		refNos, err := generateRefNos(ctx, database, 1)
+		if err != nil {
+			return nil, err
+		}
+		student.RefNo = refNos[0]
+	} else if err := checkRefNosAvailable(ctx, database, []string{student.RefNo}, ""); err != nil {
+		return nil, err
+	}
+
+	student.SearchTokens = studentSearchTokens(student)
+
+	_, err = collection.InsertOne(ctx, student)
+	if err != nil {
+		return nil, refNoWriteError(err, student.RefNo)
+	}
+
+	if err := enrolStudents(ctx, database, []*models.Student{student}, sessionEntityID); err != nil {
+		return nil, err
+	}
+
 	return student, nil
 }
 
@@ -72,27 +102,31 @@ func (s *studentService) Create(
 // ================= GET ALL =================
 //
 
+// GetAll lists the students enrolled in the given session, or in the active session when
+// sessionID is empty. Students of a past session carry their class of that session.
 func (s *studentService) GetAll(
 	ctx context.Context,
 	companyCode string,
+	sessionID string,
 ) ([]*models.Student, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(StudentCollection)
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
 
-	cursor, err := collection.Find(ctx, bson.M{"is_deleted": false})
+	session, err := resolveSession(ctx, database, sessionID)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var students []*models.Student
-	if err := cursor.All(ctx, &students); err != nil {
+	enrolled, err := findSessionStudents(ctx, database, session, bson.M{"is_deleted": false})
+	if err != nil {
 		return nil, err
 	}
 
+	students := make([]*models.Student, 0, len(enrolled))
+	for i := range enrolled {
+		students = append(students, &enrolled[i])
+	}
+
 	return students, nil
 }
 
@@ -171,10 +205,21 @@ func (s *studentService) Update(
 	req *requests.UpdateStudentRequest,
 ) (*models.Student, error) {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(StudentCollection)
+	current, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return nil, err
+	}
+
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(StudentCollection)
+
+	// A change of class belongs to the enrolment, which is read-only once its session closed
+	enrolmentChanged := req.BoardEntityID != nil || req.ClassEntityID != nil || req.Div != nil
+	if enrolmentChanged {
+		if err := ensureSessionWritable(ctx, database, current.SessionEntityID); err != nil {
+			return nil, err
+		}
+	}
 
 	updateFields := bson.M{}
 
@@ -184,11 +229,33 @@ func (s *studentService) Update(
 	if req.ClassEntityID != nil {
 		updateFields["class_entity_id"] = *req.ClassEntityID
 	}
-	if req.RefNo != nil {
+	if req.RefNo != nil && *req.RefNo != current.RefNo {
+		if err := checkRefNosAvailable(ctx, database, []string{*req.RefNo}, current.EntityID); err != nil {
+			return nil, err
+		}
 		updateFields["ref_no"] = *req.RefNo
 	}
-	if req.Div != nil {
-		updateFields["div"] = *req.Div
+	// The division must belong to the class the student ends up in
+	if req.ClassEntityID != nil || req.Div != nil {
+		classEntityID, div := current.ClassEntityID, current.Div
+		if req.ClassEntityID != nil {
+			classEntityID = *req.ClassEntityID
+		}
+		if req.Div != nil {
+			div = *req.Div
+		}
+
+		roster, err := loadDivisionRoster(ctx, database, current.SessionEntityID)
+		if err != nil {
+			return nil, err
+		}
+		placed, err := roster.Place(ctx, classEntityID, div, current.EntityID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Div != nil || placed != current.Div {
+			updateFields["div"] = placed
+		}
 	}
 	if req.FirstName != nil {
 		updateFields["first_name"] = *req.FirstName
@@ -200,6 +267,11 @@ func (s *studentService) Update(
 		updateFields["last_name"] = *req.LastName
 	}
 	if req.IsDeleted != nil {
+		if *req.IsDeleted {
+			if err := ensureStudentDeletable(ctx, database, current.EntityID); err != nil {
+				return nil, err
+			}
+		}
 		updateFields["is_deleted"] = *req.IsDeleted
 	}
 
@@ -207,27 +279,49 @@ func (s *studentService) Update(
 		return nil, errors.New("no fields to update")
 	}
 
+	// Keep the search tokens in step with the name and ref_no
+	if req.FirstName != nil || req.MiddleName != nil || req.LastName != nil || updateFields["ref_no"] != nil {
+		searched := *current
+		if req.FirstName != nil {
+			searched.FirstName = *req.FirstName
+		}
+		if req.MiddleName != nil {
+			searched.MiddleName = *req.MiddleName
+		}
+		if req.LastName != nil {
+			searched.LastName = *req.LastName
+		}
+		if req.RefNo != nil {
+			searched.RefNo = *req.RefNo
+		}
+		updateFields["search_tokens"] = studentSearchTokens(&searched)
+	}
+
 	updateFields["updated_at"] = time.Now()
 
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
-	}
-
 	var updated models.Student
-	err := collection.
-		FindOneAndUpdate(ctx, filter, bson.M{"$set": updateFields}, opts).
+	err = collection.
+		FindOneAndUpdate(ctx, bson.M{"_id": current.ID, "is_deleted": false}, bson.M{"$set": updateFields}, opts).
 		Decode(&updated)
 
 	if err == mongo.ErrNoDocuments {
 		return nil, errors.New("student not found")
 	}
 	if err != nil {
+		if req.RefNo != nil {
+			return nil, refNoWriteError(err, *req.RefNo)
+		}
 		return nil, err
 	}
 
+	if enrolmentChanged {
+		if err := syncEnrolment(ctx, database, &updated); err != nil {
+			return nil, err
+		}
+	}
+
 	return &updated, nil
 }
 
@@ -241,17 +335,19 @@ func (s *studentService) Delete(
 	id string,
 ) error {
 
-	db := mdb.GetMongo()
-	collection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection(StudentCollection)
+	student, err := s.GetByID(ctx, companyCode, id)
+	if err != nil {
+		return err
+	}
 
-	filter := bson.M{"entity_id": id, "is_deleted": false}
-	if oid, err := primitive.ObjectIDFromHex(id); err == nil {
-		filter = bson.M{"_id": oid, "is_deleted": false}
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	collection := database.Collection(StudentCollection)
+
+	if err := ensureStudentDeletable(ctx, database, student.EntityID); err != nil {
+		return err
 	}
 
-	result, err := collection.UpdateOne(ctx, filter, bson.M{
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": student.ID, "is_deleted": false}, bson.M{
 		"$set": bson.M{
 			"is_deleted": true,
 			"updated_at": time.Now(),
@@ -267,3 +363,20 @@ func (s *studentService) Delete(
 
 	return nil
 }
+
+// ensureStudentDeletable keeps students with payments from being deleted, which would hide
+// their payments from receipts and reports. Students who leave get a status instead.
+func ensureStudentDeletable(ctx context.Context, database *mongo.Database, studentEntityID string) error {
+	payments, err := database.Collection("payment_scanners").CountDocuments(ctx, bson.M{
+		"student_entity_id": studentEntityID,
+		"status":            bson.M{"$nin": reversedPaymentStatuses},
+		"is_deleted":        false,
+	})
+	if err != nil {
+		return err
+	}
+	if payments > 0 {
+		return errors.New("student has payments; set their status to transferred or withdrawn instead of deleting them")
+	}
+	return nil
+}
@@ -1,8 +1,16 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"shared/infra/db/mdb"
 
@@ -18,6 +26,7 @@ import (
 
 type UnpaidStudentsService interface {
 	GetUnpaidStudents(ctx context.Context, companyCode string, req *requests.GetUnpaidStudentsRequest) (*models.UnpaidStudentsResponse, error)
+	ExportUnpaidStudentsCSV(ctx context.Context, companyCode string, req *requests.GetUnpaidStudentsRequest) ([]byte, error)
 }
 
 //
@@ -34,195 +43,309 @@ func NewUnpaidStudentsService() UnpaidStudentsService {
 // ================= GET UNPAID STUDENTS =================
 //
 
+// GetUnpaidStudents returns one page of unpaid students. Pages are keyed on the
+// last row's sort value and entity ID, so they stay consistent while payments come in.
 func (s *unpaidStudentsService) GetUnpaidStudents(
 	ctx context.Context,
 	companyCode string,
 	req *requests.GetUnpaidStudentsRequest,
 ) (*models.UnpaidStudentsResponse, error) {
 
-	db := mdb.GetMongo()
-
-	// Get student collection
-	studentCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("students")
-
-	// Build filter for students
-	studentFilter := bson.M{"is_deleted": false}
-	if req.ClassEntityID != nil {
-		studentFilter["class_entity_id"] = *req.ClassEntityID
-	}
-	if req.BoardEntityID != nil {
-		studentFilter["board_entity_id"] = *req.BoardEntityID
-	}
-
-	// Find all students
-	cursor, err := studentCollection.Find(ctx, studentFilter)
+	unpaidStudents, err := findUnpaidStudents(ctx, companyCode, req)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var students []models.Student
-	if err := cursor.All(ctx, &students); err != nil {
-		return nil, err
-	}
+	return pageUnpaidStudents(unpaidStudents, req)
+}
 
-	// Get collections
-	examCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("exams")
+//
+// ================= EXPORT CSV =================
+//
 
-	bookCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("books")
+// ExportUnpaidStudentsCSV writes every matching student (no paging), one row each,
+// ready to be shared with class or parent groups
+func (s *unpaidStudentsService) ExportUnpaidStudentsCSV(
+	ctx context.Context,
+	companyCode string,
+	req *requests.GetUnpaidStudentsRequest,
+) ([]byte, error) {
 
-	paymentCollection := db.GetClient().
-		Database(fmt.Sprintf("company_%s", companyCode)).
-		Collection("payment_scanners")
+	unpaidStudents, err := findUnpaidStudents(ctx, companyCode, req)
+	if err != nil {
+		return nil, err
+	}
 
-	// Get all exams
-	cursorExams, err := examCollection.Find(ctx, bson.M{"is_deleted": false})
+	database := mdb.GetMongo().GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+	boardNames, classNames, err := loadBoardAndClassNames(ctx, database)
 	if err != nil {
 		return nil, err
 	}
-	defer cursorExams.Close(ctx)
-	var allExams []models.Exam
-	if err := cursorExams.All(ctx, &allExams); err != nil {
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"Ref No", "Student Name", "Board", "Class", "Div", "Pending Items", "Total Due"}
+	if err := writer.Write(header); err != nil {
 		return nil, err
 	}
 
-	// Map exams by class
-	classExams := make(map[string][]models.Exam)
-	for _, exam := range allExams {
-		classExams[exam.ClassEntityID] = append(classExams[exam.ClassEntityID], exam)
+	for _, student := range unpaidStudents {
+		items := make([]string, 0, len(student.PendingItems))
+		for _, item := range student.PendingItems {
+			items = append(items, fmt.Sprintf("%s (%.2f)", item.ItemName, item.DueAmount))
+		}
+		record := []string{
+			student.RefNo,
+			unpaidStudentName(student),
+			boardNames[student.BoardEntityID],
+			classNames[student.ClassEntityID],
+			student.Div,
+			strings.Join(items, "; "),
+			fmt.Sprintf("%.2f", student.TotalDue),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
 	}
 
-	// Get all books
-	cursorBooks, err := bookCollection.Find(ctx, bson.M{"is_deleted": false})
-	if err != nil {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
 		return nil, err
 	}
-	defer cursorBooks.Close(ctx)
-	var allBooks []models.Book
-	if err := cursorBooks.All(ctx, &allBooks); err != nil {
+
+	return buf.Bytes(), nil
+}
+
+//
+// ================= HELPERS =================
+//
+
+// findUnpaidStudents lists every student matching the filters with their pending items, sorted
+func findUnpaidStudents(
+	ctx context.Context,
+	companyCode string,
+	req *requests.GetUnpaidStudentsRequest,
+) ([]models.UnpaidStudent, error) {
+
+	db := mdb.GetMongo()
+	database := db.GetClient().Database(fmt.Sprintf("company_%s", companyCode))
+
+	session, err := resolveSessionPtr(ctx, database, req.SessionEntityID)
+	if err != nil {
 		return nil, err
 	}
 
-	// Map books by class
-	classBooks := make(map[string][]models.Book)
-	for _, book := range allBooks {
-		classBooks[book.ClassEntityID] = append(classBooks[book.ClassEntityID], book)
+	// Build filter for students
+	studentFilter := bson.M{"is_deleted": false}
+	if req.ClassEntityID != nil {
+		studentFilter["class_entity_id"] = *req.ClassEntityID
+	}
+	if req.BoardEntityID != nil {
+		studentFilter["board_entity_id"] = *req.BoardEntityID
+	}
+	if req.Div != nil && *req.Div != "" {
+		studentFilter["div"] = divisionMatch(*req.Div)
+	}
+	if req.Search != nil && strings.TrimSpace(*req.Search) != "" {
+		// Every word must match a name part or the ref no
+		var terms bson.A
+		for _, word := range strings.Fields(*req.Search) {
+			pattern := bson.M{"$regex": regexp.QuoteMeta(word), "$options": "i"}
+			terms = append(terms, bson.M{"$or": bson.A{
+				bson.M{"first_name": pattern},
+				bson.M{"middle_name": pattern},
+				bson.M{"last_name": pattern},
+				bson.M{"ref_no": pattern},
+			}})
+		}
+		studentFilter["$and"] = terms
 	}
 
-	// Get all payments to identify paid items
-	cursorPayments, err := paymentCollection.Find(ctx, bson.M{"is_deleted": false, "status": "paid"})
+	// Find all students enrolled in the session; students who left owe nothing further
+	students, err := findSessionStudents(ctx, database, session, studentFilter)
 	if err != nil {
 		return nil, err
 	}
-	defer cursorPayments.Close(ctx)
-	var allPayments []models.PaymentScanner
-	if err := cursorPayments.All(ctx, &allPayments); err != nil {
+	students = activeStudents(students)
+
+	ledger, _, err := loadDuesLedger(ctx, database, session)
+	if err != nil {
 		return nil, err
 	}
 
-	// Map paid items by student
-	studentPaidItems := make(map[string]map[string]bool)
-	for _, payment := range allPayments {
-		if studentPaidItems[payment.StudentEntityID] == nil {
-			studentPaidItems[payment.StudentEntityID] = make(map[string]bool)
-		}
-		studentPaidItems[payment.StudentEntityID][payment.ExamEntityID] = true
+	policy := duePolicy{Optional: OptionalExcluded}
+	if req.IncludeOptional {
+		policy.Optional = OptionalIncluded
+	}
+	if req.ItemType != nil {
+		policy.ItemType = *req.ItemType
 	}
 
-	var unpaidStudents []models.UnpaidStudent
+	unpaidStudents := []models.UnpaidStudent{}
 
 	// Process each student
 	for _, student := range students {
-		paidItems := studentPaidItems[student.EntityID]
-		exams := classExams[student.ClassEntityID]
-		books := classBooks[student.ClassEntityID]
-
-		// Build pending items
-		var pendingItems []models.PendingItem
-		var totalDue float64
-
-		// Add unpaid exams
-		for _, exam := range exams {
-			if paidItems == nil || !paidItems[exam.EntityID] {
-				// Determine if compulsory
-				isCompulsory := false
-				if exam.FeesType != "" {
-					isCompulsory = exam.FeesType == "compulsory"
-				} else {
-					isCompulsory = exam.FeesPaid
-				}
-
-				itemType := "exam"
-				if req.ItemType != nil && *req.ItemType != "all" && *req.ItemType != itemType {
-					continue
-				}
-
-				pendingItems = append(pendingItems, models.PendingItem{
-					ItemType:     "exam",
-					ItemEntityID: exam.EntityID,
-					ItemName:     exam.ExamName,
-					ItemAmount:   exam.ExamAmount,
-					DueAmount:    exam.ExamAmount,
-					IsCompulsory: isCompulsory,
-				})
-				totalDue += exam.ExamAmount
-			}
+		dues := ledger.Dues(student, policy)
+
+		// Only add student if they have pending items
+		if len(dues.Pending) == 0 {
+			continue
+		}
+		if req.MinDue != nil && dues.TotalDue < *req.MinDue {
+			continue
+		}
+
+		pendingItems := make([]models.PendingItem, 0, len(dues.Pending))
+		for _, item := range dues.Pending {
+			pendingItems = append(pendingItems, models.PendingItem{
+				ItemType:                   item.ItemType,
+				ItemEntityID:               item.EntityID,
+				ItemName:                   item.Name,
+				ItemAmount:                 item.Amount,
+				DueAmount:                  item.Amount,
+				IsCompulsory:               item.IsCompulsory,
+				CarriedFromSessionEntityID: item.CarriedFrom,
+			})
 		}
 
-		// Add unpaid books
-		for _, book := range books {
-			if paidItems == nil || !paidItems[book.EntityID] {
-				// Determine if compulsory
-				isCompulsory := false
-				if book.FeesType != "" {
-					isCompulsory = book.FeesType == "compulsory"
-				} else {
-					isCompulsory = book.FeesPaid
-				}
-
-				itemType := "book"
-				if req.ItemType != nil && *req.ItemType != "all" && *req.ItemType != itemType {
-					continue
-				}
-
-				pendingItems = append(pendingItems, models.PendingItem{
-					ItemType:     "book",
-					ItemEntityID: book.EntityID,
-					ItemName:     book.BookName,
-					ItemAmount:   book.Amount,
-					DueAmount:    book.Amount,
-					IsCompulsory: isCompulsory,
-				})
-				totalDue += book.Amount
+		unpaidStudents = append(unpaidStudents, models.UnpaidStudent{
+			ID:            student.ID,
+			EntityID:      student.EntityID,
+			RefNo:         student.RefNo,
+			FirstName:     student.FirstName,
+			MiddleName:    student.MiddleName,
+			LastName:      student.LastName,
+			Div:           student.Div,
+			BoardEntityID: student.BoardEntityID,
+			ClassEntityID: student.ClassEntityID,
+			PendingItems:  pendingItems,
+			TotalDue:      dues.TotalDue,
+		})
+	}
+
+	sort.Slice(unpaidStudents, func(i, j int) bool {
+		return unpaidStudentLess(req.SortBy, req.SortOrder)(unpaidStudents[i], unpaidStudents[j])
+	})
+
+	return unpaidStudents, nil
+}
+
+// unpaidStudentLess orders by the requested key, breaking ties on entity ID so
+// every student has a unique position for cursor paging
+func unpaidStudentLess(sortBy, sortOrder string) func(a, b models.UnpaidStudent) bool {
+	desc := sortOrder == "desc"
+	if sortOrder == "" {
+		desc = sortBy == "" || sortBy == "total_due"
+	}
+
+	return func(a, b models.UnpaidStudent) bool {
+		var cmp int
+		switch sortBy {
+		case "name":
+			cmp = strings.Compare(strings.ToLower(unpaidStudentName(a)), strings.ToLower(unpaidStudentName(b)))
+		case "ref_no":
+			cmp = strings.Compare(a.RefNo, b.RefNo)
+		default:
+			if a.TotalDue < b.TotalDue {
+				cmp = -1
+			} else if a.TotalDue > b.TotalDue {
+				cmp = 1
 			}
 		}
+		if desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp < 0
+		}
+		return a.EntityID < b.EntityID
+	}
+}
 
-		// Only add student if they have pending items
-		if len(pendingItems) > 0 {
-			unpaidStudents = append(unpaidStudents, models.UnpaidStudent{
-				ID:            student.ID,
-				EntityID:      student.EntityID,
-				RefNo:         student.RefNo,
-				FirstName:     student.FirstName,
-				MiddleName:    student.MiddleName,
-				LastName:      student.LastName,
-				Div:           student.Div,
-				BoardEntityID: student.BoardEntityID,
-				ClassEntityID: student.ClassEntityID,
-				PendingItems:  pendingItems,
-				TotalDue:      totalDue,
-			})
+// pageUnpaidStudents cuts the sorted list after the cursor's row. The cursor holds the
+// row's sort keys rather than a position, so a student who pays off their dues between
+// requests does not shift the next page.
+func pageUnpaidStudents(
+	unpaidStudents []models.UnpaidStudent,
+	req *requests.GetUnpaidStudentsRequest,
+) (*models.UnpaidStudentsResponse, error) {
+
+	less := unpaidStudentLess(req.SortBy, req.SortOrder)
+
+	start := 0
+	if req.Cursor != "" {
+		after, err := decodeUnpaidCursor(req.Cursor)
+		if err != nil {
+			return nil, err
 		}
+		start = sort.Search(len(unpaidStudents), func(i int) bool {
+			return less(after, unpaidStudents[i])
+		})
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = len(unpaidStudents)
+	}
+	end := start + limit
+	if end > len(unpaidStudents) {
+		end = len(unpaidStudents)
 	}
 
-	return &models.UnpaidStudentsResponse{
-		Students: unpaidStudents,
+	response := &models.UnpaidStudentsResponse{
+		Students: unpaidStudents[start:end],
 		Total:    len(unpaidStudents),
+		HasMore:  end < len(unpaidStudents),
+	}
+	if response.HasMore {
+		response.NextCursor = encodeUnpaidCursor(unpaidStudents[end-1])
+	}
+
+	return response, nil
+}
+
+func unpaidStudentName(student models.UnpaidStudent) string {
+	return strings.Join(strings.Fields(student.FirstName+" "+student.MiddleName+" "+student.LastName), " ")
+}
+
+// unpaidCursor carries the sort keys of the last row of a page
+type unpaidCursor struct {
+	EntityID   string  `json:"id"`
+	TotalDue   float64 `json:"due"`
+	RefNo      string  `json:"ref"`
+	FirstName  string  `json:"fn,omitempty"`
+	MiddleName string  `json:"mn,omitempty"`
+	LastName   string  `json:"ln,omitempty"`
+}
+
+func encodeUnpaidCursor(student models.UnpaidStudent) string {
+	data, _ := json.Marshal(unpaidCursor{
+		EntityID:   student.EntityID,
+		TotalDue:   student.TotalDue,
+		RefNo:      student.RefNo,
+		FirstName:  student.FirstName,
+		MiddleName: student.MiddleName,
+		LastName:   student.LastName,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUnpaidCursor(value string) (models.UnpaidStudent, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return models.UnpaidStudent{}, errors.New("invalid cursor")
+	}
+	var cursor unpaidCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return models.UnpaidStudent{}, errors.New("invalid cursor")
+	}
+	return models.UnpaidStudent{
+		EntityID:   cursor.EntityID,
+		TotalDue:   cursor.TotalDue,
+		RefNo:      cursor.RefNo,
+		FirstName:  cursor.FirstName,
+		MiddleName: cursor.MiddleName,
+		LastName:   cursor.LastName,
 	}, nil
 }
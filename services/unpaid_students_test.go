@@ -0,0 +1,45 @@
+package services
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/nandani-y-meizo/school-backend/models"
+	"github.com/nandani-y-meizo/school-backend/requests"
+)
+
+func TestPageUnpaidStudents(t *testing.T) {
+	students := []models.UnpaidStudent{
+		{EntityID: "a", TotalDue: 500},
+		{EntityID: "b", TotalDue: 300},
+		{EntityID: "c", TotalDue: 300},
+		{EntityID: "d", TotalDue: 200},
+		{EntityID: "e", TotalDue: 100},
+	}
+	less := unpaidStudentLess("total_due", "desc")
+	sort.Slice(students, func(i, j int) bool { return less(students[i], students[j]) })
+
+	req := &requests.GetUnpaidStudentsRequest{SortBy: "total_due", SortOrder: "desc", Limit: 2}
+	first, err := pageUnpaidStudents(students, req)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if len(first.Students) != 2 || first.Students[1].EntityID != "b" || !first.HasMore {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	// "a" pays off their dues before the next page is requested; "c" must not be skipped
+	req.Cursor = first.NextCursor
+	second, err := pageUnpaidStudents(students[1:], req)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(second.Students) != 2 || second.Students[0].EntityID != "c" || second.Students[1].EntityID != "d" {
+		t.Errorf("unexpected second page %+v", second.Students)
+	}
+
+	req.Cursor = "not a cursor"
+	if _, err := pageUnpaidStudents(students, req); err == nil {
+		t.Error("expected an invalid cursor to be refused")
+	}
+}
@@ -165,6 +165,9 @@ func (s *userService) Update(
 	if req.Email != nil {
 		updateFields["email"] = *req.Email
 	}
+	if req.Role != nil {
+		updateFields["role"] = *req.Role
+	}
 	if req.IsDeleted != nil {
 		updateFields["is_deleted"] = *req.IsDeleted
 	}